@@ -0,0 +1,185 @@
+package annotator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/JulzDiverse/aviator/differ"
+	"github.com/JulzDiverse/aviator/transport"
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+const (
+	GitHub = "github"
+	GitLab = "gitlab"
+
+	defaultGitHubAPI = "https://api.github.com"
+	maxBodyLength    = 60000
+)
+
+type FileDiff struct {
+	File    string
+	Changes []differ.Change
+}
+
+var pullRef = regexp.MustCompile(`^refs/pull/(\d+)/`)
+
+func Body(diffs []FileDiff) string {
+	var b strings.Builder
+	b.WriteString("### Rendered diff\n\n")
+
+	changed := 0
+	for _, d := range diffs {
+		if len(d.Changes) == 0 {
+			continue
+		}
+		changed++
+
+		fmt.Fprintf(&b, "<details><summary><code>%s</code> (%d changes)</summary>\n\n```diff\n", d.File, len(d.Changes))
+		for _, c := range d.Changes {
+			switch c.Kind {
+			case differ.Added:
+				fmt.Fprintf(&b, "+ %s: %s\n", c.Path, c.New)
+			case differ.Removed:
+				fmt.Fprintf(&b, "- %s: %s\n", c.Path, c.Old)
+			default:
+				fmt.Fprintf(&b, "- %s: %s\n+ %s: %s\n", c.Path, c.Old, c.Path, c.New)
+			}
+		}
+		b.WriteString("```\n\n</details>\n\n")
+	}
+
+	if changed == 0 {
+		b.WriteString("No changes in rendered files.\n")
+	}
+
+	body := b.String()
+	if len(body) > maxBodyLength {
+		body = body[:maxBodyLength] + "\n```\n\n_Diff truncated._\n"
+	}
+	return body
+}
+
+func Post(provider, body string) error {
+	var req *http.Request
+	var err error
+	switch provider {
+	case GitHub:
+		req, err = gitHubRequest(body)
+	case GitLab:
+		req, err = gitLabRequest(body)
+	default:
+		return ansi.Errorf("@R{Unknown annotation provider} @m{%s}@R{: use github or gitlab}", provider)
+	}
+	if err != nil {
+		return err
+	}
+
+	client, err := transport.Client(transport.Annotate, 0)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, ansi.Sprintf("@R{Posting rendered diff to} @m{%s} @R{FAILED}", provider))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return ansi.Errorf("@R{Posting rendered diff to} @m{%s} @R{FAILED}: %s %s", provider, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+func gitHubRequest(body string) (*http.Request, error) {
+	token, err := env("GITHUB_TOKEN")
+	if err != nil {
+		return nil, err
+	}
+	repo, err := env("GITHUB_REPOSITORY")
+	if err != nil {
+		return nil, err
+	}
+
+	pr := os.Getenv("AVIATOR_PULL_REQUEST")
+	if pr == "" {
+		m := pullRef.FindStringSubmatch(os.Getenv("GITHUB_REF"))
+		if m == nil {
+			return nil, ansi.Errorf("@R{Cannot determine the pull request: set} @m{AVIATOR_PULL_REQUEST} @R{or run on a pull request ref}")
+		}
+		pr = m[1]
+	}
+
+	api := os.Getenv("GITHUB_API_URL")
+	if api == "" {
+		api = defaultGitHubAPI
+	}
+
+	req, err := jsonRequest(fmt.Sprintf("%s/repos/%s/issues/%s/comments", strings.TrimSuffix(api, "/"), repo, pr), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "token "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	return req, nil
+}
+
+func gitLabRequest(body string) (*http.Request, error) {
+	token, err := env("GITLAB_TOKEN")
+	if err != nil {
+		return nil, err
+	}
+	api, err := env("CI_API_V4_URL")
+	if err != nil {
+		return nil, err
+	}
+	project, err := env("CI_PROJECT_ID")
+	if err != nil {
+		return nil, err
+	}
+
+	mr := os.Getenv("AVIATOR_PULL_REQUEST")
+	if mr == "" {
+		mr, err = env("CI_MERGE_REQUEST_IID")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := jsonRequest(fmt.Sprintf("%s/projects/%s/merge_requests/%s/notes", strings.TrimSuffix(api, "/"), url.PathEscape(project), mr), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("PRIVATE-TOKEN", token)
+	return req, nil
+}
+
+func jsonRequest(endpoint, body string) (*http.Request, error) {
+	payload, err := json.Marshal(map[string]string{"body": body})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+func env(name string) (string, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return "", ansi.Errorf("@R{Environment variable} @m{%s} @R{is required to post the rendered diff}", name)
+	}
+	return value, nil
+}
@@ -0,0 +1,13 @@
+package annotator_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestAnnotator(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Annotator Suite")
+}
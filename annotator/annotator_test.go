@@ -0,0 +1,123 @@
+package annotator_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	. "github.com/JulzDiverse/aviator/annotator"
+	"github.com/JulzDiverse/aviator/differ"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Annotator", func() {
+
+	Context("Body", func() {
+		It("renders the changes of every file as a diff block", func() {
+			body := Body([]FileDiff{
+				{File: "manifests/app.yml", Changes: []differ.Change{
+					{Path: "spec.replicas", Kind: differ.Changed, Old: "1", New: "3"},
+					{Path: "spec.paused", Kind: differ.Added, New: "true"},
+					{Path: "spec.strategy", Kind: differ.Removed, Old: "Recreate"},
+				}},
+				{File: "manifests/unchanged.yml"},
+			})
+
+			Expect(body).To(ContainSubstring("<code>manifests/app.yml</code> (3 changes)"))
+			Expect(body).To(ContainSubstring("- spec.replicas: 1\n+ spec.replicas: 3\n+ spec.paused: true\n- spec.strategy: Recreate\n"))
+			Expect(body).ToNot(ContainSubstring("unchanged.yml"))
+		})
+
+		It("states when nothing changed", func() {
+			Expect(Body([]FileDiff{{File: "a.yml"}})).To(ContainSubstring("No changes in rendered files."))
+		})
+	})
+
+	Context("Post", func() {
+		var (
+			server  *httptest.Server
+			path    string
+			headers http.Header
+			payload map[string]string
+			env     map[string]string
+		)
+
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path = r.URL.EscapedPath()
+				headers = r.Header
+				b, _ := ioutil.ReadAll(r.Body)
+				json.Unmarshal(b, &payload)
+				w.WriteHeader(http.StatusCreated)
+			}))
+		})
+
+		JustBeforeEach(func() {
+			for k, v := range env {
+				os.Setenv(k, v)
+			}
+		})
+
+		AfterEach(func() {
+			for k := range env {
+				os.Unsetenv(k)
+			}
+			server.Close()
+		})
+
+		Context("to github", func() {
+			BeforeEach(func() {
+				env = map[string]string{
+					"GITHUB_API_URL":    server.URL,
+					"GITHUB_TOKEN":      "secret",
+					"GITHUB_REPOSITORY": "org/repo",
+					"GITHUB_REF":        "refs/pull/42/merge",
+				}
+			})
+
+			It("comments on the pull request of the current ref", func() {
+				Expect(Post(GitHub, "diff")).To(Succeed())
+				Expect(path).To(Equal("/repos/org/repo/issues/42/comments"))
+				Expect(headers.Get("Authorization")).To(Equal("token secret"))
+				Expect(payload["body"]).To(Equal("diff"))
+			})
+
+			It("fails outside of a pull request", func() {
+				os.Setenv("GITHUB_REF", "refs/heads/main")
+				Expect(Post(GitHub, "diff")).To(MatchError(ContainSubstring("Cannot determine the pull request")))
+			})
+		})
+
+		Context("to gitlab", func() {
+			BeforeEach(func() {
+				env = map[string]string{
+					"CI_API_V4_URL":        server.URL + "/api/v4",
+					"GITLAB_TOKEN":         "secret",
+					"CI_PROJECT_ID":        "group/project",
+					"CI_MERGE_REQUEST_IID": "7",
+				}
+			})
+
+			It("adds a note to the merge request", func() {
+				Expect(Post(GitLab, "diff")).To(Succeed())
+				Expect(path).To(Equal("/api/v4/projects/group%2Fproject/merge_requests/7/notes"))
+				Expect(headers.Get("PRIVATE-TOKEN")).To(Equal("secret"))
+				Expect(payload["body"]).To(Equal("diff"))
+			})
+
+			It("requires a token", func() {
+				os.Unsetenv("GITLAB_TOKEN")
+				Expect(Post(GitLab, "diff")).To(MatchError(ContainSubstring("GITLAB_TOKEN")))
+			})
+		})
+
+		It("rejects unknown providers", func() {
+			env = map[string]string{}
+			Expect(Post("bitbucket", "diff")).To(MatchError(ContainSubstring("Unknown annotation provider")))
+		})
+	})
+})
@@ -0,0 +1,241 @@
+package asserter
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/starkandwayne/goutils/ansi"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var docSeparator = regexp.MustCompile(`(?m)^---\s*$`)
+var expression = regexp.MustCompile(`^(\S+)\s+(exists|absent|==|!=|>=|<=|>|<|matches|in)\s*(.*)$`)
+
+type Assertion struct {
+	Expression string
+	Path       string
+	Operator   string
+	Value      interface{}
+
+	pattern *regexp.Regexp
+}
+
+type Violation struct {
+	Assertion string
+	Path      string
+	Message   string
+}
+
+func Parse(expr string) (Assertion, error) {
+	m := expression.FindStringSubmatch(strings.TrimSpace(expr))
+	if m == nil {
+		return Assertion{}, ansi.Errorf("@R{Invalid assertion} @m{%s}@R{: expected} @m{<path> <operator> [value]}", expr)
+	}
+
+	a := Assertion{Expression: expr, Path: m[1], Operator: m[2]}
+	switch a.Operator {
+	case "exists", "absent":
+		if m[3] != "" {
+			return a, ansi.Errorf("@R{Invalid assertion} @m{%s}@R{:} @m{%s} @R{takes no value}", expr, a.Operator)
+		}
+		return a, nil
+	case "matches":
+		pattern, err := regexp.Compile(m[3])
+		if err != nil {
+			return a, ansi.Errorf("@R{Invalid assertion} @m{%s}@R{: %s}", expr, err.Error())
+		}
+		a.pattern = pattern
+		return a, nil
+	}
+
+	if m[3] == "" {
+		return a, ansi.Errorf("@R{Invalid assertion} @m{%s}@R{:} @m{%s} @R{requires a value}", expr, a.Operator)
+	}
+	err := yaml.Unmarshal([]byte(m[3]), &a.Value)
+	if err != nil {
+		return a, ansi.Errorf("@R{Invalid assertion} @m{%s}@R{: %s}", expr, err.Error())
+	}
+	if _, ok := a.Value.([]interface{}); a.Operator == "in" && !ok {
+		return a, ansi.Errorf("@R{Invalid assertion} @m{%s}@R{:} @m{in} @R{requires a list like} @m{[a, b]}", expr)
+	}
+	return a, nil
+}
+
+func Check(file []byte, exprs []string) ([]Violation, error) {
+	assertions := []Assertion{}
+	for _, expr := range exprs {
+		a, err := Parse(expr)
+		if err != nil {
+			return nil, err
+		}
+		assertions = append(assertions, a)
+	}
+
+	docs := []interface{}{}
+	for i, doc := range docSeparator.Split(string(file), -1) {
+		if strings.TrimSpace(doc) == "" {
+			continue
+		}
+		var parsed interface{}
+		err := yaml.Unmarshal([]byte(doc), &parsed)
+		if err != nil {
+			return nil, ansi.Errorf("@R{Parsing document %d for assertions failed}: %s", i, err.Error())
+		}
+		if parsed != nil {
+			docs = append(docs, parsed)
+		}
+	}
+
+	violations := []Violation{}
+	for i, doc := range docs {
+		prefix := ""
+		if len(docs) > 1 {
+			prefix = fmt.Sprintf("[%d].", i)
+		}
+		for _, a := range assertions {
+			for _, v := range a.check(doc) {
+				v.Path = prefix + v.Path
+				violations = append(violations, v)
+			}
+		}
+	}
+	return violations, nil
+}
+
+func (a Assertion) check(doc interface{}) []Violation {
+	found := Lookup(doc, a.Path)
+	paths := []string{}
+	for p := range found {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	switch a.Operator {
+	case "exists":
+		if len(found) == 0 {
+			return []Violation{{a.Expression, a.Path, "does not exist"}}
+		}
+		return nil
+	case "absent":
+		violations := []Violation{}
+		for _, p := range paths {
+			violations = append(violations, Violation{a.Expression, p, "exists"})
+		}
+		return violations
+	}
+
+	if len(found) == 0 {
+		return []Violation{{a.Expression, a.Path, "does not exist"}}
+	}
+
+	violations := []Violation{}
+	for _, p := range paths {
+		if !a.holds(found[p]) {
+			violations = append(violations, Violation{a.Expression, p, fmt.Sprintf("is %v", found[p])})
+		}
+	}
+	return violations
+}
+
+func (a Assertion) holds(value interface{}) bool {
+	switch a.Operator {
+	case "matches":
+		return a.pattern.MatchString(fmt.Sprint(value))
+	case "==":
+		return equal(value, a.Value)
+	case "!=":
+		return !equal(value, a.Value)
+	case "in":
+		for _, allowed := range a.Value.([]interface{}) {
+			if equal(value, allowed) {
+				return true
+			}
+		}
+		return false
+	}
+
+	actual, ok := number(value)
+	expected, ok2 := number(a.Value)
+	if !ok || !ok2 {
+		return false
+	}
+	switch a.Operator {
+	case ">":
+		return actual > expected
+	case ">=":
+		return actual >= expected
+	case "<":
+		return actual < expected
+	case "<=":
+		return actual <= expected
+	}
+	return false
+}
+
+func Lookup(doc interface{}, path string) map[string]interface{} {
+	return lookup(doc, strings.Split(path, "."), "")
+}
+
+func lookup(value interface{}, segments []string, path string) map[string]interface{} {
+	if len(segments) == 0 {
+		return map[string]interface{}{path: value}
+	}
+
+	result := map[string]interface{}{}
+	segment, rest := segments[0], segments[1:]
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		for key, child := range v {
+			name := fmt.Sprint(key)
+			if segment == "*" || segment == name {
+				merge(result, lookup(child, rest, join(path, name)))
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			if segment == "*" || segment == strconv.Itoa(i) {
+				merge(result, lookup(child, rest, join(path, strconv.Itoa(i))))
+			}
+		}
+	}
+	return result
+}
+
+func join(path, segment string) string {
+	if path == "" {
+		return segment
+	}
+	return path + "." + segment
+}
+
+func merge(into, from map[string]interface{}) {
+	for k, v := range from {
+		into[k] = v
+	}
+}
+
+func equal(a, b interface{}) bool {
+	if x, ok := number(a); ok {
+		if y, ok := number(b); ok {
+			return x == y
+		}
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+func number(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
@@ -0,0 +1,13 @@
+package asserter_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestAsserter(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Asserter Suite")
+}
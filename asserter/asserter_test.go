@@ -0,0 +1,68 @@
+package asserter_test
+
+import (
+	. "github.com/JulzDiverse/aviator/asserter"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Asserter", func() {
+
+	deployment := []byte(`
+kind: Deployment
+metadata:
+  name: app
+  labels:
+    team: platform
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: app
+        image: registry.example.com/app:1.0
+      - name: sidecar
+        image: docker.io/envoy:1.2
+`)
+
+	It("passes if all assertions hold", func() {
+		violations, err := Check(deployment, []string{
+			"spec.replicas >= 2",
+			"metadata.labels.team exists",
+			"metadata.labels.owner absent",
+			"kind == Deployment",
+			"metadata.name in [app, web]",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(violations).To(BeEmpty())
+	})
+
+	It("reports every violated assertion with its path", func() {
+		violations, err := Check(deployment, []string{
+			"spec.replicas > 5",
+			"metadata.labels.owner exists",
+			`spec.template.spec.containers.*.image matches ^registry\.example\.com/`,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(violations).To(Equal([]Violation{
+			{Assertion: "spec.replicas > 5", Path: "spec.replicas", Message: "is 3"},
+			{Assertion: "metadata.labels.owner exists", Path: "metadata.labels.owner", Message: "does not exist"},
+			{Assertion: `spec.template.spec.containers.*.image matches ^registry\.example\.com/`, Path: "spec.template.spec.containers.1.image", Message: "is docker.io/envoy:1.2"},
+		}))
+	})
+
+	It("checks every document of a multi-document file", func() {
+		violations, err := Check([]byte("---\nreplicas: 1\n---\nreplicas: 2\n"), []string{"replicas >= 2"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(violations).To(Equal([]Violation{{Assertion: "replicas >= 2", Path: "[0].replicas", Message: "is 1"}}))
+	})
+
+	It("fails on an invalid assertion", func() {
+		_, err := Check(deployment, []string{"spec.replicas"})
+		Expect(err).To(MatchError(ContainSubstring("Invalid assertion")))
+
+		_, err = Check(deployment, []string{"metadata.name in app"})
+		Expect(err).To(MatchError(ContainSubstring("requires a list")))
+	})
+})
@@ -0,0 +1,140 @@
+package authorizer
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/JulzDiverse/aviator"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+// Authorizer looks up the credentials of remote sources. Without a matching
+// rule the ambient credentials of the environment are used.
+type Authorizer struct {
+	rules []aviator.SourceAuth
+}
+
+func New(rules []aviator.SourceAuth) *Authorizer {
+	return &Authorizer{rules: rules}
+}
+
+// Validate checks that every rule has a prefix and at most one kind of HTTP
+// credentials.
+func Validate(rules []aviator.SourceAuth) error {
+	prefixes := map[string]bool{}
+	for i, rule := range rules {
+		if rule.Prefix == "" {
+			return ansi.Errorf("@R{INVALID SYNTAX}: auth %d requires a @m{prefix}", i)
+		}
+		if prefixes[rule.Prefix] {
+			return ansi.Errorf("@R{INVALID SYNTAX}: auth prefix @m{%s} is defined more than once", rule.Prefix)
+		}
+		prefixes[rule.Prefix] = true
+		if (rule.UsernameEnv == "") != (rule.PasswordEnv == "") {
+			return ansi.Errorf("@R{INVALID SYNTAX}: auth @m{%s} requires both @m{username_env} and @m{password_env}", rule.Prefix)
+		}
+		if rule.TokenEnv != "" && rule.UsernameEnv != "" {
+			return ansi.Errorf("@R{INVALID SYNTAX}: auth @m{%s} can use either @m{token_env} or @m{username_env}/@m{password_env}", rule.Prefix)
+		}
+	}
+	return nil
+}
+
+// Match returns the rule with the longest prefix of source.
+func (a *Authorizer) Match(source string) (aviator.SourceAuth, bool) {
+	var result aviator.SourceAuth
+	found := false
+	if a == nil {
+		return result, false
+	}
+	for _, rule := range a.rules {
+		if strings.HasPrefix(source, rule.Prefix) && (!found || len(rule.Prefix) > len(result.Prefix)) {
+			result, found = rule, true
+		}
+	}
+	return result, found
+}
+
+// Header returns the Authorization header for source, empty if it has no
+// HTTP credentials.
+func (a *Authorizer) Header(source string) (string, error) {
+	rule, ok := a.Match(source)
+	if !ok {
+		return "", nil
+	}
+
+	switch {
+	case rule.TokenEnv != "":
+		token, err := env(rule, rule.TokenEnv)
+		if err != nil {
+			return "", err
+		}
+		return "Bearer " + token, nil
+	case rule.UsernameEnv != "":
+		username, err := env(rule, rule.UsernameEnv)
+		if err != nil {
+			return "", err
+		}
+		password, err := env(rule, rule.PasswordEnv)
+		if err != nil {
+			return "", err
+		}
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password)), nil
+	}
+	return "", nil
+}
+
+// GitArgs returns the global git options for source, which send its HTTP
+// credentials.
+func (a *Authorizer) GitArgs(source string) ([]string, error) {
+	header, err := a.Header(source)
+	if err != nil || header == "" {
+		return nil, err
+	}
+	return []string{"-c", "http.extraHeader=Authorization: " + header}, nil
+}
+
+// GitEnv returns the environment variables of git for source, which select
+// its SSH key or agent.
+func (a *Authorizer) GitEnv(source string) []string {
+	rule, ok := a.Match(source)
+	if !ok {
+		return nil
+	}
+
+	result := []string{}
+	if rule.SSHKey != "" {
+		result = append(result, "GIT_SSH_COMMAND=ssh -i '"+expandHome(rule.SSHKey)+"' -o IdentitiesOnly=yes")
+	}
+	if rule.SSHAgent != "" {
+		result = append(result, "SSH_AUTH_SOCK="+expandHome(rule.SSHAgent))
+	}
+	return result
+}
+
+// AWSProfile returns the AWS profile of source, empty for the default one.
+func (a *Authorizer) AWSProfile(source string) string {
+	rule, _ := a.Match(source)
+	return rule.AWSProfile
+}
+
+func env(rule aviator.SourceAuth, name string) (string, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return "", ansi.Errorf("@R{Environment variable} @m{%s} @R{of the auth for} @m{%s} @R{is not set}", name, rule.Prefix)
+	}
+	return value, nil
+}
+
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[2:])
+}
@@ -0,0 +1,95 @@
+package authorizer_test
+
+import (
+	"os"
+
+	"github.com/JulzDiverse/aviator"
+	. "github.com/JulzDiverse/aviator/authorizer"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Authorizer", func() {
+
+	var auth *Authorizer
+
+	BeforeEach(func() {
+		os.Setenv("AVIATOR_TEST_TOKEN", "token")
+		os.Setenv("AVIATOR_TEST_USER", "user")
+		os.Setenv("AVIATOR_TEST_PASSWORD", "password")
+		auth = New([]aviator.SourceAuth{
+			{Prefix: "https://artifacts.example.com/", UsernameEnv: "AVIATOR_TEST_USER", PasswordEnv: "AVIATOR_TEST_PASSWORD"},
+			{Prefix: "https://artifacts.example.com/team/", TokenEnv: "AVIATOR_TEST_TOKEN"},
+			{Prefix: "git+ssh://git@github.com/org/", SSHKey: "/keys/deploy", SSHAgent: "/run/agent.sock"},
+			{Prefix: "s3://prod-bucket/", AWSProfile: "prod"},
+		})
+	})
+
+	AfterEach(func() {
+		os.Unsetenv("AVIATOR_TEST_TOKEN")
+		os.Unsetenv("AVIATOR_TEST_USER")
+		os.Unsetenv("AVIATOR_TEST_PASSWORD")
+	})
+
+	Context("Header", func() {
+		It("uses the rule with the longest matching prefix", func() {
+			Expect(auth.Header("https://artifacts.example.com/team/base.yml")).To(Equal("Bearer token"))
+			Expect(auth.Header("https://artifacts.example.com/base.yml")).To(Equal("Basic dXNlcjpwYXNzd29yZA=="))
+		})
+
+		It("is empty without a matching rule", func() {
+			Expect(auth.Header("https://other.example.com/base.yml")).To(BeEmpty())
+			Expect(New(nil).Header("https://artifacts.example.com/base.yml")).To(BeEmpty())
+		})
+
+		It("fails if the environment variable is not set", func() {
+			os.Unsetenv("AVIATOR_TEST_TOKEN")
+			_, err := auth.Header("https://artifacts.example.com/team/base.yml")
+			Expect(err).To(MatchError(ContainSubstring("AVIATOR_TEST_TOKEN")))
+		})
+	})
+
+	Context("Git", func() {
+		It("selects the ssh key and agent of git+ssh sources", func() {
+			Expect(auth.GitEnv("git+ssh://git@github.com/org/repo.git")).To(Equal([]string{
+				"GIT_SSH_COMMAND=ssh -i '/keys/deploy' -o IdentitiesOnly=yes",
+				"SSH_AUTH_SOCK=/run/agent.sock",
+			}))
+			Expect(auth.GitEnv("git+ssh://git@github.com/other/repo.git")).To(BeEmpty())
+		})
+
+		It("sends the http credentials of git+https sources", func() {
+			auth = New([]aviator.SourceAuth{{Prefix: "git+https://github.com/org/", TokenEnv: "AVIATOR_TEST_TOKEN"}})
+			Expect(auth.GitArgs("git+https://github.com/org/repo.git")).To(Equal([]string{"-c", "http.extraHeader=Authorization: Bearer token"}))
+			Expect(auth.GitArgs("git+https://github.com/other/repo.git")).To(BeEmpty())
+		})
+	})
+
+	It("returns the aws profile of s3 sources", func() {
+		Expect(auth.AWSProfile("s3://prod-bucket/base.yml")).To(Equal("prod"))
+		Expect(auth.AWSProfile("s3://dev-bucket/base.yml")).To(BeEmpty())
+	})
+
+	Context("Validate", func() {
+		It("accepts valid rules", func() {
+			Expect(Validate([]aviator.SourceAuth{{Prefix: "https://a/", TokenEnv: "T"}, {Prefix: "s3://b/", AWSProfile: "p"}})).To(Succeed())
+		})
+
+		It("requires a prefix", func() {
+			Expect(Validate([]aviator.SourceAuth{{TokenEnv: "T"}})).To(MatchError(ContainSubstring("prefix")))
+		})
+
+		It("rejects duplicate prefixes", func() {
+			Expect(Validate([]aviator.SourceAuth{{Prefix: "https://a/"}, {Prefix: "https://a/"}})).To(MatchError(ContainSubstring("more than once")))
+		})
+
+		It("requires username and password together", func() {
+			Expect(Validate([]aviator.SourceAuth{{Prefix: "https://a/", UsernameEnv: "U"}})).To(MatchError(ContainSubstring("password_env")))
+		})
+
+		It("rejects a token combined with basic auth", func() {
+			Expect(Validate([]aviator.SourceAuth{{Prefix: "https://a/", TokenEnv: "T", UsernameEnv: "U", PasswordEnv: "P"}})).To(MatchError(ContainSubstring("either")))
+		})
+	})
+})
@@ -33,6 +33,46 @@ type FakeSpruceProcessor struct {
 	processWithOptsReturnsOnCall map[int]struct {
 		result1 error
 	}
+	PlanStub        func([]aviator.Spruce) ([]aviator.PlannedMerge, error)
+	planMutex       sync.RWMutex
+	planArgsForCall []struct {
+		arg1 []aviator.Spruce
+	}
+	planReturns struct {
+		result1 []aviator.PlannedMerge
+		result2 error
+	}
+	planReturnsOnCall map[int]struct {
+		result1 []aviator.PlannedMerge
+		result2 error
+	}
+	RenderTargetStub        func(string)
+	renderTargetMutex       sync.RWMutex
+	renderTargetArgsForCall []struct {
+		arg1 string
+	}
+	WarningsStub        func() []aviator.Warning
+	warningsMutex       sync.RWMutex
+	warningsArgsForCall []struct{}
+	warningsReturns     struct {
+		result1 []aviator.Warning
+	}
+	warningsReturnsOnCall map[int]struct {
+		result1 []aviator.Warning
+	}
+	OutputsStub        func(string) ([]string, bool)
+	outputsMutex       sync.RWMutex
+	outputsArgsForCall []struct {
+		arg1 string
+	}
+	outputsReturns struct {
+		result1 []string
+		result2 bool
+	}
+	outputsReturnsOnCall map[int]struct {
+		result1 []string
+		result2 bool
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -146,6 +186,177 @@ func (fake *FakeSpruceProcessor) ProcessWithOptsReturnsOnCall(i int, result1 err
 	}{result1}
 }
 
+func (fake *FakeSpruceProcessor) Plan(arg1 []aviator.Spruce) ([]aviator.PlannedMerge, error) {
+	var arg1Copy []aviator.Spruce
+	if arg1 != nil {
+		arg1Copy = make([]aviator.Spruce, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.planMutex.Lock()
+	ret, specificReturn := fake.planReturnsOnCall[len(fake.planArgsForCall)]
+	fake.planArgsForCall = append(fake.planArgsForCall, struct {
+		arg1 []aviator.Spruce
+	}{arg1Copy})
+	fake.recordInvocation("Plan", []interface{}{arg1Copy})
+	fake.planMutex.Unlock()
+	if fake.PlanStub != nil {
+		return fake.PlanStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fake.planReturns.result1, fake.planReturns.result2
+}
+
+func (fake *FakeSpruceProcessor) PlanCallCount() int {
+	fake.planMutex.RLock()
+	defer fake.planMutex.RUnlock()
+	return len(fake.planArgsForCall)
+}
+
+func (fake *FakeSpruceProcessor) PlanArgsForCall(i int) []aviator.Spruce {
+	fake.planMutex.RLock()
+	defer fake.planMutex.RUnlock()
+	return fake.planArgsForCall[i].arg1
+}
+
+func (fake *FakeSpruceProcessor) PlanReturns(result1 []aviator.PlannedMerge, result2 error) {
+	fake.PlanStub = nil
+	fake.planReturns = struct {
+		result1 []aviator.PlannedMerge
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSpruceProcessor) PlanReturnsOnCall(i int, result1 []aviator.PlannedMerge, result2 error) {
+	fake.PlanStub = nil
+	if fake.planReturnsOnCall == nil {
+		fake.planReturnsOnCall = make(map[int]struct {
+			result1 []aviator.PlannedMerge
+			result2 error
+		})
+	}
+	fake.planReturnsOnCall[i] = struct {
+		result1 []aviator.PlannedMerge
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSpruceProcessor) RenderTarget(arg1 string) {
+	fake.renderTargetMutex.Lock()
+	fake.renderTargetArgsForCall = append(fake.renderTargetArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("RenderTarget", []interface{}{arg1})
+	fake.renderTargetMutex.Unlock()
+	if fake.RenderTargetStub != nil {
+		fake.RenderTargetStub(arg1)
+	}
+}
+
+func (fake *FakeSpruceProcessor) RenderTargetCallCount() int {
+	fake.renderTargetMutex.RLock()
+	defer fake.renderTargetMutex.RUnlock()
+	return len(fake.renderTargetArgsForCall)
+}
+
+func (fake *FakeSpruceProcessor) RenderTargetArgsForCall(i int) string {
+	fake.renderTargetMutex.RLock()
+	defer fake.renderTargetMutex.RUnlock()
+	return fake.renderTargetArgsForCall[i].arg1
+}
+
+func (fake *FakeSpruceProcessor) Warnings() []aviator.Warning {
+	fake.warningsMutex.Lock()
+	ret, specificReturn := fake.warningsReturnsOnCall[len(fake.warningsArgsForCall)]
+	fake.warningsArgsForCall = append(fake.warningsArgsForCall, struct{}{})
+	fake.recordInvocation("Warnings", []interface{}{})
+	fake.warningsMutex.Unlock()
+	if fake.WarningsStub != nil {
+		return fake.WarningsStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.warningsReturns.result1
+}
+
+func (fake *FakeSpruceProcessor) WarningsCallCount() int {
+	fake.warningsMutex.RLock()
+	defer fake.warningsMutex.RUnlock()
+	return len(fake.warningsArgsForCall)
+}
+
+func (fake *FakeSpruceProcessor) WarningsReturns(result1 []aviator.Warning) {
+	fake.WarningsStub = nil
+	fake.warningsReturns = struct {
+		result1 []aviator.Warning
+	}{result1}
+}
+
+func (fake *FakeSpruceProcessor) WarningsReturnsOnCall(i int, result1 []aviator.Warning) {
+	fake.WarningsStub = nil
+	if fake.warningsReturnsOnCall == nil {
+		fake.warningsReturnsOnCall = make(map[int]struct {
+			result1 []aviator.Warning
+		})
+	}
+	fake.warningsReturnsOnCall[i] = struct {
+		result1 []aviator.Warning
+	}{result1}
+}
+
+func (fake *FakeSpruceProcessor) Outputs(arg1 string) ([]string, bool) {
+	fake.outputsMutex.Lock()
+	ret, specificReturn := fake.outputsReturnsOnCall[len(fake.outputsArgsForCall)]
+	fake.outputsArgsForCall = append(fake.outputsArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("Outputs", []interface{}{arg1})
+	fake.outputsMutex.Unlock()
+	if fake.OutputsStub != nil {
+		return fake.OutputsStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fake.outputsReturns.result1, fake.outputsReturns.result2
+}
+
+func (fake *FakeSpruceProcessor) OutputsCallCount() int {
+	fake.outputsMutex.RLock()
+	defer fake.outputsMutex.RUnlock()
+	return len(fake.outputsArgsForCall)
+}
+
+func (fake *FakeSpruceProcessor) OutputsArgsForCall(i int) string {
+	fake.outputsMutex.RLock()
+	defer fake.outputsMutex.RUnlock()
+	return fake.outputsArgsForCall[i].arg1
+}
+
+func (fake *FakeSpruceProcessor) OutputsReturns(result1 []string, result2 bool) {
+	fake.OutputsStub = nil
+	fake.outputsReturns = struct {
+		result1 []string
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *FakeSpruceProcessor) OutputsReturnsOnCall(i int, result1 []string, result2 bool) {
+	fake.OutputsStub = nil
+	if fake.outputsReturnsOnCall == nil {
+		fake.outputsReturnsOnCall = make(map[int]struct {
+			result1 []string
+			result2 bool
+		})
+	}
+	fake.outputsReturnsOnCall[i] = struct {
+		result1 []string
+		result2 bool
+	}{result1, result2}
+}
+
 func (fake *FakeSpruceProcessor) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -153,6 +364,14 @@ func (fake *FakeSpruceProcessor) Invocations() map[string][][]interface{} {
 	defer fake.processMutex.RUnlock()
 	fake.processWithOptsMutex.RLock()
 	defer fake.processWithOptsMutex.RUnlock()
+	fake.planMutex.RLock()
+	defer fake.planMutex.RUnlock()
+	fake.renderTargetMutex.RLock()
+	defer fake.renderTargetMutex.RUnlock()
+	fake.warningsMutex.RLock()
+	defer fake.warningsMutex.RUnlock()
+	fake.outputsMutex.RLock()
+	defer fake.outputsMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -19,6 +19,17 @@ type FakeValidator struct {
 	validateSpruceReturnsOnCall map[int]struct {
 		result1 error
 	}
+	ValidateExecutorsStub        func(aviator.AviatorYaml) error
+	validateExecutorsMutex       sync.RWMutex
+	validateExecutorsArgsForCall []struct {
+		arg1 aviator.AviatorYaml
+	}
+	validateExecutorsReturns struct {
+		result1 error
+	}
+	validateExecutorsReturnsOnCall map[int]struct {
+		result1 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -76,11 +87,61 @@ func (fake *FakeValidator) ValidateSpruceReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeValidator) ValidateExecutors(arg1 aviator.AviatorYaml) error {
+	fake.validateExecutorsMutex.Lock()
+	ret, specificReturn := fake.validateExecutorsReturnsOnCall[len(fake.validateExecutorsArgsForCall)]
+	fake.validateExecutorsArgsForCall = append(fake.validateExecutorsArgsForCall, struct {
+		arg1 aviator.AviatorYaml
+	}{arg1})
+	fake.recordInvocation("ValidateExecutors", []interface{}{arg1})
+	fake.validateExecutorsMutex.Unlock()
+	if fake.ValidateExecutorsStub != nil {
+		return fake.ValidateExecutorsStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.validateExecutorsReturns.result1
+}
+
+func (fake *FakeValidator) ValidateExecutorsCallCount() int {
+	fake.validateExecutorsMutex.RLock()
+	defer fake.validateExecutorsMutex.RUnlock()
+	return len(fake.validateExecutorsArgsForCall)
+}
+
+func (fake *FakeValidator) ValidateExecutorsArgsForCall(i int) aviator.AviatorYaml {
+	fake.validateExecutorsMutex.RLock()
+	defer fake.validateExecutorsMutex.RUnlock()
+	return fake.validateExecutorsArgsForCall[i].arg1
+}
+
+func (fake *FakeValidator) ValidateExecutorsReturns(result1 error) {
+	fake.ValidateExecutorsStub = nil
+	fake.validateExecutorsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeValidator) ValidateExecutorsReturnsOnCall(i int, result1 error) {
+	fake.ValidateExecutorsStub = nil
+	if fake.validateExecutorsReturnsOnCall == nil {
+		fake.validateExecutorsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.validateExecutorsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeValidator) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
 	fake.validateSpruceMutex.RLock()
 	defer fake.validateSpruceMutex.RUnlock()
+	fake.validateExecutorsMutex.RLock()
+	defer fake.validateExecutorsMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
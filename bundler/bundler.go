@@ -0,0 +1,96 @@
+package bundler
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+// Bundle collects files for a debug archive, which is written as a gzipped
+// tarball with all files below a single top-level directory.
+type Bundle struct {
+	root  string
+	names []string
+	files map[string][]byte
+}
+
+func New(root string) *Bundle {
+	return &Bundle{root: root, files: map[string][]byte{}}
+}
+
+// Add adds a file; adding a name again replaces its content.
+func (b *Bundle) Add(name string, content []byte) {
+	if _, ok := b.files[name]; !ok {
+		b.names = append(b.names, name)
+	}
+	b.files[name] = content
+}
+
+// AddDir adds the files below dir with their path relative to dir prefixed
+// with prefix. A missing dir adds nothing.
+func (b *Bundle) AddDir(prefix, dir string) error {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil
+	}
+	return filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		b.Add(path.Join(prefix, filepath.ToSlash(rel)), content)
+		return nil
+	})
+}
+
+// Names returns the names of the files in the order they were added.
+func (b *Bundle) Names() []string {
+	return append([]string{}, b.names...)
+}
+
+func (b *Bundle) Write(file string) error {
+	out, err := os.Create(file)
+	if err != nil {
+		return errors.Wrap(err, ansi.Sprintf("@R{Writing debug bundle} @m{%s} @R{FAILED}", file))
+	}
+	defer out.Close()
+
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+	for _, name := range b.names {
+		content := b.files[name]
+		header := &tar.Header{
+			Name:    path.Join(b.root, name),
+			Mode:    0644,
+			Size:    int64(len(content)),
+			ModTime: now,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return errors.Wrap(err, ansi.Sprintf("@R{Writing debug bundle} @m{%s} @R{FAILED}", file))
+		}
+		if _, err := tw.Write(content); err != nil {
+			return errors.Wrap(err, ansi.Sprintf("@R{Writing debug bundle} @m{%s} @R{FAILED}", file))
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return errors.Wrap(err, ansi.Sprintf("@R{Writing debug bundle} @m{%s} @R{FAILED}", file))
+	}
+	if err := gz.Close(); err != nil {
+		return errors.Wrap(err, ansi.Sprintf("@R{Writing debug bundle} @m{%s} @R{FAILED}", file))
+	}
+	return out.Close()
+}
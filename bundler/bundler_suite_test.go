@@ -0,0 +1,13 @@
+package bundler_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestBundler(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Bundler Suite")
+}
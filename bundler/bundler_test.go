@@ -0,0 +1,88 @@
+package bundler_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/JulzDiverse/aviator/bundler"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Bundler", func() {
+
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "bundler")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	readArchive := func(file string) map[string]string {
+		f, err := os.Open(file)
+		Expect(err).ToNot(HaveOccurred())
+		defer f.Close()
+		gz, err := gzip.NewReader(f)
+		Expect(err).ToNot(HaveOccurred())
+
+		files := map[string]string{}
+		tr := tar.NewReader(gz)
+		for {
+			header, err := tr.Next()
+			if err != nil {
+				break
+			}
+			content, err := ioutil.ReadAll(tr)
+			Expect(err).ToNot(HaveOccurred())
+			files[header.Name] = string(content)
+		}
+		return files
+	}
+
+	It("writes the files below the root of a gzipped tarball", func() {
+		bundle := New("debug")
+		bundle.Add("log.txt", []byte("first"))
+		bundle.Add("plan.json", []byte("{}"))
+		bundle.Add("log.txt", []byte("second"))
+		Expect(bundle.Names()).To(Equal([]string{"log.txt", "plan.json"}))
+
+		archive := filepath.Join(dir, "debug.tgz")
+		Expect(bundle.Write(archive)).To(Succeed())
+		Expect(readArchive(archive)).To(Equal(map[string]string{
+			"debug/log.txt":   "second",
+			"debug/plan.json": "{}",
+		}))
+	})
+
+	It("adds the files of a directory", func() {
+		Expect(os.MkdirAll(filepath.Join(dir, "failures", "result.yml"), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "failures", "result.yml", "error.txt"), []byte("failed"), 0644)).To(Succeed())
+
+		bundle := New("debug")
+		Expect(bundle.AddDir("failures", filepath.Join(dir, "failures"))).To(Succeed())
+		Expect(bundle.AddDir("missing", filepath.Join(dir, "missing"))).To(Succeed())
+		Expect(bundle.Names()).To(Equal([]string{"failures/result.yml/error.txt"}))
+	})
+
+	It("captures stdout and stderr", func() {
+		stop, err := Capture()
+		Expect(err).ToNot(HaveOccurred())
+		fmt.Fprint(os.Stdout, "out\n")
+		fmt.Fprint(os.Stderr, "err\n")
+		log := stop()
+
+		Expect(string(log)).To(ContainSubstring("out\n"))
+		Expect(string(log)).To(ContainSubstring("err\n"))
+		Expect(stop()).To(Equal(log))
+	})
+})
@@ -0,0 +1,63 @@
+package bundler
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+)
+
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// Capture records everything written to stdout and stderr, including the
+// output of executed commands, while still passing it through. The returned
+// stop restores stdout and stderr and returns the recorded output.
+func Capture() (func() []byte, error) {
+	log := &lockedBuffer{}
+	stdout, stderr := os.Stdout, os.Stderr
+
+	outReader, outWriter, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	errReader, errWriter, err := os.Pipe()
+	if err != nil {
+		outReader.Close()
+		outWriter.Close()
+		return nil, err
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		io.Copy(io.MultiWriter(stdout, log), outReader)
+	}()
+	go func() {
+		defer wg.Done()
+		io.Copy(io.MultiWriter(stderr, log), errReader)
+	}()
+	os.Stdout, os.Stderr = outWriter, errWriter
+
+	var once sync.Once
+	return func() []byte {
+		once.Do(func() {
+			os.Stdout, os.Stderr = stdout, stderr
+			outWriter.Close()
+			errWriter.Close()
+			wg.Wait()
+		})
+		log.mu.Lock()
+		defer log.mu.Unlock()
+		return append([]byte{}, log.buf.Bytes()...)
+	}, nil
+}
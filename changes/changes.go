@@ -0,0 +1,74 @@
+package changes
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+type Changes struct {
+	files []string
+}
+
+var changes *Changes
+
+func New(files []string) *Changes {
+	return &Changes{files}
+}
+
+func Default() *Changes {
+	return changes
+}
+
+func Use(c *Changes) {
+	changes = c
+}
+
+func Since(ref string) (*Changes, error) {
+	out, err := exec.Command("git", "diff", "--name-only", "--relative", ref+"...HEAD").Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			err = errors.New(strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, errors.Wrap(err, ansi.Sprintf("@R{Reading changes since} @m{%s} @R{FAILED}", ref))
+	}
+
+	files := []string{}
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			files = append(files, line)
+		}
+	}
+	return New(files), nil
+}
+
+func (c *Changes) Touches(patterns []string) bool {
+	if c == nil || len(patterns) == 0 {
+		return true
+	}
+
+	for _, file := range c.files {
+		for _, pattern := range patterns {
+			if matches(filepath.Clean(pattern), file) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matches(pattern, file string) bool {
+	if pattern == "." || file == pattern || strings.HasPrefix(file, pattern+"/") {
+		return true
+	}
+
+	for dir := file; dir != "."; dir = filepath.Dir(dir) {
+		if ok, _ := filepath.Match(pattern, dir); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package changes_test
+
+import (
+	. "github.com/JulzDiverse/aviator/changes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Changes", func() {
+
+	var changes *Changes
+
+	BeforeEach(func() {
+		changes = New([]string{"templates/app/deployment.yml", "README.md"})
+	})
+
+	It("touches steps without patterns", func() {
+		Expect(changes.Touches(nil)).To(BeTrue())
+	})
+
+	It("touches everything when changes are not tracked", func() {
+		var untracked *Changes
+		Expect(untracked.Touches([]string{"infra/"})).To(BeTrue())
+	})
+
+	It("matches files exactly", func() {
+		Expect(changes.Touches([]string{"README.md"})).To(BeTrue())
+		Expect(changes.Touches([]string{"README"})).To(BeFalse())
+	})
+
+	It("matches files within directories", func() {
+		Expect(changes.Touches([]string{"templates/"})).To(BeTrue())
+		Expect(changes.Touches([]string{"templates/app"})).To(BeTrue())
+		Expect(changes.Touches([]string{"templates/ap"})).To(BeFalse())
+		Expect(changes.Touches([]string{"infra/"})).To(BeFalse())
+	})
+
+	It("matches glob patterns against files and their parent directories", func() {
+		Expect(changes.Touches([]string{"templates/*/deployment.yml"})).To(BeTrue())
+		Expect(changes.Touches([]string{"templates/*"})).To(BeTrue())
+		Expect(changes.Touches([]string{"*.md"})).To(BeTrue())
+		Expect(changes.Touches([]string{"infra/*", "*.tf"})).To(BeFalse())
+	})
+})
@@ -0,0 +1,95 @@
+package cockpit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/JulzDiverse/aviator/bundler"
+	"github.com/JulzDiverse/aviator/filemanager"
+	"github.com/JulzDiverse/aviator/printer"
+)
+
+// WriteDebugBundle writes everything needed to triage a run into a gzipped
+// tarball at path: the aviator file and the arguments of the run, the
+// resolved configuration with the values of vars and environment variables
+// masked, the plan, digests of all input and written files,
+// the warnings, the log and the error of the run, and the debug bundles of
+// failing merges in failuresDir.
+func (a *Aviator) WriteDebugBundle(path, aviatorFile string, aviatorYml []byte, args []string, log []byte, failuresDir string, runErr error) error {
+	store := filemanager.Store(false, a.dryRun)
+	bundle := bundler.New("aviator-debug")
+
+	bundle.Add("aviator/"+filepath.Base(aviatorFile), aviatorYml)
+	bundle.Add("args.txt", []byte(strings.Join(args, "\n")+"\n"))
+	bundle.Add("environment.txt", []byte(fmt.Sprintf("os: %s\narch: %s\ngo: %s\n", runtime.GOOS, runtime.GOARCH, runtime.Version())))
+
+	warnings := []string{}
+	for _, w := range a.cockpit.spruceProcessor.Warnings() {
+		warnings = append(warnings, fmt.Sprintf("%s [%s] %s", w.Step, w.Category, w.Message))
+	}
+	bundle.Add("warnings.txt", lines(warnings))
+
+	if config, err := a.maskedConfig(aviatorYml); err == nil {
+		bundle.Add("resolved.yml", config)
+	} else {
+		bundle.Add("resolved.yml", []byte("# "+err.Error()+"\n"))
+	}
+
+	inputs := map[string]string{}
+	for file, content := range store.Inputs() {
+		sum := sha256.Sum256(content)
+		inputs[file] = hex.EncodeToString(sum[:])
+	}
+	bundle.Add("inputs.sha256", digestLines(inputs))
+	bundle.Add("written.sha256", digestLines(store.Digests()))
+
+	merges, commands, err := a.Plan()
+	if err == nil {
+		plan, err := printer.JSONPlan(merges, commands)
+		if err == nil {
+			bundle.Add("plan.json", plan)
+		}
+	}
+	if err != nil {
+		bundle.Add("plan.json", []byte(fmt.Sprintf("%q\n", err.Error())))
+	}
+
+	bundle.Add("log.txt", log)
+	if runErr != nil {
+		bundle.Add("error.txt", []byte(runErr.Error()+"\n"))
+	}
+
+	if failuresDir != "" {
+		if err := bundle.AddDir("failures", failuresDir); err != nil {
+			return err
+		}
+	}
+	return bundle.Write(path)
+}
+
+func lines(entries []string) []byte {
+	if len(entries) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(entries, "\n") + "\n")
+}
+
+// digestLines lists digests by file in the format of sha256sum.
+func digestLines(digests map[string]string) []byte {
+	files := []string{}
+	for file := range digests {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	entries := []string{}
+	for _, file := range files {
+		entries = append(entries, digests[file]+"  "+file)
+	}
+	return lines(entries)
+}
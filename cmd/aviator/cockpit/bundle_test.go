@@ -0,0 +1,71 @@
+package cockpit_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/JulzDiverse/aviator/cmd/aviator/cockpit"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WriteDebugBundle", func() {
+
+	var dir string
+
+	read := func(bundle, name string) string {
+		f, err := os.Open(bundle)
+		Expect(err).ToNot(HaveOccurred())
+		defer f.Close()
+		gz, err := gzip.NewReader(f)
+		Expect(err).ToNot(HaveOccurred())
+		archive := tar.NewReader(gz)
+		for {
+			header, err := archive.Next()
+			Expect(err).ToNot(HaveOccurred())
+			if header.Name == "aviator-debug/"+name {
+				content, err := ioutil.ReadAll(archive)
+				Expect(err).ToNot(HaveOccurred())
+				return string(content)
+			}
+		}
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "aviator-bundle")
+		Expect(err).ToNot(HaveOccurred())
+		os.Setenv("AVIATOR_TEST_SECRET", "s3cr3t-env")
+	})
+
+	AfterEach(func() {
+		os.Unsetenv("AVIATOR_TEST_SECRET")
+		os.RemoveAll(dir)
+	})
+
+	It("masks the values of vars and environment variables in resolved.yml", func() {
+		aviatorYml := []byte(`
+spruce:
+- base: (( env ))/base.yml
+  merge:
+  - with:
+      files: [$AVIATOR_TEST_SECRET.yml, (( var "AVIATOR_TEST_SECRET" )).yml]
+  to: $UNDEFINED_IN_TEST/result.yml
+`)
+		aviator, err := New(Options{DryRun: true, Silent: true, LenientEnv: true}).NewAviator(aviatorYml, map[string]string{"env": "s3cr3t-var"})
+		Expect(err).ToNot(HaveOccurred())
+
+		bundle := filepath.Join(dir, "debug.tgz")
+		Expect(aviator.WriteDebugBundle(bundle, "aviator.yml", aviatorYml, nil, nil, "", nil)).To(Succeed())
+
+		resolved := read(bundle, "resolved.yml")
+		Expect(resolved).To(ContainSubstring("base: <masked>/base.yml"))
+		Expect(resolved).To(ContainSubstring("- <masked>.yml\n      - <masked>.yml"))
+		Expect(resolved).To(ContainSubstring("to: $UNDEFINED_IN_TEST/result.yml"))
+		Expect(resolved).ToNot(ContainSubstring("s3cr3t"))
+	})
+})
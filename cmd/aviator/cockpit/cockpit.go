@@ -1,30 +1,90 @@
 package cockpit
 
 import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"regexp"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/JulzDiverse/aviator"
+	"github.com/JulzDiverse/aviator/annotator"
+	"github.com/JulzDiverse/aviator/asserter"
+	"github.com/JulzDiverse/aviator/authorizer"
+	"github.com/JulzDiverse/aviator/changes"
+	"github.com/JulzDiverse/aviator/coercer"
+	"github.com/JulzDiverse/aviator/copier"
+	"github.com/JulzDiverse/aviator/differ"
 	"github.com/JulzDiverse/aviator/evaluator"
 	"github.com/JulzDiverse/aviator/executor"
+	"github.com/JulzDiverse/aviator/extractor"
 	"github.com/JulzDiverse/aviator/filemanager"
+	"github.com/JulzDiverse/aviator/history"
+	"github.com/JulzDiverse/aviator/masker"
 	"github.com/JulzDiverse/aviator/printer"
 	"github.com/JulzDiverse/aviator/processor"
+	"github.com/JulzDiverse/aviator/recorder"
+	"github.com/JulzDiverse/aviator/resolver"
+	"github.com/JulzDiverse/aviator/selector"
+	"github.com/JulzDiverse/aviator/spruce"
 	"github.com/JulzDiverse/aviator/squasher"
+	"github.com/JulzDiverse/aviator/tags"
+	"github.com/JulzDiverse/aviator/templater"
+	"github.com/JulzDiverse/aviator/timer"
 	"github.com/JulzDiverse/aviator/validator"
-	"github.com/JulzDiverse/osenv"
+	"github.com/JulzDiverse/aviator/watcher"
 	"github.com/pkg/errors"
 	"github.com/starkandwayne/goutils/ansi"
-
-	yaml "gopkg.in/yaml.v2"
 )
 
+var envVarRegex = regexp.MustCompile(`\$\{?([A-Za-z_][A-Za-z0-9_]*)`)
+
 type Cockpit struct {
 	spruceProcessor aviator.SpruceProcessor
 	validator       aviator.Validator
 
 	flyExecutor     aviator.Executor
 	kubeExecutor    aviator.Executor
+	helmExecutor    aviator.Executor
 	genericExecutor aviator.Executor
+
+	options Options
+	// includes are the local aviator files included by the aviator files
+	// of the cockpit.
+	includes []string
+}
+
+// Options configure a Cockpit and the aviators it creates. The zero value
+// renders and writes files like a plain run.
+type Options struct {
+	// CurlyBraces allows the {{}} syntax in YAML files.
+	CurlyBraces bool
+	// DryRun prints files instead of writing them and skips the executors.
+	DryRun bool
+	// Silent and Verbose control what is printed.
+	Silent  bool
+	Verbose bool
+	// LenientEnv keeps references to undefined environment variables in the
+	// aviator file as they are, e.g. shell variables in executor arguments,
+	// instead of failing on them.
+	LenientEnv bool
+	// Profile selects the profile whose vars are used when evaluating the
+	// aviator file. The sections of the profile are applied with UseProfile.
+	Profile string
+
+	// DebugDir is where debug bundles of failed merges are written.
+	DebugDir         string
+	SuppressWarnings []string
+	// Parallel is the number of merges run concurrently; at least one.
+	Parallel      int
+	CollectErrors bool
+	// Stdout streams the rendered files to stdout.
+	Stdout bool
 }
 
 type Aviator struct {
@@ -34,55 +94,285 @@ type Aviator struct {
 	silent  bool
 	verbose bool
 	dryRun  bool
+	offline bool
+	tempDir string
+
+	profileRoot string
+	profile     string
+	vars        map[string]string
+
+	ctx              context.Context
+	releaseKubeOrder func()
+	applies          *recorder.Recorder
+	forceApply       bool
 
 	executor *executor.Executor
 }
 
-func New(curlyBraces, dryRun bool) *Cockpit {
+func New(options Options) *Cockpit {
+	if options.Parallel < 1 {
+		options.Parallel = 1
+	}
+
+	spruceProcessor := processor.New(options.CurlyBraces, options.DryRun)
+	spruceProcessor.DebugBundles(options.DebugDir)
+	spruceProcessor.SuppressWarnings(options.SuppressWarnings)
+	spruceProcessor.SetConcurrency(options.Parallel)
+	spruceProcessor.CollectErrors(options.CollectErrors)
+	spruceProcessor.StreamToStdout(options.Stdout)
+
 	return &Cockpit{
+		options: options,
 
-		spruceProcessor: processor.New(curlyBraces, dryRun),
+		spruceProcessor: spruceProcessor,
 		validator:       validator.New(),
 
 		flyExecutor:     executor.FlyExecutor{},
 		kubeExecutor:    executor.KubeExecutor{},
+		helmExecutor:    executor.HelmExecutor{},
 		genericExecutor: executor.GenericExecutor{},
 	}
 }
 
-func (c *Cockpit) NewAviator(aviatorYml []byte, varsMap map[string]string, silent, verbose bool, dryRun bool) (*Aviator, error) {
-	var aviator aviator.AviatorYaml
-	aviatorYml, err := resolveEnvVars(aviatorYml)
-	if err != nil {
-		return nil, errors.Wrap(err, ansi.Sprintf("@R{Reading Failed}"))
+func (c *Cockpit) NewAviator(aviatorYml []byte, varsMap map[string]string) (*Aviator, error) {
+	tempDir := ""
+	varsMap = c.profileVars(aviatorYml, varsMap)
+	aviator, err := c.parseAviator(aviatorYml, varsMap, &tempDir, false)
+	if err == nil {
+		// remote includes are fetched with the credentials of the root file
+		err = c.useAuth(aviator.Auth)
 	}
-
-	aviatorYml, err = evaluator.Evaluate(aviatorYml, varsMap)
 	if err != nil {
+		os.RemoveAll(tempDir)
 		return nil, err
 	}
 
-	aviatorYml = quoteCurlyBraces(aviatorYml)
-	err = yaml.Unmarshal(aviatorYml, &aviator)
+	aviator, err = c.includeAviatorFiles(aviator, ".", varsMap, &tempDir, false, nil, map[string]bool{})
+	if err == nil {
+		err = c.useAuth(aviator.Auth)
+	}
 	if err != nil {
-		return nil, errors.Wrap(err, ansi.Sprintf("@R{YAML Parsing Failed}"))
+		os.RemoveAll(tempDir)
+		return nil, err
 	}
 
 	err = c.validator.ValidateSpruce(aviator.Spruce)
+	if err == nil {
+		err = c.validator.ValidateExecutors(aviator)
+	}
 	if err != nil {
+		os.RemoveAll(tempDir)
 		return nil, err
 	}
+	masker.Use(masker.New(aviator.Mask))
 
 	return &Aviator{
-		c,
-		&aviator,
-		silent,
-		verbose,
-		dryRun,
-		executor.New(silent),
+		cockpit:     c,
+		AviatorYaml: &aviator,
+		silent:      c.options.Silent,
+		verbose:     c.options.Verbose,
+		dryRun:      c.options.DryRun,
+		tempDir:     tempDir,
+		vars:        varsMap,
+		executor:    executor.New(c.options.Silent),
 	}, nil
 }
 
+// parseAviator resolves temp targets, environment variables and aviator
+// variables of an aviator file and parses it. Temp targets are created in
+// tempDir, which is created if empty. If mask is set, temp targets are kept
+// and the values of environment variables and aviator variables are
+// replaced by <masked> instead.
+func (c *Cockpit) parseAviator(aviatorYml []byte, varsMap map[string]string, tempDir *string, mask bool) (aviator.AviatorYaml, error) {
+	var aviator aviator.AviatorYaml
+	var err error
+	if mask {
+		aviatorYml = maskEnv(aviatorYml)
+		varsMap = maskVars(varsMap)
+	} else {
+		var dir string
+		aviatorYml, dir, err = resolveTempTargets(aviatorYml, *tempDir)
+		if err != nil {
+			return aviator, err
+		}
+		*tempDir = dir
+
+		aviatorYml, err = expandEnv(aviatorYml, !c.options.LenientEnv)
+		if err != nil {
+			return aviator, errors.Wrap(err, ansi.Sprintf("@R{Reading Failed}"))
+		}
+	}
+
+	aviatorYml, err = evaluator.Evaluate(aviatorYml, varsMap)
+	if err != nil {
+		return aviator, err
+	}
+
+	err = unmarshalAviator(aviatorYml, &aviator)
+	return aviator, err
+}
+
+// authUser is implemented by processors which fetch sources with
+// credentials.
+type authUser interface {
+	UseAuth(auth *authorizer.Authorizer)
+}
+
+// useAuth validates the auth section and fetches remote files and git
+// sources with its credentials.
+func (c *Cockpit) useAuth(rules []aviator.SourceAuth) error {
+	if err := authorizer.Validate(rules); err != nil {
+		return err
+	}
+	auth := authorizer.New(rules)
+	filemanager.Store(false, c.options.DryRun).UseAuth(auth)
+	if p, ok := c.spruceProcessor.(authUser); ok {
+		p.UseAuth(auth)
+	}
+	return nil
+}
+
+// gitFetcher is implemented by processors which fetch git sources.
+type gitFetcher interface {
+	DisableGitFetch()
+}
+
+func (a *Aviator) EnableOfflineMode(vaultStub string) error {
+	a.offline = true
+	filemanager.Store(false, a.dryRun).DisableDownloads()
+	if p, ok := a.cockpit.spruceProcessor.(gitFetcher); ok {
+		p.DisableGitFetch()
+	}
+	secrets, err := spruce.ReadVaultStub(vaultStub)
+	if err != nil {
+		return err
+	}
+	spruce.UseVaultStub(secrets)
+	resolver.Use(resolver.New(resolver.Stub(secrets)))
+	return nil
+}
+
+// UseVault resolves the vault placeholders left in rendered files, e.g. by
+// engines other than spruce, from Vault (or the vault stub in offline
+// mode). If disabled, all vault lookups are kept as placeholders instead.
+func (a *Aviator) UseVault(enabled bool) {
+	if !enabled {
+		spruce.KeepVaultPlaceholders()
+		resolver.Use(nil)
+		return
+	}
+	if !a.offline {
+		resolver.Use(resolver.New(resolver.Vault()))
+	}
+}
+
+func (a *Aviator) UseProfile(name string) error {
+	profile, ok := a.AviatorYaml.Profiles[name]
+	if !ok {
+		return ansi.Errorf("@R{Profile} @m{%s} @R{is not defined in the aviator file}", name)
+	}
+
+	a.profile = name
+	applyProfile(a.AviatorYaml, profile)
+
+	if profile.ToDir != "" {
+		a.profileRoot = profile.ToDir
+		filemanager.Store(false, a.dryRun).UseWorkspace(profile.ToDir)
+	}
+	return nil
+}
+
+func applyProfile(config *aviator.AviatorYaml, profile aviator.Profile) {
+	config.Fly = profile.Fly
+	config.Kube = profile.Kube
+	config.Helm = profile.Helm
+	config.Exec = profile.Exec
+}
+
+func (a *Aviator) UseWorkspace(root, name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, "/\\") {
+		return ansi.Errorf("@R{Invalid workspace name} @m{%s}@R{: must be a single path segment}", name)
+	}
+
+	filemanager.Store(false, a.dryRun).UseWorkspace(filepath.Join(root, name, a.profileRoot))
+	return nil
+}
+
+// SelectSpruce restricts the spruce plan to the blocks named in only (all
+// if empty) and not named in skip.
+func (a *Aviator) SelectSpruce(only, skip []string) error {
+	selected, err := processor.SelectNamed(a.AviatorYaml.Spruce, only, skip)
+	if err != nil {
+		return err
+	}
+	a.AviatorYaml.Spruce = selected
+	return nil
+}
+
+// RenderTarget restricts the spruce plan to the merge writing target, and the
+// merges into the internal datastore it may read.
+func (a *Aviator) RenderTarget(target string) {
+	a.cockpit.spruceProcessor.RenderTarget(target)
+}
+
+// FilterTags runs only the steps carrying one of the include tags (all if
+// empty) and none of the exclude tags.
+func (a *Aviator) FilterTags(include, exclude []string) {
+	tags.Use(tags.New(include, exclude))
+}
+
+func (a *Aviator) TrackChangesSince(ref string) error {
+	c, err := changes.Since(ref)
+	if err != nil {
+		return err
+	}
+	changes.Use(c)
+	return nil
+}
+
+func (a *Aviator) SetInactivityTimeout(timeout time.Duration) {
+	a.executor.SetInactivityTimeout(timeout)
+}
+
+// UseContext aborts directory scans once ctx is done.
+func (a *Aviator) UseContext(ctx context.Context) {
+	a.ctx = ctx
+	filemanager.Store(false, a.dryRun).UseContext(ctx)
+}
+
+// SkipUnchangedApplies records successful kubectl applies below root and
+// skips the kubectl step if neither its files nor its settings changed since
+// the last one, unless force is set.
+func (a *Aviator) SkipUnchangedApplies(root string, force bool) {
+	a.applies = recorder.New(root)
+	a.forceApply = force
+}
+
+// UseRemoteCache caches remote files pinned with a sha256 below dir.
+func (a *Aviator) UseRemoteCache(dir string) {
+	filemanager.Store(false, a.dryRun).UseCache(dir)
+}
+
+// KeepBackups keeps the previous content of every changed target as
+// <target>.bak.
+func (a *Aviator) KeepBackups() {
+	filemanager.Store(false, a.dryRun).KeepBackups()
+}
+
+// EnableRollback remembers the previous content of every target, so
+// Rollback can restore them if the run fails.
+func (a *Aviator) EnableRollback() {
+	filemanager.Store(false, a.dryRun).EnableRollback()
+}
+
+func (a *Aviator) Rollback() ([]string, error) {
+	return filemanager.Store(false, a.dryRun).Rollback()
+}
+
+func (a *Aviator) UseSandbox() error {
+	return a.executor.UseSandbox(a.AviatorYaml.Sandbox)
+}
+
 func (a *Aviator) ProcessSprucePlan() error {
 	err := a.cockpit.spruceProcessor.ProcessWithOpts(a.AviatorYaml.Spruce, a.verbose, a.silent, a.dryRun)
 	if err != nil {
@@ -91,6 +381,30 @@ func (a *Aviator) ProcessSprucePlan() error {
 	return nil
 }
 
+// WatchSprucePlan re-runs the spruce blocks affected by changes of their
+// input files until ctx is done. Failures are printed and don't end the watch.
+func (a *Aviator) WatchSprucePlan(ctx context.Context, interval time.Duration) error {
+	plan := a.AviatorYaml.Spruce
+	paths := processor.WatchedPaths(plan)
+	if !a.silent {
+		ansi.Printf("@G{Watching %d paths for changes (Ctrl-C to stop)}\n", len(paths))
+	}
+
+	return watcher.New(paths, interval).Watch(ctx, func(files []string) {
+		affected := processor.Affected(plan, files)
+		if len(affected) == 0 {
+			return
+		}
+		if !a.silent {
+			ansi.Printf("\n@C{Changed:} %s\n", strings.Join(files, ", "))
+		}
+		err := a.cockpit.spruceProcessor.ProcessWithOpts(affected, a.verbose, a.silent, a.dryRun)
+		if err != nil {
+			ansi.Printf("@R{%s}\n", errors.Wrap(err, "Processing Spruce Plan FAILED").Error())
+		}
+	})
+}
+
 func (a *Aviator) ProcessSquashPlan() error {
 	var err error
 	var result []byte
@@ -107,7 +421,12 @@ func (a *Aviator) ProcessSquashPlan() error {
 			files := store.ReadFiles(c.Files)
 			squashed, err = squasher.Squash(files)
 		} else {
-			paths = append(paths, fp.CollectFilesFromDir(c.Dir, "", []string{})...)
+			var dirFiles []string
+			dirFiles, err = fp.CollectFilesFromDir(c.Dir, "", []string{})
+			if err != nil {
+				return err
+			}
+			paths = append(paths, dirFiles...)
 			files := store.ReadFiles(paths)
 			squashed, err = squasher.Squash(files)
 		}
@@ -119,6 +438,13 @@ func (a *Aviator) ProcessSquashPlan() error {
 		result = append(result, squashed...)
 	}
 
+	if !selector.IsEmpty(a.AviatorYaml.Squash.Select) {
+		result, err = selector.Select(result, a.AviatorYaml.Squash.Select)
+		if err != nil {
+			return err
+		}
+	}
+
 	if !a.silent {
 		printer.AnsiPrintSquash(paths, a.AviatorYaml.Squash.To)
 	}
@@ -126,33 +452,556 @@ func (a *Aviator) ProcessSquashPlan() error {
 	return store.WriteFile(a.AviatorYaml.Squash.To, result)
 }
 
-func (a *Aviator) ExecuteFly() error {
-	cmds, err := a.cockpit.flyExecutor.Command(a.AviatorYaml.Fly)
+func (a *Aviator) ProcessTemplatePlan() error {
+	store := filemanager.Store(false, a.dryRun)
+	for _, t := range a.AviatorYaml.Template {
+		if !a.silent {
+			printer.AnsiPrintTemplate(t.Source, t.Values, t.To)
+		}
+
+		tmpl, ok := store.ReadFile(t.Source)
+		if !ok {
+			return ansi.Errorf("@R{Error reading template} @m{%s}", t.Source)
+		}
+		values, ok := store.ReadFile(t.Values)
+		if !ok {
+			return ansi.Errorf("@R{Error reading template values} @m{%s}", t.Values)
+		}
+
+		result, err := templater.Render(t.Source, tmpl, values)
+		if err != nil {
+			return errors.Wrap(err, ansi.Sprintf("@R{Rendering template} @m{%s} @R{FAILED}", t.Source))
+		}
+
+		err = store.WriteFile(t.To, result)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *Aviator) ProcessConcatPlan() error {
+	store := filemanager.Store(false, a.dryRun)
+	fp := processor.FileProcessor{store}
+
+	for _, c := range a.AviatorYaml.Concat {
+		paths, err := a.concatPaths(c, fp)
+		if err != nil {
+			return err
+		}
+		if len(paths) == 0 {
+			return ansi.Errorf("@R{Concatenating} @m{%s} @R{FAILED: no files provided}", c.To)
+		}
+
+		files := [][]byte{}
+		for _, path := range paths {
+			file, ok := store.ReadFile(path)
+			if !ok {
+				return ansi.Errorf("@R{Concatenating} @m{%s} @R{FAILED: cannot read} @m{%s}", c.To, path)
+			}
+			files = append(files, file)
+		}
+
+		result, err := squasher.Concat(paths, files)
+		if err != nil {
+			return err
+		}
+
+		if !a.silent {
+			printer.AnsiPrintConcat(paths, c.To)
+		}
+
+		err = store.WriteFile(c.To, result)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// concatPaths returns the pieces of c in order: the targets of the named
+// merges, the files, the matches of the glob and the files of the dir, each
+// piece only at its first position.
+func (a *Aviator) concatPaths(c aviator.Concat, fp processor.FileProcessor) ([]string, error) {
+	paths := []string{}
+	seen := map[string]bool{}
+	add := func(files ...string) {
+		for _, f := range files {
+			if !seen[filepath.Clean(f)] {
+				seen[filepath.Clean(f)] = true
+				paths = append(paths, f)
+			}
+		}
+	}
+
+	for _, name := range c.Merges {
+		targets, ok := a.cockpit.spruceProcessor.Outputs(name)
+		if !ok {
+			if !a.hasSpruceStep(name) {
+				return nil, ansi.Errorf("@R{Concatenating} @m{%s} @R{FAILED: there is no spruce step named} @m{%s}", c.To, name)
+			}
+			return nil, ansi.Errorf("@R{Concatenating} @m{%s} @R{FAILED: the spruce step} @m{%s} @R{did not render a target in this run}", c.To, name)
+		}
+		add(targets...)
+	}
+
+	add(c.Files...)
+
+	if c.Glob != "" {
+		if _, err := filepath.Match(c.Glob, ""); err != nil {
+			return nil, errors.Wrap(err, ansi.Sprintf("@R{Concatenating} @m{%s} @R{FAILED: invalid glob} @m{%s}", c.To, c.Glob))
+		}
+		dir := filepath.Dir(c.Glob)
+		files, err := fp.Store.ReadDir(dir)
+		if filemanager.IsCanceled(err) {
+			return nil, err
+		}
+		matches := []string{}
+		for _, f := range files {
+			path := filepath.Join(dir, f.Name())
+			if ok, _ := filepath.Match(c.Glob, path); ok && !f.IsDir() && filepath.Clean(path) != filepath.Clean(c.To) {
+				matches = append(matches, path)
+			}
+		}
+		sort.Strings(matches)
+		add(matches...)
+	}
+
+	dirFiles, err := fp.CollectFilesFromDir(c.Dir, "", []string{})
 	if err != nil {
-		return err
+		return nil, err
+	}
+	sort.Strings(dirFiles)
+	add(dirFiles...)
+	return paths, nil
+}
+
+func (a *Aviator) hasSpruceStep(name string) bool {
+	for _, s := range a.AviatorYaml.Spruce {
+		if s.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Aviator) ProcessCopyPlan() error {
+	store := filemanager.Store(false, a.dryRun)
+	for _, c := range a.AviatorYaml.Copy {
+		targets, err := copier.Targets(c.From, c.To)
+		if err != nil {
+			return err
+		}
+
+		if !a.silent {
+			from, to := []string{}, []string{}
+			for _, t := range targets {
+				from = append(from, t.From)
+				to = append(to, t.To)
+			}
+			printer.AnsiPrintCopy(from, to, c.Symlink)
+		}
+
+		for _, t := range targets {
+			err = store.CopyFile(t.From, t.To, c.Symlink)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (a *Aviator) ProcessAssertPlan() error {
+	store := filemanager.Store(false, a.dryRun)
+	for _, as := range a.AviatorYaml.Assert {
+		file, ok := store.ReadFile(as.File)
+		if !ok {
+			return ansi.Errorf("@R{Error reading file to assert} @m{%s}", as.File)
+		}
+
+		var err error
+		if !selector.IsEmpty(as.Select) {
+			file, err = selector.Select(file, as.Select)
+			if err != nil {
+				return err
+			}
+		}
+
+		violations, err := asserter.Check(file, as.That)
+		if err != nil {
+			return err
+		}
+		if len(violations) != 0 {
+			lines := []string{}
+			for _, v := range violations {
+				lines = append(lines, ansi.Sprintf("@m{%s} %s @R{(%s)}", v.Path, v.Message, v.Assertion))
+			}
+			return ansi.Errorf("@R{Assertions on} @m{%s} @R{FAILED}:\n\t%s", as.File, strings.Join(lines, "\n\t"))
+		}
+
+		if !a.silent {
+			printer.AnsiPrintAssert(as.File, as.That)
+		}
+	}
+	return nil
+}
+
+func (a *Aviator) ProcessExtractPlan() error {
+	store := filemanager.Store(false, a.dryRun)
+	for _, ex := range a.AviatorYaml.Extract {
+		file, ok := store.ReadFile(ex.File)
+		if !ok {
+			return ansi.Errorf("@R{Error reading file to extract from} @m{%s}", ex.File)
+		}
+
+		var err error
+		if !selector.IsEmpty(ex.Select) {
+			file, err = selector.Select(file, ex.Select)
+			if err != nil {
+				return err
+			}
+		}
+
+		vars, err := extractor.Extract(file, ex.Vars)
+		if err != nil {
+			return errors.Wrap(err, ansi.Sprintf("@R{Extracting from} @m{%s} @R{FAILED}", ex.File))
+		}
+		a.executor.SetOutputs(vars)
+
+		if !a.silent {
+			printer.AnsiPrintExtract(ex.File, ex.Vars)
+		}
 	}
-	return a.executor.Execute(cmds)
+	return nil
+}
+
+func (a *Aviator) VerifyRenderedFiles() error {
+	err := filemanager.Store(false, a.dryRun).VerifyDigests()
+	if err != nil {
+		return errors.Wrap(err, "Verifying Rendered Files FAILED")
+	}
+	return nil
+}
+
+func (a *Aviator) ReportTimings(historyFile string) error {
+	report, err := timer.Default().Report(historyFile)
+	if err != nil {
+		return errors.Wrap(err, "Reporting Step Timings FAILED")
+	}
+	if !a.silent {
+		fmt.Print(report)
+	}
+	return nil
+}
+
+func (a *Aviator) WrittenFiles() []string {
+	return filemanager.Store(false, a.dryRun).Written()
+}
+
+func (a *Aviator) AnnotateRenderedDiff(provider, base string) error {
+	if a.offline {
+		return offlineError("annotate")
+	}
+
+	store := filemanager.Store(false, a.dryRun)
+	diffs := []annotator.FileDiff{}
+	for _, file := range store.Written() {
+		name := file
+		if ws := store.Workspace(); ws != "" {
+			if rel, err := filepath.Rel(ws, file); err == nil && !strings.HasPrefix(rel, "..") {
+				name = rel
+			}
+		}
+
+		rendered, err := ioutil.ReadFile(file)
+		if err != nil {
+			return errors.Wrap(err, ansi.Sprintf("@R{Reading rendered file} @m{%s} @R{FAILED}", file))
+		}
+		previous, _ := exec.Command("git", "show", base+":./"+filepath.ToSlash(name)).Output()
+
+		mask := masker.Default()
+		changes, err := differ.Diff(mask.Mask(previous), mask.Mask(rendered))
+		if err != nil {
+			return errors.Wrap(err, ansi.Sprintf("@R{Diffing} @m{%s} @R{FAILED}", name))
+		}
+		diffs = append(diffs, annotator.FileDiff{File: name, Changes: changes})
+	}
+
+	err := annotator.Post(provider, annotator.Body(diffs))
+	if err != nil {
+		return errors.Wrap(err, "Annotating Rendered Diff FAILED")
+	}
+	return nil
+}
+
+// RecordRun records the run in the history. Besides the files read through
+// the store, the inputs are the aviator file, its includes and files, e.g.
+// vars files or the vault stub, the run read otherwise. Files outside of the
+// working directory are recorded by their absolute path, which repro
+// reports as unavailable.
+func (a *Aviator) RecordRun(aviatorFile string, aviatorYml []byte, args []string, files []string) error {
+	store := filemanager.Store(false, a.dryRun)
+	inputs := store.Inputs()
+	inputs[aviatorFile] = aviatorYml
+	for _, file := range append(append([]string{}, a.cockpit.includes...), files...) {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return errors.Wrap(err, ansi.Sprintf("@R{Recording} @m{%s} @R{FAILED}", file))
+		}
+		inputs[recordedPath(file)] = content
+	}
+
+	env := []string{}
+	for _, m := range envVarRegex.FindAllStringSubmatch(string(aviatorYml), -1) {
+		env = append(env, m[1])
+	}
+
+	run, err := history.Record(".", args, inputs, store.Digests(), env)
+	if err != nil {
+		return errors.Wrap(err, "Recording Run FAILED")
+	}
+	if !a.silent {
+		ansi.Printf("@G{RECORDED RUN:} %s\n", run.ID)
+	}
+	return nil
+}
+
+// recordedPath is the path of file relative to the working directory, or its
+// absolute path if it is outside of it.
+func recordedPath(file string) string {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return file
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return abs
+	}
+	rel, err := filepath.Rel(wd, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return abs
+	}
+	return rel
+}
+
+func (a *Aviator) ExecuteFly() error {
+	if a.offline {
+		return offlineError("fly")
+	}
+
+	fly := a.AviatorYaml.Fly
+	if a.skipped("fly: "+fly.Name, fly.Condition) {
+		return nil
+	}
+
+	store := filemanager.Store(false, a.dryRun)
+	fly.Config = store.Resolve(fly.Config)
+	vars := []string{}
+	for _, v := range fly.Vars {
+		vars = append(vars, store.Resolve(v))
+	}
+	fly.Vars = vars
+
+	return timer.Default().Track("fly: "+fly.Name, fly.WarnIfLongerThan, func() error {
+		return a.executor.RunWithPolicy(fly.FailurePolicy, func() error {
+			cmds, err := a.cockpit.flyExecutor.Command(fly)
+			if err != nil {
+				return err
+			}
+			return a.executor.Execute(cmds)
+		})
+	})
 }
 
 func (a *Aviator) ExecuteKube() error {
-	cmds, err := a.cockpit.kubeExecutor.Command(a.AviatorYaml.Kube)
+	if a.offline {
+		return offlineError("kubectl")
+	}
+
+	kube := a.AviatorYaml.Kube
+	if a.skipped("kubectl: "+kube.Apply.File, kube.Apply.Condition) {
+		a.ReleaseKubeOrder()
+		return nil
+	}
+
+	kube.Apply.File = filemanager.Store(false, a.dryRun).Resolve(kube.Apply.File)
+	digest := a.kubeApplyDigest(kube)
+	if a.unchangedKubeApply(kube, digest) {
+		a.ReleaseKubeOrder()
+		return nil
+	}
+
+	return timer.Default().Track("kubectl: "+kube.Apply.File, kube.Apply.WarnIfLongerThan, func() error {
+		return a.executor.RunWithPolicy(kube.Apply.FailurePolicy, func() error {
+			if len(kube.Apply.Before) != 0 || len(kube.Apply.After) != 0 {
+				err := a.executeKubePhases(kube)
+				if err != nil {
+					return err
+				}
+				return a.recordKubeApply(kube, digest)
+			}
+
+			err := a.waitForKubeOrder(kube.Apply.Context)
+			if err != nil {
+				return err
+			}
+			cmds, err := a.cockpit.kubeExecutor.Command(kube)
+			if err != nil {
+				return err
+			}
+			err = a.executor.ExecuteAndExport(cmds, kube.Apply.Export)
+			if err != nil {
+				return err
+			}
+			return a.recordKubeApply(kube, digest)
+		})
+	})
+}
+
+func (a *Aviator) ExecuteHelm() error {
+	helm := a.AviatorYaml.Helm
+	if a.offline && helm.Command != "template" {
+		return offlineError("helm")
+	}
+
+	step := "helm: " + helm.Release
+	if a.skipped(step, helm.Condition) {
+		return nil
+	}
+
+	store := filemanager.Store(false, a.dryRun)
+	check, err := valuesCheck(helm)
+	if err != nil {
+		return err
+	}
+	values, cleanup, err := localValues(store, helm.Values, check)
+	defer cleanup()
 	if err != nil {
 		return err
 	}
-	return a.executor.Execute(cmds)
+	helm.Values = values
+
+	return timer.Default().Track(step, helm.WarnIfLongerThan, func() error {
+		return a.executor.RunWithPolicy(helm.FailurePolicy, func() error {
+			cmds, err := a.cockpit.helmExecutor.Command(helm)
+			if err != nil {
+				return err
+			}
+			output, err := a.executor.ExecuteAndCapture(cmds)
+			if err != nil {
+				return err
+			}
+			if helm.Export != "" {
+				a.executor.SetOutputs(map[string]string{helm.Export: strings.TrimSpace(string(output))})
+			}
+			if helm.To != "" {
+				return store.WriteFile(helm.To, output)
+			}
+			return nil
+		})
+	})
 }
 
-func (a *Aviator) ExecuteGeneric() error {
-	cmds, err := a.cockpit.genericExecutor.Command(a.AviatorYaml.Exec)
+// valuesCheck returns the check of values_schema against the
+// values.schema.json of a local chart, or nil if values_schema is not set.
+func valuesCheck(helm aviator.Helm) (func([]byte) ([]byte, error), error) {
+	if helm.ValuesSchema == "" {
+		return nil, nil
+	}
+
+	path := filepath.Join(helm.Chart, "values.schema.json")
+	content, err := ioutil.ReadFile(path)
 	if err != nil {
-		return err
+		return nil, ansi.Errorf("@m{values_schema} @R{requires a local chart with a} @m{values.schema.json}@R{: %s}", err.Error())
+	}
+	schema, err := coercer.Parse(content)
+	if err != nil {
+		return nil, errors.Wrap(err, ansi.Sprintf("@R{Reading} @m{%s} @R{FAILED}", path))
+	}
+
+	if helm.ValuesSchema == executor.ValuesSchemaCoerce {
+		return schema.Coerce, nil
+	}
+	return func(values []byte) ([]byte, error) {
+		return values, schema.Validate(values)
+	}, nil
+}
+
+// localValues returns paths helm can read. Values files of the internal
+// datastore, and all values files if they are checked against the chart's
+// schema, are written to a temporary directory.
+func localValues(store *filemanager.FileManager, values []string, check func([]byte) ([]byte, error)) ([]string, func(), error) {
+	dir, err := ioutil.TempDir("", "aviator-helm")
+	if err != nil {
+		return nil, func() {}, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	result := []string{}
+	for i, v := range values {
+		path := store.Resolve(v)
+		if fileExists(path) && check == nil {
+			result = append(result, path)
+			continue
+		}
+		content, ok := store.ReadFile(v)
+		if !ok {
+			return nil, cleanup, ansi.Errorf("@R{Error reading file from filesystem or internal datastore} @m{%s}", v)
+		}
+		if check != nil {
+			content, err = check(content)
+			if err != nil {
+				return nil, cleanup, errors.Wrap(err, ansi.Sprintf("@R{Checking values file} @m{%s} @R{against the chart schema FAILED}", v))
+			}
+		}
+		path = filepath.Join(dir, fmt.Sprintf("%02d-values.yml", i))
+		if err := ioutil.WriteFile(path, content, 0600); err != nil {
+			return nil, cleanup, err
+		}
+		result = append(result, path)
+	}
+	return result, cleanup, nil
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+func (a *Aviator) ExecuteGeneric() error {
+	for _, exe := range a.AviatorYaml.Exec {
+		exe := exe
+		if a.skipped("exec: "+exe.Executable, exe.Condition) {
+			continue
+		}
+		err := timer.Default().Track("exec: "+exe.Executable, exe.WarnIfLongerThan, func() error {
+			return a.executor.RunWithPolicy(exe.FailurePolicy, func() error {
+				cmds, err := a.cockpit.genericExecutor.Command([]aviator.Executable{exe})
+				if err != nil {
+					return err
+				}
+				return a.executor.ExecuteAndExport(cmds, exe.Export)
+			})
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *Aviator) skipped(step string, cond aviator.Condition) bool {
+	skip, reason, details := processor.Skipped(cond)
+	if skip && !a.silent {
+		printer.AnsiPrintSkipped(step, reason, details)
 	}
-	return a.executor.Execute(cmds)
+	return skip
 }
 
-func resolveEnvVars(input []byte) ([]byte, error) {
-	result, err := osenv.ExpandEnv(string(input))
-	return []byte(result), err
+func offlineError(feature string) error {
+	return errors.New(ansi.Sprintf("@R{offline mode:} @m{%s} @R{requires network access}", feature))
 }
 
 func quoteCurlyBraces(input []byte) []byte {
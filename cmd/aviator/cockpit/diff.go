@@ -0,0 +1,50 @@
+package cockpit
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/JulzDiverse/aviator/differ"
+	"github.com/JulzDiverse/aviator/filemanager"
+	"github.com/JulzDiverse/aviator/masker"
+	"github.com/JulzDiverse/aviator/printer"
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+// CaptureRenderedFiles keeps the files rendered by this run in memory
+// instead of writing them, to compare them with DiffRenderedFiles.
+func (a *Aviator) CaptureRenderedFiles() {
+	filemanager.Store(false, a.dryRun).CaptureWrites()
+}
+
+// DiffRenderedFiles prints the diff of every captured file against the file
+// on disk and reports whether any of them changed. With semantic the changed
+// YAML paths are printed instead of the changed lines.
+func (a *Aviator) DiffRenderedFiles(semantic bool) (bool, error) {
+	store := filemanager.Store(false, a.dryRun)
+	changed := false
+	for _, file := range store.Captured() {
+		rendered := store.CapturedFile(file)
+		current, err := ioutil.ReadFile(file)
+		if err != nil && !os.IsNotExist(err) {
+			return false, errors.Wrap(err, ansi.Sprintf("@R{Reading target} @m{%s} @R{FAILED}", file))
+		}
+
+		// a change of a masked value still counts, even though the
+		// printed diff does not show it
+		changed = changed || string(current) != string(rendered)
+		mask := masker.Default()
+		if !semantic {
+			printer.AnsiPrintUnifiedDiff(differ.Unified(file, mask.Mask(current), mask.Mask(rendered)))
+			continue
+		}
+
+		changes, err := differ.Diff(mask.Mask(current), mask.Mask(rendered))
+		if err != nil {
+			return false, errors.Wrap(err, ansi.Sprintf("@R{Diffing} @m{%s} @R{FAILED}", file))
+		}
+		printer.AnsiPrintSemanticDiff(file, changes)
+	}
+	return changed, nil
+}
@@ -0,0 +1,62 @@
+package cockpit
+
+import (
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/JulzDiverse/aviator/masker"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+var envReferenceRegex = regexp.MustCompile(`\$(\{([A-Za-z_][A-Za-z0-9_]*)\}|([A-Za-z_][A-Za-z0-9_]*))`)
+
+// expandEnv replaces $VAR and ${VAR} with the value of the environment
+// variable. Undefined variables are an error if strict is set, and are kept
+// verbatim otherwise.
+func expandEnv(input []byte, strict bool) ([]byte, error) {
+	undefined := []string{}
+	seen := map[string]bool{}
+	result := envReferenceRegex.ReplaceAllFunc(input, func(match []byte) []byte {
+		m := envReferenceRegex.FindSubmatch(match)
+		name := string(m[2]) + string(m[3])
+		if value, ok := os.LookupEnv(name); ok {
+			return []byte(value)
+		}
+		if !seen[name] {
+			seen[name] = true
+			undefined = append(undefined, name)
+		}
+		return match
+	})
+
+	if strict && len(undefined) > 0 {
+		return nil, ansi.Errorf("@R{Environment variables not set}: @m{%s}", strings.Join(undefined, ", "))
+	}
+	return result, nil
+}
+
+// maskEnv replaces the references to defined environment variables with
+// <masked>.
+func maskEnv(input []byte) []byte {
+	return envReferenceRegex.ReplaceAllFunc(input, func(match []byte) []byte {
+		m := envReferenceRegex.FindSubmatch(match)
+		if _, ok := os.LookupEnv(string(m[2]) + string(m[3])); ok {
+			return []byte(masker.Placeholder)
+		}
+		return match
+	})
+}
+
+// maskVars maps every variable of varsMap, and every environment variable
+// (( var "name" )) may fall back to, to <masked>.
+func maskVars(varsMap map[string]string) map[string]string {
+	result := map[string]string{}
+	for _, env := range os.Environ() {
+		result[strings.SplitN(env, "=", 2)[0]] = masker.Placeholder
+	}
+	for name := range varsMap {
+		result[name] = masker.Placeholder
+	}
+	return result
+}
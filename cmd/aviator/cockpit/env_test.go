@@ -0,0 +1,50 @@
+package cockpit_test
+
+import (
+	"os"
+
+	. "github.com/JulzDiverse/aviator/cmd/aviator/cockpit"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("environment variables", func() {
+
+	const aviatorYml = `
+spruce:
+- base: $AVIATOR_ENV_TEST_DIR/base.yml
+  merge:
+  - with_in: ${AVIATOR_ENV_TEST_DIR}/envs/
+  to_dir: $AVIATOR_ENV_TEST_UNDEFINED/
+exec:
+- executable: sh
+  args: ["-c", "echo ${AVIATOR_ENV_TEST_DIR}_$1"]
+`
+
+	BeforeEach(func() {
+		os.Setenv("AVIATOR_ENV_TEST_DIR", "/deploy")
+		os.Unsetenv("AVIATOR_ENV_TEST_UNDEFINED")
+	})
+
+	AfterEach(func() {
+		os.Unsetenv("AVIATOR_ENV_TEST_DIR")
+	})
+
+	It("fails on undefined ones", func() {
+		_, err := New(Options{DryRun: true, Silent: true}).NewAviator([]byte(aviatorYml), nil)
+		Expect(err).To(MatchError(ContainSubstring("AVIATOR_ENV_TEST_UNDEFINED")))
+	})
+
+	It("expands them in all fields and keeps undefined ones when lenient", func() {
+		cockpit := New(Options{DryRun: true, Silent: true, LenientEnv: true})
+		aviator, err := cockpit.NewAviator([]byte(aviatorYml), nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		spruce := aviator.AviatorYaml.Spruce[0]
+		Expect(spruce.Base).To(Equal("/deploy/base.yml"))
+		Expect(spruce.Merge[0].WithIn).To(Equal("/deploy/envs/"))
+		Expect(spruce.ToDir).To(Equal("$AVIATOR_ENV_TEST_UNDEFINED/"))
+		Expect(aviator.AviatorYaml.Exec[0].Args[1]).To(Equal("echo /deploy_$1"))
+	})
+})
@@ -0,0 +1,51 @@
+package cockpit
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/JulzDiverse/aviator"
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ReadAviatorFile reads an aviator file. CUE files are exported to JSON
+// with the cue binary, which also checks them against their constraints.
+func ReadAviatorFile(file string) ([]byte, error) {
+	if filepath.Ext(file) != ".cue" {
+		return ioutil.ReadFile(file)
+	}
+
+	if _, err := exec.LookPath("cue"); err != nil {
+		return nil, ansi.Errorf("@R{Reading} @m{%s} @R{requires the} @m{cue} @R{binary in your PATH}", file)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("cue", "export", "--out", "json", file)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, ansi.Errorf("@R{Exporting} @m{%s} @R{FAILED}:\n%s", file, stderr.String())
+	}
+	return out, nil
+}
+
+func isJSON(input []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(input), []byte("{"))
+}
+
+func unmarshalAviator(input []byte, into *aviator.AviatorYaml) error {
+	if !isJSON(input) {
+		err := yaml.Unmarshal(quoteCurlyBraces(input), into)
+		return errors.Wrap(err, ansi.Sprintf("@R{YAML Parsing Failed}"))
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(input))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(into)
+	return errors.Wrap(err, ansi.Sprintf("@R{JSON Parsing Failed}"))
+}
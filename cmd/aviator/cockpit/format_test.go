@@ -0,0 +1,68 @@
+package cockpit_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/JulzDiverse/aviator/cmd/aviator/cockpit"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Aviator file formats", func() {
+
+	var c *Cockpit
+
+	BeforeEach(func() {
+		c = New(Options{DryRun: true, Silent: true})
+	})
+
+	It("reads YAML and JSON into the same configuration", func() {
+		fromYaml, err := c.NewAviator([]byte(`
+spruce:
+- base: base.yml
+  merge:
+  - with:
+      files: [(( env )).yml]
+  to: {{out.yml}}
+`), map[string]string{"env": "prod"})
+		Expect(err).ToNot(HaveOccurred())
+
+		fromJSON, err := c.NewAviator([]byte(`{
+  "spruce": [{
+    "base": "base.yml",
+    "merge": [{"with": {"files": ["(( env )).yml"]}}],
+    "to": "{{out.yml}}"
+  }]
+}`), map[string]string{"env": "prod"})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(fromJSON.AviatorYaml).To(Equal(fromYaml.AviatorYaml))
+		Expect(fromJSON.AviatorYaml.Spruce[0].Merge[0].With.Files).To(Equal([]string{"prod.yml"}))
+	})
+
+	It("rejects unknown JSON properties", func() {
+		_, err := c.NewAviator([]byte(`{"spruce": [{"base": "base.yml", "too": "out.yml"}]}`), nil)
+		Expect(err).To(MatchError(ContainSubstring(`unknown field "too"`)))
+	})
+
+	It("rejects JSON values of the wrong type", func() {
+		_, err := c.NewAviator([]byte(`{"spruce": [{"base": ["base.yml"]}]}`), nil)
+		Expect(err).To(MatchError(ContainSubstring("JSON Parsing Failed")))
+	})
+
+	It("reads YAML and JSON files as they are", func() {
+		dir, err := ioutil.TempDir("", "cockpit")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+
+		file := filepath.Join(dir, "aviator.json")
+		Expect(ioutil.WriteFile(file, []byte(`{"spruce": []}`), 0644)).To(Succeed())
+
+		content, err := ReadAviatorFile(file)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(Equal(`{"spruce": []}`))
+	})
+})
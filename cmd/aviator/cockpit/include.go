@@ -0,0 +1,182 @@
+package cockpit
+
+import (
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/JulzDiverse/aviator"
+	"github.com/JulzDiverse/aviator/filemanager"
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+// includeAviatorFiles merges the aviator files listed in the include section
+// of config into it. Includes are resolved relative to dir, the directory
+// (or URL) of the including file, and may include further files. stack holds
+// the chain of files currently being included to detect cycles; files which
+// were already included elsewhere are skipped.
+func (c *Cockpit) includeAviatorFiles(config aviator.AviatorYaml, dir string, varsMap map[string]string, tempDir *string, mask bool, stack []string, seen map[string]bool) (aviator.AviatorYaml, error) {
+	merged := aviator.AviatorYaml{}
+	for _, include := range config.Include {
+		location := resolveInclude(dir, include)
+		for i, s := range stack {
+			if s == location {
+				cycle := append(append([]string{}, stack[i:]...), location)
+				return config, ansi.Errorf("@R{Include cycle}: @m{%s}", strings.Join(cycle, " -> "))
+			}
+		}
+		if seen[location] {
+			continue
+		}
+		seen[location] = true
+
+		input, err := readInclude(location)
+		if err != nil {
+			return config, err
+		}
+		if !mask && !filemanager.IsRemote(location) {
+			c.includes = append(c.includes, location)
+		}
+
+		included, err := c.parseAviator(input, varsMap, tempDir, mask)
+		if err != nil {
+			return config, errors.Wrap(err, ansi.Sprintf("@R{Including} @m{%s} @R{FAILED}", include))
+		}
+
+		included, err = c.includeAviatorFiles(included, includeDir(location), varsMap, tempDir, mask, append(stack, location), seen)
+		if err != nil {
+			return config, err
+		}
+		merged = mergeAviator(merged, included)
+	}
+
+	config = mergeAviator(merged, config)
+	config.Include = nil
+	return config, nil
+}
+
+func resolveInclude(dir, include string) string {
+	if filemanager.IsRemote(include) {
+		return include
+	}
+	if filemanager.IsRemote(dir) {
+		base, err := url.Parse(dir)
+		ref, refErr := url.Parse(include)
+		if err == nil && refErr == nil {
+			return base.ResolveReference(ref).String()
+		}
+	}
+	if !filepath.IsAbs(include) {
+		include = filepath.Join(dir, include)
+	}
+	if abs, err := filepath.Abs(include); err == nil {
+		return abs
+	}
+	return include
+}
+
+func includeDir(location string) string {
+	if filemanager.IsRemote(location) {
+		return location
+	}
+	return filepath.Dir(location)
+}
+
+func readInclude(location string) ([]byte, error) {
+	if filemanager.IsRemote(location) {
+		input, ok := filemanager.Store(false, false).ReadFile(location)
+		if !ok {
+			return nil, ansi.Errorf("@R{Reading included file} @m{%s} @R{FAILED}", location)
+		}
+		return input, nil
+	}
+
+	input, err := ReadAviatorFile(location)
+	if err != nil {
+		return nil, errors.Wrap(err, ansi.Sprintf("@R{Reading included file} @m{%s} @R{FAILED}", location))
+	}
+	return input, nil
+}
+
+// mergeAviator merges top onto base: steps of base run before the ones of
+// top, while executor and other single valued sections of top replace the
+// ones of base.
+func mergeAviator(base, top aviator.AviatorYaml) aviator.AviatorYaml {
+	result := top
+	result.Spruce = append(append([]aviator.Spruce{}, base.Spruce...), top.Spruce...)
+	result.Template = append(append([]aviator.Template{}, base.Template...), top.Template...)
+	result.Concat = append(append([]aviator.Concat{}, base.Concat...), top.Concat...)
+	result.Copy = append(append([]aviator.Copy{}, base.Copy...), top.Copy...)
+	result.Assert = append(append([]aviator.Assert{}, base.Assert...), top.Assert...)
+	result.Extract = append(append([]aviator.Extract{}, base.Extract...), top.Extract...)
+	result.Exec = append(append([]aviator.Executable{}, base.Exec...), top.Exec...)
+	result.Mask = append(append([]string{}, base.Mask...), top.Mask...)
+	result.Auth = mergeAuth(base.Auth, top.Auth)
+
+	if isZero(top.Squash) {
+		result.Squash = base.Squash
+	}
+	if isZero(top.Fly) {
+		result.Fly = base.Fly
+	}
+	if isZero(top.Kube) {
+		result.Kube = base.Kube
+	}
+	if isZero(top.Helm) {
+		result.Helm = base.Helm
+	}
+	if top.Workspace == "" {
+		result.Workspace = base.Workspace
+	}
+	if isZero(top.Sandbox) {
+		result.Sandbox = base.Sandbox
+	}
+	if top.VendorDir == "" {
+		result.VendorDir = base.VendorDir
+	}
+	if isZero(top.Theme) {
+		result.Theme = base.Theme
+	}
+
+	if len(base.Profiles) > 0 {
+		result.Profiles = map[string]aviator.Profile{}
+		for name, profile := range base.Profiles {
+			result.Profiles[name] = profile
+		}
+		for name, profile := range top.Profiles {
+			result.Profiles[name] = profile
+		}
+	}
+	if len(base.Dependencies) > 0 {
+		result.Dependencies = map[string]aviator.Dependency{}
+		for name, dep := range base.Dependencies {
+			result.Dependencies[name] = dep
+		}
+		for name, dep := range top.Dependencies {
+			result.Dependencies[name] = dep
+		}
+	}
+	return result
+}
+
+// mergeAuth keeps the auth rules of base whose prefix top does not
+// redefine.
+func mergeAuth(base, top []aviator.SourceAuth) []aviator.SourceAuth {
+	result := []aviator.SourceAuth{}
+	for _, rule := range base {
+		redefined := false
+		for _, t := range top {
+			redefined = redefined || t.Prefix == rule.Prefix
+		}
+		if !redefined {
+			result = append(result, rule)
+		}
+	}
+	return append(result, top...)
+}
+
+func isZero(v interface{}) bool {
+	return reflect.DeepEqual(v, reflect.Zero(reflect.TypeOf(v)).Interface())
+}
@@ -0,0 +1,122 @@
+package cockpit_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/JulzDiverse/aviator/cmd/aviator/cockpit"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("includes", func() {
+
+	var dir string
+
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(path, []byte(content), 0644)).To(Succeed())
+	}
+
+	newAviator := func(aviatorYml string) (*Aviator, error) {
+		return New(Options{DryRun: true, Silent: true}).NewAviator([]byte(aviatorYml), nil)
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "aviator-include")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("merges spruce blocks and executors of included files", func() {
+		write("common/base.yml", `
+include:
+- nested.yml
+spruce:
+- name: base
+  base: base.yml
+  to: base-out.yml
+fly:
+  name: common
+  target: target
+`)
+		write("common/nested.yml", `
+spruce:
+- name: nested
+  base: nested.yml
+  to: nested-out.yml
+`)
+
+		aviator, err := newAviator(`
+include:
+- ` + filepath.Join(dir, "common/base.yml") + `
+spruce:
+- name: own
+  base: own.yml
+  to: own-out.yml
+`)
+		Expect(err).ToNot(HaveOccurred())
+
+		names := []string{}
+		for _, spruce := range aviator.AviatorYaml.Spruce {
+			names = append(names, spruce.Name)
+		}
+		Expect(names).To(Equal([]string{"nested", "base", "own"}))
+		Expect(aviator.AviatorYaml.Fly.Name).To(Equal("common"))
+		Expect(aviator.AviatorYaml.Include).To(BeEmpty())
+	})
+
+	It("prefers executors of the including file", func() {
+		write("common.yml", `
+fly:
+  name: common
+`)
+
+		aviator, err := newAviator(`
+include:
+- ` + filepath.Join(dir, "common.yml") + `
+fly:
+  name: own
+`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(aviator.AviatorYaml.Fly.Name).To(Equal("own"))
+	})
+
+	It("includes files included several times once", func() {
+		write("a.yml", "include: [shared.yml]\n")
+		write("b.yml", "include: [shared.yml]\n")
+		write("shared.yml", `
+spruce:
+- base: shared.yml
+  to: shared-out.yml
+`)
+
+		aviator, err := newAviator(`
+include:
+- ` + filepath.Join(dir, "a.yml") + `
+- ` + filepath.Join(dir, "b.yml") + `
+`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(aviator.AviatorYaml.Spruce).To(HaveLen(1))
+	})
+
+	It("fails on include cycles", func() {
+		write("a.yml", "include: [b.yml]\n")
+		write("b.yml", "include: [a.yml]\n")
+
+		_, err := newAviator("include: [" + filepath.Join(dir, "a.yml") + "]\n")
+		Expect(err).To(MatchError(ContainSubstring("Include cycle")))
+	})
+
+	It("fails on missing included files", func() {
+		_, err := newAviator("include: [" + filepath.Join(dir, "missing.yml") + "]\n")
+		Expect(err).To(MatchError(ContainSubstring("missing.yml")))
+	})
+})
@@ -0,0 +1,186 @@
+package cockpit
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/JulzDiverse/aviator"
+	"github.com/JulzDiverse/aviator/printer"
+	"github.com/JulzDiverse/aviator/recorder"
+	"github.com/JulzDiverse/aviator/sequencer"
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+// AnnounceKubeOrder announces the before phase of the kubectl step, so the
+// kubectl steps of other runs on the same context wait until it is applied.
+func (a *Aviator) AnnounceKubeOrder() error {
+	apply := a.AviatorYaml.Kube.Apply
+	if apply.File == "" || len(apply.Before) == 0 {
+		return nil
+	}
+
+	release, err := sequencer.Default().Announce(apply.Context)
+	if err != nil {
+		return err
+	}
+	a.releaseKubeOrder = release
+	return nil
+}
+
+// ReleaseKubeOrder releases the announcement of AnnounceKubeOrder, e.g. if
+// the run is aborted before the kubectl step.
+func (a *Aviator) ReleaseKubeOrder() {
+	if a.releaseKubeOrder != nil {
+		a.releaseKubeOrder()
+	}
+}
+
+func (a *Aviator) waitForKubeOrder(kubeContext string) error {
+	gate := sequencer.Default()
+	if !gate.Pending(kubeContext) {
+		return nil
+	}
+
+	if !a.silent {
+		ansi.Printf("@y{Waiting for the before phases of other runs on} @m{%s}\n", displayKubeContext(kubeContext))
+	}
+	ctx := a.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return gate.Wait(ctx, kubeContext)
+}
+
+// executeKubePhases applies the documents of the before kinds, the
+// remaining documents and the documents of the after kinds of the kubectl
+// step one after another. The before phase does not wait for other runs.
+func (a *Aviator) executeKubePhases(kube aviator.Kube) error {
+	apply := kube.Apply
+	if apply.Kustomize {
+		return ansi.Errorf("@m{before} @R{and} @m{after} @R{cannot be used with} @m{kustomize}")
+	}
+
+	phases, err := sequencer.Phases(apply.File, apply.Recursive, apply.Before, apply.After)
+	if err != nil {
+		return err
+	}
+
+	outputs := []string{}
+	for i, phase := range phases {
+		if i == 1 {
+			a.ReleaseKubeOrder()
+			err = a.waitForKubeOrder(apply.Context)
+			if err != nil {
+				return err
+			}
+		}
+		if phase == nil {
+			continue
+		}
+
+		output, err := a.applyKubePhase(kube, phase)
+		if err != nil {
+			return err
+		}
+		outputs = append(outputs, strings.TrimSpace(string(output)))
+	}
+
+	if apply.Export != "" {
+		a.executor.SetOutputs(map[string]string{apply.Export: strings.TrimSpace(strings.Join(outputs, "\n"))})
+	}
+	return nil
+}
+
+func (a *Aviator) applyKubePhase(kube aviator.Kube, manifests []byte) ([]byte, error) {
+	file, err := ioutil.TempFile("", "aviator-kube-*.yml")
+	if err != nil {
+		return nil, errors.Wrap(err, ansi.Sprintf("@R{Writing kubectl phase FAILED}"))
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.Write(manifests)
+	file.Close()
+	if err != nil {
+		return nil, errors.Wrap(err, ansi.Sprintf("@R{Writing kubectl phase FAILED}"))
+	}
+
+	kube.Apply.File = file.Name()
+	kube.Apply.Recursive = false
+	cmds, err := a.cockpit.kubeExecutor.Command(kube)
+	if err != nil {
+		return nil, err
+	}
+	return a.executor.ExecuteAndCapture(cmds)
+}
+
+func displayKubeContext(kubeContext string) string {
+	if kubeContext == "" {
+		return "the current context"
+	}
+	return kubeContext
+}
+
+// kubeApplyDigest returns the digest of the files and settings of the
+// kubectl step, or "" if unchanged applies cannot be detected, e.g. for
+// kustomize, which may read files outside of its directory.
+func (a *Aviator) kubeApplyDigest(kube aviator.Kube) string {
+	apply := kube.Apply
+	if a.applies == nil || apply.DryRun || apply.Kustomize {
+		return ""
+	}
+
+	settings := apply
+	settings.FailurePolicy, settings.Budget, settings.Condition = aviator.FailurePolicy{}, aviator.Budget{}, aviator.Condition{}
+	digest, err := recorder.Digest(apply.File, apply.Recursive, settings)
+	if err != nil {
+		return ""
+	}
+	return digest
+}
+
+// unchangedKubeApply reports whether the last successful apply of the
+// kubectl step had the same digest. The output exported by that apply is
+// exported again for later steps.
+func (a *Aviator) unchangedKubeApply(kube aviator.Kube, digest string) bool {
+	if digest == "" || a.forceApply {
+		return false
+	}
+	last, ok := a.applies.Last(kubeApplyKey(kube.Apply))
+	if !ok || last.Digest != digest {
+		return false
+	}
+
+	if kube.Apply.Export != "" {
+		a.executor.SetOutputs(map[string]string{kube.Apply.Export: last.Output})
+	}
+	if !a.silent {
+		printer.AnsiPrintSkipped("kubectl: "+kube.Apply.File, "unchanged since the last apply", []string{
+			"last applied at " + last.Time.Local().Format(time.RFC3339),
+			"use --force-apply to apply anyway",
+		})
+	}
+	return true
+}
+
+func (a *Aviator) recordKubeApply(kube aviator.Kube, digest string) error {
+	if digest == "" {
+		return nil
+	}
+	output := ""
+	if kube.Apply.Export != "" {
+		output = a.executor.Outputs()[kube.Apply.Export]
+	}
+	return a.applies.Record(kubeApplyKey(kube.Apply), recorder.Apply{Digest: digest, Output: output})
+}
+
+func kubeApplyKey(apply aviator.KubeApply) string {
+	kubeContext := apply.Context
+	if kubeContext == "" {
+		kubeContext = "current-context"
+	}
+	return kubeContext + ":" + apply.File
+}
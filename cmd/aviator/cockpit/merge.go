@@ -0,0 +1,50 @@
+package cockpit
+
+import (
+	"io"
+	"io/ioutil"
+
+	"github.com/JulzDiverse/aviator"
+	"github.com/JulzDiverse/aviator/filemanager"
+	"github.com/JulzDiverse/aviator/spruce"
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+const stdinKey = "{{aviator_stdin.yml}}"
+
+// Merge merges files ad-hoc, without an aviator file, with the options of
+// conf. A file "-" is read from stdin, which can only be read once.
+func Merge(files []string, stdin io.Reader, conf aviator.MergeConf) ([]byte, error) {
+	if len(files) == 0 {
+		return nil, errors.New("Provide at least one file to merge: aviator merge base.yml overlay.yml")
+	}
+
+	store := filemanager.Store(false, false)
+	conf.Files = []string{}
+	readStdin := false
+	for _, file := range files {
+		if file == "-" {
+			if readStdin {
+				return nil, ansi.Errorf("@R{stdin} @m{-} @R{can only be merged once}")
+			}
+			readStdin = true
+
+			content, err := ioutil.ReadAll(stdin)
+			if err != nil {
+				return nil, errors.Wrap(err, "Reading stdin FAILED")
+			}
+			if err := store.WriteFile(stdinKey, content); err != nil {
+				return nil, err
+			}
+			file = stdinKey
+		}
+		conf.Files = append(conf.Files, file)
+	}
+
+	result, err := spruce.New(false, false).MergeWithOpts(conf)
+	if err != nil {
+		return nil, errors.Wrap(err, "Spruce Merge FAILED")
+	}
+	return result, nil
+}
@@ -0,0 +1,78 @@
+package cockpit_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/JulzDiverse/aviator"
+	. "github.com/JulzDiverse/aviator/cmd/aviator/cockpit"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Merge", func() {
+
+	var dir, base, overlay string
+
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		Expect(ioutil.WriteFile(path, []byte(content), 0644)).To(Succeed())
+		return path
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "aviator-merge")
+		Expect(err).ToNot(HaveOccurred())
+		base = write("base.yml", "meta:\n  owner: team\njobs:\n  web: 1\nlist:\n- a\n")
+		overlay = write("overlay.yml", "jobs:\n  worker: 2\nlist:\n- b\n")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("merges the files in the given order", func() {
+		result, err := Merge([]string{base, overlay}, nil, aviator.MergeConf{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(MatchYAML("meta:\n  owner: team\njobs:\n  web: 1\n  worker: 2\nlist:\n- b\n"))
+	})
+
+	It("prunes paths", func() {
+		result, err := Merge([]string{base, overlay}, nil, aviator.MergeConf{Prune: []string{"meta"}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(MatchYAML("jobs:\n  web: 1\n  worker: 2\nlist:\n- b\n"))
+	})
+
+	It("cherry-picks paths", func() {
+		result, err := Merge([]string{base, overlay}, nil, aviator.MergeConf{CherryPicks: []string{"jobs"}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(MatchYAML("jobs:\n  web: 1\n  worker: 2\n"))
+	})
+
+	It("appends arrays with fallback-append", func() {
+		result, err := Merge([]string{base, overlay}, nil, aviator.MergeConf{FallbackAppend: true, CherryPicks: []string{"list"}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(MatchYAML("list:\n- a\n- b\n"))
+	})
+
+	It("reads - from stdin", func() {
+		stdin := strings.NewReader("jobs:\n  web: 3\n")
+		result, err := Merge([]string{base, "-"}, stdin, aviator.MergeConf{CherryPicks: []string{"jobs"}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(MatchYAML("jobs:\n  web: 3\n"))
+	})
+
+	It("rejects reading stdin twice", func() {
+		_, err := Merge([]string{"-", base, "-"}, strings.NewReader("jobs: {}\n"), aviator.MergeConf{})
+		Expect(err).To(MatchError(ContainSubstring("can only be merged once")))
+	})
+
+	It("requires a file", func() {
+		_, err := Merge([]string{}, nil, aviator.MergeConf{})
+		Expect(err).To(MatchError(ContainSubstring("at least one file")))
+	})
+})
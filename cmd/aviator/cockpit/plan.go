@@ -0,0 +1,79 @@
+package cockpit
+
+import (
+	"os/exec"
+
+	"github.com/JulzDiverse/aviator"
+	"github.com/JulzDiverse/aviator/filemanager"
+	"github.com/JulzDiverse/aviator/processor"
+	"github.com/pkg/errors"
+)
+
+// Plan returns the merges of the spruce plan and the executor commands a run
+// would execute, without merging, writing files or running executors.
+func (a *Aviator) Plan() ([]aviator.PlannedMerge, []aviator.PlannedCommand, error) {
+	merges, err := a.cockpit.spruceProcessor.Plan(a.AviatorYaml.Spruce)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Planning Spruce Plan FAILED")
+	}
+
+	commands, err := a.plannedCommands()
+	if err != nil {
+		return nil, nil, err
+	}
+	return merges, commands, nil
+}
+
+func (a *Aviator) plannedCommands() ([]aviator.PlannedCommand, error) {
+	store := filemanager.Store(false, a.dryRun)
+	result := []aviator.PlannedCommand{}
+	add := func(step string, cond aviator.Condition, executor aviator.Executor, cfg interface{}) error {
+		if skip, _, _ := processor.Skipped(cond); skip {
+			return nil
+		}
+		cmds, err := executor.Command(cfg)
+		if err != nil {
+			return err
+		}
+		result = append(result, plannedCommands(step, cmds)...)
+		return nil
+	}
+
+	fly := a.AviatorYaml.Fly
+	if fly.Name != "" && fly.Target != "" && fly.Config != "" {
+		fly.Config = store.Resolve(fly.Config)
+		if err := add("fly: "+fly.Name, fly.Condition, a.cockpit.flyExecutor, fly); err != nil {
+			return nil, err
+		}
+	}
+
+	helm := a.AviatorYaml.Helm
+	if helm.Chart != "" {
+		if err := add("helm: "+helm.Release, helm.Condition, a.cockpit.helmExecutor, helm); err != nil {
+			return nil, err
+		}
+	}
+
+	kube := a.AviatorYaml.Kube
+	if kube.Apply.File != "" {
+		kube.Apply.File = store.Resolve(kube.Apply.File)
+		if err := add("kubectl: "+kube.Apply.File, kube.Apply.Condition, a.cockpit.kubeExecutor, kube); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, exe := range a.AviatorYaml.Exec {
+		if err := add("exec: "+exe.Executable, exe.Condition, a.cockpit.genericExecutor, []aviator.Executable{exe}); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func plannedCommands(step string, cmds []*exec.Cmd) []aviator.PlannedCommand {
+	result := []aviator.PlannedCommand{}
+	for _, c := range cmds {
+		result = append(result, aviator.PlannedCommand{Step: step, Args: c.Args})
+	}
+	return result
+}
@@ -0,0 +1,33 @@
+package cockpit
+
+import (
+	yaml "gopkg.in/yaml.v2"
+)
+
+// profileVars returns varsMap with the vars of the selected profile, which
+// take precedence. The aviator file is read leniently; syntax errors are
+// reported when it is parsed.
+func (c *Cockpit) profileVars(input []byte, varsMap map[string]string) map[string]string {
+	if c.options.Profile == "" {
+		return varsMap
+	}
+
+	input, _ = expandEnv(input, false)
+	var config struct {
+		Profiles map[string]struct {
+			Vars map[string]string `yaml:"vars"`
+		} `yaml:"profiles"`
+	}
+	if yaml.Unmarshal(quoteCurlyBraces(input), &config) != nil || len(config.Profiles[c.options.Profile].Vars) == 0 {
+		return varsMap
+	}
+
+	vars := map[string]string{}
+	for k, v := range varsMap {
+		vars[k] = v
+	}
+	for k, v := range config.Profiles[c.options.Profile].Vars {
+		vars[k] = v
+	}
+	return vars
+}
@@ -0,0 +1,118 @@
+package cockpit_test
+
+import (
+	. "github.com/JulzDiverse/aviator/cmd/aviator/cockpit"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("profiles", func() {
+
+	const aviatorYml = `
+spruce:
+- base: (( env ))/base.yml
+  to: (( env ))/result.yml
+profiles:
+  prod:
+    vars:
+      env: production
+    kubectl:
+      apply:
+        file: (( env ))/
+  dev: {}
+`
+
+	newAviator := func(profile string, vars map[string]string) (*Aviator, error) {
+		cockpit := New(Options{DryRun: true, Silent: true, Profile: profile})
+		return cockpit.NewAviator([]byte(aviatorYml), vars)
+	}
+
+	It("evaluates the aviator file with the vars of the selected profile", func() {
+		aviator, err := newAviator("prod", map[string]string{"env": "dev"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(aviator.AviatorYaml.Spruce[0].Base).To(Equal("production/base.yml"))
+
+		Expect(aviator.UseProfile("prod")).To(Succeed())
+		Expect(aviator.AviatorYaml.Kube.Apply.File).To(Equal("production/"))
+	})
+
+	It("keeps the vars if the profile defines none", func() {
+		aviator, err := newAviator("dev", map[string]string{"env": "dev"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(aviator.AviatorYaml.Spruce[0].To).To(Equal("dev/result.yml"))
+	})
+
+	It("fails on undefined profiles", func() {
+		aviator, err := newAviator("", map[string]string{"env": "dev"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(aviator.UseProfile("staging")).To(MatchError(ContainSubstring("staging")))
+	})
+})
+
+var _ = Describe("UseProfile", func() {
+
+	const aviatorYml = `
+spruce:
+- base: base.yml
+  to: result.yml
+fly:
+  name: pipeline
+  target: ci
+  config: pipeline.yml
+kubectl:
+  apply:
+    file: manifests/
+exec:
+- executable: echo
+  args: [default]
+profiles:
+  local:
+    kubectl:
+      apply:
+        file: local/
+        context: kind
+    exec:
+    - executable: echo
+      args: [local]
+  empty: {}
+`
+
+	var aviator *Aviator
+
+	BeforeEach(func() {
+		var err error
+		aviator, err = New(Options{DryRun: true, Silent: true}).NewAviator([]byte(aviatorYml), nil)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("replaces the executor sections with the ones of the selected profile", func() {
+		Expect(aviator.UseProfile("local")).To(Succeed())
+		Expect(aviator.AviatorYaml.Kube.Apply.File).To(Equal("local/"))
+		Expect(aviator.AviatorYaml.Kube.Apply.Context).To(Equal("kind"))
+		Expect(aviator.AviatorYaml.Exec).To(HaveLen(1))
+		Expect(aviator.AviatorYaml.Exec[0].Args).To(Equal([]string{"local"}))
+	})
+
+	It("drops the sections the profile does not define", func() {
+		Expect(aviator.UseProfile("local")).To(Succeed())
+		Expect(aviator.AviatorYaml.Fly.Name).To(BeEmpty())
+		Expect(aviator.AviatorYaml.Fly.Config).To(BeEmpty())
+
+		Expect(aviator.UseProfile("empty")).To(Succeed())
+		Expect(aviator.AviatorYaml.Kube.Apply.File).To(BeEmpty())
+		Expect(aviator.AviatorYaml.Exec).To(BeEmpty())
+	})
+
+	It("keeps the spruce section", func() {
+		Expect(aviator.UseProfile("empty")).To(Succeed())
+		Expect(aviator.AviatorYaml.Spruce).To(HaveLen(1))
+		Expect(aviator.AviatorYaml.Spruce[0].To).To(Equal("result.yml"))
+	})
+
+	It("fails on unknown profile names and keeps the executor sections", func() {
+		Expect(aviator.UseProfile("prod")).To(MatchError(ContainSubstring("prod")))
+		Expect(aviator.AviatorYaml.Fly.Name).To(Equal("pipeline"))
+		Expect(aviator.AviatorYaml.Kube.Apply.File).To(Equal("manifests/"))
+	})
+})
@@ -0,0 +1,82 @@
+package cockpit
+
+import (
+	"github.com/JulzDiverse/aviator"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ResolvedConfig renders the configuration aviator is going to run as YAML.
+// Keys holding their zero value are left out, since aviator treats them as
+// not set.
+func (a *Aviator) ResolvedConfig() ([]byte, error) {
+	return renderConfig(a.AviatorYaml)
+}
+
+// maskedConfig renders the configuration of aviatorYml like ResolvedConfig,
+// but with the values of environment variables and aviator variables
+// replaced by <masked>, e.g. secrets of vars files. The profile in use is
+// applied, a selection of steps is not.
+func (a *Aviator) maskedConfig(aviatorYml []byte) ([]byte, error) {
+	tempDir := ""
+	config, err := a.cockpit.parseAviator(aviatorYml, a.vars, &tempDir, true)
+	if err == nil {
+		config, err = a.cockpit.includeAviatorFiles(config, ".", a.vars, &tempDir, true, nil, map[string]bool{})
+	}
+	if err != nil {
+		return nil, err
+	}
+	if profile, ok := config.Profiles[a.profile]; ok && a.profile != "" {
+		applyProfile(&config, profile)
+	}
+	return renderConfig(&config)
+}
+
+func renderConfig(config *aviator.AviatorYaml) ([]byte, error) {
+	raw, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var tree yaml.MapSlice
+	err = yaml.Unmarshal(raw, &tree)
+	if err != nil {
+		return nil, err
+	}
+
+	pruned, _ := pruneZero(tree)
+	if pruned == nil {
+		pruned = yaml.MapSlice{}
+	}
+	return yaml.Marshal(pruned)
+}
+
+func pruneZero(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case yaml.MapSlice:
+		result := yaml.MapSlice{}
+		for _, item := range v {
+			if pruned, ok := pruneZero(item.Value); ok {
+				result = append(result, yaml.MapItem{Key: item.Key, Value: pruned})
+			}
+		}
+		return result, len(result) != 0
+	case []interface{}:
+		result := []interface{}{}
+		for _, item := range v {
+			pruned, _ := pruneZero(item)
+			result = append(result, pruned)
+		}
+		return result, len(result) != 0
+	case nil:
+		return nil, false
+	case string:
+		return v, v != ""
+	case bool:
+		return v, v
+	case int:
+		return v, v != 0
+	case float64:
+		return v, v != 0
+	}
+	return value, true
+}
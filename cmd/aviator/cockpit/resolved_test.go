@@ -0,0 +1,62 @@
+package cockpit_test
+
+import (
+	. "github.com/JulzDiverse/aviator/cmd/aviator/cockpit"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ResolvedConfig", func() {
+
+	var aviator *Aviator
+
+	BeforeEach(func() {
+		var err error
+		aviator, err = New(Options{DryRun: true, Silent: true}).NewAviator([]byte(`
+spruce:
+- base: (( env ))/base.yml
+  merge:
+  - with:
+      files: [overlay.yml]
+  to: {{result}}
+profiles:
+  prod:
+    kubectl:
+      apply:
+        file: prod/
+`), map[string]string{"env": "prod"})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("prints the configuration with vars resolved and unset keys left out", func() {
+		out, err := aviator.ResolvedConfig()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(out)).To(Equal(`spruce:
+- base: prod/base.yml
+  merge:
+  - with:
+      files:
+      - overlay.yml
+  to: '{{result}}'
+profiles:
+  prod:
+    kubectl:
+      apply:
+        file: prod/
+`))
+	})
+
+	It("includes the sections of the profile in use", func() {
+		Expect(aviator.UseProfile("prod")).To(Succeed())
+		aviator.AviatorYaml.Workspace = "ci"
+
+		out, err := aviator.ResolvedConfig()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(out)).To(ContainSubstring(`kubectl:
+  apply:
+    file: prod/
+`))
+		Expect(string(out)).To(ContainSubstring("workspace: ci\n"))
+	})
+})
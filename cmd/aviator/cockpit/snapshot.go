@@ -0,0 +1,55 @@
+package cockpit
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/JulzDiverse/aviator/differ"
+	"github.com/JulzDiverse/aviator/filemanager"
+	"github.com/JulzDiverse/aviator/masker"
+	"github.com/JulzDiverse/aviator/printer"
+	"github.com/JulzDiverse/aviator/snapshot"
+)
+
+// UpdateSnapshots writes the files captured with CaptureRenderedFiles as
+// snapshots to dir.
+func (a *Aviator) UpdateSnapshots(dir string) error {
+	result, err := snapshot.Update(dir, a.snapshotFiles())
+	if err != nil {
+		return err
+	}
+	printer.AnsiPrintSnapshots(result)
+	return nil
+}
+
+// VerifySnapshots compares the captured files with their snapshots in dir,
+// prints the differences and reports whether all of them match.
+func (a *Aviator) VerifySnapshots(dir string) (bool, error) {
+	files := a.snapshotFiles()
+	result, err := snapshot.Verify(dir, files)
+	if err != nil {
+		return false, err
+	}
+
+	for _, path := range result.Changed {
+		golden, err := ioutil.ReadFile(filepath.Join(dir, path))
+		if err != nil {
+			return false, err
+		}
+		mask := masker.Default()
+		printer.AnsiPrintUnifiedDiff(differ.Unified(path, mask.Mask(golden), mask.Mask(files[path])))
+	}
+	printer.AnsiPrintSnapshots(result)
+	return result.Empty(), nil
+}
+
+func (a *Aviator) snapshotFiles() map[string][]byte {
+	store := filemanager.Store(false, a.dryRun)
+	files := map[string][]byte{}
+	for _, path := range store.Captured() {
+		if snapshot.Tracked(path) {
+			files[filepath.ToSlash(filepath.Clean(path))] = store.CapturedFile(path)
+		}
+	}
+	return files
+}
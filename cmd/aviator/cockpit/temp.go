@@ -0,0 +1,62 @@
+package cockpit
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+const TempDirEnv = "AVIATOR_TEMP_DIR"
+
+var tempTarget = regexp.MustCompile(`temp://([-\w./]*)`)
+
+// resolveTempTargets replaces temp://<name> references with paths below a
+// temporary directory unique to this run. Steps writing to and reading from
+// the same name share the file, and commands run by aviator find the
+// directory in AVIATOR_TEMP_DIR. An existing directory of the run (e.g. of
+// an including aviator file) is reused.
+func resolveTempTargets(input []byte, dir string) ([]byte, string, error) {
+	matches := tempTarget.FindAllSubmatch(input, -1)
+	if len(matches) == 0 {
+		return input, dir, nil
+	}
+	for _, m := range matches {
+		name := string(m[1])
+		if filepath.IsAbs(name) || strings.HasPrefix(filepath.Clean(name), "..") {
+			return nil, "", ansi.Errorf("@R{Invalid temp target} @m{temp://%s}@R{: the name must stay within the temp directory}", name)
+		}
+	}
+
+	if dir == "" {
+		var err error
+		dir, err = ioutil.TempDir("", "aviator-temp")
+		if err != nil {
+			return nil, "", errors.Wrap(err, ansi.Sprintf("@R{Creating temp directory FAILED}"))
+		}
+		err = os.Setenv(TempDirEnv, dir)
+		if err != nil {
+			return nil, dir, err
+		}
+	}
+
+	return tempTarget.ReplaceAllFunc(input, func(match []byte) []byte {
+		name := string(tempTarget.FindSubmatch(match)[1])
+		path := filepath.Join(dir, name)
+		if name == "" || strings.HasSuffix(name, "/") {
+			path += "/"
+		}
+		return []byte(path)
+	}), dir, nil
+}
+
+// RemoveTempTargets removes the files written to temp:// targets.
+func (a *Aviator) RemoveTempTargets() {
+	if a.tempDir != "" {
+		os.RemoveAll(a.tempDir)
+	}
+}
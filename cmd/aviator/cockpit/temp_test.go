@@ -0,0 +1,56 @@
+package cockpit_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/JulzDiverse/aviator/cmd/aviator/cockpit"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("temp:// targets", func() {
+
+	It("resolves them to a temp directory unique to the run", func() {
+		aviator, err := New(Options{DryRun: true, Silent: true}).NewAviator([]byte(`
+spruce:
+- base: base.yml
+  to: temp://manifest.yml
+- base: other.yml
+  for_each:
+    files: [a.yml]
+  to_dir: temp://rendered/
+kubectl:
+  apply:
+    file: temp://manifest.yml
+`), nil)
+		Expect(err).ToNot(HaveOccurred())
+		defer aviator.RemoveTempTargets()
+
+		dir := os.Getenv(TempDirEnv)
+		Expect(dir).To(BeADirectory())
+		Expect(aviator.AviatorYaml.Spruce[0].To).To(Equal(filepath.Join(dir, "manifest.yml")))
+		Expect(aviator.AviatorYaml.Spruce[1].ToDir).To(Equal(filepath.Join(dir, "rendered") + "/"))
+		Expect(aviator.AviatorYaml.Kube.Apply.File).To(Equal(aviator.AviatorYaml.Spruce[0].To))
+
+		other, err := New(Options{DryRun: true, Silent: true}).NewAviator([]byte("spruce:\n- base: base.yml\n  to: temp://manifest.yml\n"), nil)
+		Expect(err).ToNot(HaveOccurred())
+		defer other.RemoveTempTargets()
+		Expect(other.AviatorYaml.Spruce[0].To).ToNot(Equal(aviator.AviatorYaml.Spruce[0].To))
+	})
+
+	It("removes the temp directory", func() {
+		aviator, err := New(Options{DryRun: true, Silent: true}).NewAviator([]byte("spruce:\n- base: base.yml\n  to: temp://manifest.yml\n"), nil)
+		Expect(err).ToNot(HaveOccurred())
+		dir := filepath.Dir(aviator.AviatorYaml.Spruce[0].To)
+
+		aviator.RemoveTempTargets()
+		Expect(dir).ToNot(BeAnExistingFile())
+	})
+
+	It("refuses names leaving the temp directory", func() {
+		_, err := New(Options{DryRun: true, Silent: true}).NewAviator([]byte("spruce:\n- base: base.yml\n  to: temp://../manifest.yml\n"), nil)
+		Expect(err).To(MatchError(ContainSubstring("must stay within the temp directory")))
+	})
+})
@@ -0,0 +1,716 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/JulzDiverse/aviator"
+	"github.com/JulzDiverse/aviator/authorizer"
+	"github.com/JulzDiverse/aviator/cmd/aviator/cockpit"
+	"github.com/JulzDiverse/aviator/discoverer"
+	"github.com/JulzDiverse/aviator/doctor"
+	"github.com/JulzDiverse/aviator/filemanager"
+	"github.com/JulzDiverse/aviator/fleet"
+	"github.com/JulzDiverse/aviator/history"
+	"github.com/JulzDiverse/aviator/printer"
+	"github.com/JulzDiverse/aviator/schema"
+	"github.com/JulzDiverse/aviator/snapshot"
+	"github.com/JulzDiverse/aviator/sweeper"
+	"github.com/JulzDiverse/aviator/tracer"
+	"github.com/JulzDiverse/aviator/vendorer"
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+	"github.com/urfave/cli"
+)
+
+func getCommands() []cli.Command {
+	return []cli.Command{
+		{
+			Name:  "schema",
+			Usage: "prints the JSON schema of the aviator.yml (YAML by default)",
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "json",
+					Usage: "print the schema as JSON",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				var out []byte
+				var err error
+				if c.Bool("json") {
+					out, err = schema.JSON()
+				} else {
+					out, err = schema.YAML()
+				}
+				exitWithError(err)
+				fmt.Println(string(out))
+				return nil
+			},
+		},
+		{
+			Name:  "doctor",
+			Usage: "checks the environment (binaries, aviator file, kube context, fly target, output dirs, caches)",
+			Flags: append(aviatorFileFlags(),
+				cli.StringFlag{
+					Name:  "cache-max-size",
+					Usage: "fail if the cache exceeds the given size (e.g. 2GB), like 'aviator cache gc --max-size'",
+				},
+				cli.StringFlag{
+					Name:  "cache-max-age",
+					Usage: "fail if the cache has entries not used within the given time (e.g. 30d), like 'aviator cache gc --max-age'",
+				},
+			),
+			Action: func(c *cli.Context) error {
+				policy, err := sweeper.NewPolicy(c.String("cache-max-size"), c.String("cache-max-age"))
+				exitWithError(err)
+
+				aviatorFile := findAviatorFile(c.String("file"))
+				aviator, err := newAviator(c, cockpit.Options{DryRun: true, Silent: true})
+				if err != nil {
+					printResult(doctor.Result{Check: "aviator file " + aviatorFile, Detail: err.Error(), Fix: "fix the aviator file"})
+					os.Exit(1)
+				}
+				printResult(doctor.Result{Check: "aviator file " + aviatorFile, OK: true, Detail: "valid"})
+
+				failed := false
+				for _, result := range doctor.Check(aviator.AviatorYaml, policy) {
+					printResult(result)
+					failed = failed || !result.OK
+				}
+				aviator.RemoveTempTargets()
+				if failed {
+					os.Exit(1)
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "config",
+			Usage: "prints the aviator file; with --resolved the configuration aviator runs after vars, profile and workspace are applied",
+			Flags: append(aviatorFileFlags(),
+				cli.BoolFlag{
+					Name:  "resolved",
+					Usage: "print the resolved configuration",
+				},
+				cli.StringFlag{
+					Name:  "profile, p",
+					Usage: "resolve the vars, fly, kubectl and exec sections of the given profile",
+				},
+				cli.StringFlag{
+					Name:  "workspace, w",
+					Usage: "resolve the configuration for the given workspace",
+				},
+			),
+			Action: func(c *cli.Context) error {
+				if !c.Bool("resolved") {
+					aviatorFile := findAviatorFile(c.String("file"))
+					if !verifyAviatorFileExists(aviatorFile) {
+						exitWithNoAviatorFile()
+					}
+					aviatorYml, err := cockpit.ReadAviatorFile(aviatorFile)
+					exitWithError(err)
+					fmt.Print(string(aviatorYml))
+					return nil
+				}
+
+				aviator, err := newAviator(c, cockpit.Options{DryRun: true, Silent: true, Profile: c.String("profile")})
+				exitWithError(err)
+
+				if profile := c.String("profile"); profile != "" {
+					err = aviator.UseProfile(profile)
+					exitWithError(err)
+				}
+				if workspace := c.String("workspace"); workspace != "" {
+					aviator.AviatorYaml.Workspace = workspace
+				}
+
+				out, err := aviator.ResolvedConfig()
+				exitWithError(err)
+				fmt.Print(string(out))
+				cleanup()
+				return nil
+			},
+		},
+		{
+			Name:  "plan",
+			Usage: "lists the merges and executor commands a run would execute, without merging or writing files",
+			Flags: append(aviatorFileFlags(),
+				cli.StringFlag{
+					Name:  "profile, p",
+					Usage: "plan with the vars, fly, kubectl and exec sections of the given profile",
+				},
+				curlyBracesFlag,
+				cli.StringFlag{
+					Name:  "changed-since",
+					Usage: "leave out steps with when_changed if the git diff since the given ref does not touch their paths",
+				},
+				cli.StringSliceFlag{
+					Name:  "tags",
+					Usage: "leave out steps not tagged with one of the given tags: [tag1,tag2]",
+				},
+				cli.StringSliceFlag{
+					Name:  "exclude-tags",
+					Usage: "leave out steps tagged with one of the given tags: [tag1,tag2]",
+				},
+				cli.BoolFlag{
+					Name:  "json",
+					Usage: "print the plan as JSON, including the inputs and targets of each step and the dependencies between steps",
+				},
+			),
+			Action: func(c *cli.Context) error {
+				aviator, err := newAviator(c, cockpit.Options{
+					CurlyBraces: c.Bool("curly-braces"),
+					DryRun:      true,
+					Silent:      true,
+					Profile:     c.String("profile"),
+				})
+				exitWithError(err)
+
+				if profile := c.String("profile"); profile != "" {
+					err = aviator.UseProfile(profile)
+					exitWithError(err)
+				}
+				if ref := c.String("changed-since"); ref != "" {
+					err = aviator.TrackChangesSince(ref)
+					exitWithError(err)
+				}
+				aviator.FilterTags(names(c.StringSlice("tags")), names(c.StringSlice("exclude-tags")))
+
+				merges, commands, err := aviator.Plan()
+				exitWithError(err)
+				if c.Bool("json") {
+					out, err := printer.JSONPlan(merges, commands)
+					exitWithError(err)
+					fmt.Println(string(out))
+				} else {
+					printer.AnsiPrintPlan(merges, commands)
+				}
+				cleanup()
+				return nil
+			},
+		},
+		{
+			Name:  "diff",
+			Usage: "renders the files in memory and diffs them against the current targets; exits with 1 if a target changed",
+			Flags: append(aviatorFileFlags(),
+				cli.StringFlag{
+					Name:  "workspace, w",
+					Usage: "diff against the targets in .aviator/workspaces/<name>",
+				},
+				curlyBracesFlag,
+				cli.BoolFlag{
+					Name:  "semantic",
+					Usage: "print the changed YAML paths instead of the changed lines",
+				},
+				cli.BoolFlag{
+					Name:  "color",
+					Usage: "color the diff also if stdout is not a terminal",
+				},
+			),
+			Action: func(c *cli.Context) error {
+				if c.Bool("color") {
+					ansi.Color(true)
+				}
+
+				aviator, err := newAviator(c, cockpit.Options{CurlyBraces: c.Bool("curly-braces"), Silent: true})
+				exitWithError(err)
+
+				workspace := c.String("workspace")
+				if workspace == "" {
+					workspace = aviator.AviatorYaml.Workspace
+				}
+				if workspace != "" {
+					err = aviator.UseWorkspace(workspacesDir, workspace)
+					exitWithError(err)
+				}
+
+				renderInMemory(aviator)
+
+				changed, err := aviator.DiffRenderedFiles(c.Bool("semantic"))
+				exitWithError(err)
+				cleanup()
+				if changed {
+					os.Exit(1)
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "render",
+			Usage: "renders only the merge writing the given target, e.g. a single for_each output; executors are omitted",
+			Flags: append(aviatorFileFlags(),
+				cli.StringFlag{
+					Name:  "target, t",
+					Usage: "path of the rendered file, as written by a run",
+				},
+				cli.StringFlag{
+					Name:  "workspace, w",
+					Usage: "write the rendered file under .aviator/workspaces/<name> instead of the repository tree",
+				},
+				curlyBracesFlag,
+				verboseFlag,
+			),
+			Action: func(c *cli.Context) error {
+				target := c.String("target")
+				if target == "" {
+					exitWithError(errors.New("Provide the path of the file to render: aviator render --target results/app.yml"))
+				}
+
+				aviator, err := newAviator(c, cockpit.Options{CurlyBraces: c.Bool("curly-braces"), Verbose: c.Bool("verbose")})
+				exitWithError(err)
+
+				workspace := c.String("workspace")
+				if workspace == "" {
+					workspace = aviator.AviatorYaml.Workspace
+				}
+				if workspace != "" {
+					err = aviator.UseWorkspace(workspacesDir, workspace)
+					exitWithError(err)
+				}
+
+				aviator.RenderTarget(target)
+				err = aviator.ProcessSprucePlan()
+				exitWithError(err)
+				cleanup()
+				return nil
+			},
+		},
+		{
+			Name:  "cache",
+			Usage: "manages the cache of remote files and git checkouts in ~/.aviator/cache",
+			Subcommands: []cli.Command{
+				{
+					Name:  "gc",
+					Usage: "removes cache entries not used within --max-age and the least recently used entries exceeding --max-size",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "max-size",
+							Usage: "maximum size of the cache (e.g. 2GB, 500MB)",
+						},
+						cli.StringFlag{
+							Name:  "max-age",
+							Usage: "maximum time since an entry was last used (e.g. 30d, 12h)",
+						},
+						cli.StringFlag{
+							Name:  "dir",
+							Usage: "cache directory (default: ~/.aviator/cache)",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						policy, err := sweeper.NewPolicy(c.String("max-size"), c.String("max-age"))
+						exitWithError(err)
+						if policy.Empty() {
+							exitWithError(errors.New("Provide a policy: aviator cache gc --max-size 2GB --max-age 30d"))
+						}
+
+						dir := c.String("dir")
+						if dir == "" {
+							home, err := os.UserHomeDir()
+							exitWithError(err)
+							dir = filepath.Join(home, remoteCacheDir)
+						}
+
+						result, err := sweeper.Sweep(dir, policy)
+						exitWithError(err)
+						ansi.Printf("@G{Removed %d cache entries} (%s), %s left in @m{%s}\n", len(result.Removed), sweeper.FormatSize(result.Freed), sweeper.FormatSize(result.Size), dir)
+						return nil
+					},
+				},
+			},
+		},
+		{
+			Name:  "deps",
+			Usage: "manages the template bundles declared in the dependencies section of the aviator file",
+			Subcommands: []cli.Command{
+				{
+					Name:  "vendor",
+					Usage: "fetches all dependencies into the vendor directory and records them in its " + vendorer.LockFile,
+					Flags: aviatorFileFlags(),
+					Action: func(c *cli.Context) error {
+						cfg := depsConfig(c)
+						v := vendorer.New(cfg.VendorDir)
+						v.UseAuth(authorizer.New(cfg.Auth))
+						locks, err := v.Vendor(cfg.Dependencies)
+						exitWithError(err)
+						printVendored(locks)
+						cleanup()
+						return nil
+					},
+				},
+				{
+					Name:  "verify",
+					Usage: "checks that the vendor directory matches the declared dependencies; exits with 1 if not",
+					Flags: aviatorFileFlags(),
+					Action: func(c *cli.Context) error {
+						cfg := depsConfig(c)
+						problems, err := vendorer.Verify(cfg.VendorDir, cfg.Dependencies)
+						exitWithError(err)
+						cleanup()
+						if len(problems) > 0 {
+							exitWithError(ansi.Errorf("@R{Vendored dependencies are out of date} (run 'aviator deps vendor'):\n\t%s", strings.Join(problems, "\n\t")))
+						}
+						ansi.Printf("@G{All %d dependencies are vendored}\n", len(cfg.Dependencies))
+						return nil
+					},
+				},
+			},
+		},
+		{
+			Name:  "snapshot",
+			Usage: "maintains golden copies of all rendered files to catch unintended changes of the rendering",
+			Subcommands: []cli.Command{
+				{
+					Name:  "update",
+					Usage: "renders all files and writes them as snapshots, removing snapshots of files no longer rendered",
+					Flags: snapshotFlags(),
+					Action: func(c *cli.Context) error {
+						aviator := snapshotAviator(c)
+						err := aviator.UpdateSnapshots(c.String("dir"))
+						exitWithError(err)
+						cleanup()
+						return nil
+					},
+				},
+				{
+					Name:  "verify",
+					Usage: "renders all files in memory and compares them with their snapshots; exits with 1 if any differs",
+					Flags: snapshotFlags(),
+					Action: func(c *cli.Context) error {
+						aviator := snapshotAviator(c)
+						matching, err := aviator.VerifySnapshots(c.String("dir"))
+						exitWithError(err)
+						cleanup()
+						if !matching {
+							os.Exit(1)
+						}
+						return nil
+					},
+				},
+			},
+		},
+		{
+			Name:      "auto",
+			Usage:     "renders a repository laid out as base/, overlays/<env>/ to rendered/<env>/, without an aviator file",
+			ArgsUsage: "[DIR] (default: the current directory)",
+			Flags: []cli.Flag{
+				cli.StringSliceFlag{
+					Name:  "env, e",
+					Usage: "render only the given environment (can be repeated)",
+				},
+				cli.BoolFlag{
+					Name:  "print",
+					Usage: "print the discovered aviator file instead of rendering it",
+				},
+				cli.BoolFlag{
+					Name:  "dry-run, d",
+					Usage: "print files to stdout instead of writing them",
+				},
+				curlyBracesFlag,
+				verboseFlag,
+			},
+			Action: func(c *cli.Context) error {
+				root := "."
+				if c.NArg() != 0 {
+					root = c.Args().First()
+				}
+
+				aviatorYml, err := discoverer.Discover(discoverer.DefaultLayout(root), c.StringSlice("env"))
+				exitWithError(err)
+				if c.Bool("print") {
+					fmt.Print(string(aviatorYml))
+					return nil
+				}
+
+				aviator, err := cockpit.New(cockpit.Options{
+					CurlyBraces: c.Bool("curly-braces"),
+					DryRun:      c.Bool("dry-run"),
+					Verbose:     c.Bool("verbose"),
+				}).NewAviator(aviatorYml, nil)
+				exitWithError(err)
+				cleanup = aviator.RemoveTempTargets
+
+				err = aviator.ProcessSprucePlan()
+				exitWithError(err)
+				cleanup()
+				return nil
+			},
+		},
+		{
+			Name:      "merge",
+			Usage:     "merges the given files ad-hoc, without an aviator file",
+			ArgsUsage: "BASE [OVERLAY...] (- reads a file from stdin)",
+			Flags: []cli.Flag{
+				cli.StringSliceFlag{
+					Name:  "cherry-pick",
+					Usage: "keep only the given path of the merge result (can be repeated)",
+				},
+				cli.StringSliceFlag{
+					Name:  "prune",
+					Usage: "remove the given path from the merge result (can be repeated)",
+				},
+				cli.BoolFlag{
+					Name:  "skip-eval",
+					Usage: "merge without evaluating spruce operators",
+				},
+				cli.BoolFlag{
+					Name:  "go-patch",
+					Usage: "enable go-patch files as overlays",
+				},
+				cli.BoolFlag{
+					Name:  "fallback-append",
+					Usage: "append arrays by default instead of merging them inline",
+				},
+				cli.StringFlag{
+					Name:  "to",
+					Usage: "write the result to the given file instead of stdout",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				result, err := cockpit.Merge(c.Args(), os.Stdin, aviator.MergeConf{
+					FallbackAppend: c.Bool("fallback-append"),
+					Prune:          c.StringSlice("prune"),
+					CherryPicks:    c.StringSlice("cherry-pick"),
+					SkipEval:       c.Bool("skip-eval"),
+					EnableGoPatch:  c.Bool("go-patch"),
+				})
+				exitWithError(err)
+
+				if to := c.String("to"); to != "" {
+					exitWithError(filemanager.Store(false, false).WriteFile(to, result))
+					return nil
+				}
+				fmt.Print(string(result))
+				return nil
+			},
+		},
+		{
+			Name:      "repro",
+			Usage:     "reproduces a run recorded with --record from its cached inputs",
+			ArgsUsage: "RUN-ID",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "dir",
+					Usage: "directory to reproduce the run in (default: a new temporary directory)",
+				},
+				cli.BoolFlag{
+					Name:  "render-only",
+					Usage: "render files, but omit all executors",
+				},
+				cli.StringSliceFlag{
+					Name:  "var",
+					Usage: "value of a --var of the recorded run, which are not kept in the history: --var key=value",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				if c.NArg() != 1 {
+					exitWithError(errors.New("Provide the id of the run to reproduce: aviator repro <run-id>"))
+				}
+
+				run, err := history.Load(".", c.Args().First())
+				exitWithError(err)
+
+				args, missing := unmaskVars(run.Args, varsToMap(c.StringSlice("var")))
+				if len(missing) != 0 {
+					exitWithError(ansi.Errorf("@R{Run} @m{%s} @R{was called with vars which are not kept in the history, provide them with} @m{--var}: %s", run.ID, strings.Join(missing, ", ")))
+				}
+
+				dir := c.String("dir")
+				if dir == "" {
+					dir, err = ioutil.TempDir("", "aviator-repro-")
+					exitWithError(err)
+				}
+				exitWithError(history.Restore(".", run, dir))
+
+				for _, name := range history.ChangedEnv(run) {
+					ansi.Fprintf(os.Stderr, "@Y{WARNING}: environment variable @m{%s} differs from the recorded run\n", name)
+				}
+
+				if c.Bool("render-only") {
+					args = append(args, "--render-only")
+				}
+
+				bin, err := os.Executable()
+				exitWithError(err)
+				cmd := exec.Command(bin, args...)
+				cmd.Dir = dir
+				cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+				exitWithError(errors.Wrap(cmd.Run(), ansi.Sprintf("@R{Reproducing run} @m{%s} @R{FAILED}", run.ID)))
+
+				if mismatches := history.VerifyOutputs(run, dir); len(mismatches) != 0 {
+					exitWithError(ansi.Errorf("@R{Reproduced outputs differ from run} @m{%s}:\n\t%s", run.ID, strings.Join(mismatches, "\n\t")))
+				}
+				ansi.Printf("@G{REPRODUCED} run @m{%s} in %s\n", run.ID, dir)
+				return nil
+			},
+		},
+		{
+			Name:      "replay",
+			Usage:     "re-executes a single operation of a trace recorded with --trace with its recorded inputs",
+			ArgsUsage: "TRACE-FILE",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "step",
+					Usage: "id of the operation to replay, or the step it belongs to (default: list the operations)",
+				},
+				cli.IntFlag{
+					Name:  "repeat",
+					Value: 1,
+					Usage: "replay the operation this many times, to catch nondeterministic failures",
+				},
+				cli.StringFlag{
+					Name:  "dir",
+					Usage: "directory to run commands in (default: a new temporary directory)",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				if c.NArg() != 1 {
+					exitWithError(errors.New("Provide the trace to replay: aviator replay <trace.json> --step <id>"))
+				}
+
+				trace, err := tracer.Load(c.Args().First())
+				exitWithError(err)
+				if c.String("step") == "" {
+					printOperations(trace)
+					return nil
+				}
+				op, err := trace.Find(c.String("step"))
+				exitWithError(err)
+
+				dir := c.String("dir")
+				if dir == "" && op.Kind == tracer.KindExec {
+					dir, err = ioutil.TempDir("", "aviator-replay-")
+					exitWithError(err)
+				}
+
+				failures, differences := 0, 0
+				for i := 1; i <= c.Int("repeat"); i++ {
+					output, err := tracer.Replay(".", op, dir)
+					switch {
+					case err != nil:
+						failures++
+						ansi.Printf("@R{FAILED} replay %d of operation @m{%d} (%s): %s\n", i, op.ID, op.Step, err.Error())
+					case tracer.Matches(op, output):
+						ansi.Printf("@G{REPLAYED} replay %d of operation @m{%d} (%s): output matches the trace\n", i, op.ID, op.Step)
+					default:
+						differences++
+						ansi.Printf("@Y{DIFFERS} replay %d of operation @m{%d} (%s): output differs from the trace\n", i, op.ID, op.Step)
+					}
+				}
+				if op.Error != "" {
+					ansi.Printf("The traced operation failed with: %s\n", op.Error)
+				}
+				if failures != 0 || differences != 0 {
+					exitWithError(ansi.Errorf("@R{%d of %d replays failed, %d differed from the trace}", failures, c.Int("repeat"), differences))
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "fleet",
+			Usage: "runs aviator for every pilot (repo or directory) listed in a fleet file",
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "silent, s",
+					Usage: "print only errors and the summary",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				fleetFile := c.String("file")
+				fleetYml, err := ioutil.ReadFile(fleetFile)
+				exitWithError(err)
+
+				config, err := fleet.Load(fleetYml)
+				exitWithError(err)
+
+				bin, err := os.Executable()
+				exitWithError(err)
+
+				results := fleet.New(bin, filepath.Dir(fleetFile), c.Bool("silent")).Run(config)
+				if !printFleetSummary(results) {
+					os.Exit(1)
+				}
+				return nil
+			},
+		},
+	}
+}
+
+// newAviator reads the aviator file and vars given with the aviator file
+// flags and creates its aviator with options. Its temp targets are removed
+// on cleanup.
+func newAviator(c *cli.Context, options cockpit.Options) (*cockpit.Aviator, error) {
+	aviatorFile := findAviatorFile(c.String("file"))
+	if !verifyAviatorFileExists(aviatorFile) {
+		exitWithNoAviatorFile()
+	}
+
+	aviatorYml, err := cockpit.ReadAviatorFile(aviatorFile)
+	exitWithError(err)
+
+	vars, err := loadVars(c)
+	exitWithError(err)
+
+	options.LenientEnv = c.Bool("lenient-env")
+	aviator, err := cockpit.New(options).NewAviator(aviatorYml, vars)
+	if err != nil {
+		return nil, err
+	}
+	cleanup = aviator.RemoveTempTargets
+	return aviator, nil
+}
+
+// depsConfig reads the aviator file, including the dependencies of included
+// files.
+func depsConfig(c *cli.Context) *aviator.AviatorYaml {
+	aviator, err := newAviator(c, cockpit.Options{DryRun: true, Silent: true})
+	exitWithError(err)
+
+	if len(aviator.AviatorYaml.Dependencies) == 0 {
+		exitWithError(errors.New("The aviator file declares no dependencies"))
+	}
+	return aviator.AviatorYaml
+}
+
+func snapshotFlags() []cli.Flag {
+	return append(aviatorFileFlags(),
+		curlyBracesFlag,
+		cli.StringFlag{
+			Name:  "dir",
+			Value: snapshot.DefaultDir,
+			Usage: "directory of the snapshots",
+		},
+	)
+}
+
+// snapshotAviator renders all files of the aviator file in memory.
+func snapshotAviator(c *cli.Context) *cockpit.Aviator {
+	aviator, err := newAviator(c, cockpit.Options{CurlyBraces: c.Bool("curly-braces"), Silent: true})
+	exitWithError(err)
+
+	renderInMemory(aviator)
+	return aviator
+}
+
+// renderInMemory runs all rendering steps, keeping the rendered files in
+// memory instead of writing them.
+func renderInMemory(aviator *cockpit.Aviator) {
+	aviator.CaptureRenderedFiles()
+	err := aviator.ProcessSprucePlan()
+	exitWithError(err)
+	if len(aviator.AviatorYaml.Squash.Contents) != 0 {
+		err = aviator.ProcessSquashPlan()
+		exitWithError(err)
+	}
+	if len(aviator.AviatorYaml.Template) != 0 {
+		err = aviator.ProcessTemplatePlan()
+		exitWithError(err)
+	}
+	if len(aviator.AviatorYaml.Concat) != 0 {
+		err = aviator.ProcessConcatPlan()
+		exitWithError(err)
+	}
+}
@@ -1,6 +1,10 @@
 package main
 
-import "github.com/urfave/cli"
+import (
+	"time"
+
+	"github.com/urfave/cli"
+)
 
 func setCli() *cli.App {
 	cmd := cli.NewApp()
@@ -14,37 +18,218 @@ func setCli() *cli.App {
 	cmd.Usage = "Navigate to a aviator.yml file and run aviator"
 	cmd.Version = "1.6.0"
 	cmd.Flags = getFlags()
+	cmd.Commands = getCommands()
 	return cmd
 }
 
 func getFlags() []cli.Flag {
 	var flags []cli.Flag
-	flags = []cli.Flag{
+	flags = append(aviatorFileFlags(),
+		verboseFlag,
+		cli.StringSliceFlag{
+			Name:  "suppress-warnings",
+			Usage: "hide warnings of a category printed with --verbose: [category=excluded-by-regexp|skipped|missing-file|missing-dir]",
+		},
+		cli.BoolFlag{
+			Name:  "silent, s",
+			Usage: "silent mode (no prints)",
+		},
+		cli.BoolFlag{
+			Name:  "porcelain",
+			Usage: "print only the paths of written files, one per line (implies --silent)",
+		},
 		cli.StringFlag{
-			Name:  "file, f",
-			Value: "aviator.yml",
-			Usage: "Specifies a path to an aviator yaml",
+			Name:  "profile, p",
+			Usage: "uses the vars and to_dir of the given profile and replaces the fly, kubectl and exec sections with the ones of the profile",
+		},
+		cli.StringFlag{
+			Name:  "workspace, w",
+			Usage: "write rendered files under .aviator/workspaces/<name> instead of the repository tree",
+		},
+		cli.IntFlag{
+			Name:  "parallel",
+			Value: 1,
+			Usage: "number of for_each merges of a spruce step to run concurrently",
 		},
 		cli.BoolFlag{
-			Name:  "verbose, vv",
-			Usage: "prints warnings",
+			Name:  "collect-errors",
+			Usage: "run all spruce steps and report their failures together instead of stopping at the first one",
 		},
+		curlyBracesFlag,
 		cli.BoolFlag{
-			Name:  "silent, s",
-			Usage: "silent mode (no prints)",
+			Name:  "dry-run, d",
+			Usage: "print files to stdout, executors will be omitted",
+		},
+		cli.BoolFlag{
+			Name:  "stdout",
+			Usage: "stream the spruce merge results to stdout, separated by ---, instead of writing them to files (implies --silent)",
+		},
+		cli.BoolFlag{
+			Name:  "render-only",
+			Usage: "render files, but omit all executors",
+		},
+		cli.BoolFlag{
+			Name:  "watch",
+			Usage: "after the run, re-run the spruce steps affected by changes of their input files until interrupted",
+		},
+		cli.DurationFlag{
+			Name:  "watch-interval",
+			Value: time.Second,
+			Usage: "how often --watch checks the input files for changes",
+		},
+		cli.StringFlag{
+			Name:  "cache-max-size",
+			Usage: "with --watch, trim the cache in ~/.aviator/cache to the given size every hour (e.g. 2GB)",
+		},
+		cli.StringFlag{
+			Name:  "cache-max-age",
+			Usage: "with --watch, remove cache entries not used within the given age every hour (e.g. 30d)",
+		},
+		cli.BoolFlag{
+			Name:  "backup",
+			Usage: "keep the previous content of every changed file as <file>.bak",
+		},
+		cli.BoolFlag{
+			Name:  "rollback",
+			Usage: "restore all written files if a merge of the run fails",
+		},
+		cli.BoolFlag{
+			Name:  "force-apply",
+			Usage: "run the kubectl step even if its files did not change since the last successful apply",
+		},
+		cli.BoolFlag{
+			Name:  "record",
+			Usage: "record the run and its inputs in .aviator/runs to reproduce it later with 'aviator repro'",
+		},
+		cli.StringFlag{
+			Name:  "trace",
+			Usage: "JSON file recording the merges and commands of the run with the digests of their inputs and outputs, to replay single operations with 'aviator replay'",
+		},
+		cli.BoolFlag{
+			Name:  "abort-on-drift",
+			Usage: "verify that rendered files are unchanged on disk before running executors",
+		},
+		cli.StringFlag{
+			Name:  "ca-bundle",
+			Usage: "path to a PEM CA bundle trusted for TLS connections (remote files, Vault, callback, annotate) in addition to the system roots",
+		},
+		cli.StringFlag{
+			Name:  "client-cert",
+			Usage: "path to a PEM client certificate for TLS connections, requires --client-key",
+		},
+		cli.StringFlag{
+			Name:  "client-key",
+			Usage: "path to the PEM key of --client-cert",
+		},
+		cli.StringFlag{
+			Name:  "annotate",
+			Usage: "post the semantic diff of rendered files as pull request comment: [github|gitlab]",
+		},
+		cli.StringFlag{
+			Name:  "diff-base",
+			Value: "HEAD",
+			Usage: "git ref the rendered files are compared against for --annotate",
+		},
+		cli.StringSliceFlag{
+			Name:  "only",
+			Usage: "run only the spruce blocks with the given names: [name1,name2]",
+		},
+		cli.StringSliceFlag{
+			Name:  "skip",
+			Usage: "skip the spruce blocks with the given names: [name1,name2]",
+		},
+		cli.StringSliceFlag{
+			Name:  "tags",
+			Usage: "run only the steps tagged with one of the given tags: [tag1,tag2]",
+		},
+		cli.StringSliceFlag{
+			Name:  "exclude-tags",
+			Usage: "skip the steps tagged with one of the given tags: [tag1,tag2]",
+		},
+		cli.StringFlag{
+			Name:  "changed-since",
+			Usage: "run steps with when_changed only if the git diff since the given ref (e.g. origin/main) touches their paths",
+		},
+		cli.DurationFlag{
+			Name:  "inactivity-timeout",
+			Usage: "kill executor commands which produce no output for the given duration (e.g. 10m)",
+		},
+		cli.BoolFlag{
+			Name:  "sandbox",
+			Usage: "run executors with a temporary HOME and a filtered environment",
+		},
+		cli.BoolFlag{
+			Name:  "offline",
+			Usage: "forbid network access: vault lookups are resolved from --vault-stub, fly and kubectl executors fail",
+		},
+		cli.BoolFlag{
+			Name:  "no-vault",
+			Usage: "keep vault lookups as placeholders '(( vault \"secret/path:key\" ))' instead of reading secrets from Vault",
+		},
+		cli.StringFlag{
+			Name:  "vault-stub",
+			Usage: "YAML file mapping 'secret/path:key' to values, used for vault lookups in --offline mode",
+		},
+		cli.StringFlag{
+			Name:  "callback-url",
+			Usage: "post JSON status updates (started, each finished step, finished) of the run to the given URL",
+		},
+		cli.StringFlag{
+			Name:   "callback-token",
+			Usage:  "bearer token sent with the status updates of --callback-url",
+			EnvVar: "AVIATOR_CALLBACK_TOKEN",
+		},
+		cli.StringFlag{
+			Name:   "theme",
+			Usage:  "color theme of the output: default, light (for light terminal backgrounds) or monochrome; overrides the preset of the aviator file",
+			EnvVar: "AVIATOR_THEME",
+		},
+		cli.StringFlag{
+			Name:  "timings",
+			Usage: "JSON file recording step durations across runs; prints a timing report after the run",
+		},
+		cli.BoolFlag{
+			Name:  "debug-on-failure",
+			Usage: "dump a debug bundle of failing spruce merges to " + debugBundleDir,
+		},
+		cli.StringFlag{
+			Name:  "debug-bundle",
+			Usage: "write the resolved config, plan, input digests, warnings, log and failing merges of the run to the given .tgz",
+		},
+	)
+	return flags
+}
+
+// aviatorFileFlags are the flags of every command reading an aviator file.
+func aviatorFileFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  "file, f",
+			Value: "aviator.yml",
+			Usage: "Specifies a path to an aviator file (YAML, JSON or CUE)",
 		},
 		cli.StringSliceFlag{
 			Name:  "var",
 			Usage: "provides a variable to an aviator file: [key=value]",
 		},
-		cli.BoolFlag{
-			Name:  "curly-braces, b",
-			Usage: "allow {{}} syntax in yaml files",
+		cli.StringSliceFlag{
+			Name:  "vars-file, var-file",
+			Usage: "provides the variables of a YAML file to an aviator file; --var takes precedence",
 		},
 		cli.BoolFlag{
-			Name:  "dry-run, d",
-			Usage: "print files to stdout, executors will be omitted",
+			Name:  "lenient-env",
+			Usage: "keep references to environment variables which are not set as they are, instead of failing",
 		},
 	}
-	return flags
 }
+
+var (
+	curlyBracesFlag = cli.BoolFlag{
+		Name:  "curly-braces, b",
+		Usage: "allow {{}} syntax in yaml files",
+	}
+	verboseFlag = cli.BoolFlag{
+		Name:  "verbose, vv",
+		Usage: "prints warnings",
+	}
+)
@@ -1,45 +1,215 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 
+	"github.com/JulzDiverse/aviator"
+	"github.com/JulzDiverse/aviator/bundler"
 	"github.com/JulzDiverse/aviator/cmd/aviator/cockpit"
+	"github.com/JulzDiverse/aviator/doctor"
+	"github.com/JulzDiverse/aviator/evaluator"
+	"github.com/JulzDiverse/aviator/reporter"
+	"github.com/JulzDiverse/aviator/sequencer"
+	"github.com/JulzDiverse/aviator/spruce"
+	"github.com/JulzDiverse/aviator/sweeper"
+	"github.com/JulzDiverse/aviator/themer"
+	"github.com/JulzDiverse/aviator/timer"
+	"github.com/JulzDiverse/aviator/tracer"
+	"github.com/JulzDiverse/aviator/transport"
 	"github.com/JulzDiverse/aviator/validator"
 	"github.com/starkandwayne/goutils/ansi"
 	"github.com/urfave/cli"
 )
 
+const (
+	debugBundleDir = ".aviator/debug"
+	workspacesDir  = ".aviator/workspaces"
+	remoteCacheDir = ".aviator/cache"
+	kubeOrderDir   = "aviator-kube-order"
+
+	cacheSweepInterval = time.Hour
+)
+
 func main() {
+	spruce.ServeIsolatedMerge()
+
 	cmd := setCli()
 
 	cmd.Action = func(c *cli.Context) error {
-		aviatorFile := c.String("file")
+		aviatorFile := findAviatorFile(c.String("file"))
 		if !verifyAviatorFileExists(aviatorFile) {
 			exitWithNoAviatorFile()
 		} else {
-			vars := c.StringSlice("var")
-			varsMap := varsToMap(vars)
+			err := configureTLS(c.String("ca-bundle"), c.String("client-cert"), c.String("client-key"))
+			exitWithError(err)
 
-			aviatorYml, err := ioutil.ReadFile(aviatorFile)
+			if url := c.String("callback-url"); url != "" {
+				if c.Bool("offline") {
+					exitWithError(ansi.Errorf("@R{offline mode:} @m{--callback-url} @R{requires network access}"))
+				}
+				callback := reporter.New(url, c.String("callback-token"))
+				callback.Started()
+				timer.Default().Observe(callback.Step)
+				finished = callback.Finished
+			}
+
+			varsMap, err := loadVars(c)
 			exitWithError(err)
 
-			cockpit := cockpit.New(
-				c.Bool("curly-braces"),
-				c.Bool("dry-run"),
-			)
+			aviatorYml, err := cockpit.ReadAviatorFile(aviatorFile)
+			exitWithError(err)
 
-			aviator, err := cockpit.NewAviator(
-				aviatorYml,
-				varsMap,
-				c.Bool("silent"),
-				c.Bool("verbose"),
-				c.Bool("dry-run"),
-			)
+			var debugDir string
+			if c.Bool("debug-on-failure") {
+				debugDir = debugBundleDir
+			}
+
+			var stopCapture func() []byte
+			if c.String("debug-bundle") != "" {
+				stopCapture, err = bundler.Capture()
+				exitWithError(err)
+				done := finished
+				finished = func(runErr error) {
+					// flush the captured output before aviator exits
+					stopCapture()
+					if !c.Bool("debug-on-failure") {
+						os.RemoveAll(debugDir)
+					}
+					done(runErr)
+				}
+				if debugDir == "" {
+					debugDir, err = ioutil.TempDir("", "aviator-debug")
+					exitWithError(err)
+				}
+			}
+
+			aviator, err := cockpit.New(cockpit.Options{
+				CurlyBraces:      c.Bool("curly-braces"),
+				DryRun:           c.Bool("dry-run"),
+				Silent:           c.Bool("silent") || c.Bool("porcelain") || c.Bool("stdout"),
+				Verbose:          c.Bool("verbose"),
+				LenientEnv:       c.Bool("lenient-env"),
+				Profile:          c.String("profile"),
+				DebugDir:         debugDir,
+				SuppressWarnings: c.StringSlice("suppress-warnings"),
+				Parallel:         c.Int("parallel"),
+				CollectErrors:    c.Bool("collect-errors"),
+				Stdout:           c.Bool("stdout"),
+			}).NewAviator(aviatorYml, varsMap)
 
 			handleError(err)
+			cleanup = aviator.RemoveTempTargets
+
+			if bundle := c.String("debug-bundle"); bundle != "" {
+				done := finished
+				finished = func(runErr error) {
+					err := aviator.WriteDebugBundle(bundle, aviatorFile, aviatorYml, bundleArgs(os.Args[1:]), stopCapture(), debugDir, runErr)
+					if err != nil {
+						ansi.Fprintf(os.Stderr, "@Y{WARNING}: %s\n", err.Error())
+					} else {
+						ansi.Fprintf(os.Stderr, "Debug bundle written to @m{%s}\n", bundle)
+					}
+					done(runErr)
+				}
+			}
+
+			err = useTheme(aviator.AviatorYaml.Theme, c.String("theme"))
+			exitWithError(err)
+
+			if trace := c.String("trace"); trace != "" {
+				useTracer(trace, bundleArgs(os.Args[1:]))
+			}
+
+			if profile := c.String("profile"); profile != "" {
+				err = aviator.UseProfile(profile)
+				exitWithError(err)
+			}
+
+			workspace := c.String("workspace")
+			if workspace == "" {
+				workspace = aviator.AviatorYaml.Workspace
+			}
+			if workspace != "" {
+				err = aviator.UseWorkspace(workspacesDir, workspace)
+				exitWithError(err)
+			}
+
+			if only, skip := names(c.StringSlice("only")), names(c.StringSlice("skip")); len(only) > 0 || len(skip) > 0 {
+				err = aviator.SelectSpruce(only, skip)
+				exitWithError(err)
+			}
+
+			aviator.FilterTags(names(c.StringSlice("tags")), names(c.StringSlice("exclude-tags")))
+
+			if ref := c.String("changed-since"); ref != "" {
+				err = aviator.TrackChangesSince(ref)
+				exitWithError(err)
+			}
+
+			aviator.SetInactivityTimeout(c.Duration("inactivity-timeout"))
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			go func() {
+				// restore the default handling, a second interrupt exits immediately
+				<-ctx.Done()
+				stop()
+			}()
+			aviator.UseContext(ctx)
+
+			if home, err := os.UserHomeDir(); err == nil {
+				aviator.UseRemoteCache(filepath.Join(home, remoteCacheDir))
+			}
+
+			executors := !c.Bool("dry-run") && !c.Bool("render-only") && !c.Bool("offline")
+			err = doctor.RequireBinaries(aviator.AviatorYaml, executors)
+			exitWithError(err)
+
+			if c.Bool("sandbox") || aviator.AviatorYaml.Sandbox.Enabled {
+				err = aviator.UseSandbox()
+				exitWithError(err)
+			}
+
+			if c.Bool("offline") {
+				err = aviator.EnableOfflineMode(c.String("vault-stub"))
+				exitWithError(err)
+			}
+			aviator.UseVault(!c.Bool("no-vault"))
+
+			if executors {
+				sequencer.Use(sequencer.NewGate(filepath.Join(os.TempDir(), kubeOrderDir)))
+				err = aviator.AnnounceKubeOrder()
+				exitWithError(err)
+				aviator.SkipUnchangedApplies(".", c.Bool("force-apply"))
+				cleanup = func() {
+					aviator.ReleaseKubeOrder()
+					aviator.RemoveTempTargets()
+				}
+			}
+
+			if c.Bool("backup") {
+				aviator.KeepBackups()
+			}
+			rendered := false
+			if c.Bool("rollback") {
+				aviator.EnableRollback()
+				done := finished
+				finished = func(runErr error) {
+					// files are kept once executors may have used them
+					if runErr != nil && !rendered {
+						rollback(aviator)
+					}
+					done(runErr)
+				}
+			}
 
 			err = aviator.ProcessSprucePlan()
 			exitWithError(err)
@@ -50,13 +220,55 @@ func main() {
 				exitWithError(err)
 			}
 
-			if !c.Bool("dry-run") {
+			if len(aviator.AviatorYaml.Template) != 0 {
+				err = aviator.ProcessTemplatePlan()
+				exitWithError(err)
+			}
+
+			if len(aviator.AviatorYaml.Concat) != 0 {
+				err = aviator.ProcessConcatPlan()
+				exitWithError(err)
+			}
+
+			if len(aviator.AviatorYaml.Copy) != 0 {
+				err = aviator.ProcessCopyPlan()
+				exitWithError(err)
+			}
+
+			if len(aviator.AviatorYaml.Assert) != 0 && !c.Bool("dry-run") {
+				err = aviator.ProcessAssertPlan()
+				exitWithError(err)
+			}
+
+			if len(aviator.AviatorYaml.Extract) != 0 && !c.Bool("dry-run") {
+				err = aviator.ProcessExtractPlan()
+				exitWithError(err)
+			}
+
+			if provider := c.String("annotate"); provider != "" && !c.Bool("dry-run") {
+				err = aviator.AnnotateRenderedDiff(provider, c.String("diff-base"))
+				exitWithError(err)
+			}
+
+			rendered = true
+			if !c.Bool("dry-run") && !c.Bool("render-only") {
+				if c.Bool("abort-on-drift") {
+					err = aviator.VerifyRenderedFiles()
+					exitWithError(err)
+				}
+
 				fly := aviator.AviatorYaml.Fly
 				if fly.Name != "" && fly.Target != "" && fly.Config != "" {
 					err = aviator.ExecuteFly()
 					exitWithError(err)
 				}
 
+				helm := aviator.AviatorYaml.Helm
+				if helm.Chart != "" {
+					err = aviator.ExecuteHelm()
+					exitWithError(err)
+				}
+
 				kube := aviator.AviatorYaml.Kube.Apply
 				if kube.File != "" {
 					err = aviator.ExecuteKube()
@@ -69,6 +281,39 @@ func main() {
 					exitWithError(err)
 				}
 			}
+
+			if c.Bool("record") && !c.Bool("dry-run") {
+				files := c.StringSlice("vars-file")
+				if stub := c.String("vault-stub"); stub != "" && c.Bool("offline") {
+					files = append(files, stub)
+				}
+				err = aviator.RecordRun(aviatorFile, aviatorYml, recordedArgs(os.Args[1:]), files)
+				exitWithError(err)
+			}
+
+			if timings := c.String("timings"); timings != "" {
+				err = aviator.ReportTimings(timings)
+				exitWithError(err)
+			}
+
+			if c.Bool("porcelain") {
+				for _, file := range aviator.WrittenFiles() {
+					fmt.Println(file)
+				}
+			}
+
+			if c.Bool("watch") {
+				policy, err := sweeper.NewPolicy(c.String("cache-max-size"), c.String("cache-max-age"))
+				exitWithError(err)
+				if home, err := os.UserHomeDir(); err == nil && !policy.Empty() {
+					go sweepCache(ctx, filepath.Join(home, remoteCacheDir), policy, cacheSweepInterval)
+				}
+
+				err = aviator.WatchSprucePlan(ctx, c.Duration("watch-interval"))
+				exitWithError(err)
+			}
+			cleanup()
+			finished(nil)
 		}
 
 		return nil
@@ -76,6 +321,166 @@ func main() {
 	cmd.Run(os.Args)
 }
 
+// useTheme rewrites the colors of the output with the theme of the aviator
+// file; preset overrides the preset of the file.
+func useTheme(theme aviator.Theme, preset string) error {
+	if preset != "" {
+		theme.Preset = preset
+	}
+	t, err := themer.New(theme.Preset, theme.Colors)
+	if err != nil {
+		return err
+	}
+	stop, err := themer.Apply(t)
+	if err != nil {
+		return err
+	}
+	done := finished
+	finished = func(runErr error) {
+		// flush the rewritten output before the next hooks print or read it
+		stop()
+		done(runErr)
+	}
+	return nil
+}
+
+// useTracer records the operations of the run and writes them to file once
+// it finished, including a failed run.
+func useTracer(file string, args []string) {
+	t := tracer.New(".")
+	tracer.Use(t)
+	done := finished
+	finished = func(runErr error) {
+		if err := t.Write(file, args); err != nil {
+			ansi.Fprintf(os.Stderr, "@Y{WARNING}: %s\n", err.Error())
+		}
+		done(runErr)
+	}
+}
+
+func rollback(aviator *cockpit.Aviator) {
+	restored, err := aviator.Rollback()
+	for _, file := range restored {
+		ansi.Fprintf(os.Stderr, "@Y{RESTORED} @m{%s}\n", file)
+	}
+	if err != nil {
+		ansi.Fprintf(os.Stderr, "@R{Rollback FAILED}: %s\n", err.Error())
+	}
+}
+
+// sweepCache trims the cache to the policy right away and then every interval
+// until ctx is done, so long running watches don't fill up the disk.
+func sweepCache(ctx context.Context, dir string, policy sweeper.Policy, interval time.Duration) {
+	for {
+		if _, err := sweeper.Sweep(dir, policy); err != nil {
+			ansi.Fprintf(os.Stderr, "@Y{WARNING}: trimming the cache FAILED: %s\n", err.Error())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// bundleArgs masks the callback token and the values of --var in the
+// arguments of a run.
+func bundleArgs(args []string) []string {
+	result := []string{}
+	for i, arg := range args {
+		switch {
+		case i > 0 && args[i-1] == "--callback-token":
+			arg = "***"
+		case strings.HasPrefix(arg, "--callback-token="):
+			arg = "--callback-token=***"
+		case i > 0 && args[i-1] == "--var":
+			arg = maskVar(arg)
+		case strings.HasPrefix(arg, "--var="):
+			arg = "--var=" + maskVar(strings.TrimPrefix(arg, "--var="))
+		}
+		result = append(result, arg)
+	}
+	return result
+}
+
+// maskVar keeps the name of a key=value variable.
+func maskVar(variable string) string {
+	if i := strings.Index(variable, "="); i >= 0 {
+		return variable[:i+1] + "***"
+	}
+	return "***"
+}
+
+// recordedArgs are the arguments of a run kept in the history, masked like
+// the ones of a debug bundle.
+func recordedArgs(args []string) []string {
+	result := []string{}
+	for _, arg := range bundleArgs(args) {
+		if arg != "--record" {
+			result = append(result, arg)
+		}
+	}
+	return result
+}
+
+// unmaskVars fills the values of the --var arguments masked in recorded args
+// from vars. It returns the names of masked vars vars has no value for.
+func unmaskVars(args []string, vars map[string]string) ([]string, []string) {
+	result := []string{}
+	missing := []string{}
+	unmask := func(variable string) string {
+		name := strings.TrimSuffix(variable, "=***")
+		if name == variable {
+			return variable
+		}
+		value, ok := vars[name]
+		if !ok {
+			missing = append(missing, name)
+		}
+		return name + "=" + value
+	}
+	for i, arg := range args {
+		switch {
+		case i > 0 && args[i-1] == "--var":
+			arg = unmask(arg)
+		case strings.HasPrefix(arg, "--var="):
+			arg = "--var=" + unmask(strings.TrimPrefix(arg, "--var="))
+		}
+		result = append(result, arg)
+	}
+	return result, missing
+}
+
+func loadVars(c *cli.Context) (map[string]string, error) {
+	result := map[string]string{}
+	for _, file := range c.StringSlice("vars-file") {
+		vars, err := evaluator.ReadVarsFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range vars {
+			result[k] = v
+		}
+	}
+	for k, v := range varsToMap(c.StringSlice("var")) {
+		result[k] = v
+	}
+	return result, nil
+}
+
+// names splits comma separated flag values, e.g. --only a,b --only c.
+func names(values []string) []string {
+	result := []string{}
+	for _, v := range values {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				result = append(result, name)
+			}
+		}
+	}
+	return result
+}
+
 func varsToMap(vars []string) map[string]string {
 	result := map[string]string{}
 	for _, v := range vars {
@@ -85,6 +490,16 @@ func varsToMap(vars []string) map[string]string {
 	return result
 }
 
+// configureTLS sets the CA bundle and client certificate of all network
+// operations of aviator. Executors get the CA bundle as SSL_CERT_FILE.
+func configureTLS(caBundle, clientCert, clientKey string) error {
+	err := transport.Configure(transport.Settings{CABundle: caBundle, ClientCert: clientCert, ClientKey: clientKey})
+	if err != nil || caBundle == "" {
+		return err
+	}
+	return os.Setenv("SSL_CERT_FILE", caBundle)
+}
+
 func verifyAviatorFileExists(file string) bool {
 	if file == "aviator.yml" {
 		if _, err := os.Stat(file); !os.IsNotExist(err) {
@@ -98,15 +513,35 @@ func verifyAviatorFileExists(file string) bool {
 	return false
 }
 
+func findAviatorFile(file string) string {
+	if file != "aviator.yml" || verifyAviatorFileExists(file) {
+		return file
+	}
+	for _, alternative := range []string{"aviator.json", "aviator.cue"} {
+		if verifyAviatorFileExists(alternative) {
+			return alternative
+		}
+	}
+	return file
+}
+
 func exitWithNoAviatorFile() {
 	ansi.Printf("@R{No Aviator file found.}\n\n")
-	fmt.Println("Please navigate to a directory that contains an aviator.yml or specify a AVIATOR YAML with [--file|-f] option and run aviator again")
+	fmt.Println("Please navigate to a directory that contains an aviator.yml (or aviator.json, aviator.cue) or specify a AVIATOR YAML with [--file|-f] option and run aviator again")
 	os.Exit(1)
 }
 
+// cleanup runs before aviator exits, also when it exits with an error
+var cleanup = func() {}
+
+// finished runs at the end of a run with the error the run fails with
+var finished = func(error) {}
+
 func exitWithError(err error) {
 	if err != nil {
 		ansi.Printf("@R{%s}\n", err.Error())
+		cleanup()
+		finished(err)
 		os.Exit(1)
 	}
 }
@@ -135,6 +570,7 @@ func handleError(err error) {
 		default:
 			ansi.Printf(err.Error())
 		}
+		finished(err)
 		os.Exit(1)
 	}
 }
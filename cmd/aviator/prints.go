@@ -1,6 +1,13 @@
 package main
 
 import (
+	"sort"
+	"time"
+
+	"github.com/JulzDiverse/aviator/doctor"
+	"github.com/JulzDiverse/aviator/fleet"
+	"github.com/JulzDiverse/aviator/tracer"
+	"github.com/JulzDiverse/aviator/vendorer"
 	"github.com/starkandwayne/goutils/ansi"
 )
 
@@ -48,3 +55,59 @@ func printForEachRegexpCombinationError(err error) {
 	ansi.Printf("%s\n\n", err.Error())
 	ansi.Printf("Example:\n@G{%s}", forEachRegexpCombination)
 }
+
+func printResult(result doctor.Result) {
+	if result.OK {
+		ansi.Printf("@G{OK}   %s: %s\n", result.Check, result.Detail)
+		return
+	}
+	ansi.Printf("@R{FAIL} %s: %s\n", result.Check, result.Detail)
+	if result.Fix != "" {
+		ansi.Printf("     @Y{fix:} %s\n", result.Fix)
+	}
+}
+
+func printFleetSummary(results []fleet.Result) bool {
+	ok := true
+	ansi.Printf("\n@B{FLEET SUMMARY:}\n")
+	for _, result := range results {
+		duration := result.Duration.Round(time.Millisecond)
+		if result.Err != nil {
+			ok = false
+			ansi.Printf("@R{FAIL} %s (%s): %s\n", result.Pilot, duration, result.Err.Error())
+			continue
+		}
+		ansi.Printf("@G{OK}   %s (%s)\n", result.Pilot, duration)
+	}
+	return ok
+}
+
+func printVendored(locks map[string]vendorer.Lock) {
+	names := []string{}
+	for name := range locks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		lock := locks[name]
+		source, pin := lock.Git, lock.Commit
+		if source == "" {
+			source, pin = lock.URL, "sha256:"+lock.Sha256
+		}
+		if lock.Version != "" {
+			source += "@" + lock.Version
+		}
+		ansi.Printf("@G{VENDORED} @m{%s} from %s (%s)\n", name, source, pin)
+	}
+}
+
+func printOperations(trace tracer.Trace) {
+	for _, op := range trace.Operations {
+		status := "@G{ok}"
+		if op.Error != "" {
+			status = "@R{FAILED}"
+		}
+		ansi.Printf("%4d  @m{%s} (%s, %s) "+status+"\n", op.ID, op.Step, op.Kind, op.Duration.Round(time.Millisecond))
+	}
+}
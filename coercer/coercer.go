@@ -0,0 +1,257 @@
+package coercer
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/starkandwayne/goutils/ansi"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Schema checks values files against the types of a JSON schema, as found
+// in the values.schema.json of a helm chart. Only the type related keywords
+// (type, properties, additionalProperties, items and local $refs) are
+// considered; everything else is left to helm.
+type Schema struct {
+	root map[string]interface{}
+}
+
+func Parse(data []byte) (*Schema, error) {
+	var root map[string]interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, ansi.Errorf("@R{Parsing values schema FAILED}: %s", err.Error())
+	}
+	return &Schema{root: root}, nil
+}
+
+// Failure is a value whose type does not match the schema. Path is the
+// dotted path of the value, e.g. spec.ports[0].port, empty for the root.
+type Failure struct {
+	Path    string
+	Message string
+}
+
+// Validate returns an error listing every value whose type does not match
+// the schema.
+func (s *Schema) Validate(values []byte) error {
+	_, err := s.check(values, false)
+	return err
+}
+
+// Failures returns every value whose type does not match the schema. An
+// error is only returned if values cannot be parsed.
+func (s *Schema) Failures(values []byte) ([]Failure, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(values, &doc); err != nil {
+		return nil, ansi.Errorf("@R{Parsing values file FAILED}: %s", err.Error())
+	}
+	if doc == nil {
+		return nil, nil
+	}
+
+	c := &checker{schema: s}
+	c.walk(doc, s.root, "")
+	return c.failures, nil
+}
+
+// Coerce converts scalar values to the type the schema expects where this
+// is lossless (e.g. "3" to 3 for an integer, 3 to "3" for a string) and
+// returns the resulting values file. Values which cannot be converted are
+// reported as in Validate.
+func (s *Schema) Coerce(values []byte) ([]byte, error) {
+	doc, err := s.check(values, true)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(doc)
+}
+
+func (s *Schema) check(values []byte, coerce bool) (interface{}, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(values, &doc); err != nil {
+		return nil, ansi.Errorf("@R{Parsing values file FAILED}: %s", err.Error())
+	}
+	if doc == nil {
+		return doc, nil
+	}
+
+	c := &checker{schema: s, coerce: coerce}
+	doc = c.walk(doc, s.root, "")
+	if len(c.failures) > 0 {
+		lines := []string{}
+		for _, f := range c.failures {
+			lines = append(lines, ansi.Sprintf("@m{%s}@R{: %s}", displayPath(f.Path), f.Message))
+		}
+		return nil, ansi.Errorf("@R{Values do not match the schema}:\n  %s", strings.Join(lines, "\n  "))
+	}
+	return doc, nil
+}
+
+type checker struct {
+	schema   *Schema
+	coerce   bool
+	failures []Failure
+}
+
+func (c *checker) walk(value interface{}, node map[string]interface{}, path string) interface{} {
+	node = c.resolve(node)
+	if node == nil {
+		return value
+	}
+
+	types := schemaTypes(node["type"])
+	if len(types) > 0 && !matchesAny(value, types) {
+		converted, ok := c.convert(value, types)
+		if !ok {
+			c.failures = append(c.failures, Failure{
+				Path:    strings.TrimPrefix(path, "."),
+				Message: fmt.Sprintf("expected %s, got %s", strings.Join(types, " or "), typeOf(value)),
+			})
+			return value
+		}
+		value = converted
+	}
+
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		props, _ := node["properties"].(map[string]interface{})
+		additional, _ := node["additionalProperties"].(map[string]interface{})
+		keys := []string{}
+		for k := range v {
+			keys = append(keys, fmt.Sprint(k))
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child, ok := props[k].(map[string]interface{})
+			if !ok {
+				child = additional
+			}
+			v[k] = c.walk(v[k], child, path+"."+k)
+		}
+	case []interface{}:
+		items, _ := node["items"].(map[string]interface{})
+		for i := range v {
+			v[i] = c.walk(v[i], items, fmt.Sprintf("%s[%d]", path, i))
+		}
+	}
+	return value
+}
+
+// resolve follows local references of the form #/definitions/<name> or
+// #/$defs/<name>.
+func (c *checker) resolve(node map[string]interface{}) map[string]interface{} {
+	for i := 0; node != nil && i < 32; i++ {
+		ref, ok := node["$ref"].(string)
+		if !ok {
+			return node
+		}
+		var target interface{} = c.schema.root
+		for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			m, _ := target.(map[string]interface{})
+			target = m[part]
+		}
+		node, _ = target.(map[string]interface{})
+	}
+	return node
+}
+
+func (c *checker) convert(value interface{}, types []string) (interface{}, bool) {
+	if !c.coerce {
+		return nil, false
+	}
+	for _, t := range types {
+		if converted, ok := convertTo(value, t); ok {
+			return converted, true
+		}
+	}
+	return nil, false
+}
+
+func convertTo(value interface{}, t string) (interface{}, bool) {
+	switch t {
+	case "string":
+		switch value.(type) {
+		case int, int64, uint64, float64, bool:
+			return fmt.Sprint(value), true
+		}
+	case "integer":
+		switch v := value.(type) {
+		case string:
+			i, err := strconv.Atoi(strings.TrimSpace(v))
+			return i, err == nil
+		case float64:
+			return int(v), v == math.Trunc(v)
+		}
+	case "number":
+		if v, ok := value.(string); ok {
+			f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			return f, err == nil
+		}
+	case "boolean":
+		if v, ok := value.(string); ok {
+			b, err := strconv.ParseBool(strings.TrimSpace(v))
+			return b, err == nil
+		}
+	}
+	return nil, false
+}
+
+func schemaTypes(t interface{}) []string {
+	switch v := t.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		types := []string{}
+		for _, e := range v {
+			if s, ok := e.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+func matchesAny(value interface{}, types []string) bool {
+	actual := typeOf(value)
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+		if t == "integer" && actual == "number" && value.(float64) == math.Trunc(value.(float64)) {
+			return true
+		}
+	}
+	return false
+}
+
+func typeOf(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case int, int64, uint64:
+		return "integer"
+	case float64:
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[interface{}]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func displayPath(path string) string {
+	if path == "" {
+		return "(root)"
+	}
+	return path
+}
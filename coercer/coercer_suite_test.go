@@ -0,0 +1,13 @@
+package coercer_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestCoercer(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Coercer Suite")
+}
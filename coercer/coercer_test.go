@@ -0,0 +1,117 @@
+package coercer_test
+
+import (
+	. "github.com/JulzDiverse/aviator/coercer"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Coercer", func() {
+
+	var schema *Schema
+
+	BeforeEach(func() {
+		var err error
+		schema, err = Parse([]byte(`{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "type": "object",
+  "properties": {
+    "replicas": {"type": "integer"},
+    "ratio": {"type": "number"},
+    "debug": {"type": "boolean"},
+    "image": {
+      "type": "object",
+      "properties": {
+        "tag": {"type": "string"}
+      }
+    },
+    "ports": {
+      "type": "array",
+      "items": {"$ref": "#/definitions/port"}
+    },
+    "labels": {
+      "type": "object",
+      "additionalProperties": {"type": "string"}
+    }
+  },
+  "definitions": {
+    "port": {
+      "type": "object",
+      "properties": {"containerPort": {"type": "integer"}}
+    }
+  }
+}`))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("accepts values matching the schema", func() {
+		err := schema.Validate([]byte(`
+replicas: 3
+ratio: 1
+debug: false
+image:
+  tag: "1.4"
+ports:
+- containerPort: 8080
+unknown: value
+`))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("reports every value of the wrong type with its path", func() {
+		err := schema.Validate([]byte(`
+replicas: "3"
+image:
+  tag: 1.4
+ports:
+- containerPort: http
+labels:
+  tier: 1
+`))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("replicas"))
+		Expect(err.Error()).To(ContainSubstring("image.tag"))
+		Expect(err.Error()).To(ContainSubstring("ports[0].containerPort"))
+		Expect(err.Error()).To(ContainSubstring("labels.tier"))
+	})
+
+	It("coerces scalars to the expected type", func() {
+		out, err := schema.Coerce([]byte(`
+replicas: "3"
+ratio: "0.5"
+debug: "true"
+image:
+  tag: 1.4
+ports:
+- containerPort: "8080"
+labels:
+  tier: 1
+`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(MatchYAML(`
+replicas: 3
+ratio: 0.5
+debug: true
+image:
+  tag: "1.4"
+ports:
+- containerPort: 8080
+labels:
+  tier: "1"
+`))
+	})
+
+	It("fails for values which cannot be coerced", func() {
+		_, err := schema.Coerce([]byte(`replicas: three`))
+		Expect(err).To(MatchError(ContainSubstring("replicas")))
+
+		_, err = schema.Coerce([]byte(`replicas: 1.5`))
+		Expect(err).To(MatchError(ContainSubstring("expected integer")))
+	})
+
+	It("fails for an invalid schema", func() {
+		_, err := Parse([]byte(`{"type": `))
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,117 @@
+package conformer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/JulzDiverse/aviator"
+	"github.com/JulzDiverse/aviator/coercer"
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+// Violation is a part of a YAML file which does not conform to a schema.
+// Line is 1-based and points to the offending value, or to the closest
+// enclosing value which could be located.
+type Violation struct {
+	Line     int
+	Resource string
+	Path     string
+	Message  string
+}
+
+func IsEmpty(cfg aviator.Validate) bool {
+	return !cfg.Kubernetes && cfg.Schema == ""
+}
+
+// Validate checks every document of file against the Kubernetes schemas
+// with kubeconform and/or against the JSON schema file of cfg.
+func Validate(file []byte, cfg aviator.Validate) ([]Violation, error) {
+	docs := documents(file)
+	violations := []Violation{}
+
+	if cfg.Schema != "" {
+		found, err := validateSchema(docs, cfg.Schema)
+		if err != nil {
+			return nil, err
+		}
+		violations = append(violations, found...)
+	}
+
+	if cfg.Kubernetes {
+		found, err := validateKubernetes(docs, cfg)
+		if err != nil {
+			return nil, err
+		}
+		violations = append(violations, found...)
+	}
+	return violations, nil
+}
+
+// Error returns an error listing the violations found in the file to, or
+// nil if there are none.
+func Error(to string, violations []Violation) error {
+	if len(violations) == 0 {
+		return nil
+	}
+
+	lines := []string{}
+	for _, v := range violations {
+		subject := v.Path
+		if v.Resource != "" {
+			subject = strings.TrimSpace(v.Resource + " " + v.Path)
+		}
+		if subject != "" {
+			subject += ": "
+		}
+		lines = append(lines, ansi.Sprintf("@m{%s:%d}: %s%s", to, v.Line, subject, v.Message))
+	}
+	return ansi.Errorf("@R{Validation of} @m{%s} @R{FAILED}:\n  %s", to, strings.Join(lines, "\n  "))
+}
+
+func validateSchema(docs []document, schemaFile string) ([]Violation, error) {
+	content, err := ioutil.ReadFile(schemaFile)
+	if err != nil {
+		return nil, errors.Wrap(err, ansi.Sprintf("@R{Reading schema} @m{%s} @R{FAILED}", schemaFile))
+	}
+	schema, err := coercer.Parse(content)
+	if err != nil {
+		return nil, errors.Wrap(err, ansi.Sprintf("@R{Reading schema} @m{%s} @R{FAILED}", schemaFile))
+	}
+
+	violations := []Violation{}
+	for _, doc := range docs {
+		failures, err := schema.Failures(doc.content)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range failures {
+			violations = append(violations, Violation{
+				Line:    doc.start + Line(doc.content, dottedPath(f.Path)),
+				Path:    f.Path,
+				Message: f.Message,
+			})
+		}
+	}
+	return violations, nil
+}
+
+// dottedPath splits a path like spec.ports[0].port into its segments.
+func dottedPath(path string) []string {
+	segments := []string{}
+	for _, part := range strings.Split(strings.Replace(path, "[", ".[", -1), ".") {
+		part = strings.TrimSuffix(strings.TrimPrefix(part, "["), "]")
+		if part != "" {
+			segments = append(segments, part)
+		}
+	}
+	return segments
+}
+
+func resourceName(kind, name string) string {
+	if kind == "" {
+		return name
+	}
+	return fmt.Sprintf("%s/%s", kind, name)
+}
@@ -0,0 +1,13 @@
+package conformer_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestConformer(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Conformer Suite")
+}
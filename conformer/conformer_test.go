@@ -0,0 +1,174 @@
+package conformer_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/JulzDiverse/aviator"
+	. "github.com/JulzDiverse/aviator/conformer"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Conformer", func() {
+
+	const manifests = `---
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+spec:
+  ports:
+  - name: http
+    port: "80"
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  replicas: two
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:1.0
+      - name: sidecar
+        image: 3
+`
+
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "conformer")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	Context("Line", func() {
+
+		doc := []byte(`metadata:
+  name: app
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:1.0
+      - name: sidecar
+        # the sidecar image
+        image: 3
+`)
+
+		It("locates keys and sequence items", func() {
+			Expect(Line(doc, []string{"spec", "template", "spec", "containers"})).To(Equal(6))
+			Expect(Line(doc, []string{"spec", "template", "spec", "containers", "1"})).To(Equal(9))
+			Expect(Line(doc, []string{"spec", "template", "spec", "containers", "1", "image"})).To(Equal(11))
+			Expect(Line(doc, []string{"spec", "template", "spec", "containers", "0", "name"})).To(Equal(7))
+		})
+
+		It("falls back to the deepest ancestor found", func() {
+			Expect(Line(doc, []string{"metadata", "labels", "app"})).To(Equal(1))
+			Expect(Line(doc, []string{"spec", "template", "spec", "containers", "5"})).To(Equal(6))
+			Expect(Line(doc, nil)).To(Equal(1))
+		})
+
+		It("reports whether the value itself was located", func() {
+			line, found := Locate(doc, []string{"spec", "template", "spec", "containers", "1", "image"})
+			Expect(line).To(Equal(11))
+			Expect(found).To(BeTrue())
+
+			line, found = Locate(doc, []string{"metadata", "labels", "app"})
+			Expect(line).To(Equal(1))
+			Expect(found).To(BeFalse())
+		})
+	})
+
+	Context("with a JSON schema", func() {
+
+		It("reports values not matching the schema with their line", func() {
+			schema := filepath.Join(dir, "schema.json")
+			Expect(ioutil.WriteFile(schema, []byte(`{
+  "type": "object",
+  "properties": {
+    "spec": {
+      "type": "object",
+      "properties": {
+        "replicas": {"type": "integer"},
+        "ports": {"type": "array", "items": {"properties": {"port": {"type": "integer"}}}}
+      }
+    }
+  }
+}`), 0644)).To(Succeed())
+
+			violations, err := Validate([]byte(manifests), aviator.Validate{Schema: schema})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(violations).To(Equal([]Violation{
+				{Line: 9, Path: "spec.ports[0].port", Message: "expected integer, got string"},
+				{Line: 16, Path: "spec.replicas", Message: "expected integer, got string"},
+			}))
+		})
+
+		It("fails on a missing schema", func() {
+			_, err := Validate([]byte(manifests), aviator.Validate{Schema: filepath.Join(dir, "missing.json")})
+			Expect(err).To(MatchError(ContainSubstring("missing.json")))
+		})
+	})
+
+	Context("with kubernetes", func() {
+
+		var path string
+
+		BeforeEach(func() {
+			path = os.Getenv("PATH")
+			os.Setenv("PATH", dir+string(os.PathListSeparator)+path)
+			Expect(ioutil.WriteFile(filepath.Join(dir, "kubeconform"), []byte(`#!/bin/sh
+echo "$@" > "$(dirname "$0")/args"
+for last; do :; done
+cat <<JSON
+{"resources": [
+  {"filename": "$last/0000.yml", "kind": "Service", "name": "app", "status": "statusValid"},
+  {"filename": "$last/0001.yml", "kind": "Deployment", "name": "app", "status": "statusInvalid",
+   "msg": "invalid", "validationErrors": [
+     {"path": "/spec/replicas", "msg": "expected integer, but got string"},
+     {"path": "/spec/template/spec/containers/1/image", "msg": "expected string, but got number"}
+  ]}
+]}
+JSON
+exit 1
+`), 0755)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			os.Setenv("PATH", path)
+		})
+
+		It("reports the errors of kubeconform with the line in the file", func() {
+			violations, err := Validate([]byte(manifests), aviator.Validate{Kubernetes: true, Strict: true, KubernetesVersion: "1.29.0"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(violations).To(Equal([]Violation{
+				{Line: 16, Resource: "Deployment/app", Path: "spec.replicas", Message: "expected integer, but got string"},
+				{Line: 23, Resource: "Deployment/app", Path: "spec.template.spec.containers.1.image", Message: "expected string, but got number"},
+			}))
+
+			args, err := ioutil.ReadFile(filepath.Join(dir, "args"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(args)).To(HavePrefix("-output json -strict -kubernetes-version 1.29.0 "))
+		})
+
+		It("formats the violations as error", func() {
+			violations, err := Validate([]byte(manifests), aviator.Validate{Kubernetes: true})
+			Expect(err).ToNot(HaveOccurred())
+
+			err = Error("manifests.yml", violations)
+			Expect(err).To(MatchError(ContainSubstring("manifests.yml:16: Deployment/app spec.replicas: expected integer, but got string")))
+			Expect(Error("manifests.yml", nil)).To(Succeed())
+		})
+	})
+})
@@ -0,0 +1,121 @@
+package conformer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/JulzDiverse/aviator"
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+type kubeconformOutput struct {
+	Resources []struct {
+		Filename         string `json:"filename"`
+		Kind             string `json:"kind"`
+		Name             string `json:"name"`
+		Status           string `json:"status"`
+		Msg              string `json:"msg"`
+		ValidationErrors []struct {
+			Path string `json:"path"`
+			Msg  string `json:"msg"`
+		} `json:"validationErrors"`
+	} `json:"resources"`
+}
+
+// validateKubernetes passes each document as a file of its own to
+// kubeconform, so the reported file name identifies the document.
+func validateKubernetes(docs []document, cfg aviator.Validate) ([]Violation, error) {
+	if len(docs) == 0 {
+		return nil, nil
+	}
+
+	dir, err := ioutil.TempDir("", "aviator-validate")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	for i, doc := range docs {
+		err = ioutil.WriteFile(filepath.Join(dir, fmt.Sprintf("%04d.yml", i)), doc.content, 0644)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("kubeconform", kubeconformArgs(cfg, dir)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	runErr := cmd.Run()
+	if _, ok := runErr.(*exec.ExitError); runErr != nil && !ok {
+		return nil, errors.Wrap(runErr, ansi.Sprintf("@R{Running} @m{kubeconform} @R{FAILED}"))
+	}
+
+	var output kubeconformOutput
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		return nil, ansi.Errorf("@R{Running} @m{kubeconform} @R{FAILED}: %s", strings.TrimSpace(stderr.String()+" "+err.Error()))
+	}
+
+	violations := []Violation{}
+	for _, r := range output.Resources {
+		if r.Status != "statusInvalid" && r.Status != "statusError" {
+			continue
+		}
+		i, err := strconv.Atoi(strings.TrimSuffix(filepath.Base(r.Filename), ".yml"))
+		if err != nil || i >= len(docs) {
+			return nil, ansi.Errorf("@R{Unexpected file} @m{%s} @R{in kubeconform output}", r.Filename)
+		}
+		doc := docs[i]
+		resource := resourceName(r.Kind, r.Name)
+
+		if len(r.ValidationErrors) == 0 {
+			violations = append(violations, Violation{Line: doc.start + 1, Resource: resource, Message: r.Msg})
+		}
+		for _, e := range r.ValidationErrors {
+			path := pointerPath(e.Path)
+			violations = append(violations, Violation{
+				Line:     doc.start + Line(doc.content, path),
+				Resource: resource,
+				Path:     strings.Join(path, "."),
+				Message:  e.Msg,
+			})
+		}
+	}
+	return violations, nil
+}
+
+func kubeconformArgs(cfg aviator.Validate, dir string) []string {
+	args := []string{"-output", "json"}
+	if cfg.Strict {
+		args = append(args, "-strict")
+	}
+	if cfg.IgnoreMissingSchemas {
+		args = append(args, "-ignore-missing-schemas")
+	}
+	if cfg.KubernetesVersion != "" {
+		args = append(args, "-kubernetes-version", cfg.KubernetesVersion)
+	}
+	for _, location := range cfg.SchemaLocations {
+		args = append(args, "-schema-location", location)
+	}
+	return append(args, dir)
+}
+
+// pointerPath splits a JSON pointer like /spec/ports/0 into its segments.
+func pointerPath(pointer string) []string {
+	segments := []string{}
+	for _, part := range strings.Split(pointer, "/") {
+		if part != "" {
+			segments = append(segments, strings.Replace(strings.Replace(part, "~1", "/", -1), "~0", "~", -1))
+		}
+	}
+	return segments
+}
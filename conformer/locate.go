@@ -0,0 +1,177 @@
+package conformer
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var separator = regexp.MustCompile(`^---(\s|$)`)
+
+type document struct {
+	start   int
+	content []byte
+}
+
+// documents splits a multi-document YAML file. start is the number of
+// lines before the first line of the content; empty documents are dropped.
+func documents(file []byte) []document {
+	result := []document{}
+	start, content := 0, []string{}
+	flush := func() {
+		if strings.TrimSpace(strings.Join(content, "\n")) != "" {
+			result = append(result, document{start: start, content: []byte(strings.Join(content, "\n") + "\n")})
+		}
+	}
+
+	for i, l := range strings.Split(strings.TrimSuffix(string(file), "\n"), "\n") {
+		if separator.MatchString(l) {
+			flush()
+			start, content = i+1, []string{}
+			continue
+		}
+		content = append(content, l)
+	}
+	flush()
+	return result
+}
+
+type line struct {
+	raw  int
+	eff  int
+	dash bool
+	text string
+}
+
+func parseLines(doc []byte) []line {
+	lines := []line{}
+	for _, l := range strings.Split(string(doc), "\n") {
+		text := strings.TrimLeft(l, " ")
+		parsed := line{raw: len(l) - len(text)}
+		parsed.eff = parsed.raw
+		if text == "-" || strings.HasPrefix(text, "- ") {
+			rest := strings.TrimLeft(strings.TrimPrefix(text, "-"), " ")
+			parsed.dash = true
+			parsed.eff = parsed.raw + len(text) - len(rest)
+			text = rest
+		}
+		if strings.HasPrefix(text, "#") {
+			text = ""
+		}
+		parsed.text = text
+		lines = append(lines, parsed)
+	}
+	return lines
+}
+
+// Line returns the 1-based line of the value at path in the block style
+// YAML document doc. Path segments are map keys and sequence indices. If
+// the value cannot be found, the line of its deepest ancestor found is
+// returned.
+func Line(doc []byte, path []string) int {
+	line, _ := Locate(doc, path)
+	return line
+}
+
+// Locate is like Line, but also reports whether the value itself was found
+// rather than one of its ancestors.
+func Locate(doc []byte, path []string) (int, bool) {
+	lines := parseLines(doc)
+	found := 0
+	cur, indent, item := -1, -1, false
+	for _, segment := range path {
+		next, nextItem := -1, false
+		if n, err := strconv.Atoi(segment); err == nil {
+			next = findItem(lines, cur, indent, item, n)
+			nextItem = next >= 0
+		}
+		if next < 0 {
+			next = findKey(lines, cur, indent, item, segment)
+		}
+		if next < 0 {
+			break
+		}
+
+		found++
+		cur, item = next, nextItem
+		if item {
+			indent = lines[cur].raw
+		} else {
+			indent = lines[cur].eff
+		}
+	}
+	if cur < 0 {
+		return 1, len(path) == 0
+	}
+	return cur + 1, found == len(path)
+}
+
+// block returns the indices of the non-empty lines nested below the line
+// cur, which is a sequence item if item is set.
+func block(lines []line, cur, indent int, item bool) []int {
+	result := []int{}
+	for i := cur + 1; i < len(lines); i++ {
+		l := lines[i]
+		if l.text == "" && !l.dash {
+			continue
+		}
+		if l.raw > indent || (!item && l.dash && l.raw == indent) {
+			result = append(result, i)
+			continue
+		}
+		break
+	}
+	return result
+}
+
+func findItem(lines []line, cur, indent int, item bool, n int) int {
+	candidates := block(lines, cur, indent, item)
+	if len(candidates) == 0 || !lines[candidates[0]].dash {
+		return -1
+	}
+
+	itemIndent := lines[candidates[0]].raw
+	for _, i := range candidates {
+		l := lines[i]
+		if !l.dash || l.raw != itemIndent {
+			continue
+		}
+		if n == 0 {
+			return i
+		}
+		n--
+	}
+	return -1
+}
+
+func findKey(lines []line, cur, indent int, item bool, key string) int {
+	candidates := block(lines, cur, indent, item)
+	if item {
+		candidates = append([]int{cur}, candidates...)
+	}
+
+	childIndent := -1
+	for _, i := range candidates {
+		l := lines[i]
+		if l.text == "" {
+			continue
+		}
+		if childIndent < 0 {
+			childIndent = l.eff
+		}
+		if l.eff == childIndent && isKey(l.text, key) {
+			return i
+		}
+	}
+	return -1
+}
+
+func isKey(text, key string) bool {
+	for _, k := range []string{key, `"` + key + `"`, `'` + key + `'`} {
+		if strings.HasPrefix(text, k+":") {
+			rest := text[len(k)+1:]
+			return rest == "" || strings.HasPrefix(rest, " ")
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package copier
+
+import (
+	"bytes"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+type Target struct {
+	From string
+	To   string
+}
+
+type File struct {
+	Path string
+	Dir  string
+	Base string
+	Name string
+	Ext  string
+}
+
+func Targets(from, to string) ([]Target, error) {
+	if from == "" || to == "" {
+		return nil, ansi.Errorf("@R{A copy step requires} @m{from} @R{and} @m{to}")
+	}
+
+	sources, err := sources(from)
+	if err != nil {
+		return nil, err
+	}
+
+	dest, err := template.New(to).Parse(to)
+	if err != nil {
+		return nil, errors.Wrap(err, ansi.Sprintf("@R{Parsing copy destination} @m{%s} @R{FAILED}", to))
+	}
+	templated := strings.Contains(to, "{{")
+	if len(sources) > 1 && !templated && !strings.HasSuffix(to, "/") {
+		return nil, ansi.Errorf("@R{Copying} @m{%s} @R{matches %d files, but} @m{%s} @R{is neither a directory (ending with /) nor a template}", from, len(sources), to)
+	}
+
+	targets := []Target{}
+	seen := map[string]string{}
+	for _, source := range sources {
+		target := to
+		if templated {
+			var out bytes.Buffer
+			err := dest.Execute(&out, file(source))
+			if err != nil {
+				return nil, errors.Wrap(err, ansi.Sprintf("@R{Rendering copy destination} @m{%s} @R{for} @m{%s} @R{FAILED}", to, source))
+			}
+			target = out.String()
+		}
+		if strings.HasSuffix(target, "/") {
+			target += filepath.Base(source)
+		}
+
+		if other, ok := seen[target]; ok {
+			return nil, ansi.Errorf("@R{Copying} @m{%s} @R{and} @m{%s} @R{would both write to} @m{%s}", other, source, target)
+		}
+		seen[target] = source
+		targets = append(targets, Target{From: source, To: target})
+	}
+	return targets, nil
+}
+
+func sources(from string) ([]string, error) {
+	if !strings.ContainsAny(from, "*?[") {
+		return []string{from}, nil
+	}
+
+	matches, err := filepath.Glob(from)
+	if err != nil {
+		return nil, errors.Wrap(err, ansi.Sprintf("@R{Invalid copy pattern} @m{%s}", from))
+	}
+	if len(matches) == 0 {
+		return nil, ansi.Errorf("@R{No files match the copy pattern} @m{%s}", from)
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+func file(path string) File {
+	base := filepath.Base(path)
+	ext := filepath.Ext(base)
+	return File{
+		Path: path,
+		Dir:  filepath.Dir(path),
+		Base: base,
+		Name: strings.TrimSuffix(base, ext),
+		Ext:  ext,
+	}
+}
@@ -0,0 +1,13 @@
+package copier_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestCopier(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Copier Suite")
+}
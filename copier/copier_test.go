@@ -0,0 +1,72 @@
+package copier_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/JulzDiverse/aviator/copier"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Copier", func() {
+
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "copier")
+		Expect(err).ToNot(HaveOccurred())
+		for _, f := range []string{"a.pem", "b.pem", "c.yml"} {
+			Expect(ioutil.WriteFile(filepath.Join(dir, f), []byte(f), 0644)).To(Succeed())
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("copies a single file to the given destination", func() {
+		targets, err := Targets(filepath.Join(dir, "c.yml"), "out/config.yml")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(targets).To(Equal([]Target{{From: filepath.Join(dir, "c.yml"), To: "out/config.yml"}}))
+	})
+
+	It("copies every match of a glob into a directory", func() {
+		targets, err := Targets(filepath.Join(dir, "*.pem"), "out/certs/")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(targets).To(Equal([]Target{
+			{From: filepath.Join(dir, "a.pem"), To: "out/certs/a.pem"},
+			{From: filepath.Join(dir, "b.pem"), To: "out/certs/b.pem"},
+		}))
+	})
+
+	It("renders the destination as a template for every match", func() {
+		targets, err := Targets(filepath.Join(dir, "*.pem"), "out/{{ .Name }}.crt")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(targets[0].To).To(Equal("out/a.crt"))
+		Expect(targets[1].To).To(Equal("out/b.crt"))
+	})
+
+	It("fails if a glob matches several files but the destination is a single file", func() {
+		_, err := Targets(filepath.Join(dir, "*.pem"), "out/cert.pem")
+		Expect(err).To(MatchError(ContainSubstring("is neither a directory")))
+	})
+
+	It("fails if the rendered destinations collide", func() {
+		_, err := Targets(filepath.Join(dir, "*.pem"), "out/{{ .Ext }}")
+		Expect(err).To(MatchError(ContainSubstring("would both write to")))
+	})
+
+	It("fails if a glob matches nothing", func() {
+		_, err := Targets(filepath.Join(dir, "*.key"), "out/")
+		Expect(err).To(MatchError(ContainSubstring("No files match")))
+	})
+
+	It("fails if from or to is missing", func() {
+		_, err := Targets("", "out/")
+		Expect(err).To(HaveOccurred())
+	})
+})
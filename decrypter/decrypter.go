@@ -0,0 +1,56 @@
+package decrypter
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+
+	"github.com/starkandwayne/goutils/ansi"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var marker = []byte("sops")
+
+// IsEncrypted reports whether file is a YAML or JSON document encrypted
+// with SOPS, which keeps its metadata (including the mac) in a top-level
+// sops key.
+func IsEncrypted(file []byte) bool {
+	if !bytes.Contains(file, marker) {
+		return false
+	}
+
+	var doc map[interface{}]interface{}
+	if err := yaml.Unmarshal(file, &doc); err != nil {
+		return false
+	}
+	metadata, ok := doc["sops"].(map[interface{}]interface{})
+	if !ok {
+		return false
+	}
+	_, ok = metadata["mac"]
+	return ok
+}
+
+// Decrypt decrypts the SOPS encrypted file with the sops binary. The file
+// is passed on stdin and the plaintext read from stdout, so it is never
+// written to disk. name is only used in errors.
+func Decrypt(name string, file []byte) ([]byte, error) {
+	format := "yaml"
+	if bytes.HasPrefix(bytes.TrimSpace(file), []byte("{")) {
+		format = "json"
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("sops", "--decrypt", "--input-type", format, "--output-type", format, "/dev/stdin")
+	cmd.Stdin = bytes.NewReader(file)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		reason := strings.TrimSpace(stderr.String())
+		if reason == "" {
+			reason = err.Error()
+		}
+		return nil, ansi.Errorf("@R{Decrypting} @m{%s} @R{with sops FAILED}: %s", name, reason)
+	}
+	return stdout.Bytes(), nil
+}
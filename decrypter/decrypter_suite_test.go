@@ -0,0 +1,13 @@
+package decrypter_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestDecrypter(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Decrypter Suite")
+}
@@ -0,0 +1,96 @@
+package decrypter_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/JulzDiverse/aviator/decrypter"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Decrypter", func() {
+
+	encrypted := []byte(`password: ENC[AES256_GCM,data:Tr7o=,iv:1=,tag:2=,type:str]
+sops:
+  age:
+  - recipient: age1x
+  mac: ENC[AES256_GCM,data:3=,iv:4=,tag:5=,type:str]
+  version: 3.8.1
+`)
+
+	Context("IsEncrypted", func() {
+		It("detects the sops metadata of YAML and JSON files", func() {
+			Expect(IsEncrypted(encrypted)).To(BeTrue())
+			Expect(IsEncrypted([]byte(`{"password": "ENC[...]", "sops": {"mac": "ENC[...]", "version": "3.8.1"}}`))).To(BeTrue())
+		})
+
+		It("ignores plain files", func() {
+			Expect(IsEncrypted([]byte("password: secret\n"))).To(BeFalse())
+			Expect(IsEncrypted([]byte("sops: true\n"))).To(BeFalse())
+			Expect(IsEncrypted([]byte("sops:\n  version: 3.8.1\n"))).To(BeFalse())
+			Expect(IsEncrypted([]byte("- sops\n"))).To(BeFalse())
+		})
+	})
+
+	Context("Decrypt", func() {
+
+		var dir, path string
+
+		fakeSops := func(script string) {
+			Expect(ioutil.WriteFile(filepath.Join(dir, "sops"), []byte("#!/bin/sh\n"+script), 0755)).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "decrypter")
+			Expect(err).ToNot(HaveOccurred())
+			path = os.Getenv("PATH")
+			os.Setenv("PATH", dir+string(os.PathListSeparator)+path)
+		})
+
+		AfterEach(func() {
+			os.Setenv("PATH", path)
+			os.RemoveAll(dir)
+		})
+
+		It("passes the file on stdin and returns the plaintext", func() {
+			fakeSops(`echo "$@" > "$(dirname "$0")/args"
+cat > "$(dirname "$0")/stdin"
+echo "password: secret"
+`)
+
+			plain, err := Decrypt("secrets.yml", encrypted)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(plain)).To(Equal("password: secret\n"))
+
+			args, _ := ioutil.ReadFile(filepath.Join(dir, "args"))
+			Expect(string(args)).To(Equal("--decrypt --input-type yaml --output-type yaml /dev/stdin\n"))
+			stdin, _ := ioutil.ReadFile(filepath.Join(dir, "stdin"))
+			Expect(stdin).To(Equal(encrypted))
+		})
+
+		It("keeps the format of JSON files", func() {
+			fakeSops(`echo "$@" > "$(dirname "$0")/args"
+echo '{"password": "secret"}'
+`)
+
+			_, err := Decrypt("secrets.json", []byte(`{"sops": {"mac": "x"}}`))
+			Expect(err).ToNot(HaveOccurred())
+			args, _ := ioutil.ReadFile(filepath.Join(dir, "args"))
+			Expect(string(args)).To(ContainSubstring("--input-type json --output-type json"))
+		})
+
+		It("fails with the error of sops", func() {
+			fakeSops(`echo "Failed to get the data key required to decrypt the SOPS file." >&2
+exit 128
+`)
+
+			_, err := Decrypt("secrets.yml", encrypted)
+			Expect(err).To(MatchError(ContainSubstring("secrets.yml")))
+			Expect(err).To(MatchError(ContainSubstring("Failed to get the data key")))
+		})
+	})
+})
@@ -0,0 +1,135 @@
+package differ
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/starkandwayne/goutils/ansi"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+type Change struct {
+	Path string
+	Kind string
+	Old  string
+	New  string
+}
+
+var docSeparator = regexp.MustCompile(`(?m)^---\s*$`)
+
+func Diff(old, new []byte) ([]Change, error) {
+	oldPaths, err := flattenDocs(old)
+	if err != nil {
+		return nil, ansi.Errorf("@R{Parsing previous version for diff failed}: %s", err.Error())
+	}
+	newPaths, err := flattenDocs(new)
+	if err != nil {
+		return nil, ansi.Errorf("@R{Parsing rendered version for diff failed}: %s", err.Error())
+	}
+
+	changes := []Change{}
+	for path, o := range oldPaths {
+		n, ok := newPaths[path]
+		if !ok {
+			changes = append(changes, Change{Path: path, Kind: Removed, Old: o})
+		} else if n != o {
+			changes = append(changes, Change{Path: path, Kind: Changed, Old: o, New: n})
+		}
+	}
+	for path, n := range newPaths {
+		if _, ok := oldPaths[path]; !ok {
+			changes = append(changes, Change{Path: path, Kind: Added, New: n})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+func flattenDocs(file []byte) (map[string]string, error) {
+	paths := map[string]string{}
+	docs := []interface{}{}
+	for _, doc := range docSeparator.Split(string(file), -1) {
+		if strings.TrimSpace(doc) == "" {
+			continue
+		}
+
+		var v interface{}
+		if err := yaml.Unmarshal([]byte(doc), &v); err != nil {
+			return nil, err
+		}
+		if v != nil {
+			docs = append(docs, v)
+		}
+	}
+
+	for i, doc := range docs {
+		prefix := ""
+		if len(docs) > 1 {
+			prefix = documentName(doc, i)
+		}
+		flatten(prefix, doc, paths)
+	}
+	return paths, nil
+}
+
+func documentName(doc interface{}, index int) string {
+	m, ok := doc.(map[interface{}]interface{})
+	if ok {
+		kind, _ := m["kind"].(string)
+		metadata, _ := m["metadata"].(map[interface{}]interface{})
+		name, _ := metadata["name"].(string)
+		if kind != "" && name != "" {
+			return fmt.Sprintf("%s/%s", kind, name)
+		}
+	}
+	return fmt.Sprintf("document %d", index)
+}
+
+func flatten(path string, v interface{}, paths map[string]string) {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		if len(v) == 0 {
+			paths[path] = "{}"
+		}
+		for k, value := range v {
+			flatten(join(path, fmt.Sprintf("%v", k)), value, paths)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			paths[path] = "[]"
+		}
+		for i, value := range v {
+			flatten(join(path, elementName(value, i)), value, paths)
+		}
+	default:
+		paths[path] = fmt.Sprintf("%v", v)
+	}
+}
+
+func elementName(v interface{}, index int) string {
+	if m, ok := v.(map[interface{}]interface{}); ok {
+		if name, ok := m["name"].(string); ok && name != "" {
+			return name
+		}
+	}
+	return fmt.Sprintf("[%d]", index)
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	if strings.HasPrefix(key, "[") {
+		return path + key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,13 @@
+package differ_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestDiffer(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Differ Suite")
+}
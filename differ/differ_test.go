@@ -0,0 +1,130 @@
+package differ_test
+
+import (
+	. "github.com/JulzDiverse/aviator/differ"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Differ", func() {
+
+	Context("Diff", func() {
+		It("reports added, removed and changed paths sorted by path", func() {
+			changes, err := Diff(
+				[]byte("a: 1\nb: old\nc: gone\n"),
+				[]byte("a: 1\nb: new\nd: added\n"),
+			)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changes).To(Equal([]Change{
+				{Path: "b", Kind: Changed, Old: "old", New: "new"},
+				{Path: "c", Kind: Removed, Old: "gone"},
+				{Path: "d", Kind: Added, New: "added"},
+			}))
+		})
+
+		It("ignores formatting and key order", func() {
+			changes, err := Diff(
+				[]byte("a: 1\nb: {c: 2}\n"),
+				[]byte("b:\n  c: 2\na: 1\n"),
+			)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changes).To(BeEmpty())
+		})
+
+		It("identifies list elements by their name", func() {
+			changes, err := Diff(
+				[]byte("containers:\n- name: app\n  image: app:1\n- name: sidecar\n  image: proxy:1\n"),
+				[]byte("containers:\n- name: sidecar\n  image: proxy:1\n- name: app\n  image: app:2\n"),
+			)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changes).To(Equal([]Change{
+				{Path: "containers.app.image", Kind: Changed, Old: "app:1", New: "app:2"},
+			}))
+		})
+
+		It("identifies unnamed list elements by their index", func() {
+			changes, err := Diff([]byte("args: [a, b]\n"), []byte("args: [a, c]\n"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changes).To(Equal([]Change{
+				{Path: "args[1]", Kind: Changed, Old: "b", New: "c"},
+			}))
+		})
+
+		It("identifies documents of a multi-document file by kind and name", func() {
+			changes, err := Diff(
+				[]byte("---\nkind: Service\nmetadata: {name: app}\n---\nkind: Deployment\nmetadata: {name: app}\nspec: {replicas: 1}\n"),
+				[]byte("---\nkind: Deployment\nmetadata: {name: app}\nspec: {replicas: 3}\n---\nkind: Service\nmetadata: {name: app}\n"),
+			)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changes).To(Equal([]Change{
+				{Path: "Deployment/app.spec.replicas", Kind: Changed, Old: "1", New: "3"},
+			}))
+		})
+
+		It("reports every path of a new file as added", func() {
+			changes, err := Diff(nil, []byte("a: 1\n"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changes).To(Equal([]Change{{Path: "a", Kind: Added, New: "1"}}))
+		})
+
+		It("fails on invalid yaml", func() {
+			_, err := Diff([]byte("a: 1\n"), []byte("a: [\n"))
+			Expect(err).To(MatchError(ContainSubstring("Parsing rendered version for diff failed")))
+		})
+	})
+
+	Context("Unified", func() {
+		It("returns nil for equal files", func() {
+			Expect(Unified("a.yml", []byte("a: 1\n"), []byte("a: 1\n"))).To(BeNil())
+		})
+
+		It("diffs the lines with three lines of context", func() {
+			old := []byte("a: 1\nb: 2\nc: 3\nd: 4\ne: 5\nf: 6\ng: 7\nh: 8\n")
+			new := []byte("a: 1\nb: 2\nc: 3\nd: 40\ne: 5\nf: 6\ng: 7\nh: 8\ni: 9\n")
+			Expect(Unified("a.yml", old, new)).To(Equal([]string{
+				"--- a/a.yml",
+				"+++ b/a.yml",
+				"@@ -1,8 +1,9 @@",
+				" a: 1",
+				" b: 2",
+				" c: 3",
+				"-d: 4",
+				"+d: 40",
+				" e: 5",
+				" f: 6",
+				" g: 7",
+				" h: 8",
+				"+i: 9",
+			}))
+		})
+
+		It("splits distant changes into hunks", func() {
+			old := []byte("1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n")
+			new := []byte("0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n")
+			Expect(Unified("n", old, new)).To(Equal([]string{
+				"--- a/n",
+				"+++ b/n",
+				"@@ -1,3 +1,4 @@",
+				"+0",
+				" 1",
+				" 2",
+				" 3",
+				"@@ -7,4 +8,3 @@",
+				" 7",
+				" 8",
+				" 9",
+				"-10",
+			}))
+		})
+
+		It("diffs new files against nothing", func() {
+			Expect(Unified("new.yml", nil, []byte("a: 1\n"))).To(Equal([]string{
+				"--- a/new.yml",
+				"+++ b/new.yml",
+				"@@ -0,0 +1,1 @@",
+				"+a: 1",
+			}))
+		})
+	})
+})
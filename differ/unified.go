@@ -0,0 +1,159 @@
+package differ
+
+import (
+	"fmt"
+	"strings"
+)
+
+const contextLines = 3
+
+type edit struct {
+	op   byte
+	text string
+	a, b int
+}
+
+// Unified returns the line based diff of old and new in the unified format,
+// or nil if they are equal.
+func Unified(name string, old, new []byte) []string {
+	edits := lineEdits(splitLines(old), splitLines(new))
+	changed := false
+	for _, e := range edits {
+		changed = changed || e.op != ' '
+	}
+	if !changed {
+		return nil
+	}
+
+	result := []string{"--- a/" + name, "+++ b/" + name}
+	for _, h := range hunks(edits) {
+		result = append(result, hunkHeader(h))
+		for _, e := range h {
+			result = append(result, string(e.op)+e.text)
+		}
+	}
+	return result
+}
+
+func splitLines(file []byte) []string {
+	if len(file) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(file), "\n"), "\n")
+}
+
+// lineEdits computes the shortest edit script turning a into b with the
+// Myers algorithm.
+func lineEdits(a, b []string) []edit {
+	n, m := len(a), len(b)
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+2)
+	trace := [][]int{}
+
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int{}, v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(a, b, trace, offset)
+			}
+		}
+	}
+	return nil
+}
+
+func backtrack(a, b []string, trace [][]int, offset int) []edit {
+	x, y := len(a), len(b)
+	reversed := []edit{}
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x, y = x-1, y-1
+			reversed = append(reversed, edit{' ', a[x], x, y})
+		}
+		if d > 0 {
+			if x == prevX {
+				reversed = append(reversed, edit{'+', b[prevY], prevX, prevY})
+			} else {
+				reversed = append(reversed, edit{'-', a[prevX], prevX, prevY})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	edits := make([]edit, len(reversed))
+	for i, e := range reversed {
+		edits[len(reversed)-1-i] = e
+	}
+	return edits
+}
+
+func hunks(edits []edit) [][]edit {
+	result := [][]edit{}
+	start, end := -1, -1
+	for i, e := range edits {
+		if e.op == ' ' {
+			continue
+		}
+		from, to := i-contextLines, i+contextLines+1
+		if from < 0 {
+			from = 0
+		}
+		if to > len(edits) {
+			to = len(edits)
+		}
+		if start != -1 && from > end {
+			result = append(result, edits[start:end])
+			start = -1
+		}
+		if start == -1 {
+			start = from
+		}
+		end = to
+	}
+	if start != -1 {
+		result = append(result, edits[start:end])
+	}
+	return result
+}
+
+func hunkHeader(h []edit) string {
+	aLen, bLen := 0, 0
+	for _, e := range h {
+		if e.op != '+' {
+			aLen++
+		}
+		if e.op != '-' {
+			bLen++
+		}
+	}
+	aStart, bStart := h[0].a+1, h[0].b+1
+	if aLen == 0 {
+		aStart--
+	}
+	if bLen == 0 {
+		bStart--
+	}
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", aStart, aLen, bStart, bLen)
+}
@@ -0,0 +1,192 @@
+package discoverer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+// Layout is the directory convention 'aviator auto' discovers the spruce
+// steps of a repository from.
+type Layout struct {
+	Base     string
+	Overlays string
+	Rendered string
+}
+
+// DefaultLayout returns the documented convention: base/, overlays/<env>/
+// and rendered/<env>/, below root.
+func DefaultLayout(root string) Layout {
+	return Layout{
+		Base:     filepath.Join(root, "base"),
+		Overlays: filepath.Join(root, "overlays"),
+		Rendered: filepath.Join(root, "rendered"),
+	}
+}
+
+type step struct {
+	Name  string  `yaml:"name"`
+	Base  string  `yaml:"base"`
+	Merge []merge `yaml:"merge,omitempty"`
+	To    string  `yaml:"to"`
+}
+
+type merge struct {
+	With with `yaml:"with"`
+}
+
+type with struct {
+	Files []string `yaml:"files"`
+}
+
+// Discover synthesizes the aviator file of a repository following layout.
+// Every YAML file of the base directory is rendered for each environment,
+// i.e. each directory below the overlays directory, merged with the file of
+// the same relative path in the environment if there is one. Files only an
+// environment has are rendered on their own. Without an overlays directory
+// the base files are rendered directly into the rendered directory. envs
+// restricts the environments if not empty.
+func Discover(layout Layout, envs []string) ([]byte, error) {
+	base, err := yamlFiles(layout.Base)
+	if err != nil {
+		return nil, errors.Wrap(err, ansi.Sprintf("@R{Discovering the base files in} @m{%s} @R{FAILED}", layout.Base))
+	}
+	if len(base) == 0 {
+		return nil, ansi.Errorf("@R{No YAML files found in} @m{%s}@R{; aviator auto expects base/, overlays/<env>/ and renders to rendered/<env>/}", layout.Base)
+	}
+
+	available, err := environments(layout.Overlays)
+	if err != nil {
+		return nil, err
+	}
+	selected, err := selectEnvs(available, envs)
+	if err != nil {
+		return nil, err
+	}
+
+	steps := []step{}
+	if len(available) == 0 {
+		for _, rel := range base {
+			steps = append(steps, step{
+				Name: filepath.ToSlash(rel),
+				Base: filepath.Join(layout.Base, rel),
+				To:   filepath.Join(layout.Rendered, rel),
+			})
+		}
+	}
+
+	for _, env := range selected {
+		dir := filepath.Join(layout.Overlays, env)
+		overlays, err := yamlFiles(dir)
+		if err != nil {
+			return nil, errors.Wrap(err, ansi.Sprintf("@R{Discovering the overlays in} @m{%s} @R{FAILED}", dir))
+		}
+		isOverlay := map[string]bool{}
+		for _, rel := range overlays {
+			isOverlay[rel] = true
+		}
+
+		for _, rel := range base {
+			s := step{
+				Name: env + "/" + filepath.ToSlash(rel),
+				Base: filepath.Join(layout.Base, rel),
+				To:   filepath.Join(layout.Rendered, env, rel),
+			}
+			if isOverlay[rel] {
+				s.Merge = []merge{{With: with{Files: []string{filepath.Join(dir, rel)}}}}
+				delete(isOverlay, rel)
+			}
+			steps = append(steps, s)
+		}
+		for _, rel := range overlays {
+			if isOverlay[rel] {
+				steps = append(steps, step{
+					Name: env + "/" + filepath.ToSlash(rel),
+					Base: filepath.Join(dir, rel),
+					To:   filepath.Join(layout.Rendered, env, rel),
+				})
+			}
+		}
+	}
+
+	return yaml.Marshal(map[string]interface{}{"spruce": steps})
+}
+
+// environments returns the directories below the overlays directory, which
+// may not exist.
+func environments(overlays string) ([]string, error) {
+	entries, err := ioutil.ReadDir(overlays)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, ansi.Sprintf("@R{Discovering the environments in} @m{%s} @R{FAILED}", overlays))
+	}
+
+	result := []string{}
+	for _, e := range entries {
+		if e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+			result = append(result, e.Name())
+		}
+	}
+	return result, nil
+}
+
+func selectEnvs(available, envs []string) ([]string, error) {
+	if len(envs) == 0 {
+		return available, nil
+	}
+
+	missing := []string{}
+	for _, env := range envs {
+		found := false
+		for _, a := range available {
+			found = found || a == env
+		}
+		if !found {
+			missing = append(missing, env)
+		}
+	}
+	if len(missing) != 0 {
+		return nil, ansi.Errorf("@R{No overlays for the environments} @m{%s}@R{; available are} %s", strings.Join(missing, ", "), strings.Join(available, ", "))
+	}
+	return envs, nil
+}
+
+// yamlFiles returns the YAML files below dir relative to it, sorted; none
+// if dir does not exist.
+func yamlFiles(dir string) ([]string, error) {
+	result := []string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) && path == dir {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != dir && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ext := filepath.Ext(path); ext != ".yml" && ext != ".yaml" {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		result = append(result, rel)
+		return nil
+	})
+	sort.Strings(result)
+	return result, err
+}
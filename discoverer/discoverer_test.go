@@ -0,0 +1,104 @@
+package discoverer_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/JulzDiverse/aviator"
+	. "github.com/JulzDiverse/aviator/discoverer"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Discoverer", func() {
+
+	var dir string
+
+	write := func(path string) {
+		path = filepath.Join(dir, path)
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(path, []byte("key: value\n"), 0644)).To(Succeed())
+	}
+
+	discover := func(envs ...string) []aviator.Spruce {
+		content, err := Discover(DefaultLayout(dir), envs)
+		Expect(err).ToNot(HaveOccurred())
+		var result aviator.AviatorYaml
+		Expect(yaml.Unmarshal(content, &result)).To(Succeed())
+		return result.Spruce
+	}
+
+	path := func(parts ...string) string {
+		return filepath.Join(append([]string{dir}, parts...)...)
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "discoverer")
+		Expect(err).ToNot(HaveOccurred())
+
+		write("base/app.yml")
+		write("base/db/postgres.yaml")
+		write("base/README.md")
+		write("overlays/prod/app.yml")
+		write("overlays/prod/ingress.yml")
+		write("overlays/staging/db/postgres.yaml")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("renders every base file for every environment with its overlay", func() {
+		steps := discover()
+		Expect(steps).To(HaveLen(5))
+
+		Expect(steps[0].Name).To(Equal("prod/app.yml"))
+		Expect(steps[0].Base).To(Equal(path("base", "app.yml")))
+		Expect(steps[0].Merge[0].With.Files).To(Equal([]string{path("overlays", "prod", "app.yml")}))
+		Expect(steps[0].To).To(Equal(path("rendered", "prod", "app.yml")))
+
+		Expect(steps[1].Name).To(Equal("prod/db/postgres.yaml"))
+		Expect(steps[1].Merge).To(BeEmpty())
+		Expect(steps[1].To).To(Equal(path("rendered", "prod", "db", "postgres.yaml")))
+
+		Expect(steps[3].Name).To(Equal("staging/app.yml"))
+		Expect(steps[4].Name).To(Equal("staging/db/postgres.yaml"))
+		Expect(steps[4].Merge[0].With.Files).To(Equal([]string{path("overlays", "staging", "db", "postgres.yaml")}))
+	})
+
+	It("renders files only an environment has on their own", func() {
+		steps := discover()
+		Expect(steps[2].Name).To(Equal("prod/ingress.yml"))
+		Expect(steps[2].Base).To(Equal(path("overlays", "prod", "ingress.yml")))
+		Expect(steps[2].Merge).To(BeEmpty())
+		Expect(steps[2].To).To(Equal(path("rendered", "prod", "ingress.yml")))
+	})
+
+	It("restricts the environments", func() {
+		steps := discover("staging")
+		Expect(steps).To(HaveLen(2))
+		Expect(steps[0].Name).To(Equal("staging/app.yml"))
+
+		_, err := Discover(DefaultLayout(dir), []string{"dev"})
+		Expect(err).To(MatchError(ContainSubstring("dev")))
+	})
+
+	It("renders the base files directly without overlays", func() {
+		Expect(os.RemoveAll(path("overlays"))).To(Succeed())
+		steps := discover()
+		Expect(steps).To(HaveLen(2))
+		Expect(steps[0].Name).To(Equal("app.yml"))
+		Expect(steps[0].To).To(Equal(path("rendered", "app.yml")))
+	})
+
+	It("fails without base files", func() {
+		Expect(os.RemoveAll(path("base"))).To(Succeed())
+		_, err := Discover(DefaultLayout(dir), nil)
+		Expect(err).To(MatchError(ContainSubstring("No YAML files found")))
+	})
+})
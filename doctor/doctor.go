@@ -0,0 +1,254 @@
+package doctor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/JulzDiverse/aviator"
+	"github.com/JulzDiverse/aviator/sweeper"
+	"github.com/JulzDiverse/aviator/vendorer"
+)
+
+const cacheDir = ".aviator/cache"
+
+var datastore = regexp.MustCompile(`(\{\{|\+\+)([-\_\.\/\w\p{L}\/]+)(\}\}|\+\+)`)
+
+var versionArgs = map[string][]string{
+	"fly":     {"--version"},
+	"kubectl": {"version", "--client"},
+	"helm":    {"version", "--short"},
+	"jsonnet": {"--version"},
+	"ytt":     {"version"},
+	"git":     {"--version"},
+	"aws":     {"--version"},
+	"gsutil":  {"version"},
+}
+
+type Result struct {
+	Check  string
+	OK     bool
+	Detail string
+	Fix    string
+}
+
+// Check runs all checks required by cfg. The caches are checked against
+// policy, the policy of 'aviator cache gc'.
+func Check(cfg *aviator.AviatorYaml, policy sweeper.Policy) []Result {
+	results := []Result{}
+	for _, bin := range binaries(cfg) {
+		results = append(results, CheckBinary(bin))
+	}
+
+	if cfg.Kube.Apply.File != "" {
+		results = append(results, checkKubeContext())
+	}
+	if cfg.Fly.Target != "" {
+		results = append(results, checkFlyTarget(cfg.Fly.Target))
+	}
+
+	for _, dir := range outputDirs(cfg) {
+		results = append(results, CheckWritable(dir))
+	}
+	if len(cfg.Dependencies) > 0 {
+		results = append(results, CheckDependencies(cfg))
+	}
+	for _, dir := range cacheDirs() {
+		results = append(results, CheckCache(dir, policy))
+	}
+	return results
+}
+
+func CheckBinary(bin string) Result {
+	check := fmt.Sprintf("binary %s", bin)
+	path, err := exec.LookPath(bin)
+	if err != nil {
+		return Result{Check: check, Detail: "not found in PATH", Fix: InstallHint(bin)}
+	}
+
+	args, ok := versionArgs[bin]
+	if !ok {
+		return Result{Check: check, OK: true, Detail: path}
+	}
+	out, err := exec.Command(path, args...).CombinedOutput()
+	if err != nil {
+		return Result{Check: check, Detail: fmt.Sprintf("'%s %s' failed: %s", bin, strings.Join(args, " "), firstLine(out)), Fix: fmt.Sprintf("reinstall '%s'", bin)}
+	}
+	return Result{Check: check, OK: true, Detail: firstLine(out)}
+}
+
+func CheckWritable(dir string) Result {
+	return checkWritable(fmt.Sprintf("output dir %s", dir), dir)
+}
+
+// CheckCache checks that the cache in dir is writable and that it doesn't
+// contain entries 'aviator cache gc' would remove with policy.
+func CheckCache(dir string, policy sweeper.Policy) Result {
+	check := fmt.Sprintf("cache %s", dir)
+	if result := checkWritable(check, dir); !result.OK {
+		return result
+	}
+
+	plan, err := sweeper.Plan(dir, policy)
+	if err != nil {
+		return Result{Check: check, Detail: err.Error(), Fix: fmt.Sprintf("fix the permissions of %s", dir)}
+	}
+	detail := fmt.Sprintf("writable, %s", sweeper.FormatSize(plan.Size+plan.Freed))
+	if len(plan.Removed) > 0 {
+		return Result{Check: check, Detail: fmt.Sprintf("%s, %d entries (%s) exceed the gc policy", detail, len(plan.Removed), sweeper.FormatSize(plan.Freed)), Fix: gcCommand(dir, policy)}
+	}
+	return Result{Check: check, OK: true, Detail: detail}
+}
+
+func checkWritable(check, dir string) Result {
+	for d := dir; ; d = filepath.Dir(d) {
+		info, err := os.Stat(d)
+		if err == nil {
+			if !info.IsDir() {
+				return Result{Check: check, Detail: fmt.Sprintf("%s is not a directory", d), Fix: fmt.Sprintf("remove or rename %s", d)}
+			}
+			f, err := ioutil.TempFile(d, ".aviator-doctor")
+			if err != nil {
+				return Result{Check: check, Detail: fmt.Sprintf("%s is not writable", d), Fix: fmt.Sprintf("fix the permissions of %s", d)}
+			}
+			f.Close()
+			os.Remove(f.Name())
+
+			detail := "writable"
+			if d != dir {
+				detail = fmt.Sprintf("will be created in %s", d)
+			}
+			return Result{Check: check, OK: true, Detail: detail}
+		}
+		if d == filepath.Dir(d) {
+			return Result{Check: check, Detail: err.Error()}
+		}
+	}
+}
+
+// CheckDependencies checks that the vendored dependencies match their
+// declarations in the aviator file.
+func CheckDependencies(cfg *aviator.AviatorYaml) Result {
+	dir := cfg.VendorDir
+	if dir == "" {
+		dir = vendorer.DefaultDir
+	}
+	check := fmt.Sprintf("dependencies in %s", dir)
+	problems, err := vendorer.Verify(dir, cfg.Dependencies)
+	if err != nil {
+		return Result{Check: check, Detail: err.Error(), Fix: "run 'aviator deps vendor'"}
+	}
+	if len(problems) > 0 {
+		return Result{Check: check, Detail: strings.Join(problems, ", "), Fix: "run 'aviator deps vendor'"}
+	}
+	return Result{Check: check, OK: true, Detail: fmt.Sprintf("%d vendored", len(cfg.Dependencies))}
+}
+
+func checkKubeContext() Result {
+	check := "kubectl context"
+	out, err := exec.Command("kubectl", "config", "current-context").CombinedOutput()
+	if err != nil {
+		return Result{Check: check, Detail: "no current context", Fix: "select a context with 'kubectl config use-context <name>'"}
+	}
+	context := firstLine(out)
+
+	out, err = exec.Command("kubectl", "cluster-info", "--request-timeout=5s").CombinedOutput()
+	if err != nil {
+		return Result{Check: check, Detail: fmt.Sprintf("%s is not reachable: %s", context, firstLine(out)), Fix: "check your network/VPN and credentials for the cluster"}
+	}
+	return Result{Check: check, OK: true, Detail: fmt.Sprintf("%s is reachable", context)}
+}
+
+func checkFlyTarget(target string) Result {
+	check := fmt.Sprintf("fly target %s", target)
+	out, err := exec.Command("fly", "-t", target, "status").CombinedOutput()
+	if err != nil {
+		return Result{Check: check, Detail: firstLine(out), Fix: fmt.Sprintf("run 'fly -t %s login'", target)}
+	}
+	return Result{Check: check, OK: true, Detail: firstLine(out)}
+}
+
+// cacheDirs returns the cache of remote files in the home directory and the
+// cache of recorded runs in the working directory, if there is one.
+func cacheDirs() []string {
+	result := []string{}
+	if home, err := os.UserHomeDir(); err == nil {
+		result = append(result, filepath.Join(home, cacheDir))
+	}
+	if info, err := os.Stat(cacheDir); err == nil && info.IsDir() {
+		result = append(result, cacheDir)
+	}
+	return result
+}
+
+func gcCommand(dir string, policy sweeper.Policy) string {
+	args := []string{"aviator", "cache", "gc", "--dir", dir}
+	if policy.MaxSize > 0 {
+		args = append(args, "--max-size", strconv.FormatInt(policy.MaxSize, 10))
+	}
+	if policy.MaxAge > 0 {
+		args = append(args, "--max-age", policy.MaxAge.String())
+	}
+	return fmt.Sprintf("run '%s'", strings.Join(args, " "))
+}
+
+func binaries(cfg *aviator.AviatorYaml) []string {
+	result := []string{}
+	for _, r := range Requirements(cfg) {
+		result = append(result, r.Binary)
+	}
+	return result
+}
+
+func usesSource(s aviator.Spruce, schemes ...string) bool {
+	files := append([]string{s.Base}, s.ForEach.Files...)
+	for _, m := range s.Merge {
+		files = append(files, m.With.Files...)
+	}
+	for _, f := range files {
+		for _, scheme := range schemes {
+			if strings.HasPrefix(f, scheme) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func outputDirs(cfg *aviator.AviatorYaml) []string {
+	targets := []string{cfg.Squash.To, cfg.Helm.To}
+	for _, s := range cfg.Spruce {
+		if s.ToDir != "" {
+			targets = append(targets, filepath.Join(s.ToDir, "file"))
+		}
+		targets = append(targets, s.To)
+	}
+	for _, t := range cfg.Template {
+		targets = append(targets, t.To)
+	}
+
+	set := map[string]bool{}
+	for _, t := range targets {
+		if t == "" || datastore.MatchString(t) {
+			continue
+		}
+		set[filepath.Dir(t)] = true
+	}
+
+	result := []string{}
+	for dir := range set {
+		result = append(result, dir)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func firstLine(out []byte) string {
+	return strings.TrimSpace(strings.SplitN(strings.TrimSpace(string(out)), "\n", 2)[0])
+}
@@ -0,0 +1,135 @@
+package doctor_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/JulzDiverse/aviator"
+	. "github.com/JulzDiverse/aviator/doctor"
+	"github.com/JulzDiverse/aviator/sweeper"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Doctor", func() {
+
+	var dir, home string
+
+	BeforeEach(func() {
+		dir, _ = ioutil.TempDir("", "aviator-doctor")
+		home = os.Getenv("HOME")
+		os.Setenv("HOME", dir)
+	})
+
+	AfterEach(func() {
+		os.Setenv("HOME", home)
+		os.Chmod(dir, 0755)
+		os.RemoveAll(dir)
+	})
+
+	Context("Check", func() {
+		It("checks the binaries and output dirs required by the aviator file", func() {
+			cfg := &aviator.AviatorYaml{
+				Spruce: []aviator.Spruce{
+					{Base: "base.yml", To: filepath.Join(dir, "result.yml")},
+					{Base: "base.yml", To: "{{internal}}"},
+				},
+				Exec: []aviator.Executable{{Executable: "sh"}, {Executable: "not-an-aviator-binary"}},
+			}
+
+			results := Check(cfg, sweeper.Policy{})
+			Expect(results).To(HaveLen(4))
+			Expect(results[0].Check).To(Equal("binary not-an-aviator-binary"))
+			Expect(results[0].OK).To(BeFalse())
+			Expect(results[0].Fix).To(ContainSubstring("PATH"))
+			Expect(results[1].Check).To(Equal("binary sh"))
+			Expect(results[1].OK).To(BeTrue())
+			Expect(results[2].Check).To(Equal("output dir " + dir))
+			Expect(results[2].OK).To(BeTrue())
+			Expect(results[3].Check).To(Equal("cache " + filepath.Join(dir, ".aviator/cache")))
+			Expect(results[3].OK).To(BeTrue())
+		})
+
+		It("ignores binaries of steps which don't run on the current platform", func() {
+			cfg := &aviator.AviatorYaml{
+				Exec: []aviator.Executable{{
+					Executable: "not-an-aviator-binary",
+					Condition:  aviator.Condition{RunsOn: []string{"plan9/mips"}},
+				}},
+			}
+			for _, result := range Check(cfg, sweeper.Policy{}) {
+				Expect(result.Check).ToNot(HavePrefix("binary"))
+			}
+		})
+	})
+
+	Context("CheckDependencies", func() {
+		It("reports dependencies which are not vendored", func() {
+			cfg := &aviator.AviatorYaml{
+				VendorDir:    filepath.Join(dir, "vendor"),
+				Dependencies: map[string]aviator.Dependency{"platform": {Git: "https://example.com/platform.git", Version: "v1.0.0"}},
+			}
+
+			result := CheckDependencies(cfg)
+			Expect(result.Check).To(Equal("dependencies in " + filepath.Join(dir, "vendor")))
+			Expect(result.OK).To(BeFalse())
+			Expect(result.Detail).To(Equal("platform is not vendored"))
+			Expect(result.Fix).To(Equal("run 'aviator deps vendor'"))
+		})
+	})
+
+	Context("CheckCache", func() {
+		var cache string
+
+		BeforeEach(func() {
+			cache = filepath.Join(dir, "cache")
+			os.MkdirAll(filepath.Join(cache, "sha256"), 0755)
+			ioutil.WriteFile(filepath.Join(cache, "sha256", "old"), make([]byte, 2048), 0644)
+		})
+
+		It("accepts a writable cache within the gc policy", func() {
+			result := CheckCache(cache, sweeper.Policy{MaxSize: 4096})
+			Expect(result.OK).To(BeTrue())
+			Expect(result.Detail).To(Equal("writable, 2.0Ki"))
+		})
+
+		It("fails if the cache exceeds the gc policy", func() {
+			result := CheckCache(cache, sweeper.Policy{MaxSize: 1024})
+			Expect(result.OK).To(BeFalse())
+			Expect(result.Detail).To(ContainSubstring("1 entries (2.0Ki) exceed the gc policy"))
+			Expect(result.Fix).To(Equal("run 'aviator cache gc --dir " + cache + " --max-size 1024'"))
+			Expect(filepath.Join(cache, "sha256", "old")).To(BeAnExistingFile())
+		})
+
+		It("fails if the cache is not writable", func() {
+			if os.Geteuid() == 0 {
+				Skip("root can write to any directory")
+			}
+			os.Chmod(cache, 0555)
+			defer os.Chmod(cache, 0755)
+
+			result := CheckCache(cache, sweeper.Policy{})
+			Expect(result.OK).To(BeFalse())
+			Expect(result.Detail).To(Equal(cache + " is not writable"))
+		})
+	})
+
+	Context("CheckWritable", func() {
+		It("accepts directories which will be created", func() {
+			result := CheckWritable(filepath.Join(dir, "new", "dir"))
+			Expect(result.OK).To(BeTrue())
+			Expect(result.Detail).To(ContainSubstring("will be created in " + dir))
+		})
+
+		It("fails if the path is blocked by a file", func() {
+			file := filepath.Join(dir, "file")
+			ioutil.WriteFile(file, []byte{}, 0644)
+
+			result := CheckWritable(filepath.Join(file, "dir"))
+			Expect(result.OK).To(BeFalse())
+			Expect(result.Fix).To(ContainSubstring(file))
+		})
+	})
+})
@@ -0,0 +1,172 @@
+package doctor
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/JulzDiverse/aviator"
+	"github.com/JulzDiverse/aviator/changes"
+	"github.com/JulzDiverse/aviator/platform"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+var installHints = map[string]string{
+	"fly":     "https://concourse-ci.org/fly.html (or the download links in the web UI of your Concourse)",
+	"kubectl": "https://kubernetes.io/docs/tasks/tools/",
+	"helm":    "https://helm.sh/docs/intro/install/",
+	"jsonnet": "https://jsonnet.org/",
+	"ytt":     "https://carvel.dev/ytt/",
+	"git":     "https://git-scm.com/downloads",
+	"aws":     "https://aws.amazon.com/cli/",
+	"gsutil":  "https://cloud.google.com/storage/docs/gsutil_install",
+
+	"kubeconform": "https://github.com/yannh/kubeconform",
+	"conftest":    "https://www.conftest.dev/install/",
+	"sops":        "https://github.com/getsops/sops/releases",
+	"age":         "https://github.com/FiloSottile/age#installation",
+}
+
+type Requirement struct {
+	Binary   string
+	Step     string
+	Executor bool
+}
+
+// Requirements lists the binaries the aviator file is going to run, each
+// with the first step requiring it. Steps which are skipped on the current
+// platform or without changes are left out.
+func Requirements(cfg *aviator.AviatorYaml) []Requirement {
+	seen := map[string]bool{}
+	result := []Requirement{}
+	add := func(bin, step string, executor bool) {
+		if bin == "" || seen[bin] {
+			return
+		}
+		seen[bin] = true
+		result = append(result, Requirement{Binary: bin, Step: step, Executor: executor})
+	}
+	addExecutables := func(execs []aviator.Executable, step func(aviator.Executable) string, executor bool) {
+		for _, e := range execs {
+			if !runs(e.Condition) {
+				continue
+			}
+			add(binary(e), step(e), executor)
+			for _, h := range e.FailureHook {
+				add(binary(h), step(e)+" (failure hook)", executor)
+			}
+		}
+	}
+
+	for _, s := range cfg.Spruce {
+		if !runs(s.Condition) {
+			continue
+		}
+		step := spruceStep(s)
+		if s.Engine == "jsonnet" || s.Engine == "ytt" {
+			add(s.Engine, step, false)
+		}
+		if usesSource(s, "helm://") {
+			add("helm", step, false)
+		}
+		if usesSource(s, "git+ssh://", "git+https://", "git+file://") {
+			add("git", step, false)
+		}
+		if usesSource(s, "s3://") {
+			add("aws", step, false)
+		}
+		if usesSource(s, "gs://") {
+			add("gsutil", step, false)
+		}
+		if s.Validate.Kubernetes {
+			add("kubeconform", step+" (validate)", false)
+		}
+		if len(s.Policies) > 0 {
+			add("conftest", step+" (policies)", false)
+		}
+		if len(s.Encrypt.AgeRecipients) > 0 {
+			add("age", step+" (encrypt)", false)
+		}
+		for _, m := range s.Merge {
+			if m.With.Sops {
+				add("sops", step, false)
+			}
+		}
+		addExecutables(s.PostProcess, func(aviator.Executable) string { return step + " (post_process)" }, false)
+		for _, h := range s.FailureHook {
+			add(binary(h), step+" (failure hook)", false)
+		}
+	}
+
+	fly := cfg.Fly
+	if fly.Name != "" && fly.Target != "" && fly.Config != "" && runs(fly.Condition) {
+		add("fly", "fly: "+fly.Name, true)
+		for _, h := range fly.FailureHook {
+			add(binary(h), "fly: "+fly.Name+" (failure hook)", true)
+		}
+	}
+	helm := cfg.Helm
+	if helm.Chart != "" && runs(helm.Condition) {
+		add("helm", "helm: "+helm.Release, true)
+		for _, h := range helm.FailureHook {
+			add(binary(h), "helm: "+helm.Release+" (failure hook)", true)
+		}
+	}
+	kube := cfg.Kube.Apply
+	if kube.File != "" && runs(kube.Condition) {
+		add("kubectl", "kubectl: "+kube.File, true)
+		for _, h := range kube.FailureHook {
+			add(binary(h), "kubectl: "+kube.File+" (failure hook)", true)
+		}
+	}
+	addExecutables(cfg.Exec, func(e aviator.Executable) string { return "exec: " + e.Executable }, true)
+
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Binary < result[j].Binary })
+	return result
+}
+
+// RequireBinaries fails if a binary the aviator file is going to run is not
+// in the PATH. Executor binaries are only required if executors is set.
+func RequireBinaries(cfg *aviator.AviatorYaml, executors bool) error {
+	missing := []string{}
+	for _, r := range Requirements(cfg) {
+		if r.Executor && !executors {
+			continue
+		}
+		if _, err := exec.LookPath(r.Binary); err != nil {
+			missing = append(missing, ansi.Sprintf("@m{%s} @R{required by} @m{%s}@R{:} %s", r.Binary, r.Step, InstallHint(r.Binary)))
+		}
+	}
+	if len(missing) != 0 {
+		return ansi.Errorf("@R{Required binaries are not in your PATH}:\n\t%s", strings.Join(missing, "\n\t"))
+	}
+	return nil
+}
+
+func InstallHint(bin string) string {
+	if url, ok := installHints[bin]; ok {
+		return fmt.Sprintf("install '%s' from %s or add it to your PATH", bin, url)
+	}
+	return fmt.Sprintf("install '%s' or add it to your PATH", bin)
+}
+
+func spruceStep(s aviator.Spruce) string {
+	to := s.To
+	if to == "" {
+		to = s.ToDir
+	}
+	return "spruce: " + to
+}
+
+func runs(cond aviator.Condition) bool {
+	return platform.Matches(cond.RunsOn) && changes.Default().Touches(cond.WhenChanged)
+}
+
+// binary returns the binary an executable runs; a shell command runs sh.
+func binary(e aviator.Executable) string {
+	if e.Shell {
+		return "sh"
+	}
+	return e.Executable
+}
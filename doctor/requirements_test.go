@@ -0,0 +1,89 @@
+package doctor_test
+
+import (
+	"github.com/JulzDiverse/aviator"
+	. "github.com/JulzDiverse/aviator/doctor"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Requirements", func() {
+
+	var cfg *aviator.AviatorYaml
+
+	BeforeEach(func() {
+		cfg = &aviator.AviatorYaml{
+			Spruce: []aviator.Spruce{
+				{Base: "helm://charts/app", To: "app.yml"},
+				{Base: "base.yml", To: "other.yml", PostProcess: []aviator.Executable{{Executable: "not-an-aviator-post-processor"}}},
+			},
+			Kube: aviator.Kube{Apply: aviator.KubeApply{File: "manifests/"}},
+			Exec: []aviator.Executable{{Executable: "not-an-aviator-binary"}},
+		}
+	})
+
+	It("names the first step requiring each binary", func() {
+		Expect(Requirements(cfg)).To(Equal([]Requirement{
+			{Binary: "helm", Step: "spruce: app.yml"},
+			{Binary: "kubectl", Step: "kubectl: manifests/", Executor: true},
+			{Binary: "not-an-aviator-binary", Step: "exec: not-an-aviator-binary", Executor: true},
+			{Binary: "not-an-aviator-post-processor", Step: "spruce: other.yml (post_process)"},
+		}))
+	})
+
+	It("requires git for git sources", func() {
+		cfg.Spruce[1].Merge = []aviator.Merge{{With: aviator.With{Files: []string{"git+https://github.com/org/repo.git?path=base.yml"}}}}
+		Expect(Requirements(cfg)).To(ContainElement(Requirement{Binary: "git", Step: "spruce: other.yml"}))
+	})
+
+	It("requires the object store CLIs for s3 and gs sources", func() {
+		cfg.Spruce[1].ForEach.Files = []string{"s3://bucket/a.yml", "gs://bucket/b.yml"}
+		Expect(Requirements(cfg)).To(ContainElement(Requirement{Binary: "aws", Step: "spruce: other.yml"}))
+		Expect(Requirements(cfg)).To(ContainElement(Requirement{Binary: "gsutil", Step: "spruce: other.yml"}))
+	})
+
+	It("requires kubeconform for kubernetes validation", func() {
+		cfg.Spruce[1].Validate.Kubernetes = true
+		Expect(Requirements(cfg)).To(ContainElement(Requirement{Binary: "kubeconform", Step: "spruce: other.yml (validate)"}))
+	})
+
+	It("requires conftest for policies", func() {
+		cfg.Spruce[1].Policies = []string{"policies/"}
+		Expect(Requirements(cfg)).To(ContainElement(Requirement{Binary: "conftest", Step: "spruce: other.yml (policies)"}))
+	})
+
+	It("requires sh for shell commands", func() {
+		cfg.Exec = []aviator.Executable{{Executable: "kubectl get pods | grep app", Shell: true}}
+		Expect(Requirements(cfg)).To(ContainElement(Requirement{Binary: "sh", Step: "exec: kubectl get pods | grep app", Executor: true}))
+	})
+
+	It("requires sops for files marked with sops", func() {
+		cfg.Spruce[1].Merge = []aviator.Merge{{With: aviator.With{Files: []string{"secrets.yml"}, Sops: true}}}
+		Expect(Requirements(cfg)).To(ContainElement(Requirement{Binary: "sops", Step: "spruce: other.yml"}))
+	})
+
+	It("requires age for encrypted targets", func() {
+		cfg.Spruce[1].Encrypt = aviator.Encrypt{AgeRecipients: []string{"age1xyz"}}
+		Expect(Requirements(cfg)).To(ContainElement(Requirement{Binary: "age", Step: "spruce: other.yml (encrypt)"}))
+	})
+
+	It("fails naming the binary, the step and how to install it", func() {
+		cfg.Spruce = nil
+		cfg.Kube = aviator.Kube{}
+
+		err := RequireBinaries(cfg, true)
+		Expect(err).To(MatchError(ContainSubstring("not-an-aviator-binary")))
+		Expect(err).To(MatchError(ContainSubstring("exec: not-an-aviator-binary")))
+		Expect(err).To(MatchError(ContainSubstring("add it to your PATH")))
+	})
+
+	It("ignores executor binaries if executors don't run", func() {
+		cfg.Spruce = cfg.Spruce[:0]
+		Expect(RequireBinaries(cfg, false)).To(Succeed())
+	})
+
+	It("points to the install instructions of known binaries", func() {
+		Expect(InstallHint("kubectl")).To(ContainSubstring("https://kubernetes.io/docs/tasks/tools/"))
+	})
+})
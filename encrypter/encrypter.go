@@ -0,0 +1,117 @@
+package encrypter
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+const encryptedDir = ".aviator/encrypted"
+
+// Encrypter encrypts rendered files for age recipients. As age encrypts
+// with a new file key every time, it keeps the digests of the last
+// plaintext and ciphertext of every target below .aviator/encrypted: an
+// unchanged file is not encrypted again, so re-rendering it does not change
+// the committed ciphertext.
+type Encrypter struct {
+	dir string
+}
+
+type record struct {
+	Plaintext  string `json:"plaintext"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+func New(root string) *Encrypter {
+	return &Encrypter{dir: filepath.Join(root, encryptedDir)}
+}
+
+// Encrypt encrypts file for the recipients with the age binary, ASCII
+// armored. If target holds the encryption of the same plaintext for the same
+// recipients, its content is returned instead. A nil Encrypter always
+// encrypts.
+func (e *Encrypter) Encrypt(target string, file []byte, recipients []string) ([]byte, error) {
+	plaintext := digest(append([]byte(strings.Join(recipients, "\n")+"\x00"), file...))
+	if current, ok := e.unchanged(target, plaintext); ok {
+		return current, nil
+	}
+
+	args := []string{"--encrypt", "--armor"}
+	for _, r := range recipients {
+		args = append(args, "--recipient", r)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("age", args...)
+	cmd.Stdin = bytes.NewReader(file)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		reason := strings.TrimSpace(stderr.String())
+		if reason == "" {
+			reason = err.Error()
+		}
+		return nil, ansi.Errorf("@R{Encrypting} @m{%s} @R{with age FAILED}: %s", target, reason)
+	}
+
+	ciphertext := stdout.Bytes()
+	if err := e.record(target, record{Plaintext: plaintext, Ciphertext: digest(ciphertext)}); err != nil {
+		return nil, err
+	}
+	return ciphertext, nil
+}
+
+func (e *Encrypter) unchanged(target, plaintext string) ([]byte, bool) {
+	if e == nil {
+		return nil, false
+	}
+	content, err := ioutil.ReadFile(e.file(target))
+	if err != nil {
+		return nil, false
+	}
+	var last record
+	if err := json.Unmarshal(content, &last); err != nil || last.Plaintext != plaintext {
+		return nil, false
+	}
+	current, err := ioutil.ReadFile(target)
+	if err != nil || digest(current) != last.Ciphertext {
+		return nil, false
+	}
+	return current, true
+}
+
+func (e *Encrypter) record(target string, r record) error {
+	if e == nil {
+		return nil
+	}
+	content, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(e.dir, 0755)
+	if err == nil {
+		err = ioutil.WriteFile(e.file(target), content, 0644)
+	}
+	if err != nil {
+		return ansi.Errorf("@R{Recording encryption of} @m{%s} @R{FAILED}: %s", target, err.Error())
+	}
+	return nil
+}
+
+func (e *Encrypter) file(target string) string {
+	return filepath.Join(e.dir, url.PathEscape(filepath.ToSlash(filepath.Clean(target)))+".json")
+}
+
+func digest(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,13 @@
+package encrypter_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestEncrypter(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Encrypter Suite")
+}
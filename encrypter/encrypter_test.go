@@ -0,0 +1,92 @@
+package encrypter_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/JulzDiverse/aviator/encrypter"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Encrypter", func() {
+
+	var (
+		dir, path, target string
+		recipients        []string
+	)
+
+	// the fake age numbers every encryption, as age uses a new file key
+	// every time
+	fakeAge := func(script string) {
+		Expect(ioutil.WriteFile(filepath.Join(dir, "age"), []byte("#!/bin/sh\n"+script), 0755)).To(Succeed())
+	}
+
+	encrypt := func(e *Encrypter, file string) string {
+		ciphertext, err := e.Encrypt(target, []byte(file), recipients)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ioutil.WriteFile(target, ciphertext, 0644)).To(Succeed())
+		return string(ciphertext)
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "encrypter")
+		Expect(err).ToNot(HaveOccurred())
+		target = filepath.Join(dir, "secrets.yml.age")
+		recipients = []string{"age1first", "age1second"}
+		path = os.Getenv("PATH")
+		os.Setenv("PATH", dir+string(os.PathListSeparator)+path)
+
+		fakeAge(`d="$(dirname "$0")"
+echo "$@" > "$d/args"
+n=$(( $(cat "$d/count" 2>/dev/null || echo 0) + 1 ))
+echo $n > "$d/count"
+echo "encrypted $n"
+cat
+`)
+	})
+
+	AfterEach(func() {
+		os.Setenv("PATH", path)
+		os.RemoveAll(dir)
+	})
+
+	It("encrypts the file on stdin for all recipients", func() {
+		Expect(encrypt(nil, "password: secret\n")).To(Equal("encrypted 1\npassword: secret\n"))
+
+		args, err := ioutil.ReadFile(filepath.Join(dir, "args"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(args)).To(Equal("--encrypt --armor --recipient age1first --recipient age1second\n"))
+	})
+
+	It("keeps the ciphertext of an unchanged file", func() {
+		e := New(dir)
+		Expect(encrypt(e, "password: secret\n")).To(Equal("encrypted 1\npassword: secret\n"))
+		Expect(encrypt(e, "password: secret\n")).To(Equal("encrypted 1\npassword: secret\n"))
+		Expect(filepath.Join(dir, ".aviator/encrypted")).To(BeADirectory())
+
+		Expect(encrypt(e, "password: changed\n")).To(Equal("encrypted 2\npassword: changed\n"))
+	})
+
+	It("encrypts again if the recipients or the target changed", func() {
+		e := New(dir)
+		encrypt(e, "password: secret\n")
+
+		recipients = []string{"age1first"}
+		Expect(encrypt(e, "password: secret\n")).To(Equal("encrypted 2\npassword: secret\n"))
+
+		Expect(ioutil.WriteFile(target, []byte("edited"), 0644)).To(Succeed())
+		Expect(encrypt(e, "password: secret\n")).To(Equal("encrypted 3\npassword: secret\n"))
+	})
+
+	It("fails with the reason reported by age", func() {
+		fakeAge(`echo "age: error: unknown recipient type" >&2; exit 1`)
+
+		_, err := New(dir).Encrypt(target, []byte("password: secret\n"), recipients)
+		Expect(err).To(MatchError(ContainSubstring("unknown recipient type")))
+		Expect(err).To(MatchError(ContainSubstring("secrets.yml.age")))
+	})
+})
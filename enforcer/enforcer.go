@@ -0,0 +1,88 @@
+package enforcer
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+// Result holds the messages of the deny and warn rules which matched.
+type Result struct {
+	Failures []string
+	Warnings []string
+}
+
+type conftestOutput []struct {
+	Filename string `json:"filename"`
+	Warnings []struct {
+		Msg string `json:"msg"`
+	} `json:"warnings"`
+	Failures []struct {
+		Msg string `json:"msg"`
+	} `json:"failures"`
+}
+
+// Check evaluates the documents of file against the Rego policies in the
+// given directories with conftest, conftest-style: deny and violation rules
+// are failures, warn rules are warnings.
+func Check(file []byte, policies []string) (Result, error) {
+	var result Result
+	dir, err := ioutil.TempDir("", "aviator-policies")
+	if err != nil {
+		return result, err
+	}
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "result.yml")
+	err = ioutil.WriteFile(input, file, 0644)
+	if err != nil {
+		return result, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("conftest", args(policies, input)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	runErr := cmd.Run()
+	if _, ok := runErr.(*exec.ExitError); runErr != nil && !ok {
+		return result, errors.Wrap(runErr, ansi.Sprintf("@R{Running} @m{conftest} @R{FAILED}"))
+	}
+
+	var output conftestOutput
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		return result, ansi.Errorf("@R{Running} @m{conftest} @R{FAILED}: %s", strings.TrimSpace(stderr.String()+" "+err.Error()))
+	}
+	for _, o := range output {
+		for _, f := range o.Failures {
+			result.Failures = append(result.Failures, f.Msg)
+		}
+		for _, w := range o.Warnings {
+			result.Warnings = append(result.Warnings, w.Msg)
+		}
+	}
+	return result, nil
+}
+
+func args(policies []string, input string) []string {
+	args := []string{"test", "--no-color", "--output", "json", "--parser", "yaml"}
+	for _, p := range policies {
+		args = append(args, "--policy", p)
+	}
+	return append(args, input)
+}
+
+// Error returns an error listing the failures of the policy check of the
+// file to, or nil if there are none.
+func (r Result) Error(to string) error {
+	if len(r.Failures) == 0 {
+		return nil
+	}
+	return ansi.Errorf("@R{Policy check of} @m{%s} @R{FAILED}:\n  %s", to, strings.Join(r.Failures, "\n  "))
+}
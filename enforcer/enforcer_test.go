@@ -0,0 +1,79 @@
+package enforcer_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/JulzDiverse/aviator/enforcer"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Enforcer", func() {
+
+	var dir, path string
+
+	fakeConftest := func(output string, exitCode string) {
+		script := `#!/bin/sh
+echo "$@" > "$(dirname "$0")/args"
+for last; do :; done
+cp "$last" "$(dirname "$0")/input"
+cat <<'JSON'
+` + output + `
+JSON
+exit ` + exitCode + `
+`
+		Expect(ioutil.WriteFile(filepath.Join(dir, "conftest"), []byte(script), 0755)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "enforcer")
+		Expect(err).ToNot(HaveOccurred())
+		path = os.Getenv("PATH")
+		os.Setenv("PATH", dir+string(os.PathListSeparator)+path)
+	})
+
+	AfterEach(func() {
+		os.Setenv("PATH", path)
+		os.RemoveAll(dir)
+	})
+
+	It("passes the file and the policies to conftest", func() {
+		fakeConftest(`[{"filename": "result.yml", "namespace": "main", "successes": 2}]`, "0")
+
+		result, err := Check([]byte("kind: Deployment\n"), []string{"policies/", "shared/"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(Result{}))
+		Expect(result.Error("result.yml")).To(Succeed())
+
+		args, _ := ioutil.ReadFile(filepath.Join(dir, "args"))
+		Expect(string(args)).To(HavePrefix("test --no-color --output json --parser yaml --policy policies/ --policy shared/ "))
+		input, _ := ioutil.ReadFile(filepath.Join(dir, "input"))
+		Expect(string(input)).To(Equal("kind: Deployment\n"))
+	})
+
+	It("reports deny and warn rules", func() {
+		fakeConftest(`[{"filename": "result.yml", "namespace": "main",
+  "warnings": [{"msg": "app has no owner label"}],
+  "failures": [{"msg": "containers must not run as root"}, {"msg": "image tag latest is not allowed"}]}]`, "1")
+
+		result, err := Check([]byte("kind: Deployment\n"), []string{"policies/"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Warnings).To(Equal([]string{"app has no owner label"}))
+		Expect(result.Failures).To(Equal([]string{"containers must not run as root", "image tag latest is not allowed"}))
+
+		err = result.Error("manifests/app.yml")
+		Expect(err).To(MatchError(ContainSubstring("manifests/app.yml")))
+		Expect(err).To(MatchError(ContainSubstring("containers must not run as root\n  image tag latest is not allowed")))
+	})
+
+	It("fails if conftest does not report a result", func() {
+		fakeConftest(`Error: loading policies: no policies found`, "1")
+
+		_, err := Check([]byte("kind: Deployment\n"), []string{"missing/"})
+		Expect(err).To(MatchError(ContainSubstring("conftest")))
+	})
+})
@@ -4,14 +4,35 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
 	"regexp"
 	"strings"
 )
 
 var variableFormatRegex = regexp.MustCompile(`\(\(\s([-\w\p{L}]+)\s\)\)`)
 
+// (( var "name" )) looks the variable up like (( name )), but falls back to
+// the environment variable of the same name.
+var varOperatorRegex = regexp.MustCompile(`\(\(\s+var\s+"([-\w\p{L}]+)"\s+\)\)`)
+
 func Evaluate(aviatorFile []byte, vars map[string]string) ([]byte, error) {
 	var err error
+	aviatorFile = varOperatorRegex.ReplaceAllFunc(aviatorFile, func(match []byte) []byte {
+		key := string(varOperatorRegex.FindSubmatch(match)[1])
+
+		val, ok := vars[key]
+		if !ok {
+			val, ok = os.LookupEnv(key)
+		}
+		if !ok && err == nil {
+			err = errors.New(fmt.Sprintf("Variable (( var \"%s\" )) neither provided nor set in the environment", key))
+		}
+		return replacement(val)
+	})
+	if err != nil {
+		return aviatorFile, err
+	}
+
 	return variableFormatRegex.ReplaceAllFunc(aviatorFile, func(match []byte) []byte {
 		key := string(variableFormatRegex.FindSubmatch(match)[1])
 
@@ -19,14 +40,14 @@ func Evaluate(aviatorFile []byte, vars map[string]string) ([]byte, error) {
 		if !ok {
 			err = errors.New(fmt.Sprintf("Variable (( %s )) not provided", key))
 		}
-
-		var replace []byte
-		if strings.Contains(val, "\n") {
-			replace, _ = json.Marshal(val)
-		} else {
-			replace = []byte(val)
-		}
-
-		return []byte(replace)
+		return replacement(val)
 	}), err
 }
+
+func replacement(val string) []byte {
+	if strings.Contains(val, "\n") {
+		replace, _ := json.Marshal(val)
+		return replace
+	}
+	return []byte(val)
+}
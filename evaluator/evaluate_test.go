@@ -1,6 +1,7 @@
 package evaluator_test
 
 import (
+	"os"
 	"strings"
 
 	. "github.com/onsi/ginkgo"
@@ -72,6 +73,56 @@ fly:
 			})
 		})
 
+		Context("When the var operator is used", func() {
+			BeforeEach(func() {
+				os.Setenv("AVIATOR_EVALUATE_ENV", "prod")
+				aviatorYaml = `---
+spruce:
+- base: (( var "env" ))/base.yml
+  to: (( var "AVIATOR_EVALUATE_ENV" ))/result.yml`
+			})
+
+			AfterEach(func() {
+				os.Unsetenv("AVIATOR_EVALUATE_ENV")
+			})
+
+			Context("When the variable is provided", func() {
+				BeforeEach(func() {
+					vars = map[string]string{"env": "stage", "AVIATOR_EVALUATE_ENV": "dev"}
+				})
+
+				It("should prefer the provided value over the environment", func() {
+					Expect(err).ToNot(HaveOccurred())
+					Expect(strings.TrimSpace(string(evaluated))).To(Equal(`---
+spruce:
+- base: stage/base.yml
+  to: dev/result.yml`))
+				})
+			})
+
+			Context("When the variable is only set in the environment", func() {
+				BeforeEach(func() {
+					vars = map[string]string{"env": "stage"}
+				})
+
+				It("should fall back to the environment variable", func() {
+					Expect(err).ToNot(HaveOccurred())
+					Expect(string(evaluated)).To(ContainSubstring("to: prod/result.yml"))
+				})
+			})
+
+			Context("When the variable is neither provided nor set", func() {
+				BeforeEach(func() {
+					vars = map[string]string{}
+					os.Unsetenv("AVIATOR_EVALUATE_ENV")
+				})
+
+				It("should fail with a meaningful error message", func() {
+					Expect(err).To(MatchError(ContainSubstring(`Variable (( var "env" )) neither provided nor set in the environment`)))
+				})
+			})
+		})
+
 		Context("When the variable key is not existing", func() {
 			BeforeEach(func() {
 				aviatorYaml = `---
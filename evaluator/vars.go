@@ -0,0 +1,123 @@
+package evaluator
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const AgeKeyEnv = "AVIATOR_AGE_KEY"
+
+const ageArmorHeader = "-----BEGIN AGE ENCRYPTED FILE-----"
+
+// yaml.v2 drops local tags while decoding, so age tagged keys are looked up
+// in the raw file. Vars files are flat, hence only top level keys are matched.
+var ageTagged = regexp.MustCompile(`(?m)^("[^"]+"|'[^']+'|[^\s#'"][^:]*):\s+!age(\s|$)`)
+
+// ReadVarsFile reads a flat YAML file of aviator file variables. Values
+// tagged with !age are decrypted with the age identity in AVIATOR_AGE_KEY.
+func ReadVarsFile(path string) (map[string]string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, ansi.Sprintf("@R{Reading vars file} @m{%s} @R{FAILED}", path))
+	}
+
+	encrypted := map[string]bool{}
+	for _, m := range ageTagged.FindAllSubmatch(content, -1) {
+		encrypted[strings.Trim(strings.TrimSpace(string(m[1])), `"'`)] = true
+	}
+
+	var items yaml.MapSlice
+	err = yaml.Unmarshal(content, &items)
+	if err != nil {
+		return nil, errors.Wrap(err, ansi.Sprintf("@R{Parsing vars file} @m{%s} @R{FAILED}", path))
+	}
+
+	vars := map[string]string{}
+	for _, item := range items {
+		key := fmt.Sprint(item.Key)
+		switch item.Value.(type) {
+		case yaml.MapSlice, []interface{}:
+			return nil, ansi.Errorf("@R{Vars file} @m{%s}@R{: variable} @m{%s} @R{must be a scalar}", path, key)
+		case nil:
+			vars[key] = ""
+			continue
+		}
+
+		value := fmt.Sprint(item.Value)
+		if encrypted[key] {
+			value, err = decryptAge(key, value)
+			if err != nil {
+				return nil, err
+			}
+		}
+		vars[key] = value
+	}
+	return vars, nil
+}
+
+func decryptAge(key, value string) (string, error) {
+	identity := os.Getenv(AgeKeyEnv)
+	if identity == "" {
+		return "", ansi.Errorf("@R{Decrypting} @m{%s} @R{requires an age identity in} @m{%s}", key, AgeKeyEnv)
+	}
+	if _, err := exec.LookPath("age"); err != nil {
+		return "", ansi.Errorf("@R{Decrypting} @m{%s} @R{requires the} @m{age} @R{binary in your PATH}", key)
+	}
+
+	ciphertext := []byte(value)
+	if !strings.Contains(value, ageArmorHeader) {
+		decoded, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(value), ""))
+		if err != nil {
+			return "", ansi.Errorf("@R{Decrypting} @m{%s} @R{FAILED: the value is neither armored nor base64 encoded}", key)
+		}
+		ciphertext = decoded
+	}
+
+	identityFile, cleanup, err := ageIdentityFile(identity)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("age", "--decrypt", "-i", identityFile)
+	cmd.Stdin = bytes.NewReader(ciphertext)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", ansi.Errorf("@R{Decrypting} @m{%s} @R{FAILED}:\n%s", key, stderr.String())
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+// ageIdentityFile returns AVIATOR_AGE_KEY if it names an identity file.
+// Otherwise the keys it holds are written to a temporary file, since age
+// only reads identities from files.
+func ageIdentityFile(identity string) (string, func(), error) {
+	if _, err := os.Stat(identity); err == nil {
+		return identity, func() {}, nil
+	}
+
+	file, err := ioutil.TempFile("", "aviator-age")
+	if err != nil {
+		return "", nil, errors.Wrap(err, ansi.Sprintf("@R{Writing age identity FAILED}"))
+	}
+	cleanup := func() { os.Remove(file.Name()) }
+	_, err = file.WriteString(identity + "\n")
+	file.Close()
+	if err != nil {
+		cleanup()
+		return "", nil, errors.Wrap(err, ansi.Sprintf("@R{Writing age identity FAILED}"))
+	}
+	return file.Name(), cleanup, nil
+}
@@ -0,0 +1,96 @@
+package evaluator_test
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/JulzDiverse/aviator/evaluator"
+)
+
+var _ = Describe("ReadVarsFile", func() {
+
+	var (
+		dir  string
+		path string
+		key  string
+	)
+
+	write := func(content string) string {
+		file := filepath.Join(dir, "vars.yml")
+		Expect(ioutil.WriteFile(file, []byte(content), 0644)).To(Succeed())
+		return file
+	}
+
+	BeforeEach(func() {
+		dir, _ = ioutil.TempDir("", "aviator-vars")
+		// the fake age prints the identity it was given followed by the ciphertext
+		script := "#!/bin/sh\necho \"$@\" > " + filepath.Join(dir, "args") + "\n" +
+			"printf '%s|' \"$(cat \"$3\")\"\ncat\necho\n"
+		Expect(ioutil.WriteFile(filepath.Join(dir, "age"), []byte(script), 0755)).To(Succeed())
+		path = os.Getenv("PATH")
+		os.Setenv("PATH", dir+":"+path)
+		key = os.Getenv(AgeKeyEnv)
+		os.Setenv(AgeKeyEnv, "AGE-SECRET-KEY-1FAKE")
+	})
+
+	AfterEach(func() {
+		os.Setenv("PATH", path)
+		os.Setenv(AgeKeyEnv, key)
+		os.RemoveAll(dir)
+	})
+
+	It("reads plain variables as strings", func() {
+		vars, err := ReadVarsFile(write("env: prod\nreplicas: 3\nempty:\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vars).To(Equal(map[string]string{"env": "prod", "replicas": "3", "empty": ""}))
+	})
+
+	It("decrypts armored !age values with the identity in AVIATOR_AGE_KEY", func() {
+		file := write("env: prod\npassword: !age |\n  -----BEGIN AGE ENCRYPTED FILE-----\n  c2VjcmV0\n  -----END AGE ENCRYPTED FILE-----\n")
+		vars, err := ReadVarsFile(file)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vars["env"]).To(Equal("prod"))
+		Expect(vars["password"]).To(Equal("AGE-SECRET-KEY-1FAKE|-----BEGIN AGE ENCRYPTED FILE-----\nc2VjcmV0\n-----END AGE ENCRYPTED FILE-----\n"))
+
+		args, _ := ioutil.ReadFile(filepath.Join(dir, "args"))
+		Expect(string(args)).To(HavePrefix("--decrypt -i "))
+	})
+
+	It("decodes base64 !age values before decrypting them", func() {
+		encoded := base64.StdEncoding.EncodeToString([]byte("binary"))
+		vars, err := ReadVarsFile(write("\"token\": !age " + encoded + "\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vars["token"]).To(Equal("AGE-SECRET-KEY-1FAKE|binary"))
+	})
+
+	It("reads the identity from a file if AVIATOR_AGE_KEY names one", func() {
+		identity := filepath.Join(dir, "key.txt")
+		Expect(ioutil.WriteFile(identity, []byte("AGE-SECRET-KEY-1FILE"), 0600)).To(Succeed())
+		os.Setenv(AgeKeyEnv, identity)
+
+		vars, err := ReadVarsFile(write("token: !age " + base64.StdEncoding.EncodeToString([]byte("x")) + "\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vars["token"]).To(Equal("AGE-SECRET-KEY-1FILE|x"))
+	})
+
+	It("fails if no age identity is provided", func() {
+		os.Setenv(AgeKeyEnv, "")
+		_, err := ReadVarsFile(write("token: !age c2VjcmV0\n"))
+		Expect(err).To(MatchError(ContainSubstring(AgeKeyEnv)))
+	})
+
+	It("fails on values which are neither armored nor base64", func() {
+		_, err := ReadVarsFile(write("token: !age not-base64!\n"))
+		Expect(err).To(MatchError(ContainSubstring("neither armored nor base64")))
+	})
+
+	It("fails on nested variables", func() {
+		_, err := ReadVarsFile(write("env:\n  name: prod\n"))
+		Expect(err).To(MatchError(ContainSubstring("must be a scalar")))
+	})
+})
@@ -1,61 +1,141 @@
 package executor
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/JulzDiverse/aviator"
+	"github.com/JulzDiverse/aviator/tracer"
 	"github.com/pkg/errors"
 	"github.com/starkandwayne/goutils/ansi"
 )
 
 type Executor struct {
-	silent bool
+	silent  bool
+	stdout  io.Writer
+	stderr  io.Writer
+	outputs map[string]string
+	sandbox *aviator.Sandbox
+
+	inactivityTimeout time.Duration
 }
 
 func New(silent bool) *Executor {
 	return &Executor{
-		silent: silent,
+		silent:  silent,
+		stdout:  os.Stdout,
+		stderr:  os.Stderr,
+		outputs: map[string]string{},
 	}
 }
 
+// StreamTo streams the output of commands to stdout and stderr instead of
+// the ones of aviator.
+func (e *Executor) StreamTo(stdout, stderr io.Writer) {
+	e.stdout, e.stderr = stdout, stderr
+}
+
+func (e *Executor) SetInactivityTimeout(timeout time.Duration) {
+	e.inactivityTimeout = timeout
+}
+
 func (e *Executor) Execute(cmds []*exec.Cmd) error {
+	return e.ExecuteAndExport(cmds, "")
+}
+
+func (e *Executor) ExecuteAndExport(cmds []*exec.Cmd, export string) error {
+	output, err := e.ExecuteAndCapture(cmds)
+	if err != nil {
+		return err
+	}
+
+	if export != "" {
+		e.outputs[export] = strings.TrimSpace(string(output))
+	}
+	return nil
+}
+
+func (e *Executor) ExecuteAndCapture(cmds []*exec.Cmd) ([]byte, error) {
+	var output bytes.Buffer
 	for _, c := range cmds {
+		err := e.resolveOutputs(c)
+		if err != nil {
+			return nil, err
+		}
 		if !e.silent {
 			fmt.Println(stringifyCmd(c))
 		}
-		err := e.execCmd(c)
+		out, err := tracer.Default().Exec(c, func() ([]byte, error) {
+			var out bytes.Buffer
+			err := e.execIsolated(c, &out)
+			return out.Bytes(), err
+		})
 		if err != nil {
-			return err
+			return nil, err
 		}
+		output.Write(out)
 		if !e.silent {
 			fmt.Println("")
 		}
 	}
+	return output.Bytes(), nil
+}
 
-	return nil
+func (e *Executor) execIsolated(cmd *exec.Cmd, output io.Writer) error {
+	name := filepath.Base(cmd.Path)
+	cleanup, err := e.isolate(cmd)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	return e.execCmd(cmd, name, output)
 }
 
-func (e *Executor) execCmd(cmd *exec.Cmd) error {
+func (e *Executor) execCmd(cmd *exec.Cmd, name string, output io.Writer) error {
+	stdout := newPrefixWriter(e.stdout, name)
+	stderr := newPrefixWriter(e.stderr, name)
+	defer stdout.Flush()
+	defer stderr.Flush()
+
+	// a command waiting at a prompt, e.g. of fly set-pipeline, is not hanging
+	watchdog := &watchdog{timeout: e.inactivityTimeout}
+	if stdinIsTerminal() {
+		watchdog.timeout = 0
+	}
 	if !e.silent {
-		cmd.Stdout = os.Stdout
+		cmd.Stdout = watchdog.Wrap(io.MultiWriter(stdout, output))
+	} else {
+		cmd.Stdout = watchdog.Wrap(output)
 	}
 	cmd.Stdin = os.Stdin
-	cmd.Stderr = os.Stderr
+	cmd.Stderr = watchdog.Wrap(stderr)
+	if watchdog.timeout > 0 {
+		cmd.WaitDelay = time.Second
+	}
 
-	err := cmd.Run()
+	err := cmd.Start()
 	if err != nil {
-		return errors.Wrap(err, ansi.Sprintf("@R{Failed to run %s}", cmd.Path))
+		return errors.Wrap(err, ansi.Sprintf("@R{Failed to run %s}", name))
+	}
+
+	killed := watchdog.Watch(cmd.Process)
+	err = cmd.Wait()
+	if killed() {
+		return ansi.Errorf("@R{Killed %s: no output for} @m{%s}", name, watchdog.timeout)
+	}
+	if err != nil {
+		return errors.Wrap(err, ansi.Sprintf("@R{Failed to run %s}", name))
 	}
 
 	return nil
 }
 
 func stringifyCmd(cmd *exec.Cmd) string {
-	result := ""
-	result = ansi.Sprintf("@G{AVIATOR EXECUTE:$} %s", cmd.Args[0])
-	for i := 1; i < len(cmd.Args); i++ {
-		result = fmt.Sprintf("%s %s", result, cmd.Args[i])
-	}
-	return result
+	return ansi.Sprintf("@G{AVIATOR EXECUTE:$} %s", QuoteArgs(cmd.Args))
 }
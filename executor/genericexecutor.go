@@ -3,6 +3,7 @@ package executor
 import (
 	"os/exec"
 	"reflect"
+	"strings"
 
 	"github.com/JulzDiverse/aviator"
 	"github.com/pkg/errors"
@@ -48,6 +49,11 @@ func (e GenericExecutor) Command(cfg interface{}) ([]*exec.Cmd, error) {
 			}
 		}
 
+		if exe.Shell {
+			script := strings.Join(append([]string{exe.Executable}, args...), " ")
+			cmds = append(cmds, exec.Command("sh", "-c", script))
+			continue
+		}
 		cmds = append(cmds, exec.Command(exe.Executable, args...))
 	}
 
@@ -181,6 +181,51 @@ var _ = Describe("Genericexecutor", func() {
 			})
 		})
 	})
+
+	Context("When shell is set", func() {
+		BeforeEach(func() {
+			cfg = []aviator.Executable{
+				{
+					Executable: "kubectl get pods",
+					Args:       []string{"|", "grep", "app-*"},
+					Shell:      true,
+				},
+			}
+		})
+
+		It("runs the command line in sh", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cmds[0].Args).To(Equal([]string{"sh", "-c", "kubectl get pods | grep app-*"}))
+		})
+	})
+
+	Context("When shell is not set", func() {
+		BeforeEach(func() {
+			cfg = []aviator.Executable{
+				{
+					Executable: "echo",
+					Args:       []string{"two words", "*.yml"},
+				},
+			}
+		})
+
+		It("passes every arg unchanged", func() {
+			Expect(cmds[0].Args).To(Equal([]string{"echo", "two words", "*.yml"}))
+		})
+	})
+})
+
+var _ = Describe("Quote", func() {
+	It("leaves safe args unquoted", func() {
+		Expect(Quote("--file=deploy/app.yml")).To(Equal("--file=deploy/app.yml"))
+	})
+
+	It("quotes args a shell would interpret", func() {
+		Expect(Quote("")).To(Equal("''"))
+		Expect(Quote("two words")).To(Equal("'two words'"))
+		Expect(Quote("it's $HOME")).To(Equal(`'it'\''s $HOME'`))
+		Expect(QuoteArgs([]string{"sh", "-c", "ls *.yml"})).To(Equal("sh -c 'ls *.yml'"))
+	})
 })
 
 func stringifyCmd(cmd *exec.Cmd) string {
@@ -0,0 +1,78 @@
+package executor
+
+import (
+	"fmt"
+	"os/exec"
+	"reflect"
+	"sort"
+
+	"github.com/JulzDiverse/aviator"
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+const (
+	helmTemplate = "template"
+	helmUpgrade  = "upgrade"
+
+	ValuesSchemaValidate = "validate"
+	ValuesSchemaCoerce   = "coerce"
+)
+
+type HelmExecutor struct{}
+
+func (e HelmExecutor) Command(cfg interface{}) ([]*exec.Cmd, error) {
+	helm, ok := cfg.(aviator.Helm)
+	if !ok {
+		return []*exec.Cmd{}, errors.New(ansi.Sprintf("@R{Type Assertion failed! Cannot assert %s to %s}", reflect.TypeOf(cfg), "aviator.Helm"))
+	}
+
+	if helm.Release == "" || helm.Chart == "" {
+		return []*exec.Cmd{}, ansi.Errorf("@R{The helm executor requires} @m{release} @R{and} @m{chart}")
+	}
+
+	switch helm.ValuesSchema {
+	case "", ValuesSchemaValidate, ValuesSchemaCoerce:
+	default:
+		return []*exec.Cmd{}, ansi.Errorf("@R{helm values_schema must be one of} @m{validate}@R{,} @m{coerce}@R{, got} @m{%s}", helm.ValuesSchema)
+	}
+
+	var args []string
+	switch helm.Command {
+	case helmTemplate:
+		if helm.Wait {
+			return []*exec.Cmd{}, ansi.Errorf("@m{wait} @R{is only supported by} @m{helm upgrade}")
+		}
+		args = []string{helmTemplate, helm.Release, helm.Chart}
+	case "", helmUpgrade:
+		if helm.To != "" {
+			return []*exec.Cmd{}, ansi.Errorf("@m{to} @R{is only supported by} @m{helm template}")
+		}
+		args = []string{helmUpgrade, "--install", helm.Release, helm.Chart}
+	default:
+		return []*exec.Cmd{}, ansi.Errorf("@R{helm command must be one of} @m{template}@R{,} @m{upgrade}@R{, got} @m{%s}", helm.Command)
+	}
+
+	if helm.Namespace != "" {
+		args = append(args, "--namespace", helm.Namespace)
+	}
+
+	for _, values := range helm.Values {
+		args = append(args, "--values", values)
+	}
+
+	keys := []string{}
+	for k := range helm.Set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		args = append(args, "--set", fmt.Sprintf("%s=%s", k, helm.Set[k]))
+	}
+
+	if helm.Wait {
+		args = append(args, "--wait")
+	}
+
+	return []*exec.Cmd{exec.Command("helm", args...)}, nil
+}
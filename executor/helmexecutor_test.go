@@ -0,0 +1,85 @@
+package executor_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/JulzDiverse/aviator"
+	. "github.com/JulzDiverse/aviator/executor"
+)
+
+var _ = Describe("HelmExecutor", func() {
+
+	var helm aviator.Helm
+
+	BeforeEach(func() {
+		helm = aviator.Helm{
+			Release:   "app",
+			Chart:     "charts/app",
+			Namespace: "prod",
+			Values:    []string{"values/common.yml", "values/prod.yml"},
+			Set:       map[string]string{"replicas": "3", "image.tag": "v1"},
+		}
+	})
+
+	It("upgrades or installs the release by default", func() {
+		helm.Wait = true
+		cmds, err := HelmExecutor{}.Command(helm)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cmds).To(HaveLen(1))
+		Expect(cmds[0].Args).To(Equal([]string{
+			"helm", "upgrade", "--install", "app", "charts/app",
+			"--namespace", "prod",
+			"--values", "values/common.yml", "--values", "values/prod.yml",
+			"--set", "image.tag=v1", "--set", "replicas=3",
+			"--wait",
+		}))
+	})
+
+	It("renders the release with helm template", func() {
+		helm.Command = "template"
+		helm.Set = nil
+		cmds, err := HelmExecutor{}.Command(helm)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cmds[0].Args).To(Equal([]string{
+			"helm", "template", "app", "charts/app",
+			"--namespace", "prod",
+			"--values", "values/common.yml", "--values", "values/prod.yml",
+		}))
+	})
+
+	It("rejects options which don't apply to the command", func() {
+		helm.Command = "template"
+		helm.Wait = true
+		_, err := HelmExecutor{}.Command(helm)
+		Expect(err).To(MatchError(ContainSubstring("wait")))
+
+		helm.Command = ""
+		helm.Wait = false
+		helm.To = "manifests/app.yml"
+		_, err = HelmExecutor{}.Command(helm)
+		Expect(err).To(MatchError(ContainSubstring("helm template")))
+	})
+
+	It("requires a release and a chart", func() {
+		helm.Chart = ""
+		_, err := HelmExecutor{}.Command(helm)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("rejects unknown commands", func() {
+		helm.Command = "install"
+		_, err := HelmExecutor{}.Command(helm)
+		Expect(err).To(MatchError(ContainSubstring("install")))
+	})
+
+	It("rejects unknown values_schema modes", func() {
+		helm.ValuesSchema = "coerce"
+		_, err := HelmExecutor{}.Command(helm)
+		Expect(err).ToNot(HaveOccurred())
+
+		helm.ValuesSchema = "strict"
+		_, err = HelmExecutor{}.Command(helm)
+		Expect(err).To(MatchError(ContainSubstring("strict")))
+	})
+})
@@ -19,6 +19,7 @@ const (
 	validateFlag  = "--validate"
 	outputFlag    = "--output"
 	recursiveFlag = "--recursive"
+	contextFlag   = "--context"
 )
 
 type KubeExecutor struct{}
@@ -66,5 +67,9 @@ func (e KubeExecutor) Command(cfg interface{}) ([]*exec.Cmd, error) {
 		args = append(args, outputFlag, apply.Output)
 	}
 
+	if apply.Context != "" {
+		args = append(args, contextFlag, apply.Context)
+	}
+
 	return []*exec.Cmd{exec.Command("kubectl", args...)}, nil
 }
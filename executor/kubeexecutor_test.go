@@ -129,5 +129,21 @@ var _ = Describe("Kubeexecutor", func() {
 				Expect(args).To(ContainElement("kustomize/dir"))
 			})
 		})
+
+		Context("When 'context' is set", func() {
+
+			BeforeEach(func() {
+				kubeCtl = aviator.Kube{
+					aviator.KubeApply{
+						File:    "kube.yaml",
+						Context: "staging",
+					},
+				}
+			})
+
+			It("should apply to the given context", func() {
+				Expect(args[len(args)-2:]).To(Equal([]string{"--context", "staging"}))
+			})
+		})
 	})
 })
@@ -0,0 +1,43 @@
+package executor
+
+import (
+	"os/exec"
+	"regexp"
+
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+var outputFormatRegex = regexp.MustCompile(`\(\(\s*steps\.([-\w\p{L}]+)\s*\)\)`)
+
+func (e *Executor) Outputs() map[string]string {
+	return e.outputs
+}
+
+// resolveOutputs replaces the step outputs in the args of cmd. An output
+// always ends up in the arg it is referenced in; in the script of a shell
+// it is quoted, so the shell neither splits nor interprets it.
+func (e *Executor) resolveOutputs(cmd *exec.Cmd) error {
+	var err error
+	script := shellScript(cmd)
+	for i, arg := range cmd.Args {
+		cmd.Args[i] = outputFormatRegex.ReplaceAllStringFunc(arg, func(match string) string {
+			name := outputFormatRegex.FindStringSubmatch(match)[1]
+			val, ok := e.outputs[name]
+			if !ok {
+				err = errors.New(ansi.Sprintf("@R{Step output} @m{(( steps.%s ))} @R{not exported by a previous step}", name))
+			}
+			if i == script {
+				return Quote(val)
+			}
+			return val
+		})
+	}
+	return err
+}
+
+func (e *Executor) SetOutputs(outputs map[string]string) {
+	for name, value := range outputs {
+		e.outputs[name] = value
+	}
+}
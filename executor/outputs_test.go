@@ -0,0 +1,69 @@
+package executor_test
+
+import (
+	"os/exec"
+
+	. "github.com/JulzDiverse/aviator/executor"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Step Outputs", func() {
+
+	var executor *Executor
+
+	BeforeEach(func() {
+		executor = New(true)
+	})
+
+	It("exports the trimmed stdout of a step", func() {
+		err := executor.ExecuteAndExport([]*exec.Cmd{exec.Command("echo", "  rev-42  ")}, "revision")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(executor.Outputs()).To(HaveKeyWithValue("revision", "rev-42"))
+	})
+
+	It("captures the complete stdout of a step", func() {
+		output, err := executor.ExecuteAndCapture([]*exec.Cmd{exec.Command("echo", "kind: Service")})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(output)).To(Equal("kind: Service\n"))
+	})
+
+	It("resolves exported outputs in the args of later steps", func() {
+		err := executor.ExecuteAndExport([]*exec.Cmd{exec.Command("echo", "42")}, "revision")
+		Expect(err).ToNot(HaveOccurred())
+
+		err = executor.ExecuteAndExport([]*exec.Cmd{exec.Command("echo", "release-(( steps.revision ))")}, "release")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(executor.Outputs()).To(HaveKeyWithValue("release", "release-42"))
+	})
+
+	It("resolves outputs set from outside of a step", func() {
+		executor.SetOutputs(map[string]string{"version": "1.4.2"})
+
+		err := executor.ExecuteAndExport([]*exec.Cmd{exec.Command("echo", "v(( steps.version ))")}, "tag")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(executor.Outputs()).To(HaveKeyWithValue("tag", "v1.4.2"))
+	})
+
+	It("passes outputs unchanged as a single arg", func() {
+		executor.SetOutputs(map[string]string{"message": "it's $HOME; `date`"})
+
+		err := executor.ExecuteAndExport([]*exec.Cmd{exec.Command("echo", "(( steps.message ))")}, "echoed")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(executor.Outputs()).To(HaveKeyWithValue("echoed", "it's $HOME; `date`"))
+	})
+
+	It("quotes outputs in shell scripts", func() {
+		executor.SetOutputs(map[string]string{"message": "it's $HOME; `date`"})
+
+		err := executor.ExecuteAndExport([]*exec.Cmd{exec.Command("sh", "-c", "echo (( steps.message )) | tr a-z A-Z")}, "echoed")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(executor.Outputs()).To(HaveKeyWithValue("echoed", "IT'S $HOME; `DATE`"))
+	})
+
+	It("fails if a referenced output was not exported", func() {
+		err := executor.Execute([]*exec.Cmd{exec.Command("echo", "(( steps.unknown ))")})
+		Expect(err).To(MatchError(ContainSubstring("steps.unknown")))
+	})
+})
@@ -0,0 +1,53 @@
+package executor
+
+import (
+	"github.com/JulzDiverse/aviator"
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+const (
+	PolicyAbort    = "abort"
+	PolicyContinue = "continue"
+	PolicyRetry    = "retry"
+	PolicyRunHook  = "run-hook"
+
+	defaultRetries = 3
+)
+
+func (e *Executor) RunWithPolicy(policy aviator.FailurePolicy, run func() error) error {
+	err := run()
+	if err == nil {
+		return nil
+	}
+
+	switch policy.OnFailure {
+	case PolicyRetry:
+		retries := policy.Retries
+		if retries <= 0 {
+			retries = defaultRetries
+		}
+		for i := 1; i <= retries && err != nil; i++ {
+			if !e.silent {
+				ansi.Printf("@Y{RETRY %d/%d}: %s\n\n", i, retries, err.Error())
+			}
+			err = run()
+		}
+		return err
+	case PolicyContinue:
+		if !e.silent {
+			ansi.Printf("@Y{FAILED (continuing)}: %s\n\n", err.Error())
+		}
+		return nil
+	case PolicyRunHook:
+		cmds, hookErr := GenericExecutor{}.Command(policy.FailureHook)
+		if hookErr == nil {
+			hookErr = e.Execute(cmds)
+		}
+		if hookErr != nil {
+			return errors.Wrap(err, ansi.Sprintf("@R{Failure Hook FAILED}: %s", hookErr.Error()))
+		}
+		return err
+	}
+	return err
+}
@@ -0,0 +1,103 @@
+package executor_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/JulzDiverse/aviator"
+	. "github.com/JulzDiverse/aviator/executor"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RunWithPolicy", func() {
+	var (
+		executor *Executor
+		policy   aviator.FailurePolicy
+		calls    int
+		failures int
+		err      error
+	)
+
+	BeforeEach(func() {
+		executor = New(true)
+		policy = aviator.FailurePolicy{}
+		calls = 0
+		failures = 1
+	})
+
+	JustBeforeEach(func() {
+		err = executor.RunWithPolicy(policy, func() error {
+			calls++
+			if calls <= failures {
+				return errors.New("failed")
+			}
+			return nil
+		})
+	})
+
+	Context("by default", func() {
+		It("aborts on the first failure", func() {
+			Expect(err).To(MatchError("failed"))
+			Expect(calls).To(Equal(1))
+		})
+	})
+
+	Context("When on_failure is 'continue'", func() {
+		BeforeEach(func() {
+			policy.OnFailure = PolicyContinue
+		})
+
+		It("swallows the error", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(calls).To(Equal(1))
+		})
+	})
+
+	Context("When on_failure is 'retry'", func() {
+		BeforeEach(func() {
+			policy.OnFailure = PolicyRetry
+			failures = 2
+		})
+
+		It("retries until the run succeeds", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(calls).To(Equal(3))
+		})
+
+		Context("and the retries are exhausted", func() {
+			BeforeEach(func() {
+				policy.Retries = 1
+			})
+
+			It("returns the last error", func() {
+				Expect(err).To(MatchError("failed"))
+				Expect(calls).To(Equal(2))
+			})
+		})
+	})
+
+	Context("When on_failure is 'run-hook'", func() {
+		var dir string
+
+		BeforeEach(func() {
+			dir, _ = ioutil.TempDir("", "hook")
+			policy.OnFailure = PolicyRunHook
+			policy.FailureHook = []aviator.Executable{
+				{Executable: "touch", Args: []string{filepath.Join(dir, "hooked")}},
+			}
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("runs the hook and returns the error", func() {
+			Expect(err).To(MatchError("failed"))
+			Expect(filepath.Join(dir, "hooked")).To(BeAnExistingFile())
+		})
+	})
+})
@@ -0,0 +1,40 @@
+package executor
+
+import (
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var safeArg = regexp.MustCompile(`^[-\w@%+=:,./]+$`)
+
+var shells = map[string]bool{"sh": true, "bash": true, "dash": true, "zsh": true}
+
+// Quote quotes arg for a POSIX shell, if it contains anything but letters,
+// digits and a few safe punctuation characters.
+func Quote(arg string) string {
+	if safeArg.MatchString(arg) {
+		return arg
+	}
+	return "'" + strings.Replace(arg, "'", `'\''`, -1) + "'"
+}
+
+// QuoteArgs joins args into a command line which a shell splits into args
+// again.
+func QuoteArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = Quote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// shellScript returns the index of the script in the args of a `sh -c`
+// command, or -1 if cmd does not run a script.
+func shellScript(cmd *exec.Cmd) int {
+	if len(cmd.Args) > 2 && shells[filepath.Base(cmd.Args[0])] && cmd.Args[1] == "-c" {
+		return 2
+	}
+	return -1
+}
@@ -0,0 +1,107 @@
+package executor
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	"github.com/JulzDiverse/aviator"
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+var sandboxEnv = []string{
+	"PATH", "LANG", "LC_ALL", "TERM", "TZ",
+	"SSL_CERT_FILE",
+	"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY",
+	"http_proxy", "https_proxy", "no_proxy",
+}
+
+func (e *Executor) UseSandbox(sandbox aviator.Sandbox) error {
+	if sandbox.Bubblewrap {
+		if runtime.GOOS != "linux" {
+			return ansi.Errorf("@R{Sandboxing with bubblewrap is only supported on linux}")
+		}
+		if _, err := exec.LookPath("bwrap"); err != nil {
+			return errors.Wrap(err, ansi.Sprintf("@R{Sandboxing with bubblewrap requires} @m{bwrap} @R{in PATH}"))
+		}
+	}
+
+	e.sandbox = &sandbox
+	return nil
+}
+
+func (e *Executor) isolate(cmd *exec.Cmd) (func(), error) {
+	noop := func() {}
+	if e.sandbox == nil {
+		return noop, nil
+	}
+
+	home, err := ioutil.TempDir("", "aviator-sandbox")
+	if err != nil {
+		return noop, errors.Wrap(err, ansi.Sprintf("@R{Creating sandbox HOME FAILED}"))
+	}
+	cleanup := func() { os.RemoveAll(home) }
+
+	tmp := filepath.Join(home, "tmp")
+	err = os.Mkdir(tmp, 0700)
+	if err != nil {
+		cleanup()
+		return noop, errors.Wrap(err, ansi.Sprintf("@R{Creating sandbox TMPDIR FAILED}"))
+	}
+
+	cmd.Env = sandboxEnvironment(e.sandbox.PassEnv, home, tmp)
+
+	if e.sandbox.Bubblewrap {
+		err = bubblewrap(cmd, home)
+		if err != nil {
+			cleanup()
+			return noop, err
+		}
+	}
+	return cleanup, nil
+}
+
+func sandboxEnvironment(passEnv []string, home, tmp string) []string {
+	env := []string{"HOME=" + home, "TMPDIR=" + tmp}
+	for _, name := range append(sandboxEnv, passEnv...) {
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+	return env
+}
+
+func bubblewrap(cmd *exec.Cmd, home string) error {
+	bwrap, err := exec.LookPath("bwrap")
+	if err != nil {
+		return errors.Wrap(err, ansi.Sprintf("@R{Sandboxing with bubblewrap requires} @m{bwrap} @R{in PATH}"))
+	}
+
+	args := []string{
+		"bwrap",
+		"--ro-bind", "/", "/",
+		"--dev", "/dev",
+		"--proc", "/proc",
+		"--tmpfs", "/tmp",
+	}
+	if realHome, err := os.UserHomeDir(); err == nil && realHome != "" {
+		args = append(args, "--tmpfs", realHome)
+	}
+	if dir, err := os.Getwd(); err == nil {
+		args = append(args, "--ro-bind", dir, dir)
+	}
+	args = append(args,
+		"--bind", home, home,
+		"--unshare-all", "--share-net",
+		"--die-with-parent",
+		"--",
+		cmd.Path,
+	)
+
+	cmd.Args = append(args, cmd.Args[1:]...)
+	cmd.Path = bwrap
+	return nil
+}
@@ -0,0 +1,64 @@
+package executor_test
+
+import (
+	"os"
+	"os/exec"
+
+	"github.com/JulzDiverse/aviator"
+	. "github.com/JulzDiverse/aviator/executor"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sandbox", func() {
+	var executor *Executor
+
+	BeforeEach(func() {
+		executor = New(true)
+		os.Setenv("AVIATOR_TEST_SECRET", "s3cr3t")
+		os.Setenv("AVIATOR_TEST_PASSED", "passed")
+	})
+
+	AfterEach(func() {
+		os.Unsetenv("AVIATOR_TEST_SECRET")
+		os.Unsetenv("AVIATOR_TEST_PASSED")
+	})
+
+	run := func(script string) string {
+		err := executor.ExecuteAndExport([]*exec.Cmd{exec.Command("sh", "-c", script)}, "out")
+		Expect(err).ToNot(HaveOccurred())
+		return executor.Outputs()["out"]
+	}
+
+	It("passes the environment through when not enabled", func() {
+		Expect(run("echo $AVIATOR_TEST_SECRET")).To(Equal("s3cr3t"))
+	})
+
+	Context("When enabled", func() {
+		BeforeEach(func() {
+			err := executor.UseSandbox(aviator.Sandbox{
+				Enabled: true,
+				PassEnv: []string{"AVIATOR_TEST_PASSED"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("filters environment variables not explicitly passed", func() {
+			Expect(run("echo \"$AVIATOR_TEST_SECRET|$AVIATOR_TEST_PASSED\"")).To(Equal("|passed"))
+		})
+
+		It("keeps PATH so executables can still be found", func() {
+			Expect(run("echo $PATH")).To(Equal(os.Getenv("PATH")))
+		})
+
+		It("runs every command with a fresh temporary HOME which is removed afterwards", func() {
+			home := run("echo $HOME")
+			Expect(home).ToNot(BeEmpty())
+			Expect(home).ToNot(Equal(os.Getenv("HOME")))
+			Expect(home).ToNot(BeADirectory())
+
+			Expect(run("echo $HOME")).ToNot(Equal(home))
+		})
+	})
+})
@@ -0,0 +1,120 @@
+package executor
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-isatty"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+// prefixWriter prefixes every line with the name of the command. Partial
+// lines are written right away, so prompts without a trailing newline are
+// shown before the command waits for input.
+type prefixWriter struct {
+	out     io.Writer
+	prefix  string
+	midLine bool
+	mu      sync.Mutex
+}
+
+func newPrefixWriter(out io.Writer, name string) *prefixWriter {
+	return &prefixWriter{out: out, prefix: ansi.Sprintf("@C{%s |} ", name)}
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var out bytes.Buffer
+	for rest := p; len(rest) != 0; {
+		if !w.midLine {
+			out.WriteString(w.prefix)
+		}
+		line := rest
+		if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+			line = rest[:i+1]
+		}
+		out.Write(line)
+		w.midLine = line[len(line)-1] != '\n'
+		rest = rest[len(line):]
+	}
+	if _, err := w.out.Write(out.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush ends a partial last line.
+func (w *prefixWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.midLine {
+		io.WriteString(w.out, "\n")
+		w.midLine = false
+	}
+}
+
+type watchdog struct {
+	timeout time.Duration
+	timer   *time.Timer
+	fired   bool
+	mu      sync.Mutex
+}
+
+// stdinIsTerminal reports whether commands may wait for input typed by a
+// user, which is no inactivity.
+func stdinIsTerminal() bool {
+	return isatty.IsTerminal(os.Stdin.Fd())
+}
+
+func (w *watchdog) Wrap(out io.Writer) io.Writer {
+	if w.timeout <= 0 {
+		return out
+	}
+	return activityWriter{out, w}
+}
+
+func (w *watchdog) Watch(process *os.Process) func() bool {
+	if w.timeout <= 0 {
+		return func() bool { return false }
+	}
+
+	w.mu.Lock()
+	w.timer = time.AfterFunc(w.timeout, func() {
+		w.mu.Lock()
+		w.fired = true
+		w.mu.Unlock()
+		process.Kill()
+	})
+	w.mu.Unlock()
+
+	return func() bool {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		w.timer.Stop()
+		return w.fired
+	}
+}
+
+func (w *watchdog) reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timer != nil && !w.fired {
+		w.timer.Reset(w.timeout)
+	}
+}
+
+type activityWriter struct {
+	out      io.Writer
+	watchdog *watchdog
+}
+
+func (w activityWriter) Write(p []byte) (int, error) {
+	w.watchdog.reset()
+	return w.out.Write(p)
+}
@@ -0,0 +1,79 @@
+package executor_test
+
+import (
+	"os/exec"
+	"time"
+
+	. "github.com/JulzDiverse/aviator/executor"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Inactivity Timeout", func() {
+	var executor *Executor
+
+	BeforeEach(func() {
+		executor = New(true)
+		executor.SetInactivityTimeout(300 * time.Millisecond)
+	})
+
+	run := func(script string) error {
+		return executor.ExecuteAndExport([]*exec.Cmd{exec.Command("sh", "-c", script)}, "out")
+	}
+
+	It("kills commands which produce no output within the timeout", func() {
+		start := time.Now()
+		err := run("sleep 5")
+		Expect(err).To(MatchError(ContainSubstring("no output for")))
+		Expect(time.Since(start)).To(BeNumerically("<", 4*time.Second))
+	})
+
+	It("keeps commands alive as long as they produce output", func() {
+		Expect(run("for i in 1 2 3 4 5; do echo $i; sleep 0.1; done")).To(Succeed())
+		Expect(executor.Outputs()["out"]).To(Equal("1\n2\n3\n4\n5"))
+	})
+
+	It("counts output on stderr as activity", func() {
+		Expect(run("for i in 1 2 3 4 5; do echo $i >&2; sleep 0.1; done")).To(Succeed())
+	})
+
+	It("does not time out when disabled", func() {
+		executor.SetInactivityTimeout(0)
+		Expect(run("sleep 0.5")).To(Succeed())
+	})
+})
+
+var _ = Describe("Streaming", func() {
+	var (
+		executor       *Executor
+		stdout, stderr *gbytes.Buffer
+	)
+
+	BeforeEach(func() {
+		executor = New(false)
+		stdout, stderr = gbytes.NewBuffer(), gbytes.NewBuffer()
+		executor.StreamTo(stdout, stderr)
+		executor.SetInactivityTimeout(5 * time.Second)
+	})
+
+	It("prefixes every line with the name of the command", func() {
+		Expect(executor.Execute([]*exec.Cmd{exec.Command("sh", "-c", "echo one; echo two >&2; echo three")})).To(Succeed())
+		Expect(string(stdout.Contents())).To(MatchRegexp(`sh \|.* one\n`))
+		Expect(string(stdout.Contents())).To(MatchRegexp(`sh \|.* three\n$`))
+		Expect(string(stderr.Contents())).To(MatchRegexp(`sh \|.* two\n$`))
+	})
+
+	It("shows prompts without a trailing newline while the command waits", func() {
+		done := make(chan error, 1)
+		go func() {
+			done <- executor.Execute([]*exec.Cmd{exec.Command("sh", "-c", "printf 'apply configuration? [yN]: '; sleep 1; echo n")})
+		}()
+
+		Eventually(stdout, 500*time.Millisecond).Should(gbytes.Say(`apply configuration\? \[yN\]: `))
+		Consistently(done, 200*time.Millisecond).ShouldNot(Receive())
+		Eventually(done, 3*time.Second).Should(Receive(BeNil()))
+		Expect(string(stdout.Contents())).To(MatchRegexp(`apply configuration\? \[yN\]: n\n$`))
+	})
+})
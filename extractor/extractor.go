@@ -0,0 +1,74 @@
+package extractor
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/JulzDiverse/aviator/asserter"
+	"github.com/starkandwayne/goutils/ansi"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var docSeparator = regexp.MustCompile(`(?m)^---\s*$`)
+
+func Extract(file []byte, vars map[string]string) (map[string]string, error) {
+	docs := []interface{}{}
+	for i, doc := range docSeparator.Split(string(file), -1) {
+		if strings.TrimSpace(doc) == "" {
+			continue
+		}
+		var parsed interface{}
+		err := yaml.Unmarshal([]byte(doc), &parsed)
+		if err != nil {
+			return nil, ansi.Errorf("@R{Parsing document %d for extraction failed}: %s", i, err.Error())
+		}
+		if parsed != nil {
+			docs = append(docs, parsed)
+		}
+	}
+
+	names := []string{}
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := map[string]string{}
+	for _, name := range names {
+		path := vars[name]
+		found := []interface{}{}
+		for _, doc := range docs {
+			for _, value := range asserter.Lookup(doc, path) {
+				found = append(found, value)
+			}
+		}
+
+		switch len(found) {
+		case 0:
+			return nil, ansi.Errorf("@R{Extracting} @m{%s}@R{: path} @m{%s} @R{does not exist}", name, path)
+		case 1:
+		default:
+			return nil, ansi.Errorf("@R{Extracting} @m{%s}@R{: path} @m{%s} @R{matches %d values, expected exactly one}", name, path, len(found))
+		}
+
+		value, err := stringify(found[0])
+		if err != nil {
+			return nil, ansi.Errorf("@R{Extracting} @m{%s} @R{FAILED}: %s", name, err.Error())
+		}
+		result[name] = value
+	}
+	return result, nil
+}
+
+func stringify(value interface{}) (string, error) {
+	switch value.(type) {
+	case map[interface{}]interface{}, []interface{}:
+		out, err := yaml.Marshal(value)
+		return strings.TrimSpace(string(out)), err
+	case nil:
+		return "", nil
+	}
+	return fmt.Sprint(value), nil
+}
@@ -0,0 +1,13 @@
+package extractor_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestExtractor(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Extractor Suite")
+}
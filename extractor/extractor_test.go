@@ -0,0 +1,59 @@
+package extractor_test
+
+import (
+	. "github.com/JulzDiverse/aviator/extractor"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Extractor", func() {
+
+	manifest := []byte(`---
+kind: Service
+metadata:
+  name: app
+---
+kind: Deployment
+metadata:
+  labels:
+    version: 1.4.2
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: app
+        image: registry.example.com/app:1.4.2
+`)
+
+	It("extracts the values at the given paths", func() {
+		vars, err := Extract(manifest, map[string]string{
+			"version":  "metadata.labels.version",
+			"replicas": "spec.replicas",
+			"image":    "spec.template.spec.containers.0.image",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vars).To(Equal(map[string]string{
+			"version":  "1.4.2",
+			"replicas": "3",
+			"image":    "registry.example.com/app:1.4.2",
+		}))
+	})
+
+	It("extracts documents as YAML", func() {
+		vars, err := Extract(manifest, map[string]string{"labels": "metadata.labels"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vars).To(HaveKeyWithValue("labels", "version: 1.4.2"))
+	})
+
+	It("fails if a path does not exist", func() {
+		_, err := Extract(manifest, map[string]string{"missing": "spec.missing"})
+		Expect(err).To(MatchError(ContainSubstring("does not exist")))
+	})
+
+	It("fails if a path is ambiguous", func() {
+		_, err := Extract(manifest, map[string]string{"kind": "kind"})
+		Expect(err).To(MatchError(ContainSubstring("matches 2 values")))
+	})
+})
@@ -0,0 +1,46 @@
+package filemanager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// writeAtomic writes file to a temp file next to path and renames it over
+// path, so readers never see a partially written target. The mode of an
+// existing target is kept unless perm sets one; a symlinked target is
+// replaced at the link's destination.
+func writeAtomic(path string, file []byte, perm Permissions) error {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if perm.Mode != 0 {
+		mode = perm.Mode
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(file)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), mode)
+	}
+	if err != nil {
+		return err
+	}
+	chown(tmp.Name(), perm.Owner)
+	return os.Rename(tmp.Name(), path)
+}
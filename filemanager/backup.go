@@ -0,0 +1,99 @@
+package filemanager
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+// original is the content of a target before the run first wrote it.
+type original struct {
+	content []byte
+	existed bool
+	// linked is set if the run replaced the target with a symlink, which
+	// must not be followed when restoring it.
+	linked bool
+}
+
+// KeepBackups keeps the previous content of every target the run changes
+// as <target>.bak.
+func (ds *FileManager) KeepBackups() {
+	ds.backups = true
+}
+
+// EnableRollback remembers the previous content of every target the run
+// writes, so Rollback can restore them.
+func (ds *FileManager) EnableRollback() {
+	ds.originals = map[string]original{}
+}
+
+// Rollback restores all targets written since EnableRollback: previous
+// content is written back and targets which did not exist are removed. It
+// returns the restored targets.
+func (ds *FileManager) Rollback() ([]string, error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	restored := []string{}
+	for i := len(ds.written) - 1; i >= 0; i-- {
+		key := ds.written[i]
+		orig, ok := ds.originals[key]
+		if !ok {
+			continue
+		}
+
+		var err error
+		switch {
+		case orig.existed && orig.linked:
+			// the content is written in place of the link, not through it
+			err = os.Remove(key)
+			if err == nil || os.IsNotExist(err) {
+				err = writeAtomic(key, orig.content, Permissions{})
+			}
+		case orig.existed:
+			err = writeAtomic(key, orig.content, Permissions{})
+		default:
+			err = os.Remove(key)
+			if os.IsNotExist(err) {
+				err = nil
+			}
+		}
+		if err != nil {
+			return restored, ansi.Errorf("@R{Restoring} @m{%s} @R{FAILED}: %s", key, err.Error())
+		}
+		delete(ds.originals, key)
+		restored = append(restored, key)
+	}
+	return restored, nil
+}
+
+// preserve keeps the current content of the target key before the run
+// writes it for the first time.
+func (ds *FileManager) preserve(key string, file []byte) error {
+	if _, ok := ds.digests[key]; ok || (!ds.backups && ds.originals == nil) {
+		return nil
+	}
+
+	current, err := ioutil.ReadFile(key)
+	if err != nil && !os.IsNotExist(err) {
+		return writeError(key, err)
+	}
+	existed := err == nil
+
+	if ds.originals != nil {
+		ds.originals[key] = original{content: current, existed: existed}
+	}
+	if ds.backups && existed && !bytes.Equal(current, file) {
+		// the backup may hold secrets, so it is as accessible as the target
+		perm := Permissions{}
+		if info, err := os.Stat(key); err == nil {
+			perm.Mode = info.Mode().Perm()
+		}
+		if err := writeAtomic(key+".bak", current, perm); err != nil {
+			return writeError(key+".bak", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package filemanager
+
+import "sort"
+
+// CaptureWrites keeps files written to disk in memory instead. Later reads of
+// a captured file return the captured content.
+func (ds *FileManager) CaptureWrites() {
+	ds.captured = map[string][]byte{}
+}
+
+// Captured returns the paths of the captured files, sorted.
+func (ds *FileManager) Captured() []string {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	paths := []string{}
+	for path := range ds.captured {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// CapturedFile returns the captured content of path.
+func (ds *FileManager) CapturedFile(path string) []byte {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	return ds.captured[path]
+}
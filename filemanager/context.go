@@ -0,0 +1,43 @@
+package filemanager
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+// UseContext aborts directory scans of the store as soon as ctx is done.
+func (ds *FileManager) UseContext(ctx context.Context) {
+	ds.ctx = ctx
+}
+
+// IsCanceled reports whether err stems from an aborted directory scan.
+func IsCanceled(err error) bool {
+	cause := errors.Cause(err)
+	return cause == context.Canceled || cause == context.DeadlineExceeded
+}
+
+func (ds *FileManager) canceled(path string) error {
+	if ds.ctx == nil || ds.ctx.Err() == nil {
+		return nil
+	}
+	return errors.Wrap(ds.ctx.Err(), ansi.Sprintf("@R{Scanning} @m{%s} @R{canceled}", path))
+}
+
+func (ds *FileManager) fillSliceWithFiles(root string, files *[]string) filepath.WalkFunc {
+	return func(path string, info os.FileInfo, err error) error {
+		if err := ds.canceled(root); err != nil {
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			*files = append(*files, path)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,91 @@
+package filemanager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+func (ds *FileManager) CopyFile(src, dest string, symlink bool) error {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	src = ds.Resolve(src)
+	info, err := os.Stat(src)
+	if err != nil {
+		return errors.Wrap(err, ansi.Sprintf("@R{Reading} @m{%s} @R{FAILED}", src))
+	}
+	if info.IsDir() {
+		return ansi.Errorf("@R{Copying} @m{%s} @R{FAILED: directories can not be copied}", src)
+	}
+
+	content, err := ioutil.ReadFile(src)
+	if err != nil {
+		return errors.Wrap(err, ansi.Sprintf("@R{Reading} @m{%s} @R{FAILED}", src))
+	}
+	ds.inputs[src] = content
+
+	if ds.DryRun {
+		return nil
+	}
+
+	dest = ds.workspacePath(dest)
+	// copies are written like rendered files, so --backup and --rollback
+	// cover them
+	err = ds.preserve(dest, content)
+	if err != nil {
+		return err
+	}
+	if symlink {
+		if o, ok := ds.originals[dest]; ok {
+			o.linked = true
+			ds.originals[dest] = o
+		}
+		createNonExistingDirs(dest)
+		err = link(src, dest)
+		if err != nil {
+			return writeError(dest, err)
+		}
+	} else {
+		perm := ds.permissions[dest]
+		if perm.Mode == 0 {
+			perm.Mode = info.Mode().Perm()
+		}
+		err = writeWithRetry(dest, content, perm)
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, ok := ds.digests[dest]; !ok {
+		ds.written = append(ds.written, dest)
+	}
+	ds.digests[dest] = digest(content)
+	return nil
+}
+
+func link(src, dest string) error {
+	absSrc, err := filepath.Abs(src)
+	if err != nil {
+		return err
+	}
+	absDest, err := filepath.Abs(dest)
+	if err != nil {
+		return err
+	}
+	target, err := filepath.Rel(filepath.Dir(absDest), absSrc)
+	if err != nil {
+		target = absSrc
+	}
+
+	if _, err := os.Lstat(dest); err == nil {
+		err = os.Remove(dest)
+		if err != nil {
+			return err
+		}
+	}
+	return os.Symlink(target, dest)
+}
@@ -0,0 +1,54 @@
+package filemanager
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"sort"
+
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+func (ds *FileManager) Digests() map[string]string {
+	result := map[string]string{}
+	for k, v := range ds.digests {
+		result[k] = v
+	}
+	return result
+}
+
+func (ds *FileManager) Written() []string {
+	return append([]string{}, ds.written...)
+}
+
+func (ds *FileManager) Inputs() map[string][]byte {
+	result := map[string][]byte{}
+	for k, v := range ds.inputs {
+		result[k] = v
+	}
+	return result
+}
+
+func (ds *FileManager) VerifyDigests() error {
+	keys := []string{}
+	for k := range ds.digests {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		file, err := ioutil.ReadFile(key)
+		if err != nil {
+			return ansi.Errorf("@R{Drift detected}: @m{%s} @R{was written by this run but cannot be read anymore}: %s", key, err.Error())
+		}
+		if digest(file) != ds.digests[key] {
+			return ansi.Errorf("@R{Drift detected}: @m{%s} @R{was modified after it was written by this run}", key)
+		}
+	}
+	return nil
+}
+
+func digest(file []byte) string {
+	sum := sha256.Sum256(file)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,197 @@
+package filemanager
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JulzDiverse/aviator/transport"
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+var (
+	downloadRetries = 4
+	downloadBackoff = 250 * time.Millisecond
+	maxRetryAfter   = 30 * time.Second
+	downloadTimeout = 2 * time.Minute
+)
+
+// retryableError is a failed download attempt which may succeed when
+// retried, after wait if the server asked for it.
+type retryableError struct {
+	err  error
+	wait time.Duration
+}
+
+func (e *retryableError) Error() string {
+	return e.err.Error()
+}
+
+// partialDownload holds the bytes received by an interrupted download and
+// the validator (ETag or Last-Modified) to resume it with.
+type partialDownload struct {
+	data      []byte
+	validator string
+}
+
+// download reads a remote file. https downloads are retried with backoff on
+// network errors and 408, 429 and 5xx responses, and interrupted transfers
+// are resumed with a range request if the server supports it. Object stores
+// are read once, their CLIs retry on their own.
+func (ds *FileManager) download(location, sum string) ([]byte, error) {
+	ctx := ds.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if cmd := objectStore(location); cmd != nil {
+		args := cmd(location)
+		if profile := ds.auth.AWSProfile(location); profile != "" && strings.HasPrefix(location, "s3://") {
+			args = append(args, "--profile", profile)
+		}
+		file, err := readObject(ctx, args, location)
+		if err == nil && sum != "" && digest(file) != sum {
+			err = checksumError(location, sum, file)
+		}
+		return file, err
+	}
+
+	authorization, err := ds.auth.Header(location)
+	if err != nil {
+		return nil, err
+	}
+
+	var partial *partialDownload
+	backoff := downloadBackoff
+	for attempt := 0; ; attempt++ {
+		file, resumed, err := downloadAttempt(ctx, location, authorization, &partial)
+		if err == nil && sum != "" && digest(file) != sum {
+			err = checksumError(location, sum, file)
+			if resumed {
+				// the parts may not belong together, start over
+				err = &retryableError{err: err}
+			}
+		}
+		if err == nil {
+			return file, nil
+		}
+
+		retry, ok := err.(*retryableError)
+		if !ok || attempt == downloadRetries || ctx.Err() != nil {
+			if ok {
+				return nil, retry.err
+			}
+			return nil, err
+		}
+
+		wait := backoff
+		if retry.wait > 0 {
+			wait = retry.wait
+		}
+		ansi.Fprintf(os.Stderr, "@Y{WARNING}: %s, retrying in %s (attempt %d of %d)\n", retry.err.Error(), wait, attempt+2, downloadRetries+1)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, retry.err
+		}
+		backoff *= 2
+	}
+}
+
+func downloadAttempt(ctx context.Context, location, authorization string, partial **partialDownload) ([]byte, bool, error) {
+	failed := func(err error) error {
+		return errors.Wrap(err, ansi.Sprintf("@R{Downloading} @m{%s} @R{FAILED}", location))
+	}
+
+	req, err := http.NewRequest(http.MethodGet, location, nil)
+	if err != nil {
+		return nil, false, failed(err)
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	if p := *partial; p != nil {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", len(p.data)))
+		req.Header.Set("If-Range", p.validator)
+	}
+
+	client, err := transport.Client(transport.Remote, downloadTimeout)
+	if err != nil {
+		return nil, false, failed(err)
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, false, &retryableError{err: failed(err)}
+	}
+	defer resp.Body.Close()
+
+	var received []byte
+	resumed := false
+	switch {
+	case resp.StatusCode == http.StatusOK:
+	case resp.StatusCode == http.StatusPartialContent && *partial != nil && rangeStart(resp) == len((*partial).data):
+		received, resumed = (*partial).data, true
+	default:
+		err := ansi.Errorf("@R{Downloading} @m{%s} @R{FAILED}: %s", location, resp.Status)
+		*partial = nil
+		if resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			return nil, false, &retryableError{err: err, wait: retryAfter(resp)}
+		}
+		return nil, false, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	received = append(received, body...)
+	if err != nil {
+		*partial = nil
+		if validator := rangeValidator(resp); validator != "" && len(received) > 0 {
+			*partial = &partialDownload{data: received, validator: validator}
+		}
+		return nil, false, &retryableError{err: failed(err)}
+	}
+	return received, resumed, nil
+}
+
+// rangeValidator returns the validator to resume the download of resp
+// with, or "" if the server does not support range requests.
+func rangeValidator(resp *http.Response) string {
+	if resp.StatusCode == http.StatusOK && resp.Header.Get("Accept-Ranges") != "bytes" {
+		return ""
+	}
+	if etag := resp.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return resp.Header.Get("Last-Modified")
+}
+
+// rangeStart returns the first byte of a 206 response, e.g. 100 for
+// Content-Range: bytes 100-199/200.
+func rangeStart(resp *http.Response) int {
+	var start, end, size int
+	_, err := fmt.Sscanf(resp.Header.Get("Content-Range"), "bytes %d-%d/%d", &start, &end, &size)
+	if err != nil {
+		return -1
+	}
+	return start
+}
+
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	wait := time.Duration(seconds) * time.Second
+	if wait > maxRetryAfter {
+		wait = maxRetryAfter
+	}
+	return wait
+}
+
+func checksumError(location, sum string, file []byte) error {
+	return ansi.Errorf("@R{Checksum mismatch for} @m{%s}@R{: expected sha256} @m{%s}@R{, got} @m{%s}", location, sum, digest(file))
+}
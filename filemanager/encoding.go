@@ -0,0 +1,97 @@
+package filemanager
+
+import (
+	"bytes"
+
+	"github.com/starkandwayne/goutils/ansi"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+	bomUTF32LE = []byte{0xFF, 0xFE, 0x00, 0x00}
+	bomUTF32BE = []byte{0x00, 0x00, 0xFE, 0xFF}
+)
+
+// decode returns file as UTF-8 without a byte order mark. transcoded names
+// the encoding the file was transcoded from, if any.
+func decode(key string, file []byte) (result []byte, transcoded string, err error) {
+	switch {
+	case bytes.HasPrefix(file, bomUTF32LE), bytes.HasPrefix(file, bomUTF32BE):
+		return nil, "", ansi.Errorf("@R{Unsupported encoding} @m{%s}: @R{UTF-32 input files are not supported, please convert it to UTF-8}", key)
+	case bytes.HasPrefix(file, bomUTF8):
+		return bytes.TrimPrefix(file, bomUTF8), "", nil
+	case bytes.HasPrefix(file, bomUTF16LE):
+		return transcodeUTF16(key, file, unicode.LittleEndian, "UTF-16LE")
+	case bytes.HasPrefix(file, bomUTF16BE):
+		return transcodeUTF16(key, file, unicode.BigEndian, "UTF-16BE")
+	case looksLikeUTF16(file):
+		return nil, "", ansi.Errorf("@R{Unsupported encoding} @m{%s}: @R{file looks like UTF-16 without a byte order mark, please convert it to UTF-8}", key)
+	}
+	return file, "", nil
+}
+
+func transcodeUTF16(key string, file []byte, endianness unicode.Endianness, name string) ([]byte, string, error) {
+	decoder := unicode.UTF16(endianness, unicode.ExpectBOM).NewDecoder()
+	result, _, err := transform.Bytes(decoder, file)
+	if err != nil {
+		return nil, "", ansi.Errorf("@R{Failed to transcode} @m{%s} @R{from %s}: %s", key, name, err.Error())
+	}
+	return result, name, nil
+}
+
+// transcoded records that key was transcoded from encoding, once per file.
+// The caller holds ds.mu.
+func (ds *FileManager) transcoded(key, encoding string) {
+	if encoding == "" || ds.encodings[key] {
+		return
+	}
+	if ds.encodings == nil {
+		ds.encodings = map[string]bool{}
+	}
+	ds.encodings[key] = true
+	ds.encodingWarnings = append(ds.encodingWarnings, key+" is encoded as "+encoding+" and was transcoded to UTF-8")
+}
+
+// markBOM records that key starts with a UTF-8 byte order mark, which
+// decode strips. The caller holds ds.mu.
+func (ds *FileManager) markBOM(key string, file []byte) {
+	if !bytes.HasPrefix(file, bomUTF8) {
+		return
+	}
+	if ds.boms == nil {
+		ds.boms = map[string]bool{}
+	}
+	ds.boms[key] = true
+}
+
+// HasBOM reports whether the file read as key started with a UTF-8 byte
+// order mark, which ReadFile stripped.
+func (ds *FileManager) HasBOM(key string) bool {
+	if !IsRemote(key) {
+		key = ds.Resolve(key)
+	}
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	return ds.boms[key]
+}
+
+// EncodingWarnings returns the warnings about transcoded files since the
+// last call.
+func (ds *FileManager) EncodingWarnings() []string {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	warnings := ds.encodingWarnings
+	ds.encodingWarnings = nil
+	return warnings
+}
+
+func looksLikeUTF16(file []byte) bool {
+	if len(file) < 4 {
+		return false
+	}
+	return bytes.IndexByte(file[:4], 0x00) != -1
+}
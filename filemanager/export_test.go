@@ -0,0 +1,22 @@
+package filemanager
+
+import "time"
+
+// FailWrites makes the next writes of WriteFile fail with errs, one error
+// per attempt, and shortens the backoff between the retries to backoff.
+// The returned function restores the real writes.
+func FailWrites(backoff time.Duration, errs ...error) (restore func()) {
+	original, originalBackoff := atomicWrite, writeBackoff
+	atomicWrite = func(path string, file []byte, perm Permissions) error {
+		if len(errs) > 0 {
+			err := errs[0]
+			errs = errs[1:]
+			return err
+		}
+		return original(path, file, perm)
+	}
+	writeBackoff = backoff
+	return func() {
+		atomicWrite, writeBackoff = original, originalBackoff
+	}
+}
@@ -1,13 +1,16 @@
 package filemanager
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 
+	"github.com/JulzDiverse/aviator/authorizer"
 	"github.com/JulzDiverse/mingoak"
 	"github.com/starkandwayne/goutils/ansi"
 )
@@ -16,6 +19,24 @@ type FileManager struct {
 	CurlyBraces bool
 	DryRun      bool
 	root        *mingoak.Dir
+	digests     map[string]string
+	written     []string
+	workspace   string
+	inputs      map[string][]byte
+	mu          sync.Mutex
+	ctx         context.Context
+	cache       string
+	offline     bool
+	auth        *authorizer.Authorizer
+	remote      map[string][]byte
+	captured    map[string][]byte
+	backups     bool
+	originals   map[string]original
+	permissions map[string]Permissions
+
+	encodings        map[string]bool
+	encodingWarnings []string
+	boms             map[string]bool
 }
 
 //var quoteRegexOld = `\{\{([-\_\.\/\w\p{L}\/]+)\}\}`
@@ -26,12 +47,35 @@ var store *FileManager
 
 func Store(curlyBraces, dryRun bool) *FileManager {
 	if store == nil {
-		store = &FileManager{curlyBraces, dryRun, mingoak.MkRoot()}
+		store = New(curlyBraces, dryRun)
 	}
 	return store
 }
 
+func New(curlyBraces, dryRun bool) *FileManager {
+	return &FileManager{
+		CurlyBraces: curlyBraces,
+		DryRun:      dryRun,
+		root:        mingoak.MkRoot(),
+		digests:     map[string]string{},
+		inputs:      map[string][]byte{},
+		remote:      map[string][]byte{},
+	}
+}
+
 func (ds *FileManager) ReadFile(key string) ([]byte, bool) {
+	if IsRemote(key) {
+		return ds.readRemote(key)
+	}
+
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	if file, ok := ds.captured[ds.workspacePath(key)]; ok {
+		return file, true
+	}
+
+	key = ds.Resolve(key)
 	if _, err := os.Stat(key); os.IsNotExist(err) {
 		if re.MatchString(key) {
 			key = getKeyFromRegexp(key)
@@ -46,6 +90,15 @@ func (ds *FileManager) ReadFile(key string) ([]byte, bool) {
 	if err != nil {
 		return nil, false
 	}
+	ds.inputs[key] = file
+	ds.markBOM(key, file)
+
+	file, encoding, err := decode(key, file)
+	if err != nil {
+		ansi.Fprintf(os.Stderr, "%s\n", err.Error())
+		return nil, false
+	}
+	ds.transcoded(key, encoding)
 	return file, true
 }
 
@@ -59,6 +112,9 @@ func (ds *FileManager) ReadFiles(keys []string) [][]byte {
 }
 
 func (ds *FileManager) WriteFile(key string, file []byte) error {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
 	if ds.CurlyBraces {
 		file = dequoteCurlyBraces(file)
 	}
@@ -67,14 +123,23 @@ func (ds *FileManager) WriteFile(key string, file []byte) error {
 		key = getKeyFromRegexp(key)
 		ds.root.MkDirAll(getPathFromFilePath(key))
 		ds.root.WriteFile(key, []byte(file))
+	} else if ds.captured != nil {
+		ds.captured[ds.workspacePath(key)] = file
 	} else {
-		createNonExistingDirs(key)
-
 		if !ds.DryRun {
-			err := ioutil.WriteFile(key, file, 0644)
+			key = ds.workspacePath(key)
+			err := ds.preserve(key, file)
+			if err != nil {
+				return err
+			}
+			err = writeWithRetry(key, file, ds.permissions[key])
 			if err != nil {
-				ansi.Errorf("@R{Error writing file} @m{%s}: %s\n", key, err.Error())
+				return err
+			}
+			if _, ok := ds.digests[key]; !ok {
+				ds.written = append(ds.written, key)
 			}
+			ds.digests[key] = digest(file)
 		} else {
 			ansi.Printf("\n@C{RESULT:}\n")
 			fmt.Println(string(file))
@@ -91,6 +156,10 @@ func getPathFromFilePath(filepath string) string {
 }
 
 func (fm *FileManager) ReadDir(path string) ([]os.FileInfo, error) {
+	if err := fm.canceled(path); err != nil {
+		return nil, err
+	}
+
 	var filePaths []os.FileInfo
 	if re.MatchString(path) {
 
@@ -102,7 +171,7 @@ func (fm *FileManager) ReadDir(path string) ([]os.FileInfo, error) {
 		filePaths = files
 	} else {
 
-		files, err := ioutil.ReadDir(path)
+		files, err := ioutil.ReadDir(fm.Resolve(path))
 		if err != nil {
 			return nil, err
 		}
@@ -113,6 +182,10 @@ func (fm *FileManager) ReadDir(path string) ([]os.FileInfo, error) {
 }
 
 func (fm *FileManager) Walk(path string) ([]string, error) {
+	if err := fm.canceled(path); err != nil {
+		return nil, err
+	}
+
 	sl := []string{}
 	if re.MatchString(path) {
 		path = getKeyFromRegexp(path)
@@ -122,25 +195,20 @@ func (fm *FileManager) Walk(path string) ([]string, error) {
 		}
 		sl = files
 	} else {
-		if _, err := os.Stat(path); os.IsNotExist(err) {
+		resolved := fm.Resolve(path)
+		if _, err := os.Stat(resolved); os.IsNotExist(err) {
 			return nil, err
 		} else {
-			err := filepath.Walk(path, fillSliceWithFiles(&sl))
+			err := filepath.Walk(resolved, fm.fillSliceWithFiles(path, &sl))
 			if err != nil {
 				return nil, err
 			}
 		}
-	}
-	return sl, nil
-}
-
-func fillSliceWithFiles(files *[]string) filepath.WalkFunc {
-	return func(path string, info os.FileInfo, err error) error {
-		if !info.IsDir() {
-			*files = append(*files, path)
+		if resolved != path {
+			sl = fm.trimWorkspace(sl)
 		}
-		return nil
 	}
+	return sl, nil
 }
 
 func createNonExistingDirs(path string) {
@@ -1,6 +1,22 @@
 package filemanager_test
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/JulzDiverse/aviator"
+	"github.com/JulzDiverse/aviator/authorizer"
 	. "github.com/JulzDiverse/aviator/filemanager"
 
 	. "github.com/onsi/ginkgo"
@@ -66,6 +82,17 @@ var _ = Describe("Filemanager", func() {
 			Expect(ok).To(Equal(true))
 			Expect(string(file)).To(ContainSubstring("test:"))
 		})
+
+		It("records the files read from the filesystem as inputs", func() {
+			_, ok := store.ReadFile("integration/fake.yml")
+			Expect(ok).To(Equal(true))
+			store.ReadFile("{{key}}")
+
+			inputs := store.Inputs()
+			Expect(inputs).To(HaveKey("integration/fake.yml"))
+			Expect(inputs).ToNot(HaveKey("{{key}}"))
+			Expect(string(inputs["integration/fake.yml"])).To(ContainSubstring("test:"))
+		})
 	})
 
 	//Context("WriteFile", func() {
@@ -89,3 +116,821 @@ var _ = Describe("Filemanager", func() {
 		})
 	})
 })
+
+var _ = Describe("Encoding", func() {
+
+	var (
+		store *FileManager
+		dir   string
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "aviator-encoding")
+		Expect(err).ToNot(HaveOccurred())
+		store = Store(false, false)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	write := func(name string, content []byte) string {
+		path := filepath.Join(dir, name)
+		Expect(ioutil.WriteFile(path, content, 0644)).To(Succeed())
+		return path
+	}
+
+	It("strips a UTF-8 byte order mark", func() {
+		path := write("bom.yml", append([]byte{0xEF, 0xBB, 0xBF}, []byte("key: value")...))
+		file, ok := store.ReadFile(path)
+		Expect(ok).To(BeTrue())
+		Expect(string(file)).To(Equal("key: value"))
+		Expect(store.HasBOM(path)).To(BeTrue())
+
+		path = write("nobom.yml", []byte("key: value"))
+		store.ReadFile(path)
+		Expect(store.HasBOM(path)).To(BeFalse())
+	})
+
+	It("transcodes UTF-16 files with a byte order mark to UTF-8", func() {
+		path := write("utf16.yml", []byte{0xFF, 0xFE, 'k', 0x00, ':', 0x00, ' ', 0x00, 'v', 0x00})
+		file, ok := store.ReadFile(path)
+		Expect(ok).To(BeTrue())
+		Expect(string(file)).To(Equal("k: v"))
+	})
+
+	It("warns about transcoded files once", func() {
+		store = New(false, false)
+		path := write("utf16be.yml", []byte{0xFE, 0xFF, 0x00, 'k', 0x00, ':', 0x00, ' ', 0x00, 'v'})
+		for i := 0; i < 3; i++ {
+			_, ok := store.ReadFile(path)
+			Expect(ok).To(BeTrue())
+		}
+		Expect(store.EncodingWarnings()).To(Equal([]string{path + " is encoded as UTF-16BE and was transcoded to UTF-8"}))
+		Expect(store.EncodingWarnings()).To(BeEmpty())
+
+		store.ReadFile(path)
+		Expect(store.EncodingWarnings()).To(BeEmpty())
+	})
+
+	It("refuses UTF-16 files without a byte order mark", func() {
+		path := write("nobom.yml", []byte{'k', 0x00, ':', 0x00, ' ', 0x00, 'v', 0x00})
+		_, ok := store.ReadFile(path)
+		Expect(ok).To(BeFalse())
+	})
+})
+
+var _ = Describe("VerifyDigests", func() {
+
+	var (
+		store *FileManager
+		dir   string
+		path  string
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "aviator-drift")
+		Expect(err).ToNot(HaveOccurred())
+		path = filepath.Join(dir, "result.yml")
+
+		store = New(false, false)
+		Expect(store.WriteFile(path, []byte("key: value"))).To(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("records the digest of written files", func() {
+		Expect(store.Digests()).To(HaveKey(path))
+	})
+
+	It("succeeds when the written files are unchanged", func() {
+		Expect(store.VerifyDigests()).To(Succeed())
+	})
+
+	It("fails when a written file was modified in between", func() {
+		Expect(ioutil.WriteFile(path, []byte("key: changed"), 0644)).To(Succeed())
+		err := store.VerifyDigests()
+		Expect(err).To(MatchError(ContainSubstring(path)))
+		Expect(err).To(MatchError(ContainSubstring("modified")))
+	})
+})
+
+var _ = Describe("CaptureWrites", func() {
+
+	var (
+		store *FileManager
+		dir   string
+		path  string
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "aviator-capture")
+		Expect(err).ToNot(HaveOccurred())
+		path = filepath.Join(dir, "result.yml")
+		Expect(ioutil.WriteFile(path, []byte("key: old"), 0644)).To(Succeed())
+
+		store = New(false, false)
+		store.CaptureWrites()
+		Expect(store.WriteFile(path, []byte("key: new"))).To(Succeed())
+		Expect(store.WriteFile("{{internal.yml}}", []byte("key: internal"))).To(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("keeps files written to disk in memory", func() {
+		content, _ := ioutil.ReadFile(path)
+		Expect(string(content)).To(Equal("key: old"))
+
+		Expect(store.Captured()).To(Equal([]string{path}))
+		Expect(string(store.CapturedFile(path))).To(Equal("key: new"))
+		Expect(store.Written()).To(BeEmpty())
+	})
+
+	It("reads captured files from memory", func() {
+		content, ok := store.ReadFile(path)
+		Expect(ok).To(BeTrue())
+		Expect(string(content)).To(Equal("key: new"))
+	})
+})
+
+var _ = Describe("WriteFile errors", func() {
+
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "aviator-write")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("returns an error naming the target path and the errno", func() {
+		blocker := filepath.Join(dir, "file")
+		Expect(ioutil.WriteFile(blocker, []byte("x"), 0644)).To(Succeed())
+
+		target := filepath.Join(blocker, "result.yml")
+		err := New(false, false).WriteFile(target, []byte("key: value"))
+		Expect(err).To(MatchError(ContainSubstring(target)))
+		Expect(err).To(MatchError(ContainSubstring("errno")))
+	})
+
+	It("reports the errno of a failed rename", func() {
+		target := filepath.Join(dir, "result.yml")
+		Expect(os.MkdirAll(filepath.Join(target, "nested"), 0755)).To(Succeed())
+
+		err := New(false, false).WriteFile(target, []byte("key: value"))
+		Expect(err).To(MatchError(MatchRegexp(`errno \d+`)))
+	})
+
+	It("retries transient errors with backoff", func() {
+		target := filepath.Join(dir, "result.yml")
+		restore := FailWrites(20*time.Millisecond,
+			&os.PathError{Op: "write", Path: target, Err: syscall.EAGAIN},
+			&os.LinkError{Op: "rename", Old: target + ".tmp", New: target, Err: syscall.ESTALE},
+		)
+		defer restore()
+
+		start := time.Now()
+		Expect(New(false, false).WriteFile(target, []byte("key: value"))).To(Succeed())
+		Expect(time.Since(start)).To(BeNumerically(">=", 60*time.Millisecond))
+		Expect(ioutil.ReadFile(target)).To(Equal([]byte("key: value")))
+	})
+
+	It("gives up after the last retry", func() {
+		target := filepath.Join(dir, "result.yml")
+		eagain := &os.PathError{Op: "write", Path: target, Err: syscall.EAGAIN}
+		restore := FailWrites(time.Millisecond, eagain, eagain, eagain, eagain)
+		defer restore()
+
+		err := New(false, false).WriteFile(target, []byte("key: value"))
+		Expect(err).To(MatchError(ContainSubstring(fmt.Sprintf("errno %d", int(syscall.EAGAIN)))))
+		Expect(target).ToNot(BeAnExistingFile())
+	})
+
+	It("doesn't retry permanent errors", func() {
+		target := filepath.Join(dir, "result.yml")
+		restore := FailWrites(time.Hour, &os.PathError{Op: "write", Path: target, Err: syscall.ENOSPC})
+		defer restore()
+
+		err := New(false, false).WriteFile(target, []byte("key: value"))
+		Expect(err).To(MatchError(ContainSubstring("no space left on device")))
+	})
+
+	It("creates missing parent directories", func() {
+		target := filepath.Join(dir, "nested", "dir", "result.yml")
+		Expect(New(false, false).WriteFile(target, []byte("key: value"))).To(Succeed())
+		Expect(target).To(BeAnExistingFile())
+	})
+})
+
+var _ = Describe("Atomic writes", func() {
+
+	var dir, target string
+
+	read := func(path string) string {
+		content, err := ioutil.ReadFile(path)
+		Expect(err).ToNot(HaveOccurred())
+		return string(content)
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "aviator-atomic")
+		Expect(err).ToNot(HaveOccurred())
+		target = filepath.Join(dir, "result.yml")
+		Expect(ioutil.WriteFile(target, []byte("key: old"), 0600)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("replaces the target keeping its mode and leaves no temp files", func() {
+		Expect(New(false, false).WriteFile(target, []byte("key: new"))).To(Succeed())
+		Expect(read(target)).To(Equal("key: new"))
+
+		info, err := os.Stat(target)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+		files, err := ioutil.ReadDir(dir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(files).To(HaveLen(1))
+	})
+
+	It("writes through symlinks", func() {
+		link := filepath.Join(dir, "link.yml")
+		Expect(os.Symlink(target, link)).To(Succeed())
+
+		Expect(New(false, false).WriteFile(link, []byte("key: new"))).To(Succeed())
+		Expect(read(target)).To(Equal("key: new"))
+		Expect(os.Readlink(link)).To(Equal(target))
+	})
+
+	It("keeps the content before the run as .bak", func() {
+		store := New(false, false)
+		store.KeepBackups()
+
+		Expect(store.WriteFile(target, []byte("key: new"))).To(Succeed())
+		Expect(store.WriteFile(target, []byte("key: newer"))).To(Succeed())
+		Expect(read(target + ".bak")).To(Equal("key: old"))
+
+		created := filepath.Join(dir, "created.yml")
+		Expect(store.WriteFile(created, []byte("key: new"))).To(Succeed())
+		Expect(created + ".bak").ToNot(BeAnExistingFile())
+	})
+
+	It("does not back up unchanged targets", func() {
+		store := New(false, false)
+		store.KeepBackups()
+
+		Expect(store.WriteFile(target, []byte("key: old"))).To(Succeed())
+		Expect(target + ".bak").ToNot(BeAnExistingFile())
+	})
+
+	It("rolls back all targets written since EnableRollback", func() {
+		store := New(false, false)
+		store.EnableRollback()
+
+		created := filepath.Join(dir, "nested", "created.yml")
+		Expect(store.WriteFile(target, []byte("key: new"))).To(Succeed())
+		Expect(store.WriteFile(created, []byte("key: new"))).To(Succeed())
+		Expect(store.WriteFile(target, []byte("key: newer"))).To(Succeed())
+
+		restored, err := store.Rollback()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(restored).To(Equal([]string{created, target}))
+		Expect(read(target)).To(Equal("key: old"))
+		Expect(created).ToNot(BeAnExistingFile())
+	})
+
+	It("does not roll back without EnableRollback", func() {
+		store := New(false, false)
+		Expect(store.WriteFile(target, []byte("key: new"))).To(Succeed())
+
+		restored, err := store.Rollback()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(restored).To(BeEmpty())
+		Expect(read(target)).To(Equal("key: new"))
+	})
+})
+
+var _ = Describe("Permissions", func() {
+
+	var dir, base, target string
+
+	mode := func(path string) os.FileMode {
+		info, err := os.Stat(path)
+		Expect(err).ToNot(HaveOccurred())
+		return info.Mode().Perm()
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "aviator-permissions")
+		Expect(err).ToNot(HaveOccurred())
+		base = filepath.Join(dir, "base.yml")
+		target = filepath.Join(dir, "result.yml")
+		Expect(ioutil.WriteFile(base, []byte("key: base"), 0640)).To(Succeed())
+		Expect(os.Chmod(base, 0640)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("writes new and existing targets with the configured mode", func() {
+		store := New(false, false)
+		Expect(store.SetPermissions(target, 0600, "")).To(Succeed())
+		Expect(store.WriteFile(target, []byte("key: new"))).To(Succeed())
+		Expect(mode(target)).To(Equal(os.FileMode(0600)))
+
+		Expect(os.Chmod(target, 0666)).To(Succeed())
+		Expect(store.WriteFile(target, []byte("key: newer"))).To(Succeed())
+		Expect(mode(target)).To(Equal(os.FileMode(0600)))
+	})
+
+	It("keeps the mode of the base file, unless a mode is configured", func() {
+		store := New(false, false)
+		Expect(store.SetPermissions(target, 0, base)).To(Succeed())
+		Expect(store.WriteFile(target, []byte("key: new"))).To(Succeed())
+		Expect(mode(target)).To(Equal(os.FileMode(0640)))
+
+		Expect(store.SetPermissions(target, 0600, base)).To(Succeed())
+		Expect(store.WriteFile(target, []byte("key: newer"))).To(Succeed())
+		Expect(mode(target)).To(Equal(os.FileMode(0600)))
+	})
+
+	It("fails to preserve the permissions of files which are not local", func() {
+		err := New(false, false).SetPermissions(target, 0, "{{datastore.yml}}")
+		Expect(err).To(MatchError(ContainSubstring("not a local file")))
+	})
+
+	It("writes backups with the mode of the target", func() {
+		Expect(ioutil.WriteFile(target, []byte("secret: old"), 0600)).To(Succeed())
+		Expect(ioutil.WriteFile(target+".bak", []byte("stale"), 0644)).To(Succeed())
+		store := New(false, false)
+		store.KeepBackups()
+
+		Expect(store.WriteFile(target, []byte("secret: new"))).To(Succeed())
+		Expect(mode(target + ".bak")).To(Equal(os.FileMode(0600)))
+	})
+
+	It("parses octal modes", func() {
+		Expect(ParseMode("0600")).To(Equal(os.FileMode(0600)))
+		Expect(ParseMode("644")).To(Equal(os.FileMode(0644)))
+		Expect(ParseMode("")).To(Equal(os.FileMode(0)))
+		for _, invalid := range []string{"rw-------", "0800", "01777", "0"} {
+			_, err := ParseMode(invalid)
+			Expect(err).To(HaveOccurred(), invalid)
+		}
+	})
+})
+
+var _ = Describe("Written", func() {
+
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "aviator-written")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("lists the files written to disk once, in write order", func() {
+		store := New(false, false)
+		first := filepath.Join(dir, "b.yml")
+		second := filepath.Join(dir, "a.yml")
+
+		Expect(store.WriteFile(first, []byte("key: 1"))).To(Succeed())
+		Expect(store.WriteFile(second, []byte("key: 2"))).To(Succeed())
+		Expect(store.WriteFile(first, []byte("key: 3"))).To(Succeed())
+		Expect(store.WriteFile("{{internal}}", []byte("key: 4"))).To(Succeed())
+
+		Expect(store.Written()).To(Equal([]string{first, second}))
+	})
+})
+
+var _ = Describe("Workspace", func() {
+
+	var dir, root string
+	var store *FileManager
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "aviator-workspace")
+		Expect(err).ToNot(HaveOccurred())
+
+		root = filepath.Join(dir, "workspaces", "ci")
+		store = New(false, false)
+		store.UseWorkspace(root)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("writes relative paths below the workspace root", func() {
+		Expect(store.WriteFile("out/result.yml", []byte("key: value"))).To(Succeed())
+		Expect(filepath.Join(root, "out", "result.yml")).To(BeAnExistingFile())
+		Expect(store.Written()).To(Equal([]string{filepath.Join(root, "out", "result.yml")}))
+	})
+
+	It("leaves absolute paths and datastore keys untouched", func() {
+		target := filepath.Join(dir, "absolute.yml")
+		Expect(store.WriteFile(target, []byte("key: value"))).To(Succeed())
+		Expect(target).To(BeAnExistingFile())
+
+		Expect(store.WriteFile("{{internal.yml}}", []byte("key: value"))).To(Succeed())
+		Expect(store.Resolve("{{internal.yml}}")).To(Equal("{{internal.yml}}"))
+	})
+
+	It("reads files rendered into the workspace back by their original path", func() {
+		Expect(store.WriteFile("out/result.yml", []byte("key: value"))).To(Succeed())
+
+		file, ok := store.ReadFile("out/result.yml")
+		Expect(ok).To(BeTrue())
+		Expect(string(file)).To(Equal("key: value"))
+		Expect(store.Resolve("out/result.yml")).To(Equal(filepath.Join(root, "out", "result.yml")))
+	})
+
+	It("walks workspace directories returning the original paths", func() {
+		Expect(store.WriteFile("out/a.yml", []byte("key: a"))).To(Succeed())
+
+		files, err := store.Walk("out")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(files).To(Equal([]string{filepath.Join("out", "a.yml")}))
+	})
+
+	It("falls back to the original path when nothing was rendered into the workspace", func() {
+		Expect(store.Resolve("missing.yml")).To(Equal("missing.yml"))
+	})
+})
+
+var _ = Describe("CopyFile", func() {
+
+	var dir string
+	var store *FileManager
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "aviator-copy")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "cert.pem"), []byte("cert"), 0600)).To(Succeed())
+		store = New(false, false)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("copies the file content and mode and records the written file", func() {
+		dest := filepath.Join(dir, "out", "cert.pem")
+		Expect(store.CopyFile(filepath.Join(dir, "cert.pem"), dest, false)).To(Succeed())
+
+		content, err := ioutil.ReadFile(dest)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(Equal("cert"))
+		info, err := os.Stat(dest)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+		Expect(store.Written()).To(Equal([]string{dest}))
+	})
+
+	It("creates a relative symlink to the source", func() {
+		dest := filepath.Join(dir, "out", "cert.pem")
+		Expect(store.CopyFile(filepath.Join(dir, "cert.pem"), dest, true)).To(Succeed())
+		Expect(store.CopyFile(filepath.Join(dir, "cert.pem"), dest, true)).To(Succeed())
+
+		target, err := os.Readlink(dest)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(target).To(Equal(filepath.Join("..", "cert.pem")))
+		Expect(store.VerifyDigests()).To(Succeed())
+	})
+
+	It("keeps backups of and rolls back copied targets", func() {
+		dest := filepath.Join(dir, "out", "cert.pem")
+		link := filepath.Join(dir, "out", "link.pem")
+		Expect(os.MkdirAll(filepath.Dir(dest), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(dest, []byte("old"), 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(link, []byte("old link"), 0644)).To(Succeed())
+		store.KeepBackups()
+		store.EnableRollback()
+
+		Expect(store.CopyFile(filepath.Join(dir, "cert.pem"), dest, false)).To(Succeed())
+		Expect(store.CopyFile(filepath.Join(dir, "cert.pem"), link, true)).To(Succeed())
+		Expect(ioutil.ReadFile(dest + ".bak")).To(Equal([]byte("old")))
+
+		restored, err := store.Rollback()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(restored).To(Equal([]string{link, dest}))
+		Expect(ioutil.ReadFile(dest)).To(Equal([]byte("old")))
+		Expect(ioutil.ReadFile(link)).To(Equal([]byte("old link")))
+		Expect(ioutil.ReadFile(filepath.Join(dir, "cert.pem"))).To(Equal([]byte("cert")))
+	})
+
+	It("does not write anything in dry-run mode", func() {
+		store = New(false, true)
+		dest := filepath.Join(dir, "out", "cert.pem")
+		Expect(store.CopyFile(filepath.Join(dir, "cert.pem"), dest, false)).To(Succeed())
+		Expect(dest).ToNot(BeAnExistingFile())
+	})
+
+	It("fails if the source does not exist", func() {
+		Expect(store.CopyFile(filepath.Join(dir, "missing.pem"), filepath.Join(dir, "out.pem"), false)).ToNot(Succeed())
+	})
+})
+
+var _ = Describe("UseContext", func() {
+
+	var dir string
+	var store *FileManager
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "aviator-context")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(dir, "sub"), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "sub", "file.yml"), []byte("a: b"), 0644)).To(Succeed())
+		store = New(false, false)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("scans directories while the context is not done", func() {
+		store.UseContext(context.Background())
+		files, err := store.Walk(dir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(files).To(Equal([]string{filepath.Join(dir, "sub", "file.yml")}))
+	})
+
+	It("aborts Walk and ReadDir once the context is canceled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		store.UseContext(ctx)
+		cancel()
+
+		_, err := store.Walk(dir)
+		Expect(err).To(HaveOccurred())
+		Expect(IsCanceled(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring(dir))
+
+		_, err = store.ReadDir(dir)
+		Expect(IsCanceled(err)).To(BeTrue())
+
+		_, err = store.Walk("{{datastore}}")
+		Expect(IsCanceled(err)).To(BeTrue())
+	})
+
+	It("does not treat a missing directory as canceled", func() {
+		_, err := store.Walk(filepath.Join(dir, "missing"))
+		Expect(err).To(HaveOccurred())
+		Expect(IsCanceled(err)).To(BeFalse())
+	})
+})
+
+var _ = Describe("Remote files", func() {
+
+	var (
+		server    *httptest.Server
+		transport http.RoundTripper
+		requests  int
+		header    string
+		cache     string
+		store     *FileManager
+	)
+
+	sum := func(content string) string {
+		s := sha256.Sum256([]byte(content))
+		return hex.EncodeToString(s[:])
+	}
+
+	BeforeEach(func() {
+		requests = 0
+		server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			header = r.Header.Get("Authorization")
+			if r.URL.Path != "/base.yml" {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write([]byte("remote: true\n"))
+		}))
+		transport = http.DefaultTransport
+		http.DefaultTransport = server.Client().Transport
+		cache, _ = ioutil.TempDir("", "aviator-cache")
+		store = New(false, false)
+		store.UseCache(cache)
+	})
+
+	AfterEach(func() {
+		http.DefaultTransport = transport
+		server.Close()
+		os.RemoveAll(cache)
+	})
+
+	It("downloads https files once per run", func() {
+		file, ok := store.ReadFile(server.URL + "/base.yml")
+		Expect(ok).To(BeTrue())
+		Expect(string(file)).To(Equal("remote: true\n"))
+
+		_, ok = store.ReadFile(server.URL + "/base.yml")
+		Expect(ok).To(BeTrue())
+		Expect(requests).To(Equal(1))
+	})
+
+	It("fails on missing files and checksum mismatches", func() {
+		_, ok := store.ReadFile(server.URL + "/missing.yml")
+		Expect(ok).To(BeFalse())
+
+		_, ok = store.ReadFile(server.URL + "/base.yml#sha256=" + sum("other"))
+		Expect(ok).To(BeFalse())
+	})
+
+	It("caches pinned files by their digest", func() {
+		key := server.URL + "/base.yml#sha256=" + sum("remote: true\n")
+		_, ok := store.ReadFile(key)
+		Expect(ok).To(BeTrue())
+		Expect(filepath.Join(cache, "sha256", sum("remote: true\n"))).To(BeAnExistingFile())
+
+		offline := New(false, false)
+		offline.UseCache(cache)
+		offline.DisableDownloads()
+		file, ok := offline.ReadFile(key)
+		Expect(ok).To(BeTrue())
+		Expect(string(file)).To(Equal("remote: true\n"))
+		Expect(requests).To(Equal(1))
+
+		_, ok = offline.ReadFile(server.URL + "/base.yml")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("sends the token of the matching auth rule", func() {
+		os.Setenv("AVIATOR_TEST_TOKEN", "secret")
+		defer os.Unsetenv("AVIATOR_TEST_TOKEN")
+		store.UseAuth(authorizer.New([]aviator.SourceAuth{
+			{Prefix: server.URL + "/", TokenEnv: "AVIATOR_TEST_TOKEN"},
+			{Prefix: "https://other.example.com/", UsernameEnv: "USER", PasswordEnv: "PASSWORD"},
+		}))
+
+		_, ok := store.ReadFile(server.URL + "/base.yml")
+		Expect(ok).To(BeTrue())
+		Expect(header).To(Equal("Bearer secret"))
+	})
+
+	It("fails if the token of the matching auth rule is not set", func() {
+		store.UseAuth(authorizer.New([]aviator.SourceAuth{{Prefix: server.URL + "/", TokenEnv: "AVIATOR_TEST_UNSET"}}))
+
+		_, err := store.Fetch(server.URL + "/base.yml")
+		Expect(err).To(MatchError(ContainSubstring("AVIATOR_TEST_UNSET")))
+		Expect(requests).To(Equal(0))
+	})
+})
+
+var _ = Describe("Flaky downloads", func() {
+
+	var (
+		server    *httptest.Server
+		transport http.RoundTripper
+		handler   func(w http.ResponseWriter, r *http.Request, attempt int)
+		ranges    []string
+		attempts  int
+	)
+
+	content := strings.Repeat("key: value\n", 100)
+
+	BeforeEach(func() {
+		attempts = 0
+		ranges = []string{}
+		server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			ranges = append(ranges, r.Header.Get("Range")+" "+r.Header.Get("If-Range"))
+			handler(w, r, attempts)
+		}))
+		transport = http.DefaultTransport
+		http.DefaultTransport = server.Client().Transport
+	})
+
+	AfterEach(func() {
+		http.DefaultTransport = transport
+		server.Close()
+	})
+
+	It("retries server errors", func() {
+		handler = func(w http.ResponseWriter, r *http.Request, attempt int) {
+			if attempt < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(content))
+		}
+
+		file, ok := New(false, false).ReadFile(server.URL + "/base.yml")
+		Expect(ok).To(BeTrue())
+		Expect(string(file)).To(Equal(content))
+		Expect(attempts).To(Equal(3))
+	})
+
+	It("does not retry client errors", func() {
+		handler = func(w http.ResponseWriter, r *http.Request, attempt int) {
+			http.NotFound(w, r)
+		}
+
+		_, ok := New(false, false).ReadFile(server.URL + "/base.yml")
+		Expect(ok).To(BeFalse())
+		Expect(attempts).To(Equal(1))
+	})
+
+	It("resumes interrupted transfers with a range request", func() {
+		handler = func(w http.ResponseWriter, r *http.Request, attempt int) {
+			w.Header().Set("Accept-Ranges", "bytes")
+			w.Header().Set("ETag", `"v1"`)
+			if attempt == 1 {
+				w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+				w.Write([]byte(content[:400]))
+				w.(http.Flusher).Flush()
+				panic(http.ErrAbortHandler)
+			}
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes 400-%d/%d", len(content)-1, len(content)))
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write([]byte(content[400:]))
+		}
+
+		sum := sha256.Sum256([]byte(content))
+		file, ok := New(false, false).ReadFile(server.URL + "/base.yml#sha256=" + hex.EncodeToString(sum[:]))
+		Expect(ok).To(BeTrue())
+		Expect(string(file)).To(Equal(content))
+		Expect(ranges).To(Equal([]string{" ", `bytes=400- "v1"`}))
+	})
+})
+
+var _ = Describe("Object store files", func() {
+
+	var (
+		bin   string
+		path  string
+		store *FileManager
+	)
+
+	BeforeEach(func() {
+		bin, _ = ioutil.TempDir("", "aviator-object-store")
+		aws := "#!/bin/sh\necho \"$@\" > " + filepath.Join(bin, "aws.args") + "\necho 'store: s3'\n"
+		gsutil := "#!/bin/sh\necho \"$@\" > " + filepath.Join(bin, "gsutil.args") + "\necho 'store: gs'\n"
+		Expect(ioutil.WriteFile(filepath.Join(bin, "aws"), []byte(aws), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(bin, "gsutil"), []byte(gsutil), 0755)).To(Succeed())
+		path = os.Getenv("PATH")
+		os.Setenv("PATH", bin+":"+path)
+		store = New(false, false)
+	})
+
+	AfterEach(func() {
+		os.Setenv("PATH", path)
+		os.RemoveAll(bin)
+	})
+
+	It("reads s3 objects with the aws CLI", func() {
+		file, ok := store.ReadFile("s3://bucket/envs/my prod.yml")
+		Expect(ok).To(BeTrue())
+		Expect(string(file)).To(Equal("store: s3\n"))
+		args, _ := ioutil.ReadFile(filepath.Join(bin, "aws.args"))
+		Expect(string(args)).To(Equal("s3 cp --quiet s3://bucket/envs/my prod.yml -\n"))
+	})
+
+	It("reads s3 objects with the aws profile of the matching auth rule", func() {
+		store.UseAuth(authorizer.New([]aviator.SourceAuth{{Prefix: "s3://bucket/", AWSProfile: "prod"}}))
+		_, ok := store.ReadFile("s3://bucket/base.yml")
+		Expect(ok).To(BeTrue())
+		args, _ := ioutil.ReadFile(filepath.Join(bin, "aws.args"))
+		Expect(string(args)).To(Equal("s3 cp --quiet s3://bucket/base.yml - --profile prod\n"))
+	})
+
+	It("reads gs objects with gsutil", func() {
+		sum := sha256.Sum256([]byte("store: gs\n"))
+		file, ok := store.ReadFile("gs://bucket/base.yml#sha256=" + hex.EncodeToString(sum[:]))
+		Expect(ok).To(BeTrue())
+		Expect(string(file)).To(Equal("store: gs\n"))
+		args, _ := ioutil.ReadFile(filepath.Join(bin, "gsutil.args"))
+		Expect(string(args)).To(Equal("-q cat gs://bucket/base.yml\n"))
+	})
+
+	It("fails if the CLI fails", func() {
+		Expect(ioutil.WriteFile(filepath.Join(bin, "aws"), []byte("#!/bin/sh\necho 'AccessDenied' >&2\nexit 1\n"), 0755)).To(Succeed())
+		_, ok := store.ReadFile("s3://bucket/base.yml")
+		Expect(ok).To(BeFalse())
+	})
+})
@@ -0,0 +1,16 @@
+//go:build !windows
+// +build !windows
+
+package filemanager
+
+import (
+	"os"
+	"syscall"
+)
+
+func ownerOf(info os.FileInfo) *Owner {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return &Owner{UID: int(stat.Uid), GID: int(stat.Gid)}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+//go:build !windows
+// +build !windows
+
+package filemanager_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	. "github.com/JulzDiverse/aviator/filemanager"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Owner", func() {
+
+	var dir string
+
+	BeforeEach(func() {
+		if os.Geteuid() != 0 {
+			Skip("only root may give files away")
+		}
+		var err error
+		dir, err = ioutil.TempDir("", "aviator-owner")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("keeps the owner of the base file", func() {
+		base := filepath.Join(dir, "base.yml")
+		target := filepath.Join(dir, "result.yml")
+		Expect(ioutil.WriteFile(base, []byte("key: base"), 0644)).To(Succeed())
+		Expect(os.Chown(base, 4321, 8765)).To(Succeed())
+
+		store := New(false, false)
+		Expect(store.SetPermissions(target, 0, base)).To(Succeed())
+		Expect(store.WriteFile(target, []byte("key: new"))).To(Succeed())
+
+		info, err := os.Stat(target)
+		Expect(err).ToNot(HaveOccurred())
+		stat := info.Sys().(*syscall.Stat_t)
+		Expect([]uint32{stat.Uid, stat.Gid}).To(Equal([]uint32{4321, 8765}))
+	})
+})
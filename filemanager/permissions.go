@@ -0,0 +1,69 @@
+package filemanager
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+// Permissions are the mode and owner a target is written with. A zero Mode
+// keeps the mode of an existing target (0644 for a new one); without an
+// Owner, the target is owned by the user running aviator.
+type Permissions struct {
+	Mode  os.FileMode
+	Owner *Owner
+}
+
+type Owner struct {
+	UID int
+	GID int
+}
+
+// ParseMode parses an octal file mode like 0600. An empty mode is 0.
+func ParseMode(mode string) (os.FileMode, error) {
+	if mode == "" {
+		return 0, nil
+	}
+	m, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || m == 0 || m > 0777 {
+		return 0, ansi.Errorf("@R{Invalid file mode} @m{%s}@R{; use octal permissions like 0600}", mode)
+	}
+	return os.FileMode(m), nil
+}
+
+// SetPermissions sets the permissions the target key is written with. With
+// from, the mode and owner of the local file from are kept; a mode other
+// than 0 overrides its mode.
+func (ds *FileManager) SetPermissions(key string, mode os.FileMode, from string) error {
+	perm := Permissions{Mode: mode}
+	if from != "" {
+		info, err := os.Stat(ds.Resolve(from))
+		if err != nil || !info.Mode().IsRegular() {
+			return ansi.Errorf("@R{Cannot preserve the permissions of} @m{%s}@R{, it is not a local file}", from)
+		}
+		if perm.Mode == 0 {
+			perm.Mode = info.Mode().Perm()
+		}
+		perm.Owner = ownerOf(info)
+	}
+
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	if ds.permissions == nil {
+		ds.permissions = map[string]Permissions{}
+	}
+	ds.permissions[ds.workspacePath(key)] = perm
+	return nil
+}
+
+// chown gives path to owner. Only privileged users may give files away, so
+// an owner which cannot be kept is reported, but does not fail the write.
+func chown(path string, owner *Owner) {
+	if owner == nil {
+		return
+	}
+	if err := os.Chown(path, owner.UID, owner.GID); err != nil {
+		ansi.Fprintf(os.Stderr, "@Y{WARNING}: cannot keep the owner %d:%d of @m{%s}: %s\n", owner.UID, owner.GID, path, err.Error())
+	}
+}
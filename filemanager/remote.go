@@ -0,0 +1,163 @@
+package filemanager
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/JulzDiverse/aviator/authorizer"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+// object stores are read with their CLIs, which take region and credentials
+// from the standard environment variables (AWS_REGION, AWS_PROFILE,
+// GOOGLE_APPLICATION_CREDENTIALS, ...) unless an auth rule sets the
+// aws_profile of an s3 source
+var objectStores = map[string]func(location string) []string{
+	"s3://": func(location string) []string { return []string{"aws", "s3", "cp", "--quiet", location, "-"} },
+	"gs://": func(location string) []string { return []string{"gsutil", "-q", "cat", location} },
+}
+
+// UseCache caches remote files pinned with a sha256 below dir. Pinned files
+// are cached by their digest, so cached files never go stale.
+func (ds *FileManager) UseCache(dir string) {
+	ds.cache = dir
+}
+
+// UseAuth sets the credentials of the remote files.
+func (ds *FileManager) UseAuth(auth *authorizer.Authorizer) {
+	ds.auth = auth
+}
+
+// DisableDownloads restricts remote files to the ones in the cache.
+func (ds *FileManager) DisableDownloads() {
+	ds.offline = true
+}
+
+func IsRemote(path string) bool {
+	return strings.HasPrefix(path, "https://") || objectStore(path) != nil
+}
+
+func objectStore(path string) func(string) []string {
+	for scheme, cmd := range objectStores {
+		if strings.HasPrefix(path, scheme) {
+			return cmd
+		}
+	}
+	return nil
+}
+
+func (ds *FileManager) readRemote(key string) ([]byte, bool) {
+	ds.mu.Lock()
+	file, ok := ds.remote[key]
+	ds.mu.Unlock()
+	if ok {
+		return file, true
+	}
+
+	file, err := ds.Fetch(key)
+	if err != nil {
+		ansi.Fprintf(os.Stderr, "%s\n", err.Error())
+		return nil, false
+	}
+
+	raw := file
+	file, encoding, err := decode(key, file)
+	if err != nil {
+		ansi.Fprintf(os.Stderr, "%s\n", err.Error())
+		return nil, false
+	}
+
+	ds.mu.Lock()
+	ds.markBOM(key, raw)
+	ds.transcoded(key, encoding)
+	ds.remote[key] = file
+	ds.mu.Unlock()
+	return file, true
+}
+
+// Fetch downloads the remote file key, or reads it from the cache if it is
+// pinned with a sha256. Unlike ReadFile it returns why the download failed.
+func (ds *FileManager) Fetch(key string) ([]byte, error) {
+	location, sum, err := parseRemote(key)
+	if err != nil {
+		return nil, err
+	}
+
+	cached := ""
+	if sum != "" && ds.cache != "" {
+		cached = filepath.Join(ds.cache, "sha256", sum)
+		if file, err := ioutil.ReadFile(cached); err == nil && digest(file) == sum {
+			// the modification time tells 'aviator cache gc' when the file was last used
+			now := time.Now()
+			os.Chtimes(cached, now, now)
+			return file, nil
+		}
+	}
+
+	if ds.offline {
+		return nil, ansi.Errorf("@R{Downloading} @m{%s} @R{is not possible in offline mode, only cached files pinned with a sha256 are available}", location)
+	}
+
+	file, err := ds.download(location, sum)
+	if err != nil {
+		return nil, err
+	}
+
+	if cached != "" {
+		err = writeCached(cached, file)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return file, nil
+}
+
+// parseRemote splits the optional #sha256=<digest> pin off a remote file.
+// The location is kept as it is, object keys must not be re-escaped.
+func parseRemote(key string) (string, string, error) {
+	i := strings.Index(key, "#")
+	if i < 0 {
+		return key, "", nil
+	}
+
+	location, fragment := key[:i], key[i+1:]
+	if !strings.HasPrefix(fragment, "sha256=") {
+		return "", "", ansi.Errorf("@R{Invalid remote file} @m{%s}@R{: the fragment must be} @m{#sha256=<digest>}", key)
+	}
+	return location, strings.ToLower(strings.TrimPrefix(fragment, "sha256=")), nil
+}
+
+func readObject(ctx context.Context, cmd []string, location string) ([]byte, error) {
+	if _, err := exec.LookPath(cmd[0]); err != nil {
+		return nil, ansi.Errorf("@R{Reading} @m{%s} @R{requires the} @m{%s} @R{binary in your PATH}", location, cmd[0])
+	}
+
+	var stderr bytes.Buffer
+	c := exec.CommandContext(ctx, cmd[0], cmd[1:]...)
+	c.Stderr = &stderr
+	out, err := c.Output()
+	if err != nil {
+		return nil, ansi.Errorf("@R{Downloading} @m{%s} @R{FAILED}:\n%s", location, stderr.String())
+	}
+	return out, nil
+}
+
+func writeCached(path string, file []byte) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return writeError(path, err)
+	}
+	tmp := fmt.Sprintf("%s.%d.tmp", path, os.Getpid())
+	err = ioutil.WriteFile(tmp, file, 0644)
+	if err != nil {
+		return writeError(tmp, err)
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,61 @@
+package filemanager
+
+import (
+	"errors"
+	"syscall"
+	"time"
+
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+var (
+	writeRetries = 3
+	writeBackoff = 100 * time.Millisecond
+
+	// atomicWrite is the write retried by writeWithRetry
+	atomicWrite = writeAtomic
+)
+
+func writeWithRetry(path string, file []byte, perm Permissions) error {
+	var err error
+	backoff := writeBackoff
+	for attempt := 0; ; attempt++ {
+		createNonExistingDirs(path)
+		err = atomicWrite(path, file, perm)
+		if err == nil || !isTransient(err) || attempt == writeRetries {
+			break
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+
+	if err != nil {
+		return writeError(path, err)
+	}
+	return nil
+}
+
+func isTransient(err error) bool {
+	switch errno(err) {
+	case syscall.EPERM, syscall.ENOENT, syscall.EAGAIN, syscall.EBUSY, syscall.EINTR, syscall.ESTALE:
+		return true
+	}
+	return false
+}
+
+// errno returns the errno wrapped by err, e.g. by the *os.PathError of a
+// write or the *os.LinkError of a rename.
+func errno(err error) syscall.Errno {
+	var e syscall.Errno
+	if errors.As(err, &e) {
+		return e
+	}
+	return 0
+}
+
+func writeError(path string, err error) error {
+	if e := errno(err); e != 0 {
+		return ansi.Errorf("@R{Error writing file} @m{%s}: %s (errno %d)", path, e.Error(), int(e))
+	}
+	return ansi.Errorf("@R{Error writing file} @m{%s}: %s", path, err.Error())
+}
@@ -0,0 +1,49 @@
+package filemanager
+
+import (
+	"os"
+	"path/filepath"
+)
+
+func (ds *FileManager) UseWorkspace(root string) {
+	ds.workspace = root
+}
+
+func (ds *FileManager) Workspace() string {
+	return ds.workspace
+}
+
+func (ds *FileManager) Resolve(path string) string {
+	if !ds.inWorkspace(path) {
+		return path
+	}
+
+	candidate := filepath.Join(ds.workspace, path)
+	if _, err := os.Stat(candidate); err != nil {
+		return path
+	}
+	return candidate
+}
+
+func (ds *FileManager) workspacePath(path string) string {
+	if !ds.inWorkspace(path) {
+		return path
+	}
+	return filepath.Join(ds.workspace, path)
+}
+
+func (ds *FileManager) inWorkspace(path string) bool {
+	return ds.workspace != "" && path != "" && !filepath.IsAbs(path) && !re.MatchString(path)
+}
+
+func (ds *FileManager) trimWorkspace(paths []string) []string {
+	result := []string{}
+	for _, p := range paths {
+		rel, err := filepath.Rel(ds.workspace, p)
+		if err != nil {
+			rel = p
+		}
+		result = append(result, rel)
+	}
+	return result
+}
@@ -0,0 +1,210 @@
+package fleet
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/JulzDiverse/aviator"
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const checkoutDir = ".aviator/fleet"
+
+// pilot names are used as directory and workspace names
+var nameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+type Result struct {
+	Pilot    string
+	Duration time.Duration
+	Err      error
+}
+
+type Fleet struct {
+	bin    string
+	dir    string
+	silent bool
+	out    io.Writer
+	mu     sync.Mutex
+}
+
+func Load(file []byte) (aviator.Fleet, error) {
+	var fleet aviator.Fleet
+	err := yaml.Unmarshal(file, &fleet)
+	if err != nil {
+		return fleet, errors.Wrap(err, ansi.Sprintf("@R{YAML Parsing Failed}"))
+	}
+	return fleet, Validate(fleet)
+}
+
+func Validate(fleet aviator.Fleet) error {
+	if len(fleet.Pilots) == 0 {
+		return ansi.Errorf("@R{INVALID SYNTAX}: fleet file defines no @m{pilots}")
+	}
+
+	names := map[string]bool{}
+	for i, p := range fleet.Pilots {
+		if p.Name == "" {
+			return ansi.Errorf("@R{INVALID SYNTAX}: pilot %d requires a @m{name}", i)
+		}
+		if !nameRegex.MatchString(p.Name) {
+			return ansi.Errorf("@R{INVALID SYNTAX}: pilot name @m{%s} must start with a letter or digit and contain only letters, digits, '_', '.' and '-'", p.Name)
+		}
+		if names[p.Name] {
+			return ansi.Errorf("@R{INVALID SYNTAX}: pilot @m{%s} is defined more than once", p.Name)
+		}
+		names[p.Name] = true
+		if p.Dir == "" && p.Repo == "" {
+			return ansi.Errorf("@R{INVALID SYNTAX}: pilot @m{%s} requires a @m{dir} or a @m{repo}", p.Name)
+		}
+	}
+	return nil
+}
+
+func New(bin, dir string, silent bool) *Fleet {
+	return &Fleet{bin: bin, dir: dir, silent: silent, out: os.Stdout}
+}
+
+func (f *Fleet) Run(fleet aviator.Fleet) []Result {
+	results := make([]Result, len(fleet.Pilots))
+	parallel := fleet.Parallel
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, parallel)
+	for i, p := range fleet.Pilots {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, p aviator.Pilot) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = f.fly(p, fleet.Vars, parallel > 1)
+		}(i, p)
+	}
+	wg.Wait()
+	return results
+}
+
+func (f *Fleet) fly(p aviator.Pilot, vars map[string]string, buffered bool) Result {
+	start := time.Now()
+
+	var output bytes.Buffer
+	var out io.Writer = &output
+	if !buffered {
+		out = f.out
+		f.header(p.Name)
+	}
+
+	err := f.run(p, vars, out)
+	if buffered {
+		f.mu.Lock()
+		f.header(p.Name)
+		f.out.Write(output.Bytes())
+		f.mu.Unlock()
+	}
+	return Result{Pilot: p.Name, Duration: time.Since(start), Err: err}
+}
+
+func (f *Fleet) run(p aviator.Pilot, vars map[string]string, out io.Writer) error {
+	dir := f.resolve(p.Dir)
+	if p.Repo != "" {
+		checkout, err := f.checkout(p, out)
+		if err != nil {
+			return err
+		}
+		dir = filepath.Join(checkout, p.Dir)
+	}
+
+	cmd := exec.Command(f.bin, args(p, vars)...)
+	cmd.Dir = dir
+	cmd.Stdout = out
+	cmd.Stderr = out
+	if f.silent {
+		cmd.Stdout = nil
+	}
+
+	err := cmd.Run()
+	if err != nil {
+		return errors.Wrap(err, ansi.Sprintf("@R{Pilot} @m{%s} @R{FAILED}", p.Name))
+	}
+	return nil
+}
+
+func (f *Fleet) checkout(p aviator.Pilot, out io.Writer) (string, error) {
+	root := f.resolve(checkoutDir)
+	dir := filepath.Join(root, p.Name)
+	if filepath.Dir(dir) != filepath.Clean(root) {
+		return "", ansi.Errorf("@R{Checkout of pilot} @m{%s} @R{is not below} @m{%s}", p.Name, root)
+	}
+	err := os.RemoveAll(dir)
+	if err != nil {
+		return "", err
+	}
+
+	cloneArgs := []string{"clone", "--depth", "1"}
+	if p.Ref != "" {
+		cloneArgs = append(cloneArgs, "--branch", p.Ref)
+	}
+	cmd := exec.Command("git", append(cloneArgs, p.Repo, dir)...)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	err = cmd.Run()
+	if err != nil {
+		return "", errors.Wrap(err, ansi.Sprintf("@R{Cloning} @m{%s} @R{for pilot} @m{%s} @R{FAILED}", p.Repo, p.Name))
+	}
+	return dir, nil
+}
+
+func (f *Fleet) resolve(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(f.dir, path)
+}
+
+func (f *Fleet) header(name string) {
+	if !f.silent {
+		fmt.Fprint(f.out, ansi.Sprintf("@G{PILOT:} @m{%s}\n", name))
+	}
+}
+
+func args(p aviator.Pilot, vars map[string]string) []string {
+	file := p.File
+	if file == "" {
+		file = "aviator.yml"
+	}
+	workspace := p.Workspace
+	if workspace == "" {
+		workspace = p.Name
+	}
+
+	all := map[string]string{}
+	for k, v := range vars {
+		all[k] = v
+	}
+	for k, v := range p.Vars {
+		all[k] = v
+	}
+	keys := []string{}
+	for k := range all {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	result := []string{"--file", file, "--workspace", workspace}
+	for _, k := range keys {
+		result = append(result, "--var", k+"="+all[k])
+	}
+	return append(result, p.Args...)
+}
@@ -0,0 +1,108 @@
+package fleet_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/JulzDiverse/aviator"
+	. "github.com/JulzDiverse/aviator/fleet"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Fleet", func() {
+
+	Context("Load", func() {
+		It("parses and validates a fleet file", func() {
+			fleet, err := Load([]byte("parallel: 2\npilots:\n- name: a\n  dir: a\n"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fleet.Parallel).To(Equal(2))
+			Expect(fleet.Pilots[0].Name).To(Equal("a"))
+		})
+
+		It("requires a dir or repo per pilot", func() {
+			_, err := Load([]byte("pilots:\n- name: a\n"))
+			Expect(err).To(MatchError(ContainSubstring("requires a")))
+		})
+
+		It("requires unique pilot names", func() {
+			_, err := Load([]byte("pilots:\n- name: a\n  dir: a\n- name: a\n  dir: b\n"))
+			Expect(err).To(MatchError(ContainSubstring("more than once")))
+		})
+
+		It("rejects pilot names which are not a single path segment", func() {
+			for _, name := range []string{"..", ".", "../..", "a/b", ".hidden", "\"a b\""} {
+				_, err := Load([]byte("pilots:\n- name: " + name + "\n  repo: https://example.com/repo.git\n"))
+				Expect(err).To(MatchError(ContainSubstring("must start with a letter or digit")), name)
+			}
+
+			_, err := Load([]byte("pilots:\n- name: eu-west_1.prod\n  dir: a\n"))
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Context("Run", func() {
+		var dir, bin string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "aviator-fleet")
+			Expect(err).ToNot(HaveOccurred())
+
+			bin = filepath.Join(dir, "aviator")
+			script := "#!/bin/sh\necho \"$@\" > invocation\ntest ! -f fail\n"
+			Expect(ioutil.WriteFile(bin, []byte(script), 0755)).To(Succeed())
+
+			for _, name := range []string{"a", "b", "c"} {
+				Expect(os.Mkdir(filepath.Join(dir, name), 0755)).To(Succeed())
+			}
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		invocation := func(name string) string {
+			out, err := ioutil.ReadFile(filepath.Join(dir, name, "invocation"))
+			Expect(err).ToNot(HaveOccurred())
+			return strings.TrimSpace(string(out))
+		}
+
+		It("runs aviator in the directory of every pilot with its own workspace", func() {
+			results := New(bin, dir, true).Run(aviator.Fleet{
+				Vars: map[string]string{"env": "dev", "region": "eu"},
+				Pilots: []aviator.Pilot{
+					{Name: "a", Dir: "a"},
+					{Name: "b", Dir: "b", File: "other.yml", Workspace: "ci", Vars: map[string]string{"env": "prod"}, Args: []string{"--dry-run"}},
+				},
+			})
+
+			Expect(results).To(HaveLen(2))
+			Expect(results[0].Err).ToNot(HaveOccurred())
+			Expect(results[1].Err).ToNot(HaveOccurred())
+			Expect(invocation("a")).To(Equal("--file aviator.yml --workspace a --var env=dev --var region=eu"))
+			Expect(invocation("b")).To(Equal("--file other.yml --workspace ci --var env=prod --var region=eu --dry-run"))
+		})
+
+		It("runs pilots in parallel and reports failures per pilot", func() {
+			Expect(ioutil.WriteFile(filepath.Join(dir, "b", "fail"), []byte{}, 0644)).To(Succeed())
+
+			results := New(bin, dir, true).Run(aviator.Fleet{
+				Parallel: 3,
+				Pilots: []aviator.Pilot{
+					{Name: "a", Dir: "a"},
+					{Name: "b", Dir: "b"},
+					{Name: "c", Dir: "c"},
+				},
+			})
+
+			Expect(results[0].Err).ToNot(HaveOccurred())
+			Expect(results[1].Err).To(MatchError(ContainSubstring("b")))
+			Expect(results[2].Err).ToNot(HaveOccurred())
+			Expect(invocation("c")).To(ContainSubstring("--workspace c"))
+		})
+	})
+})
@@ -0,0 +1,133 @@
+package guard
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+const pollInterval = 20 * time.Millisecond
+
+var sizeRegex = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*(|B|K|KB|Ki|KiB|M|MB|Mi|MiB|G|GB|Gi|GiB|T|TB|Ti|TiB)$`)
+
+var units = map[string]uint64{
+	"": 1, "B": 1,
+	"K": 1000, "KB": 1000, "Ki": 1 << 10, "KiB": 1 << 10,
+	"M": 1000 * 1000, "MB": 1000 * 1000, "Mi": 1 << 20, "MiB": 1 << 20,
+	"G": 1000 * 1000 * 1000, "GB": 1000 * 1000 * 1000, "Gi": 1 << 30, "GiB": 1 << 30,
+	"T": 1000 * 1000 * 1000 * 1000, "TB": 1000 * 1000 * 1000 * 1000, "Ti": 1 << 40, "TiB": 1 << 40,
+}
+
+// ParseSize parses sizes like 512Mi, 2G or 1024 (bytes). K, M, G and T are
+// decimal, Ki, Mi, Gi and Ti binary units; every size of aviator uses them.
+func ParseSize(size string) (uint64, error) {
+	m := sizeRegex.FindStringSubmatch(strings.TrimSpace(size))
+	if m == nil {
+		return 0, ansi.Errorf("@R{Invalid size} @m{%s}@R{: use e.g. 512Mi or 2G}", size)
+	}
+	if !strings.Contains(m[1], ".") {
+		n, err := strconv.ParseUint(m[1], 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		return n * units[m[2]], nil
+	}
+	n, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(n * float64(units[m[2]])), nil
+}
+
+// Run runs cmd with stdin and returns what it printed to stdout. The process
+// is killed if it runs longer than timeout or its resident memory exceeds
+// maxMemory; both are optional. If it fails, its stderr is the error.
+func Run(step, timeout, maxMemory string, cmd *exec.Cmd, stdin []byte) ([]byte, error) {
+	var deadline <-chan time.Time
+	if timeout != "" {
+		d, err := time.ParseDuration(timeout)
+		if err != nil {
+			return nil, errors.Wrap(err, ansi.Sprintf("@R{Invalid timeout} @m{%s}", timeout))
+		}
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	var limit uint64
+	if maxMemory != "" {
+		var err error
+		limit, err = ParseSize(maxMemory)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return nil, errors.Wrap(err, ansi.Sprintf("@R{Starting the merge of} @m{%s} @R{FAILED}", step))
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				if msg := strings.TrimSpace(stderr.String()); msg != "" {
+					return nil, errors.New(msg)
+				}
+				return nil, errors.Wrap(err, ansi.Sprintf("@R{Merge of} @m{%s} @R{FAILED}", step))
+			}
+			return stdout.Bytes(), nil
+		case <-deadline:
+			kill(cmd, done)
+			return nil, ansi.Errorf("@R{Aborted} @m{%s}@R{: merge did not finish within} @m{%s}", step, timeout)
+		case <-ticker.C:
+			if limit != 0 && residentMemory(cmd.Process.Pid) > limit {
+				kill(cmd, done)
+				return nil, ansi.Errorf("@R{Aborted} @m{%s}@R{: merge exceeded the memory limit of} @m{%s}", step, maxMemory)
+			}
+		}
+	}
+}
+
+func kill(cmd *exec.Cmd, done <-chan error) {
+	cmd.Process.Kill()
+	<-done
+}
+
+// residentMemory returns the resident memory of the process pid, read from
+// /proc or, where there is none, from ps.
+func residentMemory(pid int) uint64 {
+	if statm, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/statm", pid)); err == nil {
+		fields := strings.Fields(string(statm))
+		if len(fields) > 1 {
+			pages, _ := strconv.ParseUint(fields[1], 10, 64)
+			return pages * uint64(os.Getpagesize())
+		}
+	}
+
+	out, err := exec.Command("ps", "-o", "rss=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return 0
+	}
+	kb, _ := strconv.ParseUint(strings.TrimSpace(string(out)), 10, 64)
+	return kb << 10
+}
@@ -0,0 +1,61 @@
+package guard_test
+
+import (
+	"os/exec"
+	"time"
+
+	. "github.com/JulzDiverse/aviator/guard"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Guard", func() {
+
+	Context("ParseSize", func() {
+		It("parses binary and decimal units", func() {
+			Expect(ParseSize("512Mi")).To(Equal(uint64(512 << 20)))
+			Expect(ParseSize("2G")).To(Equal(uint64(2000000000)))
+			Expect(ParseSize("1024")).To(Equal(uint64(1024)))
+			Expect(ParseSize("2GB")).To(Equal(uint64(2000000000)))
+			Expect(ParseSize("1.5Gi")).To(Equal(uint64(3 << 29)))
+			Expect(ParseSize("1Ti")).To(Equal(uint64(1 << 40)))
+		})
+
+		It("rejects invalid sizes", func() {
+			_, err := ParseSize("lots")
+			Expect(err).To(MatchError(ContainSubstring("Invalid size")))
+		})
+	})
+
+	Context("Run", func() {
+		It("returns what the process printed", func() {
+			out, err := Run("step", "1s", "1Gi", exec.Command("cat"), []byte("merged"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(out)).To(Equal("merged"))
+		})
+
+		It("returns the stderr of failed processes", func() {
+			_, err := Run("step", "1s", "", exec.Command("sh", "-c", "echo merge failed >&2; exit 1"), nil)
+			Expect(err).To(MatchError("merge failed"))
+		})
+
+		It("kills processes exceeding the timeout", func() {
+			start := time.Now()
+			cmd := exec.Command("sleep", "2")
+			_, err := Run("step", "100ms", "", cmd, nil)
+			Expect(err).To(MatchError(ContainSubstring("step: merge did not finish within 100ms")))
+			Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+			Expect(cmd.ProcessState).ToNot(BeNil())
+		})
+
+		It("kills processes exceeding the memory limit", func() {
+			start := time.Now()
+			cmd := exec.Command("sh", "-c", `hog=$(head -c 67108864 /dev/zero | tr '\0' x); while :; do :; done`)
+			_, err := Run("step", "5s", "16Mi", cmd, nil)
+			Expect(err).To(MatchError(ContainSubstring("step: merge exceeded the memory limit of 16Mi")))
+			Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+			Expect(cmd.ProcessState).ToNot(BeNil())
+		})
+	})
+})
@@ -0,0 +1,177 @@
+package history
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+const (
+	runsDir  = ".aviator/runs"
+	cacheDir = ".aviator/cache"
+)
+
+type Run struct {
+	ID      string            `json:"id"`
+	Time    time.Time         `json:"time"`
+	Args    []string          `json:"args"`
+	Inputs  map[string]string `json:"inputs"`
+	Outputs map[string]string `json:"outputs"`
+	Env     map[string]string `json:"env"`
+}
+
+func Record(root string, args []string, inputs map[string][]byte, outputs map[string]string, env []string) (Run, error) {
+	run := Run{
+		Time:    time.Now().UTC(),
+		Args:    args,
+		Inputs:  map[string]string{},
+		Outputs: outputs,
+		Env:     map[string]string{},
+	}
+
+	for path, content := range inputs {
+		sum, err := Cache(root, content)
+		if err != nil {
+			return run, err
+		}
+		run.Inputs[path] = sum
+	}
+	for _, name := range env {
+		run.Env[name] = Digest([]byte(os.Getenv(name)))
+	}
+
+	id, err := json.Marshal(run)
+	if err != nil {
+		return run, err
+	}
+	run.ID = run.Time.Format("20060102-150405") + "-" + Digest(id)[:8]
+
+	out, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return run, err
+	}
+	return run, writeFile(filepath.Join(root, runsDir, run.ID+".json"), out, 0644)
+}
+
+func Load(root, id string) (Run, error) {
+	var run Run
+	file, err := ioutil.ReadFile(filepath.Join(root, runsDir, id+".json"))
+	if err != nil {
+		return run, errors.Wrap(err, ansi.Sprintf("@R{Run} @m{%s} @R{not found in the history}", id))
+	}
+	err = json.Unmarshal(file, &run)
+	if err != nil {
+		return run, errors.Wrap(err, ansi.Sprintf("@R{Reading run} @m{%s} @R{FAILED}", id))
+	}
+	return run, nil
+}
+
+func Restore(root string, run Run, dir string) error {
+	missing := []string{}
+	for _, path := range sortedKeys(run.Inputs) {
+		if filepath.IsAbs(path) || strings.HasPrefix(filepath.Clean(path), "..") {
+			missing = append(missing, fmt.Sprintf("%s (outside of the working directory)", path))
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(root, cacheDir, run.Inputs[path])); err != nil {
+			missing = append(missing, fmt.Sprintf("%s (not in cache)", path))
+		}
+	}
+	if len(missing) != 0 {
+		return ansi.Errorf("@R{Run} @m{%s} @R{cannot be reproduced, inputs are unavailable}:\n\t%s", run.ID, strings.Join(missing, "\n\t"))
+	}
+
+	for path, sum := range run.Inputs {
+		content, err := Cached(root, sum)
+		if err != nil {
+			return errors.Wrap(err, ansi.Sprintf("@R{Input} @m{%s} @R{of run} @m{%s}", path, run.ID))
+		}
+		err = writeFile(filepath.Join(dir, path), content, 0644)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func ChangedEnv(run Run) []string {
+	changed := []string{}
+	for _, name := range sortedKeys(run.Env) {
+		if Digest([]byte(os.Getenv(name))) != run.Env[name] {
+			changed = append(changed, name)
+		}
+	}
+	return changed
+}
+
+func VerifyOutputs(run Run, dir string) []string {
+	mismatches := []string{}
+	for _, path := range sortedKeys(run.Outputs) {
+		if filepath.IsAbs(path) {
+			continue
+		}
+		content, err := ioutil.ReadFile(filepath.Join(dir, path))
+		if err != nil || Digest(content) != run.Outputs[path] {
+			mismatches = append(mismatches, path)
+		}
+	}
+	return mismatches
+}
+
+func Digest(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// Cache stores content in the content-addressed cache below root and returns
+// its digest. The inputs of a run may hold secrets, so cache entries are
+// only readable by the user.
+func Cache(root string, content []byte) (string, error) {
+	sum := Digest(content)
+	path := filepath.Join(root, cacheDir, sum)
+	err := writeFile(path, content, 0600)
+	if err == nil {
+		// entries written by earlier versions were readable by everyone
+		err = os.Chmod(path, 0600)
+	}
+	return sum, err
+}
+
+// Cached reads the content with the digest sum from the cache below root.
+func Cached(root, sum string) ([]byte, error) {
+	content, err := ioutil.ReadFile(filepath.Join(root, cacheDir, sum))
+	if err != nil {
+		return nil, ansi.Errorf("@R{Content} @m{%s} @R{is not in the cache}", sum)
+	}
+	if Digest(content) != sum {
+		return nil, ansi.Errorf("@R{Cached content} @m{%s} @R{is corrupted}", sum)
+	}
+	return content, nil
+}
+
+func writeFile(path string, content []byte, mode os.FileMode) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, content, mode)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,86 @@
+package history_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/JulzDiverse/aviator/history"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("History", func() {
+
+	var root, dir string
+	var run Run
+
+	BeforeEach(func() {
+		var err error
+		root, err = ioutil.TempDir("", "aviator-history")
+		Expect(err).ToNot(HaveOccurred())
+		dir, err = ioutil.TempDir("", "aviator-repro")
+		Expect(err).ToNot(HaveOccurred())
+
+		os.Setenv("AVIATOR_TEST_ENV", "one")
+		run, err = Record(root,
+			[]string{"--file", "aviator.yml"},
+			map[string][]byte{"aviator.yml": []byte("spruce: []\n"), "templates/base.yml": []byte("a: 1\n")},
+			map[string]string{"out/result.yml": Digest([]byte("a: 1\n"))},
+			[]string{"AVIATOR_TEST_ENV"},
+		)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.Unsetenv("AVIATOR_TEST_ENV")
+		os.RemoveAll(root)
+		os.RemoveAll(dir)
+	})
+
+	It("records runs which can be loaded by their id", func() {
+		loaded, err := Load(root, run.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(loaded.Args).To(Equal([]string{"--file", "aviator.yml"}))
+		Expect(loaded.Inputs).To(HaveKeyWithValue("templates/base.yml", Digest([]byte("a: 1\n"))))
+	})
+
+	It("keeps the cached inputs readable only by the user", func() {
+		info, err := os.Stat(filepath.Join(root, ".aviator", "cache", Digest([]byte("a: 1\n"))))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+	})
+
+	It("fails to load unknown runs", func() {
+		_, err := Load(root, "unknown")
+		Expect(err).To(MatchError(ContainSubstring("not found in the history")))
+	})
+
+	It("restores the recorded inputs into a directory", func() {
+		Expect(Restore(root, run, dir)).To(Succeed())
+		content, err := ioutil.ReadFile(filepath.Join(dir, "templates", "base.yml"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(Equal("a: 1\n"))
+	})
+
+	It("refuses to restore runs whose inputs are not cached anymore", func() {
+		Expect(os.RemoveAll(filepath.Join(root, ".aviator", "cache"))).To(Succeed())
+		err := Restore(root, run, dir)
+		Expect(err).To(MatchError(ContainSubstring("templates/base.yml (not in cache)")))
+	})
+
+	It("reports environment variables which changed since the run", func() {
+		Expect(ChangedEnv(run)).To(BeEmpty())
+		os.Setenv("AVIATOR_TEST_ENV", "two")
+		Expect(ChangedEnv(run)).To(Equal([]string{"AVIATOR_TEST_ENV"}))
+	})
+
+	It("verifies reproduced outputs against the recorded digests", func() {
+		Expect(VerifyOutputs(run, dir)).To(Equal([]string{"out/result.yml"}))
+
+		Expect(os.MkdirAll(filepath.Join(dir, "out"), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "out", "result.yml"), []byte("a: 1\n"), 0644)).To(Succeed())
+		Expect(VerifyOutputs(run, dir)).To(BeEmpty())
+	})
+})
@@ -0,0 +1,231 @@
+package masker
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/JulzDiverse/aviator/conformer"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Placeholder replaces every masked value.
+const Placeholder = "<masked>"
+
+var separator = regexp.MustCompile(`^---(\s|$)`)
+
+// Masker replaces the values at a list of YAML paths, so they do not show up
+// in diffs or debug bundles. Paths are dotted, e.g. tls.certs[0].key, and
+// each segment may be a glob: * matches any single key or index and **
+// matches any number of them.
+type Masker struct {
+	patterns [][]string
+}
+
+var masker *Masker
+
+func New(paths []string) *Masker {
+	if len(paths) == 0 {
+		return nil
+	}
+
+	m := &Masker{}
+	for _, p := range paths {
+		m.patterns = append(m.patterns, segments(p))
+	}
+	return m
+}
+
+func Default() *Masker {
+	return masker
+}
+
+func Use(m *Masker) {
+	masker = m
+}
+
+// Mask returns file with the masked values replaced by the placeholder.
+// Documents which cannot be parsed are returned unchanged.
+func (m *Masker) Mask(file []byte) []byte {
+	if m == nil || len(file) == 0 {
+		return file
+	}
+
+	lines := strings.Split(string(file), "\n")
+	result := []string{}
+	start := 0
+	for i := 0; i <= len(lines); i++ {
+		if i < len(lines) && !separator.MatchString(lines[i]) {
+			continue
+		}
+		result = append(result, m.maskDocument(lines[start:i])...)
+		if i < len(lines) {
+			result = append(result, lines[i])
+		}
+		start = i + 1
+	}
+	return []byte(strings.Join(result, "\n"))
+}
+
+type target struct {
+	path []string
+	item bool
+}
+
+func (m *Masker) maskDocument(lines []string) []string {
+	doc := []byte(strings.Join(lines, "\n"))
+	var value interface{}
+	if err := yaml.Unmarshal(doc, &value); err != nil {
+		return lines
+	}
+
+	targets := []target{}
+	m.collect(value, []string{}, false, &targets)
+
+	replaced := map[int]string{}
+	dropped := map[int]bool{}
+	for _, t := range targets {
+		line, found := conformer.Locate(doc, t.path)
+		if !found || line > len(lines) {
+			continue
+		}
+		i := line - 1
+		masked, indent, ok := maskLine(lines[i], t)
+		if !ok {
+			continue
+		}
+		replaced[i] = masked
+		for _, c := range continuation(lines, i, indent, t.item) {
+			dropped[c] = true
+		}
+	}
+
+	result := []string{}
+	for i, l := range lines {
+		if dropped[i] {
+			continue
+		}
+		if masked, ok := replaced[i]; ok {
+			l = masked
+		}
+		result = append(result, l)
+	}
+	return result
+}
+
+// collect adds the path of every value matching a pattern to targets. Values
+// below a match are not visited, as they are masked with it.
+func (m *Masker) collect(value interface{}, at []string, item bool, targets *[]target) {
+	if len(at) > 0 && m.matches(at) {
+		*targets = append(*targets, target{path: append([]string{}, at...), item: item})
+		return
+	}
+
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		for k, child := range v {
+			m.collect(child, append(at, fmt.Sprint(k)), false, targets)
+		}
+	case []interface{}:
+		for i, child := range v {
+			m.collect(child, append(at, fmt.Sprint(i)), true, targets)
+		}
+	}
+}
+
+func (m *Masker) matches(at []string) bool {
+	for _, p := range m.patterns {
+		if match(p, at) {
+			return true
+		}
+	}
+	return false
+}
+
+func match(pattern, at []string) bool {
+	if len(pattern) == 0 {
+		return len(at) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(at); i++ {
+			if match(pattern[1:], at[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(at) == 0 {
+		return false
+	}
+	ok, err := path.Match(pattern[0], at[0])
+	if err != nil {
+		ok = pattern[0] == at[0]
+	}
+	return ok && match(pattern[1:], at[1:])
+}
+
+// segments splits a dotted path into its keys and indices, e.g.
+// a.b[0].c into a, b, 0 and c.
+func segments(p string) []string {
+	result := []string{}
+	for _, s := range strings.Split(strings.Replace(p, "[", ".[", -1), ".") {
+		s = strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+		if s != "" {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// maskLine replaces the value on the line of t and returns the indentation
+// below which its continuation lines are nested.
+func maskLine(l string, t target) (string, int, bool) {
+	raw := len(l) - len(strings.TrimLeft(l, " "))
+	text := l[raw:]
+	if t.item {
+		if text != "-" && !strings.HasPrefix(text, "- ") {
+			return "", 0, false
+		}
+		return l[:raw] + "- " + Placeholder, raw, true
+	}
+
+	eff := raw
+	if text == "-" || strings.HasPrefix(text, "- ") {
+		rest := strings.TrimLeft(strings.TrimPrefix(text, "-"), " ")
+		eff += len(text) - len(rest)
+		text = rest
+	}
+	key := t.path[len(t.path)-1]
+	for _, k := range []string{key, `"` + key + `"`, `'` + key + `'`} {
+		if strings.HasPrefix(text, k+":") {
+			return l[:eff] + k + ": " + Placeholder, eff, true
+		}
+	}
+	return "", 0, false
+}
+
+// continuation returns the lines after i which belong to its value, i.e.
+// the lines of a block scalar, a nested map or a sequence.
+func continuation(lines []string, i, indent int, item bool) []int {
+	last := i
+	for j := i + 1; j < len(lines); j++ {
+		text := strings.TrimLeft(lines[j], " ")
+		raw := len(lines[j]) - len(text)
+		if text == "" {
+			continue
+		}
+		dash := text == "-" || strings.HasPrefix(text, "- ")
+		if raw > indent || (!item && dash && raw == indent) {
+			last = j
+			continue
+		}
+		break
+	}
+
+	result := []int{}
+	for j := i + 1; j <= last; j++ {
+		result = append(result, j)
+	}
+	return result
+}
@@ -0,0 +1,13 @@
+package masker_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestMasker(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Masker Suite")
+}
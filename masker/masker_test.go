@@ -0,0 +1,75 @@
+package masker_test
+
+import (
+	. "github.com/JulzDiverse/aviator/masker"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Masker", func() {
+
+	file := []byte(`name: app
+tls:
+  cert: |
+    -----BEGIN CERTIFICATE-----
+    MIIB
+
+    -----END CERTIFICATE-----
+  key: secret
+users:
+- name: admin
+  password: hunter2
+- name: guest
+  password: guest
+---
+tls:
+  cert: other
+  ca:
+    chain:
+    - one
+    - two
+`)
+
+	It("replaces the values at the paths", func() {
+		masked := New([]string{"tls.cert", "users[0].password"}).Mask(file)
+		Expect(string(masked)).To(Equal(`name: app
+tls:
+  cert: <masked>
+  key: secret
+users:
+- name: admin
+  password: <masked>
+- name: guest
+  password: guest
+---
+tls:
+  cert: <masked>
+  ca:
+    chain:
+    - one
+    - two
+`))
+	})
+
+	It("supports wildcards", func() {
+		masked := New([]string{"users.*.pass*", "**.chain"}).Mask(file)
+		Expect(string(masked)).To(ContainSubstring("- name: admin\n  password: <masked>\n- name: guest\n  password: <masked>\n"))
+		Expect(string(masked)).To(HaveSuffix("  ca:\n    chain: <masked>\n"))
+		Expect(string(masked)).To(ContainSubstring("MIIB"))
+	})
+
+	It("masks whole sequence items", func() {
+		masked := New([]string{"users[1]"}).Mask(file)
+		Expect(string(masked)).To(ContainSubstring("  password: hunter2\n- <masked>\n---\n"))
+	})
+
+	It("leaves files which are no YAML unchanged", func() {
+		Expect(New([]string{"tls"}).Mask([]byte("key: [unclosed\n"))).To(Equal([]byte("key: [unclosed\n")))
+	})
+
+	It("is a no-op without paths", func() {
+		Expect(New(nil)).To(BeNil())
+		Expect(New(nil).Mask(file)).To(Equal(file))
+	})
+})
@@ -3,88 +3,290 @@ package aviator
 import (
 	"os"
 	"os/exec"
+	"time"
 )
 
 type AviatorYaml struct {
-	Spruce []Spruce     `yaml:"spruce"`
-	Squash Squash       `yaml:"squash"`
-	Fly    Fly          `yaml:"fly"`
-	Kube   Kube         `yaml:"kubectl"`
-	Exec   []Executable `yaml:"exec"`
+	Include  []string     `yaml:"include" json:"include"`
+	Spruce   []Spruce     `yaml:"spruce" json:"spruce"`
+	Squash   Squash       `yaml:"squash" json:"squash"`
+	Template []Template   `yaml:"template" json:"template"`
+	Concat   []Concat     `yaml:"concat" json:"concat"`
+	Copy     []Copy       `yaml:"copy" json:"copy"`
+	Assert   []Assert     `yaml:"assert" json:"assert"`
+	Extract  []Extract    `yaml:"extract" json:"extract"`
+	Fly      Fly          `yaml:"fly" json:"fly"`
+	Kube     Kube         `yaml:"kubectl" json:"kubectl"`
+	Helm     Helm         `yaml:"helm" json:"helm"`
+	Exec     []Executable `yaml:"exec" json:"exec"`
+
+	Profiles  map[string]Profile `yaml:"profiles" json:"profiles"`
+	Workspace string             `yaml:"workspace" json:"workspace"`
+	Sandbox   Sandbox            `yaml:"sandbox" json:"sandbox"`
+	Mask      []string           `yaml:"mask" json:"mask"`
+	Auth      []SourceAuth       `yaml:"auth" json:"auth"`
+
+	Dependencies map[string]Dependency `yaml:"dependencies" json:"dependencies"`
+	VendorDir    string                `yaml:"vendor_dir" json:"vendor_dir"`
+	Theme        Theme                 `yaml:"theme" json:"theme"`
+}
+
+// SourceAuth are the credentials of the remote sources whose reference
+// starts with Prefix, e.g. https://artifacts.example.com/ or
+// git+ssh://git@github.com/org/. Secrets are read from the environment
+// variables named here.
+type SourceAuth struct {
+	Prefix string `yaml:"prefix" json:"prefix"`
+
+	// git+ssh sources
+	SSHKey   string `yaml:"ssh_key" json:"ssh_key"`
+	SSHAgent string `yaml:"ssh_agent" json:"ssh_agent"`
+
+	// https and git+https sources
+	TokenEnv    string `yaml:"token_env" json:"token_env"`
+	UsernameEnv string `yaml:"username_env" json:"username_env"`
+	PasswordEnv string `yaml:"password_env" json:"password_env"`
+
+	// s3 sources
+	AWSProfile string `yaml:"aws_profile" json:"aws_profile"`
+}
+
+type Theme struct {
+	Preset string            `yaml:"preset" json:"preset"`
+	Colors map[string]string `yaml:"colors" json:"colors"`
+}
+
+// Dependency is a template bundle vendored by 'aviator deps vendor', either
+// a git repository at a version (tag, branch or commit) or a file or
+// .tar.gz archive downloaded from url.
+type Dependency struct {
+	Git     string `yaml:"git" json:"git"`
+	URL     string `yaml:"url" json:"url"`
+	Version string `yaml:"version" json:"version"`
+	Path    string `yaml:"path" json:"path"`
+	Sha256  string `yaml:"sha256" json:"sha256"`
+}
+
+type Fleet struct {
+	Parallel int               `yaml:"parallel" json:"parallel"`
+	Vars     map[string]string `yaml:"vars" json:"vars"`
+	Pilots   []Pilot           `yaml:"pilots" json:"pilots"`
+}
+
+type Pilot struct {
+	Name      string            `yaml:"name" json:"name"`
+	Dir       string            `yaml:"dir" json:"dir"`
+	Repo      string            `yaml:"repo" json:"repo"`
+	Ref       string            `yaml:"ref" json:"ref"`
+	File      string            `yaml:"file" json:"file"`
+	Workspace string            `yaml:"workspace" json:"workspace"`
+	Vars      map[string]string `yaml:"vars" json:"vars"`
+	Args      []string          `yaml:"args" json:"args"`
+}
+
+type Sandbox struct {
+	Enabled    bool     `yaml:"enabled" json:"enabled"`
+	PassEnv    []string `yaml:"pass_env" json:"pass_env"`
+	Bubblewrap bool     `yaml:"bubblewrap" json:"bubblewrap"`
+}
+
+type Profile struct {
+	Vars  map[string]string `yaml:"vars" json:"vars"`
+	ToDir string            `yaml:"to_dir" json:"to_dir"`
+	Fly   Fly               `yaml:"fly" json:"fly"`
+	Kube  Kube              `yaml:"kubectl" json:"kubectl"`
+	Helm  Helm              `yaml:"helm" json:"helm"`
+	Exec  []Executable      `yaml:"exec" json:"exec"`
 }
 
 type Spruce struct {
-	Base        string   `yaml:"base"`
-	Merge       []Merge  `yaml:"merge"`
-	ForEach     ForEach  `yaml:"for_each"`
-	Prune       []string `yaml:"prune"`
-	CherryPicks []string `yaml:"cherry_pick"`
-	SkipEval    bool     `yaml:"skip_eval"`
-	GoPatch     bool     `yaml:"go_patch"`
-	To          string   `yaml:"to"`
-	ToDir       string   `yaml:"to_dir"`
-	Modify      Modify   `yaml:"modify"`
+	Name            string            `yaml:"name" json:"name"`
+	Base            string            `yaml:"base" json:"base"`
+	DefaultsDoc     string            `yaml:"defaults_doc" json:"defaults_doc"`
+	Engine          string            `yaml:"engine" json:"engine"`
+	ExtVars         map[string]string `yaml:"ext_vars" json:"ext_vars"`
+	Templates       []string          `yaml:"templates" json:"templates"`
+	Values          []string          `yaml:"values" json:"values"`
+	DataValuesEnv   string            `yaml:"data_values_env" json:"data_values_env"`
+	Merge           []Merge           `yaml:"merge" json:"merge"`
+	ForEach         ForEach           `yaml:"for_each" json:"for_each"`
+	Prune           []string          `yaml:"prune" json:"prune"`
+	CherryPicks     []string          `yaml:"cherry_pick" json:"cherry_pick"`
+	SkipEval        bool              `yaml:"skip_eval" json:"skip_eval"`
+	GoPatch         bool              `yaml:"go_patch" json:"go_patch"`
+	OpsFiles        OpsFiles          `yaml:"ops_files" json:"ops_files"`
+	To              string            `yaml:"to" json:"to"`
+	ToDir           string            `yaml:"to_dir" json:"to_dir"`
+	NormalizeTarget string            `yaml:"normalize_target" json:"normalize_target"`
+	Modify          Modify            `yaml:"modify" json:"modify"`
+	Encoding        Encoding          `yaml:"encoding" json:"encoding"`
+	Encrypt         Encrypt           `yaml:"encrypt" json:"encrypt"`
+	Mode            string            `yaml:"mode" json:"mode"`
+	PreservePerms   bool              `yaml:"preserve_permissions" json:"preserve_permissions"`
+	Priority        int               `yaml:"priority" json:"priority"`
+	PostProcess     []Executable      `yaml:"post_process" json:"post_process"`
+	Select          Select            `yaml:"select" json:"select"`
+	SplitByKind     bool              `yaml:"split_by_kind" json:"split_by_kind"`
+	ScanSecrets     string            `yaml:"scan_secrets" json:"scan_secrets"`
+	MergeTimeout    string            `yaml:"merge_timeout" json:"merge_timeout"`
+	MaxMemory       string            `yaml:"max_memory" json:"max_memory"`
+	Validate        Validate          `yaml:"validate" json:"validate"`
+	Policies        []string          `yaml:"policies" json:"policies"`
+
+	FailurePolicy `yaml:",inline"`
+	Budget        `yaml:",inline"`
+	Condition     `yaml:",inline"`
+}
+
+type Validate struct {
+	Kubernetes           bool     `yaml:"kubernetes" json:"kubernetes"`
+	KubernetesVersion    string   `yaml:"kubernetes_version" json:"kubernetes_version"`
+	SchemaLocations      []string `yaml:"schema_locations" json:"schema_locations"`
+	Strict               bool     `yaml:"strict" json:"strict"`
+	IgnoreMissingSchemas bool     `yaml:"ignore_missing_schemas" json:"ignore_missing_schemas"`
+	Schema               string   `yaml:"schema" json:"schema"`
+}
+
+type Select struct {
+	Kind       []string          `yaml:"kind" json:"kind"`
+	APIVersion []string          `yaml:"api_version" json:"api_version"`
+	Name       []string          `yaml:"name" json:"name"`
+	Labels     map[string]string `yaml:"labels" json:"labels"`
+	Exclude    bool              `yaml:"exclude" json:"exclude"`
+}
+
+type FailurePolicy struct {
+	OnFailure   string       `yaml:"on_failure" json:"on_failure"`
+	Retries     int          `yaml:"retries" json:"retries"`
+	FailureHook []Executable `yaml:"failure_hook" json:"failure_hook"`
+}
+
+type Budget struct {
+	WarnIfLongerThan string `yaml:"warn_if_longer_than" json:"warn_if_longer_than"`
+}
+
+type Condition struct {
+	WhenChanged []string `yaml:"when_changed" json:"when_changed"`
+	RunsOn      []string `yaml:"runs_on" json:"runs_on"`
+	Tags        []string `yaml:"tags" json:"tags"`
+}
+
+type OpsFiles struct {
+	Before []string `yaml:"before" json:"before"`
+	After  []string `yaml:"after" json:"after"`
+}
+
+type Encoding struct {
+	Newline         string `yaml:"newline" json:"newline"`
+	TrailingNewline bool   `yaml:"trailing_newline" json:"trailing_newline"`
+	StripBOM        *bool  `yaml:"strip_bom" json:"strip_bom"`
+}
+
+type Encrypt struct {
+	AgeRecipients []string `yaml:"age_recipients" json:"age_recipients"`
 }
 
 type Merge struct {
-	With      With     `yaml:"with"`
-	WithIn    string   `yaml:"with_in"`
-	WithAllIn string   `yaml:"with_all_in"`
-	Except    []string `yaml:"except"`
-	Regexp    string   `yaml:"regexp"`
+	With      With     `yaml:"with" json:"with"`
+	WithIn    string   `yaml:"with_in" json:"with_in"`
+	WithAllIn string   `yaml:"with_all_in" json:"with_all_in"`
+	Except    []string `yaml:"except" json:"except"`
+	Regexp    string   `yaml:"regexp" json:"regexp"`
+	Invert    bool     `yaml:"regexp_invert" json:"regexp_invert"`
+	Pick      []string `yaml:"pick" json:"pick"`
 }
 
 type With struct {
-	Files []string `yaml:"files"`
-	InDir string   `yaml:"in_dir"`
-	Skip  bool     `yaml:"skip_non_existing"`
+	Files    []string `yaml:"files" json:"files"`
+	InDir    string   `yaml:"in_dir" json:"in_dir"`
+	Skip     bool     `yaml:"skip_non_existing" json:"skip_non_existing"`
+	Sops     bool     `yaml:"sops" json:"sops"`
+	SkipEval bool     `yaml:"skip_eval" json:"skip_eval"`
 }
 
 type ForEach struct {
-	Files          []string `yaml:"files"`
-	InDir          string   `yaml:"in_dir"`
-	Skip           bool     `yaml:"skip_non_existing"`
-	In             string   `yaml:"in"`
-	Except         []string `yaml:"except"`
-	SubDirs        bool     `yaml:"include_sub_dirs"`
-	EnableMatching bool     `yaml:"enable_matching"`
-	CopyParents    bool     `yaml:"copy_parents"`
-	ForAll         string   `yaml:"for_all"`
-	Regexp         string   `yaml:"regexp"`
+	Files          []string `yaml:"files" json:"files"`
+	InDir          string   `yaml:"in_dir" json:"in_dir"`
+	Skip           bool     `yaml:"skip_non_existing" json:"skip_non_existing"`
+	In             string   `yaml:"in" json:"in"`
+	Except         []string `yaml:"except" json:"except"`
+	SubDirs        bool     `yaml:"include_sub_dirs" json:"include_sub_dirs"`
+	EnableMatching bool     `yaml:"enable_matching" json:"enable_matching"`
+	CopyParents    bool     `yaml:"copy_parents" json:"copy_parents"`
+	ForAll         string   `yaml:"for_all" json:"for_all"`
+	Regexp         string   `yaml:"regexp" json:"regexp"`
+	Invert         bool     `yaml:"regexp_invert" json:"regexp_invert"`
+	Metadata       bool     `yaml:"metadata" json:"metadata"`
 }
 
 type Fly struct {
-	Name           string            `yaml:"name"`
-	Target         string            `yaml:"target"`
-	Config         string            `yaml:"config"`
-	Vars           []string          `yaml:"load_vars_from"`
-	Expose         bool              `yaml:"expose"`
-	Var            map[string]string `yaml:"vars"`
-	NonInteractive bool              `yaml:"non_interactive"`
-	CheckCreds     bool              `yaml:"check_creds"`
+	Name           string            `yaml:"name" json:"name"`
+	Target         string            `yaml:"target" json:"target"`
+	Config         string            `yaml:"config" json:"config"`
+	Vars           []string          `yaml:"load_vars_from" json:"load_vars_from"`
+	Expose         bool              `yaml:"expose" json:"expose"`
+	Var            map[string]string `yaml:"vars" json:"vars"`
+	NonInteractive bool              `yaml:"non_interactive" json:"non_interactive"`
+	CheckCreds     bool              `yaml:"check_creds" json:"check_creds"`
 
 	//Validate Pipeline
-	ValidatePipeline bool `yaml:"validate_pipeline"`
-	Strict           bool `yaml:"strict"`
+	ValidatePipeline bool `yaml:"validate_pipeline" json:"validate_pipeline"`
+	Strict           bool `yaml:"strict" json:"strict"`
 
 	//Format Pipeline
-	FormatPipeline bool `yaml:"format_pipeline"`
-	Write          bool `yaml:"write"`
+	FormatPipeline bool `yaml:"format_pipeline" json:"format_pipeline"`
+	Write          bool `yaml:"write" json:"write"`
+
+	FailurePolicy `yaml:",inline"`
+	Budget        `yaml:",inline"`
+	Condition     `yaml:",inline"`
 }
 
 type Kube struct {
-	Apply KubeApply `yaml:"apply"`
+	Apply KubeApply `yaml:"apply" json:"apply"`
 }
 
 type KubeApply struct {
-	File      string `yaml:"file"`
-	Force     bool   `yaml:"force"`
-	DryRun    bool   `yaml:"dry_run"`
-	Overwrite bool   `yaml:"no_overwrite"`
-	Recursive bool   `yaml:"recursive"`
-	Output    string `yaml:"output"`
-	Kustomize bool   `yaml:"kustomize"`
-	Validate  bool   `yaml:"validate"`
+	File      string `yaml:"file" json:"file"`
+	Force     bool   `yaml:"force" json:"force"`
+	DryRun    bool   `yaml:"dry_run" json:"dry_run"`
+	Overwrite bool   `yaml:"no_overwrite" json:"no_overwrite"`
+	Recursive bool   `yaml:"recursive" json:"recursive"`
+	Output    string `yaml:"output" json:"output"`
+	Kustomize bool   `yaml:"kustomize" json:"kustomize"`
+	Validate  bool   `yaml:"validate" json:"validate"`
+	Export    string `yaml:"export" json:"export"`
+
+	Context string   `yaml:"context" json:"context"`
+	Before  []string `yaml:"before" json:"before"`
+	After   []string `yaml:"after" json:"after"`
+
+	FailurePolicy `yaml:",inline"`
+	Budget        `yaml:",inline"`
+	Condition     `yaml:",inline"`
+}
+
+type Helm struct {
+	Command      string            `yaml:"command" json:"command"`
+	Release      string            `yaml:"release" json:"release"`
+	Chart        string            `yaml:"chart" json:"chart"`
+	Namespace    string            `yaml:"namespace" json:"namespace"`
+	Values       []string          `yaml:"values" json:"values"`
+	ValuesSchema string            `yaml:"values_schema" json:"values_schema"`
+	Set          map[string]string `yaml:"set" json:"set"`
+	Wait         bool              `yaml:"wait" json:"wait"`
+	To           string            `yaml:"to" json:"to"`
+	Export       string            `yaml:"export" json:"export"`
+
+	FailurePolicy `yaml:",inline"`
+	Budget        `yaml:",inline"`
+	Condition     `yaml:",inline"`
+}
+
+type Warning struct {
+	Category string
+	Step     string
+	Message  string
 }
 
 type MergeConf struct {
@@ -94,51 +296,138 @@ type MergeConf struct {
 	SkipEval       bool
 	FallbackAppend bool
 	EnableGoPatch  bool
+	OpsFiles       []string
+	KeepBOM        bool
+	Sops           []string
+	SkipEvalFiles  []string
+	Guard          MergeGuard
+	Phases         *MergePhases
+}
+
+// MergeGuard limits a merge. A guarded merge runs in a child process, which
+// is killed if it exceeds Timeout or MaxMemory; the error names Step.
+type MergeGuard struct {
+	Step      string
+	Timeout   string
+	MaxMemory string
+}
+
+type MergePhases struct {
+	Read  time.Duration
+	Merge time.Duration
+	Eval  time.Duration
+	Write time.Duration
+}
+
+// PlannedMerge is a merge the spruce plan would run, as listed by the plan
+// command.
+type PlannedMerge struct {
+	Step string
+	To   string
+	MergeConf
+}
+
+// PlannedCommand is an executor command the run would execute.
+type PlannedCommand struct {
+	Step string
+	Args []string
+}
+
+// ForEachSummary counts the candidate files of a for_each step and why
+// candidates did not render a target.
+type ForEachSummary struct {
+	Step       string
+	Considered int
+	Matched    int
+	Excluded   map[string]int
 }
 
 type Modify struct {
-	Delete []string  `yaml:"delete"`
-	Set    []PathVal `yaml:"set"`
-	Update []PathVal `yaml:"update"`
+	Delete []string  `yaml:"delete" json:"delete"`
+	Set    []PathVal `yaml:"set" json:"set"`
+	Update []PathVal `yaml:"update" json:"update"`
 }
 
 type PathVal struct {
-	Path  string `yaml:"path"`
-	Value string `yaml:"value"`
+	Path  string `yaml:"path" json:"path"`
+	Value string `yaml:"value" json:"value"`
+}
+
+type Template struct {
+	Source string `yaml:"source" json:"source"`
+	Values string `yaml:"values" json:"values"`
+	To     string `yaml:"to" json:"to"`
+}
+
+type Concat struct {
+	Merges []string `yaml:"merges" json:"merges"`
+	Files  []string `yaml:"files" json:"files"`
+	Glob   string   `yaml:"glob" json:"glob"`
+	Dir    string   `yaml:"dir" json:"dir"`
+	To     string   `yaml:"to" json:"to"`
+}
+
+type Copy struct {
+	From    string `yaml:"from" json:"from"`
+	To      string `yaml:"to" json:"to"`
+	Symlink bool   `yaml:"symlink" json:"symlink"`
+}
+
+type Assert struct {
+	File   string   `yaml:"file" json:"file"`
+	That   []string `yaml:"that" json:"that"`
+	Select Select   `yaml:"select" json:"select"`
+}
+
+type Extract struct {
+	File   string            `yaml:"file" json:"file"`
+	Vars   map[string]string `yaml:"vars" json:"vars"`
+	Select Select            `yaml:"select" json:"select"`
 }
 
 type Squash struct {
-	Contents []SquashContent `yaml:"contents"`
-	To       string          `yaml:"to"`
+	Contents []SquashContent `yaml:"contents" json:"contents"`
+	To       string          `yaml:"to" json:"to"`
+	Select   Select          `yaml:"select" json:"select"`
 }
 
 type SquashContent struct {
-	Files  []string `yaml:"files"`
-	Except []string `yaml:"except"`
-	Dir    string   `yaml:"dir"`
+	Files  []string `yaml:"files" json:"files"`
+	Except []string `yaml:"except" json:"except"`
+	Dir    string   `yaml:"dir" json:"dir"`
 }
 
 type Executable struct {
-	Executable    string   `yaml:"executable"`
-	GlobalOptions []Option `yaml:"global_options"`
-	Command       Command  `yaml:"command"`
-	Args          []string `yaml:"args"`
+	Executable    string   `yaml:"executable" json:"executable"`
+	GlobalOptions []Option `yaml:"global_options" json:"global_options"`
+	Command       Command  `yaml:"command" json:"command"`
+	Args          []string `yaml:"args" json:"args"`
+	Shell         bool     `yaml:"shell" json:"shell"`
+	Export        string   `yaml:"export" json:"export"`
+
+	FailurePolicy `yaml:",inline"`
+	Budget        `yaml:",inline"`
+	Condition     `yaml:",inline"`
 }
 
 type Option struct {
-	Name  string `yaml:"name"`
-	Value string `yaml:"value"`
+	Name  string `yaml:"name" json:"name"`
+	Value string `yaml:"value" json:"value"`
 }
 
 type Command struct {
-	Name    string   `yaml:"name"`
-	Options []Option `yaml:"options"`
+	Name    string   `yaml:"name" json:"name"`
+	Options []Option `yaml:"options" json:"options"`
 }
 
 //go:generate counterfeiter . SpruceProcessor
 type SpruceProcessor interface {
 	Process([]Spruce) error
 	ProcessWithOpts([]Spruce, bool, bool, bool) error
+	Plan([]Spruce) ([]PlannedMerge, error)
+	RenderTarget(string)
+	Warnings() []Warning
+	Outputs(string) ([]string, bool)
 }
 
 //go:generate counterfeiter . Executor
@@ -162,6 +451,7 @@ type FileStore interface {
 //go:generate counterfeiter . Validator
 type Validator interface {
 	ValidateSpruce([]Spruce) error
+	ValidateExecutors(AviatorYaml) error
 }
 
 //go:generate counterfeiter . Modifier
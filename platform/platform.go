@@ -0,0 +1,32 @@
+package platform
+
+import (
+	"runtime"
+	"strings"
+)
+
+func Current() string {
+	return runtime.GOOS + "/" + runtime.GOARCH
+}
+
+func Matches(runsOn []string) bool {
+	return Supports(runsOn, Current())
+}
+
+func Supports(runsOn []string, platform string) bool {
+	if len(runsOn) == 0 {
+		return true
+	}
+
+	current := strings.SplitN(platform, "/", 2)
+	for _, p := range runsOn {
+		parts := strings.SplitN(strings.TrimSpace(p), "/", 2)
+		if parts[0] != current[0] && parts[0] != "*" {
+			continue
+		}
+		if len(parts) == 1 || parts[1] == "*" || (len(current) == 2 && parts[1] == current[1]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package platform_test
+
+import (
+	. "github.com/JulzDiverse/aviator/platform"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Platform", func() {
+
+	It("matches every platform without constraints", func() {
+		Expect(Supports(nil, "linux/amd64")).To(BeTrue())
+	})
+
+	It("matches os/arch pairs", func() {
+		Expect(Supports([]string{"linux/amd64"}, "linux/amd64")).To(BeTrue())
+		Expect(Supports([]string{"linux/amd64"}, "linux/arm64")).To(BeFalse())
+		Expect(Supports([]string{"linux/amd64", "darwin/arm64"}, "darwin/arm64")).To(BeTrue())
+	})
+
+	It("matches every arch of an os given without arch", func() {
+		Expect(Supports([]string{"darwin"}, "darwin/amd64")).To(BeTrue())
+		Expect(Supports([]string{"darwin/*"}, "darwin/arm64")).To(BeTrue())
+		Expect(Supports([]string{"darwin"}, "linux/amd64")).To(BeFalse())
+	})
+
+	It("matches wildcard os", func() {
+		Expect(Supports([]string{"*/arm64"}, "linux/arm64")).To(BeTrue())
+		Expect(Supports([]string{"*/arm64"}, "linux/amd64")).To(BeFalse())
+	})
+
+	It("knows the current platform", func() {
+		Expect(Matches([]string{Current()})).To(BeTrue())
+	})
+})
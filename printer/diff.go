@@ -0,0 +1,50 @@
+package printer
+
+import (
+	"strings"
+
+	"github.com/JulzDiverse/aviator/differ"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+func AnsiPrintUnifiedDiff(lines []string) {
+	BeautyPrintUnifiedDiff(lines, ansi.Printf)
+}
+
+func BeautyPrintUnifiedDiff(lines []string, printf Print) {
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+			printf("@W{%s}\n", line)
+		case strings.HasPrefix(line, "@@"):
+			printf("@C{%s}\n", line)
+		case strings.HasPrefix(line, "+"):
+			printf("@G{%s}\n", line)
+		case strings.HasPrefix(line, "-"):
+			printf("@R{%s}\n", line)
+		default:
+			printf("%s\n", line)
+		}
+	}
+}
+
+func AnsiPrintSemanticDiff(file string, changes []differ.Change) {
+	BeautyPrintSemanticDiff(file, changes, ansi.Printf)
+}
+
+func BeautyPrintSemanticDiff(file string, changes []differ.Change, printf Print) {
+	if len(changes) == 0 {
+		return
+	}
+	printf("@W{%s}\n", file)
+	for _, c := range changes {
+		switch c.Kind {
+		case differ.Added:
+			printf("\t@G{+ %s: %s}\n", c.Path, c.New)
+		case differ.Removed:
+			printf("\t@R{- %s: %s}\n", c.Path, c.Old)
+		default:
+			printf("\t@Y{~ %s: %s -> %s}\n", c.Path, c.Old, c.New)
+		}
+	}
+}
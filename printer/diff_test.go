@@ -0,0 +1,50 @@
+package printer_test
+
+import (
+	"bytes"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/JulzDiverse/aviator/differ"
+	. "github.com/JulzDiverse/aviator/printer"
+)
+
+var _ = Describe("Diff", func() {
+	var buf bytes.Buffer
+
+	printf := func(format string, a ...interface{}) (int, error) {
+		return fmt.Fprintf(&buf, format, a...)
+	}
+
+	BeforeEach(func() {
+		buf.Reset()
+	})
+
+	It("colors the lines of a unified diff", func() {
+		BeautyPrintUnifiedDiff([]string{"--- a/a.yml", "+++ b/a.yml", "@@ -1,2 +1,2 @@", " a: 1", "-b: 2", "+b: 3"}, printf)
+
+		Expect(buf.String()).To(Equal(`@W{--- a/a.yml}
+@W{+++ b/a.yml}
+@C{@@ -1,2 +1,2 @@}
+ a: 1
+@R{-b: 2}
+@G{+b: 3}
+`))
+	})
+
+	It("prints the changed paths of a semantic diff", func() {
+		BeautyPrintSemanticDiff("a.yml", []differ.Change{
+			{Path: "b", Kind: differ.Changed, Old: "2", New: "3"},
+			{Path: "c", Kind: differ.Added, New: "4"},
+		}, printf)
+
+		Expect(buf.String()).To(Equal("@W{a.yml}\n\t@Y{~ b: 2 -> 3}\n\t@G{+ c: 4}\n"))
+	})
+
+	It("prints nothing for unchanged files", func() {
+		BeautyPrintSemanticDiff("a.yml", nil, printf)
+		Expect(buf.String()).To(BeEmpty())
+	})
+})
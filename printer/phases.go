@@ -0,0 +1,27 @@
+package printer
+
+import (
+	"time"
+
+	"github.com/JulzDiverse/aviator"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+func AnsiPrintPhases(phases aviator.MergePhases) {
+	BeautyPrintPhases(phases, ansi.Printf)
+}
+
+func BeautyPrintPhases(phases aviator.MergePhases, printf Print) {
+	printf("\t@c{TIMING:} read %s @c{|} merge %s @c{|} eval %s @c{|} write %s\n\n",
+		round(phases.Read), round(phases.Merge), round(phases.Eval), round(phases.Write))
+}
+
+func round(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(10 * time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond)
+	}
+	return d.Round(time.Microsecond)
+}
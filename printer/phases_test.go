@@ -0,0 +1,32 @@
+package printer_test
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/JulzDiverse/aviator"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/JulzDiverse/aviator/printer"
+)
+
+var _ = Describe("Phases", func() {
+	Context("BeautyPrintPhases", func() {
+		It("prints the rounded duration of every phase", func() {
+			var buf bytes.Buffer
+			BeautyPrintPhases(aviator.MergePhases{
+				Read:  1234567 * time.Nanosecond,
+				Merge: 800 * time.Nanosecond,
+				Eval:  2345 * time.Millisecond,
+				Write: 45 * time.Microsecond,
+			}, func(format string, a ...interface{}) (int, error) {
+				return fmt.Fprintf(&buf, format, a...)
+			})
+
+			Expect(buf.String()).To(Equal("\t@c{TIMING:} read 1.23ms @c{|} merge 1µs @c{|} eval 2.35s @c{|} write 45µs\n\n"))
+		})
+	})
+})
@@ -0,0 +1,43 @@
+package printer
+
+import (
+	"strings"
+
+	"github.com/JulzDiverse/aviator"
+	"github.com/JulzDiverse/aviator/executor"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+func AnsiPrintPlan(merges []aviator.PlannedMerge, commands []aviator.PlannedCommand) {
+	BeautyPrintPlan(merges, commands, ansi.Printf)
+}
+
+func BeautyPrintPlan(merges []aviator.PlannedMerge, commands []aviator.PlannedCommand, printf Print) {
+	step := ""
+	for _, m := range merges {
+		if m.Step != step {
+			step = m.Step
+			printf("@G{%s}\n", step)
+		}
+		printf("\t%s\n", strings.Join(m.Files, ", "))
+		for _, prune := range m.Prune {
+			printf("\t\t@C{--prune} %s\n", prune)
+		}
+		for _, pick := range m.CherryPicks {
+			printf("\t\t@C{--cherry-pick} %s\n", pick)
+		}
+		for _, ops := range m.OpsFiles {
+			printf("\t\t@C{--ops-file} %s\n", ops)
+		}
+		if m.SkipEval {
+			printf("\t\t@C{--skip-eval}\n")
+		}
+		printf("\t\t@G{-> %s}\n", m.To)
+	}
+
+	for _, c := range commands {
+		printf("@G{%s}\n", c.Step)
+		printf("\t%s\n", executor.QuoteArgs(c.Args))
+	}
+	printf("\n%d merges, %d executor commands\n", len(merges), len(commands))
+}
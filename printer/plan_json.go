@@ -0,0 +1,124 @@
+package printer
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/JulzDiverse/aviator"
+)
+
+// PlanDocument is the machine readable form of a plan, as printed by
+// 'aviator plan --json'. Steps are listed in execution order; an edge links
+// a step to a later step reading one of its targets.
+type PlanDocument struct {
+	Steps []PlanStep `json:"steps"`
+	Edges []PlanEdge `json:"edges"`
+}
+
+type PlanStep struct {
+	Name     string      `json:"name"`
+	Kind     string      `json:"kind"`
+	Merges   []PlanMerge `json:"merges,omitempty"`
+	Commands [][]string  `json:"commands,omitempty"`
+	Inputs   []string    `json:"inputs"`
+	Targets  []string    `json:"targets"`
+}
+
+type PlanMerge struct {
+	Files       []string `json:"files"`
+	Prune       []string `json:"prune,omitempty"`
+	CherryPicks []string `json:"cherry_picks,omitempty"`
+	OpsFiles    []string `json:"ops_files,omitempty"`
+	SkipEval    bool     `json:"skip_eval,omitempty"`
+	To          string   `json:"to"`
+}
+
+type PlanEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Via  string `json:"via"`
+}
+
+func JSONPlan(merges []aviator.PlannedMerge, commands []aviator.PlannedCommand) ([]byte, error) {
+	return json.MarshalIndent(NewPlanDocument(merges, commands), "", "  ")
+}
+
+func NewPlanDocument(merges []aviator.PlannedMerge, commands []aviator.PlannedCommand) PlanDocument {
+	doc := PlanDocument{Steps: []PlanStep{}, Edges: []PlanEdge{}}
+	step := func(name string) *PlanStep {
+		if n := len(doc.Steps); n > 0 && doc.Steps[n-1].Name == name {
+			return &doc.Steps[n-1]
+		}
+		doc.Steps = append(doc.Steps, PlanStep{Name: name, Kind: stepKind(name), Inputs: []string{}, Targets: []string{}})
+		return &doc.Steps[len(doc.Steps)-1]
+	}
+
+	for _, m := range merges {
+		s := step(m.Step)
+		s.Merges = append(s.Merges, PlanMerge{
+			Files:       m.Files,
+			Prune:       m.Prune,
+			CherryPicks: m.CherryPicks,
+			OpsFiles:    m.OpsFiles,
+			SkipEval:    m.SkipEval,
+			To:          m.To,
+		})
+		s.Inputs = appendUnique(s.Inputs, m.Files...)
+		s.Inputs = appendUnique(s.Inputs, m.OpsFiles...)
+		s.Targets = appendUnique(s.Targets, m.To)
+	}
+
+	for _, c := range commands {
+		s := step(c.Step)
+		s.Commands = append(s.Commands, c.Args)
+		for _, arg := range c.Args {
+			for _, prev := range doc.Steps {
+				if prev.Name != s.Name && producedBy(arg, prev.Targets) != "" {
+					s.Inputs = appendUnique(s.Inputs, arg)
+				}
+			}
+		}
+	}
+
+	for i, s := range doc.Steps {
+		for _, input := range s.Inputs {
+			for _, prev := range doc.Steps[:i] {
+				if via := producedBy(input, prev.Targets); via != "" {
+					doc.Edges = append(doc.Edges, PlanEdge{From: prev.Name, To: s.Name, Via: via})
+				}
+			}
+		}
+	}
+	return doc
+}
+
+// stepKind returns the kind of a step from its name, e.g. spruce for
+// "spruce: result.yml".
+func stepKind(name string) string {
+	return strings.SplitN(name, ":", 2)[0]
+}
+
+// producedBy returns the first target equal to input or within the input
+// directory.
+func producedBy(input string, targets []string) string {
+	dir := strings.TrimSuffix(input, "/") + "/"
+	for _, t := range targets {
+		if t == input || strings.HasPrefix(t, dir) {
+			return t
+		}
+	}
+	return ""
+}
+
+func appendUnique(list []string, items ...string) []string {
+	for _, item := range items {
+		found := false
+		for _, l := range list {
+			found = found || l == item
+		}
+		if !found {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,80 @@
+package printer_test
+
+import (
+	"bytes"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/JulzDiverse/aviator"
+	. "github.com/JulzDiverse/aviator/printer"
+)
+
+var _ = Describe("Plan", func() {
+	var buf bytes.Buffer
+
+	printf := func(format string, a ...interface{}) (int, error) {
+		return fmt.Fprintf(&buf, format, a...)
+	}
+
+	BeforeEach(func() {
+		buf.Reset()
+	})
+
+	It("prints the merges grouped by step and the executor commands", func() {
+		BeautyPrintPlan([]aviator.PlannedMerge{
+			{Step: "spruce: results/", To: "results/a.yml", MergeConf: aviator.MergeConf{Files: []string{"base.yml", "a.yml"}, Prune: []string{"meta"}}},
+			{Step: "spruce: results/", To: "results/b.yml", MergeConf: aviator.MergeConf{Files: []string{"base.yml", "b.yml"}, CherryPicks: []string{"jobs"}}},
+		}, []aviator.PlannedCommand{
+			{Step: "kubectl: results/", Args: []string{"kubectl", "apply", "-f", "results/"}},
+		}, printf)
+
+		Expect(buf.String()).To(Equal(`@G{spruce: results/}
+	base.yml, a.yml
+		@C{--prune} meta
+		@G{-> results/a.yml}
+	base.yml, b.yml
+		@C{--cherry-pick} jobs
+		@G{-> results/b.yml}
+@G{kubectl: results/}
+	kubectl apply -f results/
+
+2 merges, 1 executor commands
+`))
+	})
+})
+
+var _ = Describe("JSON Plan", func() {
+	It("lists the inputs and targets of the steps and their dependencies", func() {
+		doc := NewPlanDocument([]aviator.PlannedMerge{
+			{Step: "spruce: results/", To: "results/a.yml", MergeConf: aviator.MergeConf{Files: []string{"base.yml", "a.yml"}, Prune: []string{"meta"}}},
+			{Step: "spruce: results/", To: "results/b.yml", MergeConf: aviator.MergeConf{Files: []string{"base.yml", "b.yml"}}},
+			{Step: "spruce: summary.yml", To: "summary.yml", MergeConf: aviator.MergeConf{Files: []string{"results/a.yml", "meta.yml"}}},
+		}, []aviator.PlannedCommand{
+			{Step: "kubectl: results/", Args: []string{"kubectl", "apply", "-f", "results/"}},
+		})
+
+		Expect(doc.Steps).To(HaveLen(3))
+		Expect(doc.Steps[0].Kind).To(Equal("spruce"))
+		Expect(doc.Steps[0].Merges).To(HaveLen(2))
+		Expect(doc.Steps[0].Merges[0].Prune).To(Equal([]string{"meta"}))
+		Expect(doc.Steps[0].Inputs).To(Equal([]string{"base.yml", "a.yml", "b.yml"}))
+		Expect(doc.Steps[0].Targets).To(Equal([]string{"results/a.yml", "results/b.yml"}))
+
+		Expect(doc.Steps[2].Kind).To(Equal("kubectl"))
+		Expect(doc.Steps[2].Commands).To(Equal([][]string{{"kubectl", "apply", "-f", "results/"}}))
+		Expect(doc.Steps[2].Inputs).To(Equal([]string{"results/"}))
+
+		Expect(doc.Edges).To(Equal([]PlanEdge{
+			{From: "spruce: results/", To: "spruce: summary.yml", Via: "results/a.yml"},
+			{From: "spruce: results/", To: "kubectl: results/", Via: "results/a.yml"},
+		}))
+	})
+
+	It("renders valid JSON", func() {
+		out, err := JSONPlan(nil, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(MatchJSON(`{"steps": [], "edges": []}`))
+	})
+})
@@ -10,11 +10,11 @@ import (
 
 type Print func(string, ...interface{}) (int, error)
 
-func AnsiPrint(opts aviator.MergeConf, to string, warnings []string, verbose bool) {
+func AnsiPrint(opts aviator.MergeConf, to string, warnings []aviator.Warning, verbose bool) {
 	BeautyfulPrint(opts, to, warnings, verbose, ansi.Printf)
 }
 
-func BeautyfulPrint(opts aviator.MergeConf, to string, warnings []string, verbose bool, printf Print) {
+func BeautyfulPrint(opts aviator.MergeConf, to string, warnings []aviator.Warning, verbose bool, printf Print) {
 	printf("@G{SPRUCE MERGE:}\n")
 	if len(opts.Prune) != 0 {
 		for _, prune := range opts.Prune {
@@ -24,12 +24,18 @@ func BeautyfulPrint(opts aviator.MergeConf, to string, warnings []string, verbos
 	for _, file := range opts.Files {
 		printf("\t%s\n", file)
 	}
+	for _, ops := range opts.OpsFiles {
+		printf("\t@C{--ops-file} %s\n", ops)
+	}
 	printf("\t@G{to: %s}\n\n", to)
 	if verbose && (len(warnings) > 0) { //global variable
 		printf("\t@Y{WARNINGS:}\n")
 		for _, w := range warnings {
-			sl := strings.Split(w, ":")
-			printf("\t@y{%s}:@Y{%s}\n", sl[0], sl[1])
+			sl := strings.SplitN(w.Message, ":", 2)
+			if len(sl) == 1 {
+				sl = append(sl, "")
+			}
+			printf("\t@y{%s}:@Y{%s} @c{[%s, %s]}\n", sl[0], sl[1], w.Category, w.Step)
 		}
 		fmt.Println()
 		fmt.Println()
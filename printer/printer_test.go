@@ -17,7 +17,7 @@ var _ = Describe("Printer", func() {
 	var (
 		opts     aviator.MergeConf
 		expected string
-		warnings []string
+		warnings []aviator.Warning
 		to       string
 	)
 
@@ -34,13 +34,18 @@ var _ = Describe("Printer", func() {
 	@G{to: dest}
 
 	@Y{WARNINGS:}
-	@y{skipped}:@Y{x}
-	@y{skipped}:@Y{y}
+	@y{skipped}:@Y{x} @c{[skipped, spruce: dest]}
+	@y{skipped}:@Y{y} @c{[skipped, spruce: dest]}
+	@y{no detail}:@Y{} @c{[missing-dir, spruce: dest]}
 
 
 `
 
-		warnings = []string{"skipped:x", "skipped:y"}
+		warnings = []aviator.Warning{
+			{Category: "skipped", Step: "spruce: dest", Message: "skipped:x"},
+			{Category: "skipped", Step: "spruce: dest", Message: "skipped:y"},
+			{Category: "missing-dir", Step: "spruce: dest", Message: "no detail"},
+		}
 		to = "dest"
 	})
 
@@ -49,10 +54,16 @@ var _ = Describe("Printer", func() {
 			output := captureOutput(BeautyfulPrint, opts, to, warnings, true, fmt.Printf)
 			Expect(output).To(Equal(expected))
 		})
+
+		It("prints the ops files applied after the merge", func() {
+			opts = aviator.MergeConf{Files: []string{"file"}, OpsFiles: []string{"ops.yml"}}
+			output := captureOutput(BeautyfulPrint, opts, to, nil, false, fmt.Printf)
+			Expect(output).To(Equal("@G{SPRUCE MERGE:}\n\tfile\n\t@C{--ops-file} ops.yml\n\t@G{to: dest}\n\n"))
+		})
 	})
 })
 
-func captureOutput(f func(aviator.MergeConf, string, []string, bool, Print), opts aviator.MergeConf, to string, warnings []string, verbose bool, printf Print) string {
+func captureOutput(f func(aviator.MergeConf, string, []aviator.Warning, bool, Print), opts aviator.MergeConf, to string, warnings []aviator.Warning, verbose bool, printf Print) string {
 	old := os.Stdout
 	r, w, _ := os.Pipe()
 	os.Stdout = w
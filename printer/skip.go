@@ -0,0 +1,16 @@
+package printer
+
+import "github.com/starkandwayne/goutils/ansi"
+
+func AnsiPrintSkipped(step, reason string, details []string) {
+	BeautyPrintSkipped(step, reason, details, ansi.Printf)
+}
+
+func BeautyPrintSkipped(step, reason string, details []string, printf Print) {
+	printf("@Y{SKIPPED:} %s\n", step)
+	printf("\t@Y{%s:}\n", reason)
+	for _, p := range details {
+		printf("\t@w{%s}\n", p)
+	}
+	printf("\n")
+}
@@ -0,0 +1,29 @@
+package printer_test
+
+import (
+	"bytes"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/JulzDiverse/aviator/printer"
+)
+
+var _ = Describe("Skipped", func() {
+	Context("BeautyPrintSkipped", func() {
+		It("prints the step, the reason and its details", func() {
+			var buf bytes.Buffer
+			BeautyPrintSkipped("kubectl: manifests/", "no changes in", []string{"infra/", "*.tf"}, func(format string, a ...interface{}) (int, error) {
+				return fmt.Fprintf(&buf, format, a...)
+			})
+
+			Expect(buf.String()).To(Equal(`@Y{SKIPPED:} kubectl: manifests/
+	@Y{no changes in:}
+	@w{infra/}
+	@w{*.tf}
+
+`))
+		})
+	})
+})
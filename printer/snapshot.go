@@ -0,0 +1,23 @@
+package printer
+
+import (
+	"github.com/JulzDiverse/aviator/snapshot"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+func AnsiPrintSnapshots(result snapshot.Result) {
+	BeautyPrintSnapshots(result, ansi.Printf)
+}
+
+func BeautyPrintSnapshots(result snapshot.Result, printf Print) {
+	for _, path := range result.Added {
+		printf("@G{added}    %s\n", path)
+	}
+	for _, path := range result.Changed {
+		printf("@Y{changed}  %s\n", path)
+	}
+	for _, path := range result.Removed {
+		printf("@R{removed}  %s\n", path)
+	}
+	printf("\n%d added, %d changed, %d removed\n", len(result.Added), len(result.Changed), len(result.Removed))
+}
@@ -0,0 +1,32 @@
+package printer_test
+
+import (
+	"bytes"
+	"fmt"
+
+	. "github.com/JulzDiverse/aviator/printer"
+	"github.com/JulzDiverse/aviator/snapshot"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Snapshots", func() {
+	It("prints the added, changed and removed snapshots", func() {
+		var buf bytes.Buffer
+		BeautyPrintSnapshots(snapshot.Result{
+			Added:   []string{"results/new.yml"},
+			Changed: []string{"results/api.yml"},
+			Removed: []string{"results/old.yml"},
+		}, func(format string, a ...interface{}) (int, error) {
+			return fmt.Fprintf(&buf, format, a...)
+		})
+
+		Expect(buf.String()).To(Equal(`@G{added}    results/new.yml
+@Y{changed}  results/api.yml
+@R{removed}  results/old.yml
+
+1 added, 1 changed, 1 removed
+`))
+	})
+})
@@ -1,6 +1,10 @@
 package printer
 
-import "github.com/starkandwayne/goutils/ansi"
+import (
+	"sort"
+
+	"github.com/starkandwayne/goutils/ansi"
+)
 
 func AnsiPrintSquash(files []string, to string) {
 	BeautyPrintSquash(files, to, ansi.Printf)
@@ -13,3 +17,72 @@ func BeautyPrintSquash(files []string, to string, printf Print) {
 	}
 	printf("\t@M{to: %s}\n", to)
 }
+
+func AnsiPrintConcat(files []string, to string) {
+	BeautyPrintConcat(files, to, ansi.Printf)
+}
+
+func BeautyPrintConcat(files []string, to string, printf Print) {
+	printf("@M{CONCAT FILES:}\n")
+	for _, f := range files {
+		printf("\t@w{%s}\n", f)
+	}
+	printf("\t@M{to: %s}\n\n", to)
+}
+
+func AnsiPrintTemplate(source, values, to string) {
+	BeautyPrintTemplate(source, values, to, ansi.Printf)
+}
+
+func BeautyPrintTemplate(source, values, to string, printf Print) {
+	printf("@B{RENDER TEMPLATE:}\n")
+	printf("\t@w{%s}\n", source)
+	printf("\t@C{--values} %s\n", values)
+	printf("\t@B{to: %s}\n\n", to)
+}
+
+func AnsiPrintCopy(from, to []string, symlink bool) {
+	BeautyPrintCopy(from, to, symlink, ansi.Printf)
+}
+
+func BeautyPrintCopy(from, to []string, symlink bool, printf Print) {
+	if symlink {
+		printf("@B{LINK FILES:}\n")
+	} else {
+		printf("@B{COPY FILES:}\n")
+	}
+	for i := range from {
+		printf("\t@w{%s} @B{->} %s\n", from[i], to[i])
+	}
+	printf("\n")
+}
+
+func AnsiPrintAssert(file string, assertions []string) {
+	BeautyPrintAssert(file, assertions, ansi.Printf)
+}
+
+func BeautyPrintAssert(file string, assertions []string, printf Print) {
+	printf("@G{ASSERTIONS PASSED:} %s\n", file)
+	for _, a := range assertions {
+		printf("\t@w{%s}\n", a)
+	}
+	printf("\n")
+}
+
+func AnsiPrintExtract(file string, vars map[string]string) {
+	BeautyPrintExtract(file, vars, ansi.Printf)
+}
+
+func BeautyPrintExtract(file string, vars map[string]string, printf Print) {
+	names := []string{}
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	printf("@B{EXTRACT FROM:} %s\n", file)
+	for _, name := range names {
+		printf("\t@w{%s} @B{->} (( steps.%s ))\n", vars[name], name)
+	}
+	printf("\n")
+}
@@ -0,0 +1,40 @@
+package printer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/JulzDiverse/aviator"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+func AnsiPrintForEachSummary(summary aviator.ForEachSummary) {
+	BeautyPrintForEachSummary(summary, ansi.Printf)
+}
+
+func BeautyPrintForEachSummary(summary aviator.ForEachSummary, printf Print) {
+	reasons := []string{}
+	excluded := 0
+	for reason, n := range summary.Excluded {
+		reasons = append(reasons, reason)
+		excluded += n
+	}
+	sort.Strings(reasons)
+
+	details := []string{}
+	for _, reason := range reasons {
+		details = append(details, fmt.Sprintf("%d by %s", summary.Excluded[reason], reason))
+	}
+
+	printf("@G{FOR EACH SUMMARY:} %s\n", summary.Step)
+	printf("\t%d considered, %d matched, %d excluded", summary.Considered, summary.Matched, excluded)
+	if len(details) != 0 {
+		printf(" (%s)", strings.Join(details, ", "))
+	}
+	printf("\n")
+	if summary.Matched == 0 {
+		printf("\t@Y{no file matched, nothing was rendered}\n")
+	}
+	printf("\n")
+}
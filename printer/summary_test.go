@@ -0,0 +1,48 @@
+package printer_test
+
+import (
+	"bytes"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/JulzDiverse/aviator"
+	. "github.com/JulzDiverse/aviator/printer"
+)
+
+var _ = Describe("ForEachSummary", func() {
+	var buf bytes.Buffer
+
+	printf := func(format string, a ...interface{}) (int, error) {
+		return fmt.Fprintf(&buf, format, a...)
+	}
+
+	BeforeEach(func() {
+		buf.Reset()
+	})
+
+	It("prints the candidates, the matches and the exclusions by reason", func() {
+		BeautyPrintForEachSummary(aviator.ForEachSummary{
+			Step:       "spruce: results/",
+			Considered: 5,
+			Matched:    2,
+			Excluded:   map[string]int{"regexp": 2, "except": 1},
+		}, printf)
+
+		Expect(buf.String()).To(Equal(`@G{FOR EACH SUMMARY:} spruce: results/
+	5 considered, 2 matched, 3 excluded (1 by except, 2 by regexp)
+
+`))
+	})
+
+	It("highlights steps which rendered nothing", func() {
+		BeautyPrintForEachSummary(aviator.ForEachSummary{Step: "spruce: results/", Excluded: map[string]int{}}, printf)
+
+		Expect(buf.String()).To(Equal(`@G{FOR EACH SUMMARY:} spruce: results/
+	0 considered, 0 matched, 0 excluded
+	@Y{no file matched, nothing was rendered}
+
+`))
+	})
+})
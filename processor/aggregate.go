@@ -0,0 +1,51 @@
+package processor
+
+import (
+	"strings"
+
+	"github.com/JulzDiverse/aviator"
+	"github.com/JulzDiverse/aviator/printer"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+type failures struct {
+	steps    []aviator.Spruce
+	messages []string
+}
+
+func (p *Processor) CollectErrors(collect bool) {
+	p.collectErrors = collect
+}
+
+func (f *failures) add(cfg aviator.Spruce, err error) {
+	f.steps = append(f.steps, cfg)
+	f.messages = append(f.messages, ansi.Sprintf("@m{%s}: %s", stepName(cfg), err.Error()))
+}
+
+func (f *failures) blocking(cfg aviator.Spruce) string {
+	for _, failed := range f.steps {
+		if dependsOn(cfg, failed) {
+			return stepName(failed)
+		}
+	}
+	return ""
+}
+
+func (p *Processor) skipBlocked(cfg aviator.Spruce, f *failures) bool {
+	failed := f.blocking(cfg)
+	if failed == "" {
+		return false
+	}
+	if !p.silent {
+		printer.AnsiPrintSkipped(stepName(cfg), "reads the output of a failed step", []string{failed})
+	}
+	f.messages = append(f.messages, ansi.Sprintf("@m{%s}: skipped, reads the output of the failed step @m{%s}", stepName(cfg), failed))
+	return true
+}
+
+func (f *failures) err(total int) error {
+	if len(f.messages) == 0 {
+		return nil
+	}
+	return ansi.Errorf("@R{%d of %d spruce steps FAILED}:\n\t%s", len(f.messages), total, strings.Join(f.messages, "\n\t"))
+}
@@ -0,0 +1,21 @@
+package processor
+
+import (
+	"github.com/JulzDiverse/aviator"
+	"github.com/JulzDiverse/aviator/changes"
+	"github.com/JulzDiverse/aviator/platform"
+	"github.com/JulzDiverse/aviator/tags"
+)
+
+func Skipped(cond aviator.Condition) (bool, string, []string) {
+	if !platform.Matches(cond.RunsOn) {
+		return true, "runs only on (current: " + platform.Current() + ")", cond.RunsOn
+	}
+	if !changes.Default().Touches(cond.WhenChanged) {
+		return true, "no changes in", cond.WhenChanged
+	}
+	if !tags.Default().Matches(cond.Tags) {
+		return true, "not selected by tags", cond.Tags
+	}
+	return false, "", nil
+}
@@ -0,0 +1,67 @@
+package processor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/JulzDiverse/aviator"
+	"github.com/JulzDiverse/aviator/masker"
+)
+
+var unsafeChars = regexp.MustCompile(`[^-\w.]+`)
+
+func (p *Processor) DebugBundles(dir string) {
+	p.debugDir = dir
+}
+
+func (p *Processor) writeDebugBundle(mergeConf aviator.MergeConf, to string, mergeErr error) (string, error) {
+	dir := filepath.Join(p.debugDir, unsafeChars.ReplaceAllString(resolveBraces(to), "_"))
+	inputs := filepath.Join(dir, "inputs")
+	if err := os.MkdirAll(inputs, 0755); err != nil {
+		return "", err
+	}
+
+	var concatenated, reproduce []string
+	for i, file := range mergeConf.Files {
+		content, _ := p.store.ReadFile(file)
+		content = masker.Default().Mask(content)
+		name := fmt.Sprintf("%02d-%s", i, unsafeChars.ReplaceAllString(filepath.Base(resolveBraces(file)), "_"))
+		if err := ioutil.WriteFile(filepath.Join(inputs, name), content, 0644); err != nil {
+			return "", err
+		}
+		concatenated = append(concatenated, fmt.Sprintf("# %s\n%s", file, content))
+		reproduce = append(reproduce, filepath.Join("inputs", name))
+	}
+
+	cmd := []string{"spruce", "merge"}
+	if mergeConf.SkipEval {
+		cmd = append(cmd, "--skip-eval")
+	}
+	if mergeConf.EnableGoPatch {
+		cmd = append(cmd, "--go-patch")
+	}
+	for _, prune := range mergeConf.Prune {
+		cmd = append(cmd, "--prune", prune)
+	}
+	for _, pick := range mergeConf.CherryPicks {
+		cmd = append(cmd, "--cherry-pick", pick)
+	}
+	cmd = append(cmd, reproduce...)
+
+	files := map[string]string{
+		"files.txt":    strings.Join(mergeConf.Files, "\n") + "\n",
+		"inputs.yml":   strings.Join(concatenated, "\n---\n"),
+		"error.txt":    mergeErr.Error() + "\n",
+		"reproduce.sh": "#!/bin/sh\ncd \"$(dirname \"$0\")\"\n" + strings.Join(cmd, " ") + "\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0755); err != nil {
+			return "", err
+		}
+	}
+	return dir, nil
+}
@@ -0,0 +1,48 @@
+package processor
+
+import (
+	"github.com/JulzDiverse/aviator"
+	"github.com/JulzDiverse/aviator/strategic"
+	"github.com/JulzDiverse/aviator/tracer"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+const (
+	engineSpruce         = "spruce"
+	engineStrategicMerge = "strategic-merge"
+	engineJsonnet        = "jsonnet"
+	engineYtt            = "ytt"
+)
+
+func (p *Processor) merge(mergeConf aviator.MergeConf, cfg aviator.Spruce, to string) ([]byte, error) {
+	switch cfg.Engine {
+	case engineJsonnet:
+		return p.evaluateJsonnet(mergeConf.Files[0], cfg.ExtVars, to)
+	case engineYtt:
+		return p.evaluateYtt(mergeConf.Files, cfg)
+	case engineStrategicMerge:
+		docs, err := p.readAll(mergeConf.Files)
+		if err != nil {
+			return nil, err
+		}
+		return strategic.Merge(docs)
+	}
+	if cfg.MergeTimeout != "" || cfg.MaxMemory != "" {
+		mergeConf.Guard = aviator.MergeGuard{Step: "spruce: " + to, Timeout: cfg.MergeTimeout, MaxMemory: cfg.MaxMemory}
+	}
+	return tracer.Default().Merge("spruce: "+to, mergeConf, p.store.ReadFile, func() ([]byte, error) {
+		return p.spruceClient.MergeWithOpts(mergeConf)
+	})
+}
+
+func (p *Processor) readAll(files []string) ([][]byte, error) {
+	docs := [][]byte{}
+	for _, file := range files {
+		data, ok := p.store.ReadFile(file)
+		if !ok {
+			return nil, ansi.Errorf("@R{Error reading file from filesystem or internal datastore} @m{%s}", file)
+		}
+		docs = append(docs, data)
+	}
+	return docs, nil
+}
@@ -0,0 +1,222 @@
+package processor
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/JulzDiverse/aviator/authorizer"
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+const gitScheme = "git+"
+
+// UseGitCache checks out git sources below dir. The checkouts are kept
+// between runs; every repository and ref is fetched once per run and reused
+// by all steps.
+func (p *Processor) UseGitCache(dir string) {
+	p.gitCache = dir
+}
+
+// DisableGitFetch restricts git sources to the checkouts in the cache.
+func (p *Processor) DisableGitFetch() {
+	p.gitOffline = true
+}
+
+// UseAuth sets the credentials of the git sources.
+func (p *Processor) UseAuth(auth *authorizer.Authorizer) {
+	p.auth = auth
+}
+
+func isGitSource(file string) bool {
+	return strings.HasPrefix(file, gitScheme+"ssh://") || strings.HasPrefix(file, gitScheme+"https://") || strings.HasPrefix(file, gitScheme+"file://")
+}
+
+func defaultGitCache() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".aviator", "cache", "git")
+}
+
+// resolveGit returns the local path of the file a source of the form
+// git+https://host/repo.git?ref=<branch|tag|sha>&path=<file> refers to.
+func (p *Processor) resolveGit(source string) (string, error) {
+	repo, query := strings.TrimPrefix(source, gitScheme), ""
+	if i := strings.Index(repo, "?"); i >= 0 {
+		repo, query = repo[:i], repo[i+1:]
+	}
+
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return "", errors.Wrap(err, ansi.Sprintf("@R{Invalid git source} @m{%s}", source))
+	}
+	path := filepath.Clean(params.Get("path"))
+	if params.Get("path") == "" || filepath.IsAbs(path) || strings.HasPrefix(path, "..") {
+		return "", ansi.Errorf("@R{Invalid git source} @m{%s}@R{: requires a} @m{path} @R{within the repository}", source)
+	}
+	ref := params.Get("ref")
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	dir, err := p.checkoutGit(repo, ref, path)
+	if err != nil {
+		return "", err
+	}
+
+	file := filepath.Join(dir, path)
+	if _, err := os.Stat(file); err != nil {
+		return "", ansi.Errorf("@R{Git source} @m{%s}@R{:} @m{%s} @R{does not exist at} @m{%s}", source, path, ref)
+	}
+	return file, nil
+}
+
+func (p *Processor) checkoutGit(repo, ref, path string) (string, error) {
+	key := repo + "@" + ref
+	if p.checkouts == nil {
+		p.checkouts = map[string]string{}
+	}
+
+	cache := p.gitCache
+	if cache == "" {
+		var err error
+		cache, err = ioutil.TempDir("", "aviator-git")
+		if err != nil {
+			return "", errors.Wrap(err, ansi.Sprintf("@R{Creating git cache FAILED}"))
+		}
+		p.gitCache = cache
+	}
+
+	// the checkout is named after the repository, so for_each target names
+	// derived from the parent directory stay readable
+	sum := sha256.Sum256([]byte(key))
+	entry := filepath.Join(cache, hex.EncodeToString(sum[:8]))
+	dir := filepath.Join(entry, strings.TrimSuffix(filepath.Base(repo), ".git"))
+
+	_, fetched := p.checkouts[key]
+	if p.gitOffline {
+		if err := git(dir, "rev-parse", "-q", "--verify", "HEAD"); err != nil || !sparsePath(dir, path) {
+			return "", ansi.Errorf("@R{Git source} @m{%s} @R{at} @m{%s} @R{is not in the cache, which is required in offline mode}", repo+"?path="+path, ref)
+		}
+	} else {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+			err = git("", "init", "-q", dir)
+			if err == nil {
+				err = git(dir, "remote", "add", "origin", repo)
+			}
+			if err == nil {
+				err = git(dir, "config", "core.sparseCheckout", "true")
+			}
+			if err != nil {
+				return "", ansi.Errorf("@R{Fetching} @m{%s} @R{at} @m{%s} @R{FAILED}:\n%s", repo, ref, err.Error())
+			}
+		}
+
+		added, err := addSparsePath(dir, path)
+		if err != nil {
+			return "", errors.Wrap(err, ansi.Sprintf("@R{Updating the sparse checkout of} @m{%s} @R{FAILED}", repo))
+		}
+
+		// the checkout is kept between runs: the ref is fetched again once
+		// per run, and only the blobs of the referenced paths are downloaded
+		steps := [][]string{}
+		if !fetched {
+			steps = append(steps,
+				[]string{"fetch", "-q", "--depth", "1", "--filter=blob:none", "origin", ref},
+				[]string{"checkout", "-q", "--force", "FETCH_HEAD"},
+			)
+		}
+		authArgs, err := p.auth.GitArgs(gitScheme + repo)
+		if err != nil {
+			return "", err
+		}
+		authEnv := p.auth.GitEnv(gitScheme + repo)
+		if !fetched || added {
+			steps = append(steps, []string{"read-tree", "-mu", "HEAD"})
+		}
+		for _, args := range steps {
+			if err := gitWithAuth(dir, authEnv, append(authArgs, args...)...); err != nil {
+				return "", ansi.Errorf("@R{Fetching} @m{%s} @R{at} @m{%s} @R{FAILED}:\n%s", repo, ref, err.Error())
+			}
+		}
+	}
+
+	// the modification time tells 'aviator cache gc' when the checkout was last used
+	now := time.Now()
+	os.Chtimes(entry, now, now)
+
+	p.checkouts[key] = dir
+	return dir, nil
+}
+
+// git runs git in dir, if not empty, and returns its stderr as error.
+func git(dir string, args ...string) error {
+	return gitWithAuth(dir, nil, args...)
+}
+
+// gitWithAuth runs git like git with the additional environment variables
+// of the credentials.
+func gitWithAuth(dir string, env []string, args ...string) error {
+	if dir != "" {
+		args = append([]string{"-C", dir}, args...)
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Stderr = &stderr
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	if err := cmd.Run(); err != nil {
+		return errors.New(stderr.String())
+	}
+	return nil
+}
+
+func sparseFile(dir string) string {
+	return filepath.Join(dir, ".git", "info", "sparse-checkout")
+}
+
+func sparsePattern(path string) string {
+	return "/" + filepath.ToSlash(path)
+}
+
+func sparsePath(dir, path string) bool {
+	content, err := ioutil.ReadFile(sparseFile(dir))
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if line == sparsePattern(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// addSparsePath adds path to the sparse checkout of dir and reports whether
+// it was missing.
+func addSparsePath(dir, path string) (bool, error) {
+	if sparsePath(dir, path) {
+		return false, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(sparseFile(dir)), 0755); err != nil {
+		return false, err
+	}
+	f, err := os.OpenFile(sparseFile(dir), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	_, err = f.WriteString(sparsePattern(path) + "\n")
+	return true, err
+}
@@ -0,0 +1,121 @@
+package processor
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	helmScheme = "helm://"
+	helmPrefix = "{{aviator_helm/"
+)
+
+var docSeparator = regexp.MustCompile(`(?m)^---\s*$`)
+
+type manifest struct {
+	Kind     string `yaml:"kind"`
+	Metadata struct {
+		Name string `yaml:"name"`
+	} `yaml:"metadata"`
+}
+
+func isHelmSource(file string) bool {
+	return strings.HasPrefix(file, helmScheme)
+}
+
+func helmFileName(file string) string {
+	if strings.HasPrefix(file, helmPrefix) {
+		return resolveBraces(file)
+	}
+	return file
+}
+
+func (p *Processor) expandSources(files []string) ([]string, error) {
+	result := []string{}
+	for _, file := range files {
+		if isGitSource(file) {
+			local, err := p.resolveGit(file)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, local)
+			continue
+		}
+		if !isHelmSource(file) {
+			result = append(result, file)
+			continue
+		}
+
+		rendered, err := p.renderHelm(file)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, rendered...)
+	}
+	return result, nil
+}
+
+func (p *Processor) renderHelm(source string) ([]string, error) {
+	chart, query := strings.TrimPrefix(source, helmScheme), ""
+	if i := strings.Index(chart, "?"); i >= 0 {
+		chart, query = chart[:i], chart[i+1:]
+	}
+
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, errors.Wrap(err, ansi.Sprintf("@R{Invalid helm source} @m{%s}", source))
+	}
+
+	release := params.Get("release")
+	if release == "" {
+		release = filepath.Base(chart)
+	}
+
+	args := []string{"template", release, chart}
+	for _, values := range params["values"] {
+		args = append(args, "--values", values)
+	}
+	for _, set := range params["set"] {
+		args = append(args, "--set", set)
+	}
+	if ns := params.Get("namespace"); ns != "" {
+		args = append(args, "--namespace", ns)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("helm", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrap(err, ansi.Sprintf("@R{Rendering helm chart} @m{%s} @R{FAILED}: %s", chart, stderr.String()))
+	}
+
+	files := []string{}
+	for _, doc := range docSeparator.Split(stdout.String(), -1) {
+		var m manifest
+		var content interface{}
+		if err := yaml.Unmarshal([]byte(doc), &content); err != nil {
+			return nil, errors.Wrap(err, ansi.Sprintf("@R{Parsing output of helm chart} @m{%s} @R{FAILED}", chart))
+		}
+		if content == nil {
+			continue
+		}
+		yaml.Unmarshal([]byte(doc), &m)
+
+		key := fmt.Sprintf("%s%s/%s-%s.yml}}", helmPrefix, release, strings.ToLower(m.Kind), m.Metadata.Name)
+		if err := p.store.WriteFile(key, []byte(doc)); err != nil {
+			return nil, err
+		}
+		files = append(files, key)
+	}
+	return files, nil
+}
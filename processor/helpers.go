@@ -1,14 +1,15 @@
 package processor
 
 import (
+	"bytes"
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
 
 	"github.com/JulzDiverse/aviator"
+	"github.com/starkandwayne/goutils/ansi"
 )
 
 var quoteRegex = `(\{\{|\+\+)([-\_\.\/\w\p{L}\/]+)(\}\}|\+\+)`
@@ -23,12 +24,20 @@ func except(except []string, file string) bool {
 	return false
 }
 
-func getRegexp(regexpString string) string {
+func getRegexp(regexpString string) (*regexp.Regexp, error) {
 	regex := ".*"
 	if regexpString != "" {
 		regex = regexpString
 	}
-	return regex
+	compiled, err := regexp.Compile(regex)
+	if err != nil {
+		return nil, ansi.Errorf("@R{Invalid regexp} @m{%s}: %s", regex, err.Error())
+	}
+	return compiled, nil
+}
+
+func matchRegexp(regex *regexp.Regexp, invert bool, name string) bool {
+	return regex.MatchString(name) != invert
 }
 
 func fileExists(path string) bool {
@@ -56,6 +65,14 @@ func concatResults(sl1 [][]byte, sl2 ...[][]byte) [][]byte {
 	return sl1
 }
 
+func stepName(cfg aviator.Spruce) string {
+	to := cfg.To
+	if to == "" {
+		to = cfg.ToDir
+	}
+	return fmt.Sprintf("spruce: %s", to)
+}
+
 func mergeType(cfg aviator.Spruce) string {
 	if (cfg.ForEach.Files == nil ||
 		len(cfg.ForEach.Files) == 0) &&
@@ -78,25 +95,6 @@ func mergeType(cfg aviator.Spruce) string {
 	return ""
 }
 
-//TODO: filemanager
-func getAllFilesIncludingSubDirs(path string) []string {
-	sl := []string{}
-	err := filepath.Walk(path, fillSliceWithFiles(&sl))
-	if err != nil {
-		log.Fatal(err)
-	}
-	return sl
-}
-
-func fillSliceWithFiles(files *[]string) filepath.WalkFunc {
-	return func(path string, info os.FileInfo, err error) error {
-		if !info.IsDir() {
-			*files = append(*files, path)
-		}
-		return nil
-	}
-}
-
 func concatFileNameWithPath(path string) (string, string) {
 	var fileName, parent string
 	chunked := strings.Split(path, "/")
@@ -150,3 +148,15 @@ func resolveBraces(s string) string {
 
 	return s
 }
+
+func encode(file []byte, enc aviator.Encoding) []byte {
+	if enc.TrailingNewline && !bytes.HasSuffix(file, []byte("\n")) {
+		file = append(file, '\n')
+	}
+
+	if enc.Newline == "crlf" {
+		file = bytes.Replace(file, []byte("\r\n"), []byte("\n"), -1)
+		file = bytes.Replace(file, []byte("\n"), []byte("\r\n"), -1)
+	}
+	return file
+}
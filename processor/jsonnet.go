@@ -0,0 +1,58 @@
+package processor
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func (p *Processor) evaluateJsonnet(entrypoint string, extVars map[string]string, to string) ([]byte, error) {
+	keys := []string{}
+	for k := range extVars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	args := []string{}
+	for _, k := range keys {
+		args = append(args, "--ext-str", fmt.Sprintf("%s=%s", k, extVars[k]))
+	}
+
+	var stdin []byte
+	if _, err := os.Stat(entrypoint); err == nil {
+		args = append(args, entrypoint)
+	} else {
+		file, ok := p.store.ReadFile(entrypoint)
+		if !ok {
+			return nil, ansi.Errorf("@R{Error reading file from filesystem or internal datastore} @m{%s}", entrypoint)
+		}
+		stdin = file
+		args = append(args, "-")
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("jsonnet", args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrap(err, ansi.Sprintf("@R{Evaluating jsonnet} @m{%s} @R{FAILED}: %s", entrypoint, stderr.String()))
+	}
+
+	if filepath.Ext(resolveBraces(to)) == ".json" {
+		return stdout.Bytes(), nil
+	}
+
+	var doc interface{}
+	if err := yaml.Unmarshal(stdout.Bytes(), &doc); err != nil {
+		return nil, errors.Wrap(err, ansi.Sprintf("@R{Converting output of jsonnet} @m{%s} @R{to YAML FAILED}", entrypoint))
+	}
+	return yaml.Marshal(doc)
+}
@@ -0,0 +1,51 @@
+package processor
+
+import (
+	"strings"
+
+	"github.com/JulzDiverse/aviator"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+// ProcessNamed runs only the spruce blocks carrying one of the given names.
+func (p *Processor) ProcessNamed(config []aviator.Spruce, names []string) error {
+	selected, err := SelectNamed(config, names, nil)
+	if err != nil {
+		return err
+	}
+	return p.Process(selected)
+}
+
+// SelectNamed returns the spruce blocks named in only (all if only is empty)
+// and not named in skip. Blocks without a name are never selected by only.
+// Naming a block which does not exist is an error, as it most likely is a
+// typo.
+func SelectNamed(config []aviator.Spruce, only, skip []string) ([]aviator.Spruce, error) {
+	known := map[string]bool{}
+	for _, cfg := range config {
+		if cfg.Name != "" {
+			known[cfg.Name] = true
+		}
+	}
+	unknown := []string{}
+	for _, name := range append(append([]string{}, only...), skip...) {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, ansi.Errorf("@R{No spruce block named} @m{%s}", strings.Join(unknown, ", "))
+	}
+
+	selected := []aviator.Spruce{}
+	for _, cfg := range config {
+		if len(only) > 0 && !except(only, cfg.Name) {
+			continue
+		}
+		if cfg.Name != "" && except(skip, cfg.Name) {
+			continue
+		}
+		selected = append(selected, cfg)
+	}
+	return selected, nil
+}
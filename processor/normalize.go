@@ -0,0 +1,76 @@
+package processor
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/JulzDiverse/aviator"
+	"github.com/starkandwayne/goutils/ansi"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	normalizeNone  = "none"
+	normalizeLower = "lower"
+	normalizeSlug  = "slug"
+)
+
+var slugUnsafe = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+func normalizeTarget(mode, to string) string {
+	if mode == "" || mode == normalizeNone {
+		return to
+	}
+
+	prefix, path, suffix := "", to, ""
+	if m := re.FindStringSubmatch(to); m != nil {
+		prefix, path, suffix = m[len(m)-3], m[len(m)-2], m[len(m)-1]
+	}
+
+	dir, name := filepath.Split(path)
+	switch mode {
+	case normalizeLower:
+		name = strings.ToLower(name)
+	case normalizeSlug:
+		name = slug(name)
+	}
+	return prefix + dir + name + suffix
+}
+
+func slug(name string) string {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+
+	stripped := []rune{}
+	for _, r := range norm.NFKD.String(base) {
+		if !unicode.Is(unicode.Mn, r) {
+			stripped = append(stripped, r)
+		}
+	}
+
+	base = slugUnsafe.ReplaceAllString(strings.ToLower(string(stripped)), "-")
+	base = strings.Trim(base, "-")
+	if base == "" {
+		base = "-"
+	}
+	return base + strings.ToLower(ext)
+}
+
+func (p *Processor) claimTarget(to, source string) error {
+	key := strings.ToLower(to)
+	if other, ok := p.targets[key]; ok && other != source {
+		return ansi.Errorf("@R{Target} @m{%s} @R{of} @m{%s} @R{collides with the target of} @m{%s}", to, source, other)
+	}
+	p.targets[key] = source
+	return nil
+}
+
+func (p *Processor) targetName(cfg aviator.Spruce, name, source string) (string, error) {
+	to := normalizeTarget(cfg.NormalizeTarget, createTargetName(cfg.ToDir, name))
+	if cfg.NormalizeTarget == "" || cfg.NormalizeTarget == normalizeNone {
+		return to, nil
+	}
+	return to, p.claimTarget(to, source)
+}
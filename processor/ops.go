@@ -0,0 +1,46 @@
+package processor
+
+import (
+	"github.com/cppforlife/go-patch/patch"
+	"github.com/starkandwayne/goutils/ansi"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func (p *Processor) applyOpsFiles(result []byte, files []string) ([]byte, error) {
+	var doc interface{}
+	err := yaml.Unmarshal(result, &doc)
+	if err != nil {
+		return nil, ansi.Errorf("@R{Applying ops files FAILED: merge result is not valid YAML}: %s", err.Error())
+	}
+
+	for _, file := range files {
+		content, ok := p.store.ReadFile(file)
+		if !ok {
+			return nil, ansi.Errorf("@R{Error reading file from filesystem or internal datastore} @m{%s}", file)
+		}
+
+		defs := []patch.OpDefinition{}
+		err = yaml.Unmarshal(content, &defs)
+		if err != nil {
+			return nil, ansi.Errorf("@m{%s}: @R{Parsing ops file FAILED}: %s", file, err.Error())
+		}
+		ops, err := patch.NewOpsFromDefinitions(defs)
+		if err != nil {
+			return nil, ansi.Errorf("@m{%s}: @R{Parsing ops file FAILED}: %s", file, err.Error())
+		}
+
+		doc, err = ops.Apply(doc)
+		if err != nil {
+			return nil, ansi.Errorf("@m{%s}: @R{Applying ops file FAILED}: %s", file, err.Error())
+		}
+	}
+	return yaml.Marshal(doc)
+}
+
+func resolveEach(files []string) []string {
+	var resolved []string
+	for _, file := range files {
+		resolved = append(resolved, resolveBraces(file))
+	}
+	return resolved
+}
@@ -0,0 +1,21 @@
+package processor
+
+// Outputs returns the targets the spruce steps named name rendered in this
+// run, in the order they were rendered. ok is false if no such step
+// rendered a target, e.g. because it was skipped.
+func (p *Processor) Outputs(name string) (targets []string, ok bool) {
+	targets, ok = p.outputs[name]
+	return targets, ok
+}
+
+func (p *Processor) output(name, to string) {
+	if name == "" || p.streamed(to) {
+		return
+	}
+	if p.outputs == nil {
+		p.outputs = map[string][]string{}
+	}
+	if !except(p.outputs[name], to) {
+		p.outputs[name] = append(p.outputs[name], to)
+	}
+}
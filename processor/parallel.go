@@ -0,0 +1,87 @@
+package processor
+
+import (
+	"sync"
+
+	"github.com/JulzDiverse/aviator"
+)
+
+type mergeJob struct {
+	files    []string
+	to       string
+	warnings []aviator.Warning
+}
+
+type jobResult struct {
+	result []byte
+	phases *aviator.MergePhases
+	err    error
+}
+
+func (p *Processor) SetConcurrency(concurrency int) {
+	p.concurrency = concurrency
+}
+
+func (p *Processor) runJobs(cfg aviator.Spruce) error {
+	jobs := p.jobs
+	p.jobs = nil
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	results := make([]jobResult, len(jobs))
+	done := make([]chan struct{}, len(jobs))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+
+	workers := p.concurrency
+	if workers > len(jobs) {
+		workers = len(jobs)
+	}
+
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				result, phases, err := p.render(jobs[i], cfg)
+				results[i] = jobResult{result, phases, err}
+				close(done[i])
+			}
+		}()
+	}
+
+	abort := make(chan struct{})
+	go func() {
+		defer close(queue)
+		for i := range jobs {
+			select {
+			case queue <- i:
+			case <-abort:
+				return
+			}
+		}
+	}()
+
+	var err error
+	for i, job := range jobs {
+		<-done[i]
+		job.warnings = append(job.warnings, p.encodingWarnings()...)
+		p.printJob(job, cfg)
+		r := results[i]
+		err = r.err
+		if err == nil {
+			err = p.write(job, cfg, r.result, r.phases)
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	close(abort)
+	wg.Wait()
+	return err
+}
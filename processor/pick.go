@@ -0,0 +1,122 @@
+package processor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+// pick cuts the given paths out of every file without merging it, so merge
+// operators like (( append )) or (( replace )) in the picked subtrees reach
+// the main merge unchanged.
+func (p *Processor) pick(files []string, picks []string) ([]string, error) {
+	if len(picks) == 0 {
+		return files, nil
+	}
+
+	result := []string{}
+	for _, file := range files {
+		content, ok := p.store.ReadFile(file)
+		if !ok {
+			return nil, ansi.Errorf("@R{Picking from} @m{%s} @R{FAILED}: file not found", file)
+		}
+		var doc interface{}
+		if err := yaml.Unmarshal(content, &doc); err != nil {
+			return nil, ansi.Errorf("@R{Picking from} @m{%s} @R{FAILED}: %s", file, err.Error())
+		}
+
+		var tree interface{} = map[interface{}]interface{}{}
+		for _, path := range picks {
+			picked, ok := pickPath(doc, strings.Split(path, "."))
+			if !ok {
+				return nil, ansi.Errorf("@R{Picking} @m{%s} @R{from} @m{%s} @R{FAILED}: path not found", path, file)
+			}
+			tree = mergePicked(tree, picked)
+		}
+		picked, err := yaml.Marshal(tree)
+		if err != nil {
+			return nil, err
+		}
+
+		key := fmt.Sprintf("{{aviator_pick/%d.yml}}", p.pickCount)
+		p.pickCount++
+		if err := p.store.WriteFile(key, picked); err != nil {
+			return nil, err
+		}
+		result = append(result, key)
+	}
+	return result, nil
+}
+
+// pickPath returns the subtree of node at path, within its parents. As in
+// spruce paths, array elements are selected by their name or index.
+func pickPath(node interface{}, path []string) (interface{}, bool) {
+	if len(path) == 0 {
+		return node, true
+	}
+
+	switch n := node.(type) {
+	case map[interface{}]interface{}:
+		child, ok := n[path[0]]
+		if !ok {
+			return nil, false
+		}
+		picked, ok := pickPath(child, path[1:])
+		return map[interface{}]interface{}{path[0]: picked}, ok
+	case []interface{}:
+		for i, element := range n {
+			named, isMap := element.(map[interface{}]interface{})
+			if !(isMap && named["name"] == path[0]) && strconv.Itoa(i) != path[0] {
+				continue
+			}
+			picked, ok := pickPath(element, path[1:])
+			if m, isMap := picked.(map[interface{}]interface{}); isMap && named["name"] != nil {
+				m["name"] = named["name"]
+			}
+			return []interface{}{picked}, ok
+		}
+	}
+	return nil, false
+}
+
+// mergePicked merges the picked subtree b into a.
+func mergePicked(a, b interface{}) interface{} {
+	switch bt := b.(type) {
+	case map[interface{}]interface{}:
+		at, ok := a.(map[interface{}]interface{})
+		if !ok {
+			return b
+		}
+		for k, v := range bt {
+			if existing, ok := at[k]; ok {
+				at[k] = mergePicked(existing, v)
+			} else {
+				at[k] = v
+			}
+		}
+		return at
+	case []interface{}:
+		at, ok := a.([]interface{})
+		if !ok {
+			return b
+		}
+	elements:
+		for _, v := range bt {
+			if named, ok := v.(map[interface{}]interface{}); ok && named["name"] != nil {
+				for i, existing := range at {
+					if e, ok := existing.(map[interface{}]interface{}); ok && e["name"] == named["name"] {
+						at[i] = mergePicked(e, named)
+						continue elements
+					}
+				}
+			}
+			at = append(at, v)
+		}
+		return at
+	}
+	return b
+}
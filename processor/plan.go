@@ -0,0 +1,33 @@
+package processor
+
+import (
+	"github.com/JulzDiverse/aviator"
+)
+
+// Plan resolves the for_each expansions of the spruce plan and returns the
+// merges it would run, without merging or writing rendered files. Steps
+// skipped by their conditions are left out.
+func (p *Processor) Plan(config []aviator.Spruce) ([]aviator.PlannedMerge, error) {
+	p.silent, p.planned = true, []aviator.PlannedMerge{}
+	defer func() { p.planned = nil }()
+
+	for _, cfg := range prioritize(config) {
+		p.step, p.seen, p.targets = stepName(cfg), map[string]bool{}, map[string]string{}
+		p.summary = nil
+		if skip, _, _ := Skipped(cfg.Condition); skip {
+			continue
+		}
+		if err := p.dispatch(cfg); err != nil {
+			return nil, err
+		}
+	}
+	return p.planned, nil
+}
+
+func (p *Processor) plan(job mergeJob, cfg aviator.Spruce) {
+	p.planned = append(p.planned, aviator.PlannedMerge{
+		Step:      p.step,
+		To:        job.to,
+		MergeConf: p.mergeConf(job, cfg),
+	})
+}
@@ -0,0 +1,30 @@
+package processor
+
+import (
+	"bytes"
+
+	"github.com/JulzDiverse/aviator"
+	"github.com/JulzDiverse/aviator/executor"
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+func postProcess(file []byte, processors []aviator.Executable) ([]byte, error) {
+	cmds, err := executor.GenericExecutor{}.Command(processors)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cmd := range cmds {
+		var stdout, stderr bytes.Buffer
+		cmd.Stdin = bytes.NewReader(file)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+
+		if err := cmd.Run(); err != nil {
+			return nil, errors.Wrap(err, ansi.Sprintf("@R{Post-Processor} @m{%s} @R{FAILED}: %s", cmd.Path, stderr.String()))
+		}
+		file = stdout.Bytes()
+	}
+	return file, nil
+}
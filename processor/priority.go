@@ -0,0 +1,109 @@
+package processor
+
+import (
+	"strings"
+
+	"github.com/JulzDiverse/aviator"
+)
+
+// prioritize orders the spruce plan by descending priority. A block is never
+// moved in front of an earlier block whose output it reads, which writes the
+// same target or which reads its target.
+func prioritize(config []aviator.Spruce) []aviator.Spruce {
+	deps := make([][]int, len(config))
+	for i := range config {
+		for j := 0; j < i; j++ {
+			if dependsOn(config[i], config[j]) {
+				deps[i] = append(deps[i], j)
+			}
+		}
+	}
+
+	done := make([]bool, len(config))
+	result := []aviator.Spruce{}
+	for len(result) < len(config) {
+		next := -1
+		for i := range config {
+			if done[i] || !resolved(deps[i], done) {
+				continue
+			}
+			if next == -1 || config[i].Priority > config[next].Priority {
+				next = i
+			}
+		}
+		done[next] = true
+		result = append(result, config[next])
+	}
+	return result
+}
+
+func resolved(deps []int, done []bool) bool {
+	for _, d := range deps {
+		if !done[d] {
+			return false
+		}
+	}
+	return true
+}
+
+func dependsOn(cfg, earlier aviator.Spruce) bool {
+	outputs := outputPaths(cfg)
+	earlierOutputs := outputPaths(earlier)
+	return overlaps(inputPaths(cfg), earlierOutputs) ||
+		overlaps(outputs, earlierOutputs) ||
+		overlaps(outputs, inputPaths(earlier))
+}
+
+func overlaps(paths, others []string) bool {
+	for _, p := range paths {
+		for _, o := range others {
+			if strings.HasPrefix(p, o) || strings.HasPrefix(o, p) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func outputPaths(cfg aviator.Spruce) []string {
+	result := []string{}
+	for _, o := range []string{cfg.To, cfg.ToDir} {
+		if o != "" {
+			result = append(result, resolveBraces(o))
+		}
+	}
+	return result
+}
+
+func inputPaths(cfg aviator.Spruce) []string {
+	result := []string{}
+	for _, in := range inputs(cfg) {
+		result = append(result, resolveBraces(in))
+	}
+	return result
+}
+
+func inputs(cfg aviator.Spruce) []string {
+	inputs := []string{cfg.Base, cfg.DefaultsDoc, cfg.ForEach.In, cfg.ForEach.ForAll}
+	inputs = append(inputs, cfg.OpsFiles.Before...)
+	inputs = append(inputs, cfg.OpsFiles.After...)
+	inputs = append(inputs, cfg.Templates...)
+	inputs = append(inputs, cfg.Values...)
+	for _, f := range cfg.ForEach.Files {
+		inputs = append(inputs, cfg.ForEach.InDir+f)
+	}
+	for _, m := range cfg.Merge {
+		inputs = append(inputs, m.WithIn, m.WithAllIn)
+		for _, f := range m.With.Files {
+			inputs = append(inputs, m.With.InDir+f)
+		}
+	}
+
+	result := []string{}
+	for _, in := range inputs {
+		if in != "" {
+			result = append(result, in)
+		}
+	}
+	return result
+}
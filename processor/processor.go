@@ -2,27 +2,58 @@ package processor
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
+	"time"
 
 	"github.com/JulzDiverse/aviator"
+	"github.com/JulzDiverse/aviator/authorizer"
+	"github.com/JulzDiverse/aviator/encrypter"
+	"github.com/JulzDiverse/aviator/executor"
 	"github.com/JulzDiverse/aviator/filemanager"
 	"github.com/JulzDiverse/aviator/modifier"
 	"github.com/JulzDiverse/aviator/printer"
+	"github.com/JulzDiverse/aviator/resolver"
+	"github.com/JulzDiverse/aviator/selector"
 	"github.com/JulzDiverse/aviator/spruce"
+	"github.com/JulzDiverse/aviator/timer"
 	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+	yaml "gopkg.in/yaml.v2"
 )
 
 type WriterFunc func([]byte, string) error
 
 type Processor struct {
-	spruceClient aviator.SpruceClient
-	store        aviator.FileStore
-	modifier     aviator.Modifier
-	verbose      bool
-	silent       bool
-	warnings     []string
+	spruceClient  aviator.SpruceClient
+	store         aviator.FileStore
+	modifier      aviator.Modifier
+	encrypter     *encrypter.Encrypter
+	verbose       bool
+	silent        bool
+	warnings      []aviator.Warning
+	reported      []aviator.Warning
+	seen          map[string]bool
+	suppressed    map[string]bool
+	step          string
+	targets       map[string]string
+	concurrency   int
+	collectErrors bool
+	jobs          []mergeJob
+	metaCount     int
+	pickCount     int
+	debugDir      string
+	gitCache      string
+	gitOffline    bool
+	checkouts     map[string]string
+	auth          *authorizer.Authorizer
+	summary       *aviator.ForEachSummary
+	planned       []aviator.PlannedMerge
+	toStdout      bool
+	target        string
+	targetFound   bool
+	outputs       map[string][]string
 }
 
 func NewTestProcessor(spruceClient aviator.SpruceClient, store aviator.FileStore, modifier aviator.Modifier) *Processor {
@@ -38,6 +69,8 @@ func New(curlyBraces, dryRun bool) *Processor {
 		store:        filemanager.Store(curlyBraces, dryRun),
 		spruceClient: spruce.New(curlyBraces, dryRun),
 		modifier:     modifier.New(),
+		encrypter:    encrypter.New("."),
+		gitCache:     defaultGitCache(),
 	}
 }
 
@@ -55,41 +88,111 @@ func (p *Processor) ProcessSilent(config []aviator.Spruce) error {
 
 func (p *Processor) ProcessWithOpts(config []aviator.Spruce, verbose, silent, dryRun bool) error {
 	p.verbose, p.silent = verbose, silent
-	var err error
-	for _, cfg := range config {
-		switch mergeType(cfg) {
-		case "default":
-			err = p.defaultMerge(cfg)
-		case "forEach":
-			err = p.forEachFileMerge(cfg)
-		case "forEachIn":
-			err = p.forEachInMerge(cfg)
-		case "walkThrough":
-			err = p.walk(cfg, "")
-		case "walkThroughForAll":
-			err = p.forAll(cfg)
+	exec := executor.New(silent)
+	failed := &failures{}
+	for _, cfg := range prioritize(config) {
+		cfg := cfg
+		p.step, p.seen, p.targets = stepName(cfg), map[string]bool{}, map[string]string{}
+		if skip, reason, details := Skipped(cfg.Condition); skip {
+			if !silent {
+				printer.AnsiPrintSkipped(stepName(cfg), reason, details)
+			}
+			continue
 		}
+		if p.skipBlocked(cfg, failed) {
+			continue
+		}
+		err := timer.Default().Track(stepName(cfg), cfg.WarnIfLongerThan, func() error {
+			return exec.RunWithPolicy(cfg.FailurePolicy, func() error {
+				return p.process(cfg)
+			})
+		})
 		if err != nil {
-			return err
+			if !p.collectErrors || filemanager.IsCanceled(err) {
+				return err
+			}
+			if !silent {
+				ansi.Printf("@R{FAILED (collecting)}: %s\n\n", err.Error())
+			}
+			failed.add(cfg, err)
 		}
 	}
-	return err
+	if err := failed.err(len(config)); err != nil {
+		return err
+	}
+	return p.targetMissing()
+}
+
+func (p *Processor) process(cfg aviator.Spruce) error {
+	p.jobs, p.summary = nil, newSummary(cfg)
+	err := p.dispatch(cfg)
+	if err != nil {
+		return err
+	}
+	err = p.runJobs(cfg)
+	if err != nil {
+		return err
+	}
+	if p.summary != nil && !p.silent {
+		printer.AnsiPrintForEachSummary(*p.summary)
+	}
+	return nil
+}
+
+func (p *Processor) dispatch(cfg aviator.Spruce) error {
+	switch mergeType(cfg) {
+	case "default":
+		return p.defaultMerge(cfg)
+	case "forEach":
+		return p.forEachFileMerge(cfg)
+	case "forEachIn":
+		return p.forEachInMerge(cfg)
+	case "walkThrough":
+		return p.walk(cfg, "")
+	case "walkThroughForAll":
+		return p.forAll(cfg)
+	}
+	return nil
 }
 
 func (p *Processor) defaultMerge(cfg aviator.Spruce) error {
-	files := p.collectFiles(cfg)
-	if err := p.mergeAndWrite(files, cfg, cfg.To); err != nil {
+	files, err := p.collectFiles(cfg)
+	if err != nil {
+		return err
+	}
+	to := cfg.To
+	if cfg.SplitByKind {
+		to = cfg.ToDir
+	}
+	if err := p.mergeAndWrite(files, cfg, to); err != nil {
 		return err
 	}
 	return nil
 }
 
 func (p *Processor) forEachFileMerge(cfg aviator.Spruce) error {
-	for _, file := range cfg.ForEach.Files {
-		mergeFiles := p.collectFiles(cfg)
-		fileName, _ := concatFileNameWithPath(file)
+	forEach, err := p.expandSources(cfg.ForEach.Files)
+	if err != nil {
+		return err
+	}
+
+	for i, file := range forEach {
+		p.consider()
+		p.matched()
+		mergeFiles, err := p.collectFiles(cfg)
+		if err != nil {
+			return err
+		}
+		fileName, _ := concatFileNameWithPath(remoteFileName(helmFileName(file)))
 		mergeFiles = append(mergeFiles, file)
-		targetName := createTargetName(cfg.ToDir, fileName)
+		mergeFiles, err = p.withMetadata(cfg, mergeFiles, file, i, "")
+		if err != nil {
+			return err
+		}
+		targetName, err := p.targetName(cfg, fileName, file)
+		if err != nil {
+			return err
+		}
 		if err := p.mergeAndWrite(mergeFiles, cfg, targetName); err != nil {
 			return err
 		}
@@ -103,52 +206,98 @@ func (p *Processor) forEachInMerge(cfg aviator.Spruce) error {
 		return err
 	}
 
-	regex := getRegexp(cfg.ForEach.Regexp)
-	files := p.collectFiles(cfg)
+	regex, err := getRegexp(cfg.ForEach.Regexp)
+	if err != nil {
+		return err
+	}
+	files, err := p.collectFiles(cfg)
+	if err != nil {
+		return err
+	}
+	index := 0
 	for _, f := range filePaths {
+		p.consider()
 		if except(cfg.ForEach.Except, f.Name()) {
-			p.warnings = append(p.warnings, "SKIPPED: "+f.Name())
+			p.exclude(excludedByExcept)
+			p.warn(WarningSkipped, "SKIPPED: "+f.Name())
 			continue
 		}
-		matched, _ := regexp.MatchString(regex, f.Name())
+		matched := matchRegexp(regex, cfg.ForEach.Invert, f.Name())
 		if !f.IsDir() && matched {
+			p.matched()
 			prefix := chunk(resolveBraces((cfg.ForEach.In)))
-			mergeFiles := append(files, createTargetName(cfg.ForEach.In, f.Name()))
-			targetName := createTargetName(cfg.ToDir, fmt.Sprintf("%s_%s", prefix, f.Name()))
+			file := createTargetName(cfg.ForEach.In, f.Name())
+			mergeFiles, err := p.withMetadata(cfg, append(files, file), file, index, "")
+			if err != nil {
+				return err
+			}
+			index++
+			targetName, err := p.targetName(cfg, fmt.Sprintf("%s_%s", prefix, f.Name()), file)
+			if err != nil {
+				return err
+			}
 			if err := p.mergeAndWrite(mergeFiles, cfg, targetName); err != nil {
 				return err
 			}
 		} else {
-			p.warnings = append(p.warnings, "EXCLUDED BY REGEXP "+regex+": "+cfg.ForEach.In+f.Name())
+			if f.IsDir() {
+				p.exclude(excludedAsDir)
+			} else {
+				p.exclude(excludedByRegexp)
+			}
+			p.warn(WarningExcludedByRegexp, "EXCLUDED BY REGEXP "+regex.String()+": "+cfg.ForEach.In+f.Name())
 		}
 	}
 	return nil
 }
 
 func (p *Processor) walk(cfg aviator.Spruce, outer string) error {
-	sl, err := p.store.Walk(cfg.ForEach.In) //getAllFilesIncludingSubDirs(cfg.ForEach.In)
+	sl, err := p.store.Walk(cfg.ForEach.In)
 	if err != nil {
 		return err
 	}
 
-	regex := getRegexp(cfg.ForEach.Regexp)
+	regex, err := getRegexp(cfg.ForEach.Regexp)
+	if err != nil {
+		return err
+	}
+	index := 0
 	for _, f := range sl {
 		filename, parent := concatFileNameWithPath(f)
 		match := enableMatching(cfg.ForEach, parent)
-		matched, _ := regexp.MatchString(regex, filename)
+		matched := matchRegexp(regex, cfg.ForEach.Invert, filename)
+		p.consider()
+		if !strings.Contains(outer, match) {
+			p.exclude(excludedByMatching)
+		} else if !matched {
+			p.exclude(excludedByRegexp)
+		}
 		if strings.Contains(outer, match) && matched {
-			files := p.collectFiles(cfg)
+			p.matched()
+			files, err := p.collectFiles(cfg)
+			if err != nil {
+				return err
+			}
 			if outer != "" {
 				files = append(files, f, outer)
 			} else {
 				files = append(files, f)
 			}
 
+			files, err = p.withMetadata(cfg, files, f, index, outer)
+			if err != nil {
+				return err
+			}
+			index++
+
 			if !cfg.ForEach.CopyParents {
 				parent = ""
 			}
 
-			targetName := createTargetName(cfg.ToDir, filepath.Join(parent, filename))
+			targetName, err := p.targetName(cfg, filepath.Join(parent, filename), f)
+			if err != nil {
+				return err
+			}
 			if err := p.mergeAndWrite(files, cfg, targetName); err != nil {
 				return err
 			}
@@ -160,7 +309,10 @@ func (p *Processor) walk(cfg aviator.Spruce, outer string) error {
 func (p *Processor) forAll(cfg aviator.Spruce) error {
 	forAll := cfg.ForEach.ForAll
 	if forAll != "" {
-		files, _ := p.store.ReadDir(forAll) //TODO filemanager
+		files, err := p.store.ReadDir(forAll)
+		if filemanager.IsCanceled(err) {
+			return err
+		}
 		for _, f := range files {
 			if !f.IsDir() {
 				if err := p.walk(cfg, resolveBraces(cfg.ForEach.ForAll)+f.Name()); err != nil {
@@ -172,109 +324,360 @@ func (p *Processor) forAll(cfg aviator.Spruce) error {
 	return nil
 }
 
+func (p *Processor) withMetadata(cfg aviator.Spruce, files []string, file string, index int, forAll string) ([]string, error) {
+	if !cfg.ForEach.Metadata {
+		return files, nil
+	}
+
+	meta := map[string]interface{}{
+		"filename": filepath.Base(resolveBraces(remoteFileName(file))),
+		"dir":      filepath.Dir(resolveBraces(file)),
+		"path":     resolveBraces(file),
+		"index":    index,
+	}
+	if forAll != "" {
+		meta["for_all"] = resolveBraces(forAll)
+	}
+
+	doc, err := yaml.Marshal(map[string]interface{}{"meta": meta})
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("{{aviator_meta/%d.yml}}", p.metaCount)
+	p.metaCount++
+	if err := p.store.WriteFile(key, doc); err != nil {
+		return nil, err
+	}
+	return append([]string{key}, files...), nil
+}
+
 func (p *Processor) mergeAndWrite(files []string, cfg aviator.Spruce, to string) error {
-	mergeConf := aviator.MergeConf{
-		Files:         files,
+	job := mergeJob{files: append([]string{}, files...), to: to, warnings: p.warnings}
+	p.warnings = []aviator.Warning{}
+
+	if p.planned != nil {
+		p.plan(job, cfg)
+		return nil
+	}
+
+	if p.skipTarget(to) {
+		return nil
+	}
+	if !cfg.SplitByKind {
+		p.output(cfg.Name, to)
+	}
+
+	if p.concurrency > 1 {
+		p.jobs = append(p.jobs, job)
+		return nil
+	}
+
+	result, phases, err := p.render(job, cfg)
+	job.warnings = append(job.warnings, p.encodingWarnings()...)
+	p.printJob(job, cfg)
+	if err != nil {
+		return err
+	}
+	return p.write(job, cfg, result, phases)
+}
+
+func (p *Processor) mergeConf(job mergeJob, cfg aviator.Spruce) aviator.MergeConf {
+	return aviator.MergeConf{
+		Files:         job.files,
 		SkipEval:      cfg.SkipEval,
 		Prune:         cfg.Prune,
 		CherryPicks:   cfg.CherryPicks,
-		EnableGoPatch: cfg.GoPatch,
+		EnableGoPatch: cfg.GoPatch || len(cfg.OpsFiles.Before) != 0,
+		OpsFiles:      resolveEach(cfg.OpsFiles.After),
+		KeepBOM:       cfg.Encoding.StripBOM != nil && !*cfg.Encoding.StripBOM,
+		Sops:          sopsFiles(cfg),
+		SkipEvalFiles: skipEvalFiles(cfg),
 	}
+}
 
+func (p *Processor) printJob(job mergeJob, cfg aviator.Spruce) {
 	if !p.silent {
-		printer.AnsiPrint(mergeConf, to, p.warnings, p.verbose)
+		printer.AnsiPrint(p.mergeConf(job, cfg), job.to, job.warnings, p.verbose)
 	}
+}
 
-	p.warnings = []string{}
-	result, err := p.spruceClient.MergeWithOpts(mergeConf)
+func (p *Processor) render(job mergeJob, cfg aviator.Spruce) ([]byte, *aviator.MergePhases, error) {
+	mergeConf := p.mergeConf(job, cfg)
+	to := job.to
+
+	phases := &aviator.MergePhases{}
+	mergeConf.Phases = phases
+	start := time.Now()
+	result, err := p.merge(mergeConf, cfg, to)
+	if phases.Read+phases.Merge+phases.Eval == 0 {
+		phases.Merge = time.Since(start)
+	}
 	if err != nil {
-		return errors.Wrap(err, "Spruce Merge FAILED")
+		if p.debugDir != "" {
+			dir, bundleErr := p.writeDebugBundle(mergeConf, to, err)
+			if bundleErr != nil {
+				return nil, phases, errors.Wrap(err, fmt.Sprintf("Spruce Merge FAILED (writing debug bundle failed: %s)", bundleErr))
+			}
+			return nil, phases, errors.Wrap(err, fmt.Sprintf("Spruce Merge FAILED (debug bundle: %s)", dir))
+		}
+		return nil, phases, errors.Wrap(err, "Spruce Merge FAILED")
+	}
+
+	// skip_eval results are merged again later, which resolves them
+	if !cfg.SkipEval && len(mergeConf.SkipEvalFiles) == 0 {
+		result, err = resolver.Default().Resolve(result)
+		if err != nil {
+			return nil, phases, err
+		}
+	}
+
+	if len(mergeConf.OpsFiles) > 0 {
+		result, err = p.applyOpsFiles(result, mergeConf.OpsFiles)
+		if err != nil {
+			return nil, phases, err
+		}
 	}
 
 	if len(cfg.Modify.Delete) > 0 || len(cfg.Modify.Set) > 0 || len(cfg.Modify.Update) > 0 {
 		result, err = p.modifier.Modify(result, cfg.Modify)
+		if err != nil {
+			return nil, phases, err
+		}
+	}
+
+	if len(cfg.PostProcess) > 0 {
+		result, err = postProcess(result, cfg.PostProcess)
+		if err != nil {
+			return nil, phases, err
+		}
+	}
+
+	if !selector.IsEmpty(cfg.Select) {
+		result, err = selector.Select(result, cfg.Select)
+		if err != nil {
+			return nil, phases, err
+		}
+	}
+
+	return encode(result, cfg.Encoding), phases, nil
+}
+
+func (p *Processor) write(job mergeJob, cfg aviator.Spruce, result []byte, phases *aviator.MergePhases) error {
+	// encrypted targets do not hold plaintext secrets
+	encrypted := p.encrypts(cfg, job.to)
+	var err error
+	if !encrypted {
+		err = p.scanSecrets(result, cfg.ScanSecrets, job.to)
 		if err != nil {
 			return err
 		}
 	}
 
-	err = p.store.WriteFile(to, result)
+	err = validateResult(result, cfg.Validate, job.to)
+	if err != nil {
+		return err
+	}
+
+	err = checkPolicies(result, cfg.Policies, job.to)
 	if err != nil {
 		return err
 	}
 
+	if p.streamed(job.to) {
+		return stream(result)
+	}
+
+	start := time.Now()
+	if cfg.SplitByKind {
+		err = p.writeResources(cfg, job.to, result, encrypted)
+	} else {
+		err = p.writeTarget(cfg, job.to, result, encrypted)
+	}
+	if err != nil {
+		return err
+	}
+	phases.Write = time.Since(start)
+
+	if p.verbose && !p.silent {
+		printer.AnsiPrintPhases(*phases)
+	}
 	return nil
 }
 
-func (p *Processor) collectFiles(cfg aviator.Spruce) []string {
-	files := []string{resolveBraces(cfg.Base)} //TODO: that can not be right
+func (p *Processor) writeTarget(cfg aviator.Spruce, to string, result []byte, encrypted bool) error {
+	var err error
+	if encrypted {
+		result, err = p.encrypter.Encrypt(to, result, cfg.Encrypt.AgeRecipients)
+		if err != nil {
+			return err
+		}
+	}
+	err = p.permissions(cfg, to)
+	if err != nil {
+		return err
+	}
+	return p.store.WriteFile(to, result)
+}
+
+// permissionStore is implemented by stores which write targets to disk.
+type permissionStore interface {
+	SetPermissions(key string, mode os.FileMode, from string) error
+}
+
+// permissions sets the mode and owner the target to is written with: the
+// configured mode and, with preserve_permissions, the ones of the base file.
+func (p *Processor) permissions(cfg aviator.Spruce, to string) error {
+	store, ok := p.store.(permissionStore)
+	if !ok || (cfg.Mode == "" && !cfg.PreservePerms) || re.MatchString(to) {
+		return nil
+	}
+	mode, err := filemanager.ParseMode(cfg.Mode)
+	if err != nil {
+		return err
+	}
+	from := ""
+	if cfg.PreservePerms {
+		from = cfg.Base
+	}
+	return store.SetPermissions(to, mode, from)
+}
+
+// encrypts reports whether the target to is written age encrypted. Files
+// of the internal datastore and results streamed to stdout stay plaintext.
+func (p *Processor) encrypts(cfg aviator.Spruce, to string) bool {
+	return len(cfg.Encrypt.AgeRecipients) > 0 && !re.MatchString(to) && !p.streamed(to)
+}
+
+func (p *Processor) collectFiles(cfg aviator.Spruce) ([]string, error) {
+	files, err := p.expandSources([]string{resolveBraces(cfg.Base)}) //TODO: that can not be right
+	if err != nil {
+		return nil, err
+	}
+	if cfg.DefaultsDoc != "" {
+		files = append([]string{resolveBraces(cfg.DefaultsDoc)}, files...)
+	}
+	files = append(files, resolveEach(cfg.OpsFiles.Before)...)
 	for _, m := range cfg.Merge {
 		with := p.collectFilesFromWithSection(m)
-		within := p.collectFilesFromWithInSection(m)
-		withallin := p.collectFilesFromWithAllInSection(m)
-		files = concatStringSlices(files, with, within, withallin)
+		within, err := p.collectFilesFromWithInSection(m)
+		if err != nil {
+			return nil, err
+		}
+		withallin, err := p.collectFilesFromWithAllInSection(m)
+		if err != nil {
+			return nil, err
+		}
+		inputs, err := p.expandSources(concatStringSlices(with, within, withallin))
+		if err != nil {
+			return nil, err
+		}
+		picked, err := p.pick(inputs, m.Pick)
+		if err != nil {
+			return nil, err
+		}
+		files = concatStringSlices(files, picked)
 	}
-	return files
+	return files, nil
 }
 
 func (p *Processor) collectFilesFromWithSection(merge aviator.Merge) []string {
 	var result []string
 	for _, file := range merge.With.Files {
-		if merge.With.InDir != "" {
+		if merge.With.InDir != "" && !isHelmSource(file) && !isGitSource(file) && !filemanager.IsRemote(file) {
 			dir := merge.With.InDir
 			file = dir + file
 		}
 
 		_, fileExists := p.store.ReadFile(file)
-		if !merge.With.Skip || fileExists {
+		if !merge.With.Skip || fileExists || isHelmSource(file) || isGitSource(file) {
 			result = append(result, file)
 		} else {
-			p.warnings = append(p.warnings, fmt.Sprintf("Skipped non existing file: %s", file))
+			p.warn(WarningMissingFile, fmt.Sprintf("Skipped non existing file: %s", file))
 		}
 	}
 	return result
 }
 
-func (p *Processor) collectFilesFromWithInSection(merge aviator.Merge) []string {
+// sopsFiles returns the files of the with sections marked with sops, which
+// are decrypted even if they do not look encrypted.
+func sopsFiles(cfg aviator.Spruce) []string {
+	return withFiles(cfg, func(with aviator.With) bool { return with.Sops })
+}
+
+// skipEvalFiles returns the files of the with sections marked with
+// skip_eval, whose operators are merged literally.
+func skipEvalFiles(cfg aviator.Spruce) []string {
+	return withFiles(cfg, func(with aviator.With) bool { return with.SkipEval })
+}
+
+func withFiles(cfg aviator.Spruce, marked func(aviator.With) bool) []string {
+	var result []string
+	for _, m := range cfg.Merge {
+		if !marked(m.With) {
+			continue
+		}
+		for _, file := range m.With.Files {
+			if m.With.InDir != "" && !isHelmSource(file) && !isGitSource(file) && !filemanager.IsRemote(file) {
+				file = m.With.InDir + file
+			}
+			result = append(result, file)
+		}
+	}
+	return result
+}
+
+func (p *Processor) collectFilesFromWithInSection(merge aviator.Merge) ([]string, error) {
 	result := []string{}
 	if merge.WithIn != "" {
 		within := merge.WithIn
-		files, _ := p.store.ReadDir(within)
-		regex := getRegexp(merge.Regexp)
+		files, err := p.store.ReadDir(within)
+		if filemanager.IsCanceled(err) {
+			return nil, err
+		}
+		regex, err := getRegexp(merge.Regexp)
+		if err != nil {
+			return nil, err
+		}
 		for _, f := range files {
 			if except(merge.Except, f.Name()) {
 				continue
 			}
 
-			matched, _ := regexp.MatchString(regex, f.Name())
+			matched := matchRegexp(regex, merge.Invert, f.Name())
 			if !f.IsDir() && matched {
 				result = append(result, resolveBraces(within)+f.Name())
 			} else {
-				p.warnings = append(p.warnings, "EXCLUDED BY REGEXP "+regex+": "+merge.WithIn+f.Name())
+				p.warn(WarningExcludedByRegexp, "EXCLUDED BY REGEXP "+regex.String()+": "+merge.WithIn+f.Name())
 			}
 		}
 	}
-	return result
+	return result, nil
 }
 
-func (p *Processor) collectFilesFromWithAllInSection(merge aviator.Merge) []string {
+func (p *Processor) collectFilesFromWithAllInSection(merge aviator.Merge) ([]string, error) {
 	result := []string{}
 	if merge.WithAllIn != "" {
 		allFiles, err := p.store.Walk(merge.WithAllIn)
+		if filemanager.IsCanceled(err) {
+			return nil, err
+		}
 		if err != nil {
-			p.warnings = append(p.warnings, "Given Path for with_all_in does not exist: "+merge.WithAllIn)
+			p.warn(WarningMissingDir, "Given Path for with_all_in does not exist: "+merge.WithAllIn)
 		}
 
-		//allFiles := getAllFilesIncludingSubDirs(merge.WithAllIn)
-		regex := getRegexp(merge.Regexp)
+		regex, err := getRegexp(merge.Regexp)
+		if err != nil {
+			return nil, err
+		}
 		for _, file := range allFiles {
-			matched, _ := regexp.MatchString(regex, file)
+			matched := matchRegexp(regex, merge.Invert, file)
 			if matched {
 				result = append(result, file)
 			} else {
-				p.warnings = append(p.warnings, "EXCLUDED BY REGEXP "+regex+": "+file)
+				p.warn(WarningExcludedByRegexp, "EXCLUDED BY REGEXP "+regex.String()+": "+file)
 			}
 		}
 	}
-	return result
+	return result, nil
 }
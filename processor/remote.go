@@ -0,0 +1,20 @@
+package processor
+
+import (
+	"net/url"
+
+	"github.com/JulzDiverse/aviator/filemanager"
+)
+
+// remoteFileName strips the scheme and the sha256 pin off remote files, so
+// target names and metadata are derived from the host and path only.
+func remoteFileName(file string) string {
+	if !filemanager.IsRemote(file) {
+		return file
+	}
+	u, err := url.Parse(file)
+	if err != nil {
+		return file
+	}
+	return u.Host + u.Path
+}
@@ -0,0 +1,37 @@
+package processor
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/JulzDiverse/aviator/scanner"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+const (
+	scanWarn = "warn"
+	scanFail = "fail"
+)
+
+func (p *Processor) scanSecrets(file []byte, mode, to string) error {
+	if mode == "" || re.MatchString(to) {
+		return nil
+	}
+
+	findings := scanner.Scan(file)
+	if len(findings) == 0 {
+		return nil
+	}
+
+	lines := []string{}
+	for _, f := range findings {
+		lines = append(lines, fmt.Sprintf("line %d (%s)", f.Line, f.Rule))
+	}
+
+	if mode == scanFail {
+		return ansi.Errorf("@R{Plaintext secrets detected in} @m{%s}: %s", to, strings.Join(lines, ", "))
+	}
+	ansi.Fprintf(os.Stderr, "@Y{WARNING}: plaintext secrets detected in @m{%s}: %s\n", to, strings.Join(lines, ", "))
+	return nil
+}
@@ -0,0 +1,25 @@
+package processor
+
+import (
+	"github.com/JulzDiverse/aviator"
+	"github.com/JulzDiverse/aviator/splitter"
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+// writeResources writes every Kubernetes resource of result to its own
+// file in dir, for split_by_kind.
+func (p *Processor) writeResources(cfg aviator.Spruce, dir string, result []byte, encrypted bool) error {
+	resources, err := splitter.Split(result)
+	if err != nil {
+		return errors.Wrap(err, ansi.Sprintf("@R{Splitting the result for} @m{%s} @R{FAILED}", dir))
+	}
+	for _, r := range resources {
+		to := createTargetName(dir, r.File)
+		p.output(cfg.Name, to)
+		if err := p.writeTarget(cfg, to, r.Content, encrypted); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -5,16 +5,20 @@ import (
 	"regexp"
 
 	"github.com/JulzDiverse/aviator"
+	"github.com/JulzDiverse/aviator/filemanager"
 )
 
 type FileProcessor struct {
 	Store aviator.FileStore
 }
 
-func (f *FileProcessor) CollectFilesFromDir(dir, regex string, ignore []string) []string {
+func (f *FileProcessor) CollectFilesFromDir(dir, regex string, ignore []string) ([]string, error) {
 	result := []string{}
 	if dir != "" {
-		files, _ := f.Store.ReadDir(dir)
+		files, err := f.Store.ReadDir(dir)
+		if filemanager.IsCanceled(err) {
+			return nil, err
+		}
 
 		for _, f := range files {
 			if except(ignore, f.Name()) {
@@ -27,5 +31,5 @@ func (f *FileProcessor) CollectFilesFromDir(dir, regex string, ignore []string)
 			}
 		}
 	}
-	return result
+	return result, nil
 }
@@ -0,0 +1,34 @@
+package processor
+
+import (
+	"bytes"
+	"os"
+)
+
+// Stdout as target streams the merge result to standard output.
+const Stdout = "stdout"
+
+// StreamToStdout streams the results of all merges to standard output
+// instead of writing them to files. Files of the internal datastore are
+// still written, as later steps read them.
+func (p *Processor) StreamToStdout(stream bool) {
+	p.toStdout = stream
+}
+
+func (p *Processor) streamed(to string) bool {
+	return to == Stdout || (p.toStdout && !re.MatchString(to))
+}
+
+// stream writes result as a YAML document, separated from the previous one
+// by ---.
+func stream(result []byte) error {
+	doc := result
+	if !bytes.HasPrefix(doc, []byte("---")) {
+		doc = append([]byte("---\n"), doc...)
+	}
+	if !bytes.HasSuffix(doc, []byte("\n")) {
+		doc = append(doc, '\n')
+	}
+	_, err := os.Stdout.Write(doc)
+	return err
+}
@@ -0,0 +1,35 @@
+package processor
+
+import "github.com/JulzDiverse/aviator"
+
+const (
+	excludedByExcept   = "except"
+	excludedByRegexp   = "regexp"
+	excludedAsDir      = "directory"
+	excludedByMatching = "enable_matching"
+)
+
+func (p *Processor) consider() {
+	if p.summary != nil {
+		p.summary.Considered++
+	}
+}
+
+func (p *Processor) matched() {
+	if p.summary != nil {
+		p.summary.Matched++
+	}
+}
+
+func (p *Processor) exclude(reason string) {
+	if p.summary != nil {
+		p.summary.Excluded[reason]++
+	}
+}
+
+func newSummary(cfg aviator.Spruce) *aviator.ForEachSummary {
+	if mergeType(cfg) == "default" {
+		return nil
+	}
+	return &aviator.ForEachSummary{Step: stepName(cfg), Excluded: map[string]int{}}
+}
@@ -0,0 +1,33 @@
+package processor
+
+import (
+	"path/filepath"
+
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+// RenderTarget restricts the following runs to the merge writing target, so
+// a single output of a for_each step can be re-rendered without the others.
+// Merges writing to the internal datastore still run, as the merge of the
+// target may read them. An empty target renders everything again.
+func (p *Processor) RenderTarget(target string) {
+	p.target, p.targetFound = target, false
+}
+
+func (p *Processor) skipTarget(to string) bool {
+	if p.target == "" || re.MatchString(to) {
+		return false
+	}
+	if filepath.Clean(to) == filepath.Clean(p.target) {
+		p.targetFound = true
+		return false
+	}
+	return true
+}
+
+func (p *Processor) targetMissing() error {
+	if p.target == "" || p.targetFound {
+		return nil
+	}
+	return ansi.Errorf("@R{No spruce step renders} @m{%s}", p.target)
+}
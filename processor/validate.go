@@ -0,0 +1,42 @@
+package processor
+
+import (
+	"os"
+
+	"github.com/JulzDiverse/aviator"
+	"github.com/JulzDiverse/aviator/conformer"
+	"github.com/JulzDiverse/aviator/enforcer"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+// validateResult checks the result of a step against the schemas of its
+// validate section before it is written.
+func validateResult(file []byte, cfg aviator.Validate, to string) error {
+	if conformer.IsEmpty(cfg) {
+		return nil
+	}
+
+	violations, err := conformer.Validate(file, cfg)
+	if err != nil {
+		return err
+	}
+	return conformer.Error(resolveBraces(to), violations)
+}
+
+// checkPolicies evaluates the result of a step against the Rego policies of
+// its policies section before it is written. Warnings are printed, denials
+// fail the step.
+func checkPolicies(file []byte, policies []string, to string) error {
+	if len(policies) == 0 {
+		return nil
+	}
+
+	result, err := enforcer.Check(file, policies)
+	if err != nil {
+		return err
+	}
+	for _, w := range result.Warnings {
+		ansi.Fprintf(os.Stderr, "@Y{WARNING}: policy warning for @m{%s}: %s\n", resolveBraces(to), w)
+	}
+	return result.Error(resolveBraces(to))
+}
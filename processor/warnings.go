@@ -0,0 +1,83 @@
+package processor
+
+import (
+	"os"
+	"strings"
+
+	"github.com/JulzDiverse/aviator"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+const (
+	WarningSkipped          = "skipped"
+	WarningExcludedByRegexp = "excluded-by-regexp"
+	WarningMissingFile      = "missing-file"
+	WarningMissingDir       = "missing-dir"
+	WarningTranscoded       = "transcoded"
+)
+
+var warningCategories = map[string]bool{
+	WarningSkipped:          true,
+	WarningExcludedByRegexp: true,
+	WarningMissingFile:      true,
+	WarningMissingDir:       true,
+	WarningTranscoded:       true,
+}
+
+func (p *Processor) SuppressWarnings(categories []string) {
+	p.suppressed = map[string]bool{}
+	for _, c := range categories {
+		c = strings.TrimPrefix(c, "category=")
+		if !warningCategories[c] {
+			ansi.Fprintf(os.Stderr, "@Y{WARNING}: unknown warning category @m{%s}\n", c)
+		}
+		p.suppressed[c] = true
+	}
+}
+
+func (p *Processor) warn(category, message string) {
+	if p.suppressed[category] {
+		return
+	}
+
+	key := category + "|" + message
+	if p.seen == nil {
+		p.seen = map[string]bool{}
+	}
+	if p.seen[key] {
+		return
+	}
+	p.seen[key] = true
+
+	warning := aviator.Warning{Category: category, Step: p.step, Message: message}
+	p.warnings = append(p.warnings, warning)
+	p.reported = append(p.reported, warning)
+}
+
+// encodingStore is implemented by stores which transcode the files they
+// read.
+type encodingStore interface {
+	EncodingWarnings() []string
+}
+
+// encodingWarnings reports the files transcoded by the store since the last
+// call and returns the warnings, which belong to the merge that read them.
+func (p *Processor) encodingWarnings() []aviator.Warning {
+	store, ok := p.store.(encodingStore)
+	if !ok {
+		return nil
+	}
+	pending := p.warnings
+	p.warnings = []aviator.Warning{}
+	for _, message := range store.EncodingWarnings() {
+		p.warn(WarningTranscoded, message)
+	}
+	result := p.warnings
+	p.warnings = pending
+	return result
+}
+
+// Warnings returns all warnings reported while processing.
+func (p *Processor) Warnings() []aviator.Warning {
+	return append([]aviator.Warning{}, p.reported...)
+}
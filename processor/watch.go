@@ -0,0 +1,68 @@
+package processor
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/JulzDiverse/aviator"
+	"github.com/JulzDiverse/aviator/filemanager"
+)
+
+// WatchedPaths returns the files and directories on disk the spruce plan
+// reads. Files of the internal datastore and remote sources are omitted.
+func WatchedPaths(config []aviator.Spruce) []string {
+	seen := map[string]bool{}
+	result := []string{}
+	for _, cfg := range config {
+		for _, in := range localInputs(cfg) {
+			if !seen[in] {
+				seen[in] = true
+				result = append(result, in)
+			}
+		}
+	}
+	return result
+}
+
+// Affected returns the blocks of the spruce plan which read one of the
+// changed files, followed by the blocks reading their output.
+func Affected(config []aviator.Spruce, changed []string) []aviator.Spruce {
+	affected := make([]bool, len(config))
+	for i, cfg := range config {
+		affected[i] = reads(cfg, changed)
+		for j := 0; j < i && !affected[i]; j++ {
+			affected[i] = affected[j] && dependsOn(cfg, config[j])
+		}
+	}
+
+	result := []aviator.Spruce{}
+	for i, cfg := range config {
+		if affected[i] {
+			result = append(result, cfg)
+		}
+	}
+	return result
+}
+
+func reads(cfg aviator.Spruce, changed []string) bool {
+	for _, in := range localInputs(cfg) {
+		for _, file := range changed {
+			file = filepath.Clean(file)
+			if file == in || strings.HasPrefix(file, in+string(filepath.Separator)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func localInputs(cfg aviator.Spruce) []string {
+	result := []string{}
+	for _, in := range inputs(cfg) {
+		if re.MatchString(in) || filemanager.IsRemote(in) || isGitSource(in) || isHelmSource(in) {
+			continue
+		}
+		result = append(result, filepath.Clean(in))
+	}
+	return result
+}
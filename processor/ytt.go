@@ -0,0 +1,68 @@
+package processor
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/JulzDiverse/aviator"
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+func (p *Processor) evaluateYtt(files []string, cfg aviator.Spruce) ([]byte, error) {
+	tmp, err := ioutil.TempDir("", "aviator-ytt")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	args := []string{}
+	for _, file := range append(resolveEach(cfg.Templates), files...) {
+		if file == "" {
+			continue
+		}
+		path, err := p.localFile(file, tmp, len(args))
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "-f", path)
+	}
+	for _, file := range resolveEach(cfg.Values) {
+		path, err := p.localFile(file, tmp, len(args))
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "--data-values-file", path)
+	}
+	if cfg.DataValuesEnv != "" {
+		args = append(args, "--data-values-env", cfg.DataValuesEnv)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("ytt", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrap(err, ansi.Sprintf("@R{Rendering ytt templates FAILED}: %s", stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// localFile returns a path ytt can read. Files of the internal datastore are
+// written to dir, keeping their name so ytt recognizes the file type.
+func (p *Processor) localFile(file, dir string, i int) (string, error) {
+	if _, err := os.Stat(file); err == nil {
+		return file, nil
+	}
+
+	content, ok := p.store.ReadFile(file)
+	if !ok {
+		return "", ansi.Errorf("@R{Error reading file from filesystem or internal datastore} @m{%s}", file)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%02d-%s", i, filepath.Base(file)))
+	return path, ioutil.WriteFile(path, content, 0644)
+}
@@ -0,0 +1,107 @@
+package recorder
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+const appliedDir = ".aviator/applied"
+
+// Apply is the last successful apply of a target.
+type Apply struct {
+	Digest string    `json:"digest"`
+	Output string    `json:"output"`
+	Time   time.Time `json:"time"`
+}
+
+// Recorder keeps the digest of the last successful apply of every target
+// below .aviator/applied, so applies of unchanged targets can be skipped.
+type Recorder struct {
+	dir string
+}
+
+func New(root string) *Recorder {
+	return &Recorder{dir: filepath.Join(root, appliedDir)}
+}
+
+// Last returns the last apply recorded for key, if any.
+func (r *Recorder) Last(key string) (Apply, bool) {
+	var apply Apply
+	content, err := ioutil.ReadFile(r.file(key))
+	if err != nil {
+		return apply, false
+	}
+	if err := json.Unmarshal(content, &apply); err != nil {
+		return apply, false
+	}
+	return apply, true
+}
+
+func (r *Recorder) Record(key string, apply Apply) error {
+	if apply.Time.IsZero() {
+		apply.Time = time.Now().UTC()
+	}
+	content, err := json.MarshalIndent(apply, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(r.dir, 0755)
+	if err == nil {
+		err = ioutil.WriteFile(r.file(key), content, 0644)
+	}
+	if err != nil {
+		return errors.Wrap(err, ansi.Sprintf("@R{Recording apply of} @m{%s} @R{FAILED}", key))
+	}
+	return nil
+}
+
+func (r *Recorder) file(key string) string {
+	return filepath.Join(r.dir, url.PathEscape(key)+".json")
+}
+
+// Digest returns the digest of settings and the files at path, which is
+// either a file or a directory. Subdirectories are only included if
+// recursive is set.
+func Digest(path string, recursive bool, settings interface{}) (string, error) {
+	hash := sha256.New()
+	s, err := json.Marshal(settings)
+	if err != nil {
+		return "", err
+	}
+	hash.Write(s)
+
+	err = filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if file != path && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(path, file)
+		hash.Write([]byte("\x00" + filepath.ToSlash(rel) + "\x00"))
+		hash.Write(content)
+		return nil
+	})
+	if err != nil {
+		return "", errors.Wrap(err, ansi.Sprintf("@R{Computing digest of} @m{%s} @R{FAILED}", path))
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,85 @@
+package recorder_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/JulzDiverse/aviator/recorder"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Recorder", func() {
+
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "recorder")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	write := func(path, content string) {
+		path = filepath.Join(dir, path)
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(path, []byte(content), 0644)).To(Succeed())
+	}
+
+	Context("Record", func() {
+		It("returns the last apply of a key", func() {
+			r := New(dir)
+			_, ok := r.Last("prod:manifests/app.yml")
+			Expect(ok).To(BeFalse())
+
+			Expect(r.Record("prod:manifests/app.yml", Apply{Digest: "abc", Output: "deployment/app configured"})).To(Succeed())
+			Expect(filepath.Join(dir, ".aviator/applied/prod:manifests%2Fapp.yml.json")).To(BeAnExistingFile())
+
+			last, ok := New(dir).Last("prod:manifests/app.yml")
+			Expect(ok).To(BeTrue())
+			Expect(last.Digest).To(Equal("abc"))
+			Expect(last.Output).To(Equal("deployment/app configured"))
+			Expect(last.Time.IsZero()).To(BeFalse())
+		})
+	})
+
+	Context("Digest", func() {
+		It("changes with the content of the file and the settings", func() {
+			write("app.yml", "kind: Deployment\n")
+			file := filepath.Join(dir, "app.yml")
+
+			digest, err := Digest(file, false, map[string]bool{"force": false})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(Digest(file, false, map[string]bool{"force": false})).To(Equal(digest))
+			Expect(Digest(file, false, map[string]bool{"force": true})).ToNot(Equal(digest))
+
+			write("app.yml", "kind: Service\n")
+			Expect(Digest(file, false, map[string]bool{"force": false})).ToNot(Equal(digest))
+		})
+
+		It("only includes subdirectories if recursive", func() {
+			write("manifests/app.yml", "kind: Deployment\n")
+			write("manifests/nested/svc.yml", "kind: Service\n")
+			manifests := filepath.Join(dir, "manifests")
+
+			flat, err := Digest(manifests, false, nil)
+			Expect(err).ToNot(HaveOccurred())
+			recursive, err := Digest(manifests, true, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			write("manifests/nested/svc.yml", "kind: ConfigMap\n")
+			Expect(Digest(manifests, false, nil)).To(Equal(flat))
+			Expect(Digest(manifests, true, nil)).ToNot(Equal(recursive))
+		})
+
+		It("fails for missing files", func() {
+			_, err := Digest(filepath.Join(dir, "missing.yml"), false, nil)
+			Expect(err).To(MatchError(ContainSubstring("missing.yml")))
+		})
+	})
+})
@@ -0,0 +1,106 @@
+package reporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/JulzDiverse/aviator/timer"
+	"github.com/JulzDiverse/aviator/transport"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+const (
+	Started  = "started"
+	StepDone = "step"
+	Finished = "finished"
+
+	Succeeded = "succeeded"
+	Failed    = "failed"
+
+	callbackTimeout = 10 * time.Second
+)
+
+// Event is the JSON body posted to the callback URL.
+type Event struct {
+	Event    string    `json:"event"`
+	Status   string    `json:"status,omitempty"`
+	Step     string    `json:"step,omitempty"`
+	Duration float64   `json:"duration_seconds,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// Reporter posts the progress of a run to a callback URL. Failing callbacks
+// are printed as warnings and never fail the run.
+type Reporter struct {
+	url   string
+	token string
+}
+
+func New(url, token string) *Reporter {
+	return &Reporter{
+		url:   url,
+		token: token,
+	}
+}
+
+func (r *Reporter) Started() {
+	r.post(Event{Event: Started})
+}
+
+func (r *Reporter) Step(step timer.Step, err error) {
+	r.post(withError(Event{Event: StepDone, Step: step.Name, Duration: step.Duration.Seconds()}, err))
+}
+
+func (r *Reporter) Finished(err error) {
+	r.post(withError(Event{Event: Finished}, err))
+}
+
+func withError(e Event, err error) Event {
+	e.Status = Succeeded
+	if err != nil {
+		e.Status, e.Error = Failed, err.Error()
+	}
+	return e
+}
+
+func (r *Reporter) post(e Event) {
+	e.Time = time.Now().UTC()
+	if err := r.send(e); err != nil {
+		ansi.Fprintf(os.Stderr, "@Y{WARNING}: posting @m{%s} event to the callback URL FAILED: %s\n", e.Event, err.Error())
+	}
+}
+
+func (r *Reporter) send(e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	}
+
+	client, err := transport.Client(transport.Callback, callbackTimeout)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return nil
+}
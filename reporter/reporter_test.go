@@ -0,0 +1,72 @@
+package reporter_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/JulzDiverse/aviator/reporter"
+	"github.com/JulzDiverse/aviator/timer"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Reporter", func() {
+
+	var (
+		server *httptest.Server
+		events []reporter.Event
+		auth   []string
+		status int
+	)
+
+	BeforeEach(func() {
+		events, auth, status = nil, nil, http.StatusOK
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var e reporter.Event
+			Expect(json.NewDecoder(r.Body).Decode(&e)).To(Succeed())
+			events = append(events, e)
+			auth = append(auth, r.Header.Get("Authorization"))
+			w.WriteHeader(status)
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("posts the start, every step and the end of a run", func() {
+		r := reporter.New(server.URL, "")
+		r.Started()
+		r.Step(timer.Step{Name: "spruce: result.yml", Duration: 1500 * time.Millisecond}, nil)
+		r.Step(timer.Step{Name: "kubectl: result.yml"}, errors.New("apply failed"))
+		r.Finished(errors.New("apply failed"))
+
+		Expect(events).To(HaveLen(4))
+		Expect(events[0].Event).To(Equal(reporter.Started))
+		Expect(events[1].Event).To(Equal(reporter.StepDone))
+		Expect(events[1].Step).To(Equal("spruce: result.yml"))
+		Expect(events[1].Status).To(Equal(reporter.Succeeded))
+		Expect(events[1].Duration).To(Equal(1.5))
+		Expect(events[2].Status).To(Equal(reporter.Failed))
+		Expect(events[2].Error).To(Equal("apply failed"))
+		Expect(events[3].Event).To(Equal(reporter.Finished))
+		Expect(events[3].Status).To(Equal(reporter.Failed))
+		Expect(events[3].Time).ToNot(BeZero())
+	})
+
+	It("authenticates with the given bearer token", func() {
+		reporter.New(server.URL, "secret").Started()
+		Expect(auth).To(Equal([]string{"Bearer secret"}))
+	})
+
+	It("does not fail the run if the callback fails", func() {
+		status = http.StatusInternalServerError
+		r := reporter.New(server.URL, "")
+		Expect(func() { r.Finished(nil) }).ToNot(Panic())
+		Expect(events).To(HaveLen(1))
+	})
+})
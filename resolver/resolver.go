@@ -0,0 +1,164 @@
+package resolver
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/starkandwayne/goutils/ansi"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var (
+	placeholder = regexp.MustCompile(`^\(\(\s*vault\s+"([^"]+)"\s*\)\)$`)
+	candidate   = regexp.MustCompile(`\(\(\s*vault\s`)
+	separator   = regexp.MustCompile(`^---(\s|$)`)
+)
+
+// Lookup returns the secret of a vault key of the form secret/path:key.
+type Lookup func(key string) (interface{}, error)
+
+// Resolver replaces the vault placeholders left in rendered files, e.g. by
+// skip_eval or engines other than spruce: values of the form
+// (( vault "secret/path:key" )).
+type Resolver struct {
+	lookup Lookup
+}
+
+var resolver *Resolver
+
+func New(lookup Lookup) *Resolver {
+	return &Resolver{lookup: lookup}
+}
+
+func Default() *Resolver {
+	return resolver
+}
+
+func Use(r *Resolver) {
+	resolver = r
+}
+
+// Placeholder returns the vault placeholder of key.
+func Placeholder(key string) string {
+	return fmt.Sprintf(`(( vault "%s" ))`, key)
+}
+
+// Resolve replaces the vault placeholders in the YAML documents of file.
+// Documents without placeholders are returned unchanged; the others are
+// marshalled again.
+func (r *Resolver) Resolve(file []byte) ([]byte, error) {
+	if r == nil || !candidate.Match(file) {
+		return file, nil
+	}
+
+	result := []string{}
+	doc := []string{}
+	flush := func() error {
+		resolved, err := r.resolveDocument(strings.Join(doc, "\n"))
+		if err != nil {
+			return err
+		}
+		result = append(result, resolved)
+		doc = []string{}
+		return nil
+	}
+
+	for _, l := range strings.Split(string(file), "\n") {
+		if separator.MatchString(l) {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			result = append(result, l)
+			continue
+		}
+		doc = append(doc, l)
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return []byte(strings.Join(result, "\n")), nil
+}
+
+func (r *Resolver) resolveDocument(doc string) (string, error) {
+	if !candidate.MatchString(doc) {
+		return doc, nil
+	}
+
+	var root interface{}
+	var m yaml.MapSlice
+	if err := yaml.Unmarshal([]byte(doc), &m); err == nil {
+		root = m
+	} else if err := yaml.Unmarshal([]byte(doc), &root); err != nil {
+		return doc, nil
+	}
+
+	replaced := false
+	root, err := r.walk(root, &replaced)
+	if err != nil || !replaced {
+		return doc, err
+	}
+
+	out, err := yaml.Marshal(root)
+	if err != nil {
+		return "", err
+	}
+	resolved := strings.TrimSuffix(string(out), "\n")
+	if strings.HasSuffix(doc, "\n") {
+		resolved += "\n"
+	}
+	return resolved, nil
+}
+
+func (r *Resolver) walk(value interface{}, replaced *bool) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		match := placeholder.FindStringSubmatch(v)
+		if match == nil {
+			return v, nil
+		}
+		secret, err := r.lookup(match[1])
+		if err != nil {
+			return nil, err
+		}
+		*replaced = true
+		return secret, nil
+	case yaml.MapSlice:
+		for i := range v {
+			resolved, err := r.walk(v[i].Value, replaced)
+			if err != nil {
+				return nil, err
+			}
+			v[i].Value = resolved
+		}
+	case map[interface{}]interface{}:
+		for k := range v {
+			resolved, err := r.walk(v[k], replaced)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = resolved
+		}
+	case []interface{}:
+		for i := range v {
+			resolved, err := r.walk(v[i], replaced)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = resolved
+		}
+	}
+	return value, nil
+}
+
+// Stub looks up secrets in a map of keys to values, as read from a vault
+// stub file in offline mode.
+func Stub(secrets map[string]interface{}) Lookup {
+	return func(key string) (interface{}, error) {
+		secret, ok := secrets[key]
+		if !ok {
+			return nil, ansi.Errorf("@R{offline mode: vault secret} @m{%s} @R{is not provided by the vault stub file}", key)
+		}
+		return secret, nil
+	}
+}
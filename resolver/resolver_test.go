@@ -0,0 +1,153 @@
+package resolver_test
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	. "github.com/JulzDiverse/aviator/resolver"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Resolver", func() {
+
+	var resolver *Resolver
+
+	BeforeEach(func() {
+		resolver = New(Stub(map[string]interface{}{
+			"secret/app:password": "s3cr3t",
+			"secret/app:port":     5432,
+		}))
+	})
+
+	It("replaces the placeholders in all documents", func() {
+		file := []byte(`name: app
+db:
+  password: (( vault "secret/app:password" ))
+  port: '(( vault "secret/app:port" ))'
+---
+kind: Secret
+---
+users:
+- (( vault "secret/app:password" ))
+`)
+
+		resolved, err := resolver.Resolve(file)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(resolved)).To(Equal(`name: app
+db:
+  password: s3cr3t
+  port: 5432
+---
+kind: Secret
+---
+users:
+- s3cr3t
+`))
+	})
+
+	It("leaves files without placeholders unchanged", func() {
+		file := []byte("# a comment\nname:   app\n")
+		Expect(resolver.Resolve(file)).To(Equal(file))
+	})
+
+	It("fails for unknown secrets", func() {
+		_, err := resolver.Resolve([]byte(`password: (( vault "secret/other:password" ))`))
+		Expect(err).To(MatchError(ContainSubstring("secret/other:password")))
+	})
+
+	It("does nothing if not configured", func() {
+		file := []byte(`password: (( vault "secret/app:password" ))`)
+		Expect((*Resolver)(nil).Resolve(file)).To(Equal(file))
+	})
+
+	Context("Vault", func() {
+
+		var (
+			server   *httptest.Server
+			requests []string
+			env      map[string]string
+		)
+
+		setenv := func(name, value string) {
+			if _, ok := env[name]; !ok {
+				env[name] = os.Getenv(name)
+			}
+			os.Setenv(name, value)
+		}
+
+		BeforeEach(func() {
+			requests = []string{}
+			env = map[string]string{}
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r.URL.Path+" "+r.Header.Get("X-Vault-Token"))
+				switch r.URL.Path {
+				case "/v1/secret/app":
+					w.Write([]byte(`{"data": {"password": "s3cr3t"}}`))
+				case "/v1/kv/data/app":
+					w.Write([]byte(`{"data": {"data": {"password": "v2-s3cr3t"}, "metadata": {"version": 3}}}`))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+					w.Write([]byte(`{"errors": []}`))
+				}
+			}))
+			setenv("VAULT_ADDR", server.URL)
+			setenv("VAULT_TOKEN", "token")
+			setenv("VAULT_VERSION", "")
+		})
+
+		AfterEach(func() {
+			server.Close()
+			for name, value := range env {
+				os.Setenv(name, value)
+			}
+		})
+
+		It("reads every secret once", func() {
+			lookup := Vault()
+			Expect(lookup("secret/app:password")).To(Equal("s3cr3t"))
+			Expect(lookup("secret/app:password")).To(Equal("s3cr3t"))
+			Expect(requests).To(Equal([]string{"/v1/secret/app token"}))
+		})
+
+		It("reads from the KV v2 engine", func() {
+			setenv("VAULT_VERSION", "2")
+			Expect(Vault()("kv/app:password")).To(Equal("v2-s3cr3t"))
+		})
+
+		It("fails for missing secrets and keys", func() {
+			_, err := Vault()("secret/other:password")
+			Expect(err).To(MatchError(ContainSubstring("404")))
+			_, err = Vault()("secret/app:user")
+			Expect(err).To(MatchError(ContainSubstring("has no key")))
+		})
+
+		It("requires an address and a token", func() {
+			setenv("VAULT_TOKEN", "")
+			_, err := Vault()("secret/app:password")
+			Expect(err).To(MatchError(ContainSubstring("--no-vault")))
+		})
+
+		It("trusts the CA certificate in VAULT_CACERT", func() {
+			tlsServer := httptest.NewTLSServer(server.Config.Handler)
+			defer tlsServer.Close()
+			setenv("VAULT_ADDR", tlsServer.URL)
+
+			_, err := Vault()("secret/app:password")
+			Expect(err).To(MatchError(ContainSubstring("certificate")))
+
+			ca, err := ioutil.TempFile("", "vault-ca")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.Remove(ca.Name())
+			pem.Encode(ca, &pem.Block{Type: "CERTIFICATE", Bytes: tlsServer.Certificate().Raw})
+			ca.Close()
+			setenv("VAULT_CACERT", ca.Name())
+
+			Expect(Vault()("secret/app:password")).To(Equal("s3cr3t"))
+		})
+	})
+})
@@ -0,0 +1,113 @@
+package resolver
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/JulzDiverse/aviator/transport"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+// Vault looks up secrets in the HashiCorp Vault at VAULT_ADDR with
+// VAULT_TOKEN. As for the vault operator of spruce, VAULT_VERSION selects
+// the version of the KV engine (1 by default), VAULT_CACERT, VAULT_CLIENT_CERT
+// and VAULT_CLIENT_KEY configure TLS (see transport.Config) and
+// VAULT_SKIP_VERIFY skips the verification of the TLS certificate. Every
+// secret is only read once.
+func Vault() Lookup {
+	var lock sync.Mutex
+	cache := map[string]map[string]interface{}{}
+
+	return func(key string) (interface{}, error) {
+		i := strings.LastIndex(key, ":")
+		if i <= 0 || i == len(key)-1 {
+			return nil, ansi.Errorf("@R{invalid vault key} @m{%s}@R{; must be in the form} @m{path/to/secret:key}", key)
+		}
+		path, name := key[:i], key[i+1:]
+
+		lock.Lock()
+		defer lock.Unlock()
+		secret, ok := cache[path]
+		if !ok {
+			var err error
+			secret, err = readSecret(path)
+			if err != nil {
+				return nil, err
+			}
+			cache[path] = secret
+		}
+
+		value, ok := secret[name]
+		if !ok {
+			return nil, ansi.Errorf("@R{vault secret} @m{%s} @R{has no key} @m{%s}", path, name)
+		}
+		return value, nil
+	}
+}
+
+func readSecret(path string) (map[string]interface{}, error) {
+	addr, token := os.Getenv("VAULT_ADDR"), os.Getenv("VAULT_TOKEN")
+	if addr == "" || token == "" {
+		return nil, ansi.Errorf("@R{Resolving vault secret} @m{%s} @R{FAILED}: set VAULT_ADDR and VAULT_TOKEN, or render with --no-vault", path)
+	}
+
+	url := strings.TrimSuffix(addr, "/") + "/v1/" + path
+	v2 := os.Getenv("VAULT_VERSION") == "2"
+	if v2 {
+		parts := strings.SplitN(path, "/", 2)
+		if len(parts) != 2 {
+			return nil, ansi.Errorf("@R{invalid vault path} @m{%s}@R{; must be in the form} @m{engine/path/to/secret}", path)
+		}
+		url = strings.TrimSuffix(addr, "/") + "/v1/" + parts[0] + "/data/" + parts[1]
+	}
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, ansi.Errorf("@R{Reading vault secret} @m{%s} @R{FAILED}: %s", path, err.Error())
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if ns := os.Getenv("VAULT_NAMESPACE"); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+
+	config, err := transport.Config(transport.Vault)
+	if err != nil {
+		return nil, err
+	}
+	if skip := os.Getenv("VAULT_SKIP_VERIFY"); skip != "" && skip != "0" && skip != "false" {
+		if config == nil {
+			config = &tls.Config{}
+		}
+		config.InsecureSkipVerify = true
+	}
+	res, err := transport.NewClient(config, 0).Do(req)
+	if err != nil {
+		return nil, ansi.Errorf("@R{Reading vault secret} @m{%s} @R{FAILED}: %s", path, err.Error())
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, ansi.Errorf("@R{Reading vault secret} @m{%s} @R{FAILED}: %s", path, err.Error())
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, ansi.Errorf("@R{Reading vault secret} @m{%s} @R{FAILED}: %s %s", path, res.Status, strings.TrimSpace(string(body)))
+	}
+
+	var response struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, ansi.Errorf("@R{Reading vault secret} @m{%s} @R{FAILED}: %s", path, err.Error())
+	}
+	if v2 {
+		data, _ := response.Data["data"].(map[string]interface{})
+		return data, nil
+	}
+	return response.Data, nil
+}
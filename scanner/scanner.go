@@ -0,0 +1,70 @@
+package scanner
+
+import (
+	"math"
+	"regexp"
+	"strings"
+)
+
+const minEntropy = 3.5
+
+type Finding struct {
+	Rule string
+	Line int
+}
+
+type rule struct {
+	name    string
+	pattern *regexp.Regexp
+	entropy bool
+}
+
+var rules = []rule{
+	{name: "private-key", pattern: regexp.MustCompile(`-----BEGIN ((RSA|DSA|EC|OPENSSH|PGP) )?PRIVATE KEY( BLOCK)?-----`)},
+	{name: "aws-access-key-id", pattern: regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{name: "github-token", pattern: regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+	{name: "slack-token", pattern: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`)},
+	{name: "google-api-key", pattern: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{name: "jwt", pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\b`)},
+	{
+		name:    "generic-secret",
+		pattern: regexp.MustCompile(`(?i)^\s*-?\s*["']?[\w.-]*(password|passwd|secret|token|api[_-]?key|access[_-]?key|private[_-]?key)["']?\s*:\s*["']?([^\s"']{8,})`),
+		entropy: true,
+	},
+}
+
+func Scan(file []byte) []Finding {
+	findings := []Finding{}
+	for i, line := range strings.Split(string(file), "\n") {
+		for _, r := range rules {
+			match := r.pattern.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+			if r.entropy {
+				value := match[len(match)-1]
+				if strings.HasPrefix(value, "((") || entropy(value) < minEntropy {
+					continue
+				}
+			}
+			findings = append(findings, Finding{Rule: r.name, Line: i + 1})
+			break
+		}
+	}
+	return findings
+}
+
+func entropy(s string) float64 {
+	counts := map[rune]float64{}
+	for _, c := range s {
+		counts[c]++
+	}
+
+	var result float64
+	length := float64(len([]rune(s)))
+	for _, count := range counts {
+		p := count / length
+		result -= p * math.Log2(p)
+	}
+	return result
+}
@@ -0,0 +1,108 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/JulzDiverse/aviator"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const draft = "http://json-schema.org/draft-07/schema#"
+
+func AviatorSchema() map[string]interface{} {
+	s := Generate(reflect.TypeOf(aviator.AviatorYaml{}))
+	s["$schema"] = draft
+	s["title"] = "aviator.yml"
+	return s
+}
+
+func JSON() ([]byte, error) {
+	return json.MarshalIndent(AviatorSchema(), "", "  ")
+}
+
+func YAML() ([]byte, error) {
+	return yaml.Marshal(AviatorSchema())
+}
+
+func Generate(t reflect.Type) map[string]interface{} {
+	return generate(t, map[reflect.Type]bool{})
+}
+
+func generate(t reflect.Type, seen map[reflect.Type]bool) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return generate(t.Elem(), seen)
+	case reflect.Struct:
+		if seen[t] {
+			return map[string]interface{}{"type": "object"}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		properties := map[string]interface{}{}
+		addProperties(t, properties, seen)
+		return map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{
+			"type":  "array",
+			"items": generate(t.Elem(), seen),
+		}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": generate(t.Elem(), seen),
+		}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	}
+	return map[string]interface{}{}
+}
+
+func addProperties(t reflect.Type, properties map[string]interface{}, seen map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if isInline(field) {
+			addProperties(field.Type, properties, seen)
+			continue
+		}
+
+		name := tagName(field)
+		if name == "" {
+			continue
+		}
+		properties[name] = generate(field.Type, seen)
+	}
+}
+
+func isInline(field reflect.StructField) bool {
+	return field.Anonymous && field.Type.Kind() == reflect.Struct &&
+		strings.Contains(field.Tag.Get("yaml"), "inline")
+}
+
+func tagName(field reflect.StructField) string {
+	if field.PkgPath != "" {
+		return ""
+	}
+	tag := field.Tag.Get("json")
+	if tag == "" {
+		tag = field.Tag.Get("yaml")
+	}
+	name := strings.Split(tag, ",")[0]
+	if name == "-" {
+		return ""
+	}
+	return name
+}
@@ -0,0 +1,83 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"reflect"
+
+	. "github.com/JulzDiverse/aviator/schema"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Schema", func() {
+
+	Context("Generate", func() {
+		type inner struct {
+			Name string `yaml:"name" json:"name"`
+		}
+
+		type Inlined struct {
+			Extra string `yaml:"extra" json:"extra"`
+		}
+
+		type outer struct {
+			Inlined `yaml:",inline"`
+
+			Inner   inner             `yaml:"inner" json:"inner"`
+			List    []string          `yaml:"list" json:"list"`
+			Flag    bool              `yaml:"flag" json:"flag"`
+			Vars    map[string]string `yaml:"vars" json:"vars"`
+			Ignored string            `yaml:"-" json:"-"`
+		}
+
+		var s map[string]interface{}
+
+		BeforeEach(func() {
+			s = Generate(reflect.TypeOf(outer{}))
+		})
+
+		It("describes structs as closed objects keyed by their tag names", func() {
+			Expect(s["type"]).To(Equal("object"))
+			Expect(s["additionalProperties"]).To(Equal(false))
+			props := s["properties"].(map[string]interface{})
+			Expect(props).To(HaveKey("inner"))
+			Expect(props).To(HaveKey("list"))
+			Expect(props).ToNot(HaveKey("Ignored"))
+			Expect(props).ToNot(HaveKey("-"))
+		})
+
+		It("inlines embedded structs", func() {
+			props := s["properties"].(map[string]interface{})
+			Expect(props).To(HaveKey("extra"))
+			Expect(props).ToNot(HaveKey("Inlined"))
+		})
+
+		It("maps field types to json schema types", func() {
+			props := s["properties"].(map[string]interface{})
+			Expect(props["flag"]).To(Equal(map[string]interface{}{"type": "boolean"}))
+			Expect(props["list"]).To(Equal(map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"type": "string"},
+			}))
+			Expect(props["vars"]).To(Equal(map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": map[string]interface{}{"type": "string"},
+			}))
+		})
+	})
+
+	Context("JSON", func() {
+		It("produces a valid json document for the aviator file", func() {
+			out, err := JSON()
+			Expect(err).ToNot(HaveOccurred())
+
+			var doc map[string]interface{}
+			Expect(json.Unmarshal(out, &doc)).To(Succeed())
+			Expect(doc["$schema"]).To(ContainSubstring("draft-07"))
+			props := doc["properties"].(map[string]interface{})
+			Expect(props).To(HaveKey("spruce"))
+			Expect(props).To(HaveKey("kubectl"))
+		})
+	})
+})
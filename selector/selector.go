@@ -0,0 +1,72 @@
+package selector
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/JulzDiverse/aviator"
+	"github.com/starkandwayne/goutils/ansi"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var docSeparator = regexp.MustCompile(`(?m)^---\s*$`)
+
+type manifest struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name   string            `yaml:"name"`
+		Labels map[string]string `yaml:"labels"`
+	} `yaml:"metadata"`
+}
+
+func IsEmpty(s aviator.Select) bool {
+	return len(s.Kind) == 0 && len(s.APIVersion) == 0 && len(s.Name) == 0 && len(s.Labels) == 0
+}
+
+func Select(file []byte, s aviator.Select) ([]byte, error) {
+	selected := []string{}
+	for i, doc := range docSeparator.Split(string(file), -1) {
+		if strings.TrimSpace(doc) == "" {
+			continue
+		}
+
+		var m manifest
+		if err := yaml.Unmarshal([]byte(doc), &m); err != nil {
+			return nil, ansi.Errorf("@R{Parsing document %d for select failed}: %s", i, err.Error())
+		}
+
+		if matches(m, s) != s.Exclude {
+			selected = append(selected, strings.Trim(doc, "\n"))
+		}
+	}
+
+	if len(selected) == 0 {
+		return []byte{}, nil
+	}
+	return []byte("---\n" + strings.Join(selected, "\n---\n") + "\n"), nil
+}
+
+func matches(m manifest, s aviator.Select) bool {
+	if !oneOf(m.Kind, s.Kind) || !oneOf(m.APIVersion, s.APIVersion) || !oneOf(m.Metadata.Name, s.Name) {
+		return false
+	}
+	for k, v := range s.Labels {
+		if m.Metadata.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func oneOf(value string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, a := range allowed {
+		if a == value {
+			return true
+		}
+	}
+	return false
+}
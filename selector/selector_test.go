@@ -0,0 +1,64 @@
+package selector_test
+
+import (
+	"github.com/JulzDiverse/aviator"
+	. "github.com/JulzDiverse/aviator/selector"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Select", func() {
+
+	input := []byte(`---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: apps.example.com
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: credentials
+  labels:
+    app: web
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  labels:
+    app: web
+`)
+
+	It("keeps only documents matching the filter", func() {
+		out, err := Select(input, aviator.Select{Kind: []string{"CustomResourceDefinition"}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(out)).To(Equal("---\napiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\nmetadata:\n  name: apps.example.com\n"))
+	})
+
+	It("drops matching documents with exclude", func() {
+		out, err := Select(input, aviator.Select{Kind: []string{"Secret"}, Exclude: true})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(out)).To(ContainSubstring("kind: CustomResourceDefinition"))
+		Expect(string(out)).To(ContainSubstring("kind: Deployment"))
+		Expect(string(out)).ToNot(ContainSubstring("kind: Secret"))
+	})
+
+	It("combines criteria", func() {
+		out, err := Select(input, aviator.Select{
+			APIVersion: []string{"apps/v1", "v1"},
+			Labels:     map[string]string{"app": "web"},
+			Name:       []string{"web"},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(out)).To(ContainSubstring("kind: Deployment"))
+		Expect(string(out)).ToNot(ContainSubstring("kind: Secret"))
+	})
+
+	It("returns an empty document if nothing matches", func() {
+		out, err := Select(input, aviator.Select{Kind: []string{"Service"}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(BeEmpty())
+	})
+})
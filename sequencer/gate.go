@@ -0,0 +1,117 @@
+package sequencer
+
+import (
+	"context"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+const (
+	pollInterval = 500 * time.Millisecond
+
+	// announcements of runs which died before releasing them are ignored
+	// after staleAfter
+	staleAfter = time.Hour
+)
+
+// Gate orders kubectl applies of aviator runs in progress at the same time
+// which target the same kube context. A run announces the before phase of
+// its kubectl step when it starts; the applies of other runs to the context
+// wait until the announcement is released. Runs coordinate through marker
+// files in a shared directory.
+type Gate struct {
+	dir string
+	id  string
+}
+
+var gate *Gate
+
+func NewGate(dir string) *Gate {
+	return &Gate{dir: dir, id: strconv.Itoa(os.Getpid())}
+}
+
+func Default() *Gate {
+	return gate
+}
+
+func Use(g *Gate) {
+	gate = g
+}
+
+// Announce registers a pending before phase on the kube context. The
+// returned release removes it and may be called more than once.
+func (g *Gate) Announce(kubeContext string) (func(), error) {
+	if g == nil {
+		return func() {}, nil
+	}
+
+	dir := g.contextDir(kubeContext)
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, errors.Wrap(err, ansi.Sprintf("@R{Announcing before phase FAILED}"))
+	}
+	marker := filepath.Join(dir, g.id)
+	err = ioutil.WriteFile(marker, nil, 0644)
+	if err != nil {
+		return nil, errors.Wrap(err, ansi.Sprintf("@R{Announcing before phase FAILED}"))
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { os.Remove(marker) })
+	}, nil
+}
+
+// Pending reports whether another run has a pending before phase on the
+// kube context.
+func (g *Gate) Pending(kubeContext string) bool {
+	if g == nil {
+		return false
+	}
+
+	entries, err := ioutil.ReadDir(g.contextDir(kubeContext))
+	if err != nil {
+		return false
+	}
+	for _, e := range entries {
+		if e.Name() != g.id && time.Since(e.ModTime()) < staleAfter {
+			return true
+		}
+	}
+	return false
+}
+
+// Wait blocks until no other run has a pending before phase on the kube
+// context or ctx is done.
+func (g *Gate) Wait(ctx context.Context, kubeContext string) error {
+	for g.Pending(kubeContext) {
+		select {
+		case <-ctx.Done():
+			return ansi.Errorf("@R{Waiting for before phases on} @m{%s} @R{canceled}", displayContext(kubeContext))
+		case <-time.After(pollInterval):
+		}
+	}
+	return nil
+}
+
+func (g *Gate) contextDir(kubeContext string) string {
+	if kubeContext == "" {
+		kubeContext = "current-context"
+	}
+	return filepath.Join(g.dir, url.PathEscape(kubeContext))
+}
+
+func displayContext(kubeContext string) string {
+	if kubeContext == "" {
+		return "the current context"
+	}
+	return kubeContext
+}
@@ -0,0 +1,130 @@
+package sequencer
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var documentSeparator = regexp.MustCompile(`(?m)^---[ \t]*$`)
+
+// Phases splits the manifests of path, a file or a directory of .yml, .yaml
+// and .json files as passed to kubectl apply, into three phases: the
+// documents of the before kinds in the order of before, the remaining
+// documents, and the documents of the after kinds in the order of after.
+// Phases without documents are nil.
+func Phases(path string, recursive bool, before, after []string) ([][]byte, error) {
+	documents, err := readDocuments(path, recursive)
+	if err != nil {
+		return nil, err
+	}
+
+	beforePhase := make([][][]byte, len(before))
+	afterPhase := make([][][]byte, len(after))
+	rest := [][]byte{}
+	for _, doc := range documents {
+		kind, err := kindOf(doc)
+		if err != nil {
+			return nil, err
+		}
+		if i := indexOf(before, kind); i >= 0 {
+			beforePhase[i] = append(beforePhase[i], doc)
+		} else if i := indexOf(after, kind); i >= 0 {
+			afterPhase[i] = append(afterPhase[i], doc)
+		} else {
+			rest = append(rest, doc)
+		}
+	}
+
+	return [][]byte{
+		join(flatten(beforePhase)),
+		join(rest),
+		join(flatten(afterPhase)),
+	}, nil
+}
+
+func readDocuments(path string, recursive bool) ([][]byte, error) {
+	files := []string{}
+	err := filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if file != path && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if file == path || isManifest(file) {
+			files = append(files, file)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, ansi.Sprintf("@R{Reading manifests} @m{%s} @R{FAILED}", path))
+	}
+
+	documents := [][]byte{}
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, errors.Wrap(err, ansi.Sprintf("@R{Reading manifest} @m{%s} @R{FAILED}", file))
+		}
+		for _, doc := range documentSeparator.Split(string(content), -1) {
+			if strings.TrimSpace(doc) != "" {
+				documents = append(documents, []byte(strings.Trim(doc, "\n")+"\n"))
+			}
+		}
+	}
+	return documents, nil
+}
+
+func isManifest(file string) bool {
+	switch filepath.Ext(file) {
+	case ".yml", ".yaml", ".json":
+		return true
+	}
+	return false
+}
+
+func kindOf(doc []byte) (string, error) {
+	var manifest struct {
+		Kind string `yaml:"kind"`
+	}
+	err := yaml.Unmarshal(doc, &manifest)
+	if err != nil {
+		return "", errors.Wrap(err, ansi.Sprintf("@R{Parsing manifest FAILED}"))
+	}
+	return manifest.Kind, nil
+}
+
+func indexOf(kinds []string, kind string) int {
+	for i, k := range kinds {
+		if strings.EqualFold(k, kind) {
+			return i
+		}
+	}
+	return -1
+}
+
+func flatten(groups [][][]byte) [][]byte {
+	result := [][]byte{}
+	for _, group := range groups {
+		result = append(result, group...)
+	}
+	return result
+}
+
+func join(documents [][]byte) []byte {
+	if len(documents) == 0 {
+		return nil
+	}
+	return bytes.Join(documents, []byte("---\n"))
+}
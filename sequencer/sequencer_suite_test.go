@@ -0,0 +1,13 @@
+package sequencer_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestSequencer(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Sequencer Suite")
+}
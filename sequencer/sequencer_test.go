@@ -0,0 +1,144 @@
+package sequencer_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/JulzDiverse/aviator/sequencer"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sequencer", func() {
+
+	var dir string
+
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(path, []byte(content), 0644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "sequencer")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	Context("Phases", func() {
+
+		It("splits the documents by the before and after kinds", func() {
+			write("manifests.yml", `kind: Deployment
+metadata: {name: app}
+---
+kind: Namespace
+metadata: {name: ns}
+---
+kind: CustomResourceDefinition
+metadata: {name: crd}
+---
+kind: Job
+metadata: {name: smoke}
+`)
+
+			phases, err := Phases(filepath.Join(dir, "manifests.yml"), false, []string{"CustomResourceDefinition", "Namespace"}, []string{"Job"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(phases).To(HaveLen(3))
+			Expect(string(phases[0])).To(Equal("kind: CustomResourceDefinition\nmetadata: {name: crd}\n---\nkind: Namespace\nmetadata: {name: ns}\n"))
+			Expect(string(phases[1])).To(Equal("kind: Deployment\nmetadata: {name: app}\n"))
+			Expect(string(phases[2])).To(Equal("kind: Job\nmetadata: {name: smoke}\n"))
+		})
+
+		It("reads the manifest files of a directory", func() {
+			write("a.yml", "kind: Namespace\n")
+			write("b.json", `{"kind": "Service"}`)
+			write("README.md", "kind: Namespace\n")
+			write("nested/c.yml", "kind: Namespace\n")
+
+			phases, err := Phases(dir, false, []string{"Namespace"}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(phases[0])).To(Equal("kind: Namespace\n"))
+			Expect(string(phases[1])).To(Equal(`{"kind": "Service"}` + "\n"))
+			Expect(phases[2]).To(BeNil())
+
+			phases, err = Phases(dir, true, []string{"Namespace"}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(phases[0])).To(Equal("kind: Namespace\n---\nkind: Namespace\n"))
+		})
+
+		It("fails on missing manifests", func() {
+			_, err := Phases(filepath.Join(dir, "missing.yml"), false, []string{"Namespace"}, nil)
+			Expect(err).To(MatchError(ContainSubstring("missing.yml")))
+		})
+	})
+
+	Context("Gate", func() {
+
+		It("reports announcements of other runs as pending", func() {
+			gate := NewGate(dir)
+			Expect(gate.Pending("staging")).To(BeFalse())
+
+			write("staging/4711", "")
+			Expect(gate.Pending("staging")).To(BeTrue())
+			Expect(gate.Pending("production")).To(BeFalse())
+		})
+
+		It("ignores its own announcements until released", func() {
+			gate := NewGate(dir)
+			release, err := gate.Announce("staging")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(gate.Pending("staging")).To(BeFalse())
+			Expect(dirEntries(filepath.Join(dir, "staging"))).To(HaveLen(1))
+
+			release()
+			release()
+			Expect(dirEntries(filepath.Join(dir, "staging"))).To(BeEmpty())
+		})
+
+		It("waits until other runs released their announcements", func() {
+			write("current-context/4711", "")
+			gate := NewGate(dir)
+
+			done := make(chan error)
+			go func() { done <- gate.Wait(context.Background(), "") }()
+			Consistently(done, "600ms").ShouldNot(Receive())
+
+			Expect(os.Remove(filepath.Join(dir, "current-context", "4711"))).To(Succeed())
+			Eventually(done, "2s").Should(Receive(BeNil()))
+		})
+
+		It("stops waiting when the context is canceled", func() {
+			write("staging/4711", "")
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			err := NewGate(dir).Wait(ctx, "staging")
+			Expect(err).To(MatchError(ContainSubstring("canceled")))
+		})
+
+		It("does nothing without a gate", func() {
+			var gate *Gate
+			release, err := gate.Announce("staging")
+			Expect(err).ToNot(HaveOccurred())
+			release()
+			Expect(gate.Wait(context.Background(), "staging")).To(Succeed())
+		})
+	})
+})
+
+func dirEntries(dir string) []string {
+	entries, err := ioutil.ReadDir(dir)
+	Expect(err).ToNot(HaveOccurred())
+	names := []string{}
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
@@ -0,0 +1,115 @@
+package snapshot
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+const DefaultDir = "testdata/snapshots"
+
+// Result lists the rendered files which differ from their snapshots.
+type Result struct {
+	Changed []string
+	Added   []string
+	Removed []string
+}
+
+func (r Result) Empty() bool {
+	return len(r.Changed) == 0 && len(r.Added) == 0 && len(r.Removed) == 0
+}
+
+// Tracked reports whether the rendered file path gets a snapshot. Only files
+// within the working directory do; absolute paths (e.g. temp:// targets) and
+// paths leaving it are intermediate or machine specific.
+func Tracked(path string) bool {
+	clean := filepath.Clean(path)
+	return !filepath.IsAbs(clean) && clean != ".." && !strings.HasPrefix(clean, ".."+string(filepath.Separator))
+}
+
+// Verify compares the rendered files with their snapshots in dir.
+func Verify(dir string, files map[string][]byte) (Result, error) {
+	result := Result{}
+	for _, path := range sortedPaths(files) {
+		golden, err := ioutil.ReadFile(filepath.Join(dir, path))
+		if os.IsNotExist(err) {
+			result.Added = append(result.Added, path)
+			continue
+		}
+		if err != nil {
+			return result, errors.Wrap(err, ansi.Sprintf("@R{Reading snapshot} @m{%s} @R{FAILED}", path))
+		}
+		if !bytes.Equal(golden, files[path]) {
+			result.Changed = append(result.Changed, path)
+		}
+	}
+
+	removed, err := obsolete(dir, files)
+	result.Removed = removed
+	return result, err
+}
+
+// Update writes the rendered files to dir and removes the snapshots of files
+// which are no longer rendered. It returns what changed compared to the
+// previous snapshots.
+func Update(dir string, files map[string][]byte) (Result, error) {
+	result, err := Verify(dir, files)
+	if err != nil {
+		return result, err
+	}
+
+	for _, path := range append(append([]string{}, result.Changed...), result.Added...) {
+		target := filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return result, errors.Wrap(err, ansi.Sprintf("@R{Writing snapshot} @m{%s} @R{FAILED}", path))
+		}
+		if err := ioutil.WriteFile(target, files[path], 0644); err != nil {
+			return result, errors.Wrap(err, ansi.Sprintf("@R{Writing snapshot} @m{%s} @R{FAILED}", path))
+		}
+	}
+	for _, path := range result.Removed {
+		if err := os.Remove(filepath.Join(dir, path)); err != nil {
+			return result, errors.Wrap(err, ansi.Sprintf("@R{Removing snapshot} @m{%s} @R{FAILED}", path))
+		}
+	}
+	return result, nil
+}
+
+func obsolete(dir string, files map[string][]byte) ([]string, error) {
+	removed := []string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) && path == dir {
+			return filepath.SkipDir
+		}
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if _, ok := files[filepath.ToSlash(rel)]; !ok {
+			removed = append(removed, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, ansi.Sprintf("@R{Reading snapshots in} @m{%s} @R{FAILED}", dir))
+	}
+	return removed, nil
+}
+
+func sortedPaths(files map[string][]byte) []string {
+	paths := []string{}
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
@@ -0,0 +1,79 @@
+package snapshot_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/JulzDiverse/aviator/snapshot"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Snapshot", func() {
+	var (
+		dir   string
+		files map[string][]byte
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "snapshots")
+		Expect(err).ToNot(HaveOccurred())
+		dir = filepath.Join(dir, "testdata")
+
+		files = map[string][]byte{
+			"results/api.yml": []byte("replicas: 2\n"),
+			"results/web.yml": []byte("replicas: 1\n"),
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(filepath.Dir(dir))
+	})
+
+	It("reports all files as added without snapshots", func() {
+		result, err := Verify(dir, files)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Added).To(Equal([]string{"results/api.yml", "results/web.yml"}))
+		Expect(result.Empty()).To(BeFalse())
+	})
+
+	It("writes the snapshots and verifies them afterwards", func() {
+		_, err := Update(dir, files)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ioutil.ReadFile(filepath.Join(dir, "results/api.yml"))).To(Equal([]byte("replicas: 2\n")))
+
+		result, err := Verify(dir, files)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Empty()).To(BeTrue())
+	})
+
+	It("reports changed and removed files and updates them", func() {
+		_, err := Update(dir, files)
+		Expect(err).ToNot(HaveOccurred())
+
+		files["results/api.yml"] = []byte("replicas: 3\n")
+		delete(files, "results/web.yml")
+
+		result, err := Verify(dir, files)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(Result{Changed: []string{"results/api.yml"}, Removed: []string{"results/web.yml"}}))
+
+		_, err = Update(dir, files)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(filepath.Join(dir, "results/web.yml")).ToNot(BeAnExistingFile())
+
+		result, err = Verify(dir, files)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Empty()).To(BeTrue())
+	})
+
+	It("tracks only files within the working directory", func() {
+		Expect(Tracked("results/api.yml")).To(BeTrue())
+		Expect(Tracked("./api.yml")).To(BeTrue())
+		Expect(Tracked("/tmp/aviator-123/manifest.yml")).To(BeFalse())
+		Expect(Tracked("../other/api.yml")).To(BeFalse())
+	})
+})
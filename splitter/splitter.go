@@ -0,0 +1,71 @@
+package splitter
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/starkandwayne/goutils/ansi"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var (
+	docSeparator = regexp.MustCompile(`(?m)^---\s*$`)
+	unsafeChars  = regexp.MustCompile(`[^a-z0-9._-]+`)
+)
+
+type manifest struct {
+	Kind     string `yaml:"kind"`
+	Metadata struct {
+		Name string `yaml:"name"`
+	} `yaml:"metadata"`
+}
+
+// Resource is a single Kubernetes resource of a multi-document file.
+type Resource struct {
+	File    string
+	Content []byte
+}
+
+// Split splits a multi-document file into its resources, named
+// <kind>-<name>.yml in lower case. Empty documents are dropped; documents
+// without kind or name, and resources which would get the same file name,
+// fail the split.
+func Split(file []byte) ([]Resource, error) {
+	result := []Resource{}
+	docs := map[string]int{}
+	for i, doc := range docSeparator.Split(string(file), -1) {
+		if strings.TrimSpace(doc) == "" {
+			continue
+		}
+
+		var content interface{}
+		var m manifest
+		err := yaml.Unmarshal([]byte(doc), &content)
+		if err == nil {
+			err = yaml.Unmarshal([]byte(doc), &m)
+		}
+		if err != nil {
+			return nil, ansi.Errorf("@R{Parsing document %d for split_by_kind failed}: %s", i, err.Error())
+		}
+		if content == nil {
+			// comment-only documents
+			continue
+		}
+		if m.Kind == "" || m.Metadata.Name == "" {
+			return nil, ansi.Errorf("@R{Document %d has no kind or metadata.name and cannot be split by kind}", i)
+		}
+
+		name := fileName(m)
+		if other, ok := docs[name]; ok {
+			return nil, ansi.Errorf("@R{Documents %d and %d are both split into} @m{%s}", other, i, name)
+		}
+		docs[name] = i
+		result = append(result, Resource{File: name, Content: []byte(strings.Trim(doc, "\n") + "\n")})
+	}
+	return result, nil
+}
+
+func fileName(m manifest) string {
+	name := strings.ToLower(m.Kind) + "-" + strings.ToLower(m.Metadata.Name)
+	return unsafeChars.ReplaceAllString(name, "-") + ".yml"
+}
@@ -0,0 +1,13 @@
+package splitter_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestSplitter(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Splitter Suite")
+}
@@ -0,0 +1,54 @@
+package splitter_test
+
+import (
+	. "github.com/JulzDiverse/aviator/splitter"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Split", func() {
+
+	It("writes every resource to <kind>-<name>.yml", func() {
+		resources, err := Split([]byte(`---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+---
+# Source: chart/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: Web
+`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resources).To(Equal([]Resource{
+			{File: "service-web.yml", Content: []byte("apiVersion: v1\nkind: Service\nmetadata:\n  name: web\n")},
+			{File: "deployment-web.yml", Content: []byte("# Source: chart/templates/deployment.yaml\napiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: Web\n")},
+		}))
+	})
+
+	It("drops empty and comment-only documents", func() {
+		resources, err := Split([]byte("---\n---\n# nothing\n---\nkind: ConfigMap\nmetadata:\n  name: config\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resources).To(HaveLen(1))
+		Expect(resources[0].File).To(Equal("configmap-config.yml"))
+	})
+
+	It("keeps file names within the directory", func() {
+		resources, err := Split([]byte("kind: ClusterRole\nmetadata:\n  name: system:../aggregate\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resources[0].File).To(Equal("clusterrole-system-..-aggregate.yml"))
+	})
+
+	It("fails for documents without kind or name", func() {
+		_, err := Split([]byte("kind: Service\n---\nfoo: bar\n"))
+		Expect(err).To(MatchError(ContainSubstring("no kind or metadata.name")))
+	})
+
+	It("fails for resources with the same file name", func() {
+		_, err := Split([]byte("kind: Service\nmetadata:\n  name: web\n---\nkind: Service\nmetadata:\n  name: web\n  namespace: other\n"))
+		Expect(err).To(MatchError(ContainSubstring("service-web.yml")))
+	})
+})
@@ -0,0 +1,117 @@
+package spruce
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/JulzDiverse/aviator"
+	"github.com/JulzDiverse/aviator/guard"
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+// isolatedEnv marks a child process of aviator which runs a single merge.
+const isolatedEnv = "AVIATOR_ISOLATED_MERGE"
+
+// isolatedMerge is what a guarded merge sends to its child process: the
+// read documents and everything else the merge depends on.
+type isolatedMerge struct {
+	Files          []string               `yaml:"files"`
+	Docs           []string               `yaml:"docs"`
+	Prune          []string               `yaml:"prune"`
+	CherryPicks    []string               `yaml:"cherry_picks"`
+	SkipEval       bool                   `yaml:"skip_eval"`
+	SkipEvalFiles  []string               `yaml:"skip_eval_files"`
+	FallbackAppend bool                   `yaml:"fallback_append"`
+	EnableGoPatch  bool                   `yaml:"go_patch"`
+	CurlyBraces    bool                   `yaml:"curly_braces"`
+	Vault          string                 `yaml:"vault"`
+	VaultStub      map[string]interface{} `yaml:"vault_stub"`
+}
+
+// mergeIsolated merges docs in a child process of the aviator binary. Unlike
+// a merge within aviator, killing the process ends a merge exceeding the
+// guard, and it does not hold the merge lock while doing so.
+func (sc *SpruceClient) mergeIsolated(docs [][]byte, options aviator.MergeConf) ([]byte, error) {
+	request := isolatedMerge{
+		Files:          options.Files,
+		Prune:          options.Prune,
+		CherryPicks:    options.CherryPicks,
+		SkipEval:       options.SkipEval,
+		SkipEvalFiles:  options.SkipEvalFiles,
+		FallbackAppend: options.FallbackAppend,
+		EnableGoPatch:  options.EnableGoPatch,
+		CurlyBraces:    sc.CurlyBraces,
+		Vault:          vaultMode,
+		VaultStub:      vaultStub,
+	}
+	for _, doc := range docs {
+		request.Docs = append(request.Docs, string(doc))
+	}
+	input, err := yaml.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return nil, errors.Wrap(err, ansi.Sprintf("@R{Starting the merge of} @m{%s} @R{FAILED}", options.Guard.Step))
+	}
+	cmd := exec.Command(self)
+	cmd.Env = append(os.Environ(), isolatedEnv+"=true")
+	return guard.Run(options.Guard.Step, options.Guard.Timeout, options.Guard.MaxMemory, cmd, input)
+}
+
+// ServeIsolatedMerge runs the merge sent on stdin and exits if the process
+// was started for a guarded merge; otherwise it returns. The aviator binary
+// calls it first thing.
+func ServeIsolatedMerge() {
+	if os.Getenv(isolatedEnv) == "" {
+		return
+	}
+
+	result, err := serveIsolatedMerge()
+	if err != nil {
+		fmt.Fprint(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	os.Stdout.Write(result)
+	os.Exit(0)
+}
+
+func serveIsolatedMerge() ([]byte, error) {
+	input, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		return nil, err
+	}
+	var request isolatedMerge
+	if err := yaml.Unmarshal(input, &request); err != nil {
+		return nil, err
+	}
+
+	switch request.Vault {
+	case vaultStubbed:
+		UseVaultStub(request.VaultStub)
+	case vaultPlaceholders:
+		KeepVaultPlaceholders()
+	}
+
+	docs := [][]byte{}
+	for _, doc := range request.Docs {
+		docs = append(docs, []byte(doc))
+	}
+	sc := &SpruceClient{CurlyBraces: request.CurlyBraces}
+	return sc.mergeDocs(docs, aviator.MergeConf{
+		Files:          request.Files,
+		Prune:          request.Prune,
+		CherryPicks:    request.CherryPicks,
+		SkipEval:       request.SkipEval,
+		SkipEvalFiles:  request.SkipEvalFiles,
+		FallbackAppend: request.FallbackAppend,
+		EnableGoPatch:  request.EnableGoPatch,
+	})
+}
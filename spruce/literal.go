@@ -0,0 +1,71 @@
+package spruce
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+var (
+	operatorRx    = regexp.MustCompile(`^\s*\Q((\E.*\Q))\E\s*$`)
+	placeholderRx = regexp.MustCompile(`__aviator_literal_(\d+)__`)
+
+	// array operators control how a list is merged, not how it is evaluated
+	arrayOperatorRx = regexp.MustCompile(`^\Q((\E\s*(merge|replace|inline|append|prepend|insert|delete)(\s.*)?\s*\Q))\E$`)
+)
+
+// literals hides the operators of documents merged with skip_eval behind
+// placeholders, which spruce evaluates like plain strings, and restores them
+// once the evaluation is done.
+type literals struct {
+	operators []string
+}
+
+func (l *literals) hide(node interface{}) interface{} {
+	switch n := node.(type) {
+	case map[interface{}]interface{}:
+		for k, v := range n {
+			n[k] = l.hide(v)
+		}
+	case []interface{}:
+		for i, v := range n {
+			if s, ok := v.(string); ok && i == 0 && arrayOperatorRx.MatchString(s) {
+				continue
+			}
+			n[i] = l.hide(v)
+		}
+	case string:
+		if operatorRx.MatchString(n) {
+			l.operators = append(l.operators, n)
+			return fmt.Sprintf("__aviator_literal_%d__", len(l.operators)-1)
+		}
+	}
+	return node
+}
+
+// restore replaces the placeholders with the operators they hide, also
+// where they were grabbed or concatenated by evaluated documents.
+func (l *literals) restore(node interface{}) interface{} {
+	if len(l.operators) == 0 {
+		return node
+	}
+	switch n := node.(type) {
+	case map[interface{}]interface{}:
+		for k, v := range n {
+			n[k] = l.restore(v)
+		}
+	case []interface{}:
+		for i, v := range n {
+			n[i] = l.restore(v)
+		}
+	case string:
+		return placeholderRx.ReplaceAllStringFunc(n, func(p string) string {
+			i, err := strconv.Atoi(placeholderRx.FindStringSubmatch(p)[1])
+			if err != nil || i >= len(l.operators) {
+				return p
+			}
+			return l.operators[i]
+		})
+	}
+	return node
+}
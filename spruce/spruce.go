@@ -2,10 +2,13 @@ package spruce
 
 import (
 	"regexp"
+	"sync"
+	"time"
 
 	yaml "gopkg.in/yaml.v2"
 
 	"github.com/JulzDiverse/aviator"
+	"github.com/JulzDiverse/aviator/decrypter"
 	"github.com/JulzDiverse/aviator/filemanager"
 	"github.com/cppforlife/go-patch/patch"
 	"github.com/geofffranks/simpleyaml"
@@ -22,6 +25,12 @@ var concourseRegex = `(\{\{|\+\+)([-\_\.\/\w\p{L}\/]+)(\}\}|\+\+)`
 var re = regexp.MustCompile("(" + concourseRegex + ")")
 var dere = regexp.MustCompile("['\"](" + concourseRegex + ")[\"']")
 
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// spruce keeps global state (pruned keys, vault cache, static IPs) while
+// merging and evaluating, so merges may read in parallel but not evaluate.
+var mergeLock sync.Mutex
+
 func New(curlyBraces, dryRun bool) *SpruceClient {
 	return &SpruceClient{
 		curlyBraces,
@@ -37,18 +46,47 @@ func NewWithFileFilemanager(filemanager aviator.FileStore, curlyBraces bool) *Sp
 }
 
 func (sc *SpruceClient) MergeWithOpts(options aviator.MergeConf) ([]byte, error) {
+	start := time.Now()
+	docs, err := sc.readAll(options.Files, options.Sops, options.KeepBOM)
+	if err != nil {
+		return nil, err
+	}
+	if options.Phases != nil {
+		options.Phases.Read = time.Since(start)
+	}
+
+	if options.Guard.Timeout != "" || options.Guard.MaxMemory != "" {
+		return sc.mergeIsolated(docs, options)
+	}
+	return sc.mergeDocs(docs, options)
+}
+
+// mergeDocs merges and evaluates the docs read for options.
+func (sc *SpruceClient) mergeDocs(docs [][]byte, options aviator.MergeConf) ([]byte, error) {
 	root := make(map[interface{}]interface{})
 
-	err := sc.mergeAllDocs(root, options.Files, options.FallbackAppend, options.EnableGoPatch)
+	mergeLock.Lock()
+	defer mergeLock.Unlock()
+
+	start := time.Now()
+	lits := &literals{}
+	err := sc.mergeAllDocs(root, options.Files, docs, options, lits)
 	if err != nil {
 		return nil, err
 	}
+	merged := time.Now()
 
 	ev := &Evaluator{Tree: root, SkipEval: options.SkipEval}
 	err = ev.Run(options.Prune, options.CherryPicks)
 	if err != nil {
 		return nil, err
 	}
+	lits.restore(ev.Tree)
+
+	if options.Phases != nil {
+		options.Phases.Merge = merged.Sub(start)
+		options.Phases.Eval = time.Since(merged)
+	}
 
 	resultYml, err := yaml.Marshal(ev.Tree)
 	if err != nil {
@@ -61,27 +99,74 @@ func (sc *SpruceClient) MergeWithOpts(options aviator.MergeConf) ([]byte, error)
 func (sc *SpruceClient) MergeWithOptsRaw(options aviator.MergeConf) (map[interface{}]interface{}, error) {
 	root := make(map[interface{}]interface{})
 
-	err := sc.mergeAllDocs(root, options.Files, options.FallbackAppend, options.EnableGoPatch)
+	docs, err := sc.readAll(options.Files, options.Sops, options.KeepBOM)
+	if err != nil {
+		return nil, err
+	}
+
+	mergeLock.Lock()
+	defer mergeLock.Unlock()
+
+	lits := &literals{}
+	err = sc.mergeAllDocs(root, options.Files, docs, options, lits)
 	if err != nil {
 		return nil, err
 	}
 
 	ev := &Evaluator{Tree: root, SkipEval: options.SkipEval}
 	err = ev.Run(options.Prune, options.CherryPicks)
+	lits.restore(ev.Tree)
 
 	return ev.Tree, err
 }
 
-func (sc *SpruceClient) mergeAllDocs(root map[interface{}]interface{}, paths []string, fallbackAppend bool, goPatchEnabled bool) error {
-	m := &Merger{AppendByDefault: fallbackAppend}
-	for _, path := range paths {
-		var data []byte
-		var err error
+// bomStore is implemented by stores which strip the UTF-8 byte order mark
+// of the files they read.
+type bomStore interface {
+	HasBOM(string) bool
+}
 
+// readAll reads the files to merge. SOPS encrypted files and the files
+// marked with sops are decrypted in memory. With keepBOM, files keep the
+// UTF-8 byte order mark the store stripped.
+func (sc *SpruceClient) readAll(paths, sops []string, keepBOM bool) ([][]byte, error) {
+	marked := map[string]bool{}
+	for _, path := range sops {
+		marked[path] = true
+	}
+
+	docs := [][]byte{}
+	for _, path := range paths {
 		data, ok := sc.store.ReadFile(path)
 		if !ok {
-			return ansi.Errorf("@R{Error reading file from filesystem or internal datastore} @m{%s} \n", path)
+			return nil, ansi.Errorf("@R{Error reading file from filesystem or internal datastore} @m{%s} \n", path)
+		}
+		if marked[path] || decrypter.IsEncrypted(data) {
+			var err error
+			data, err = decrypter.Decrypt(path, data)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if s, ok := sc.store.(bomStore); ok && keepBOM && s.HasBOM(path) {
+			data = append(append([]byte{}, utf8BOM...), data...)
 		}
+		docs = append(docs, data)
+	}
+	return docs, nil
+}
+
+// mergeAllDocs merges docs into root. The operators of the files marked
+// with skip_eval are hidden in lits, so they are merged literally.
+func (sc *SpruceClient) mergeAllDocs(root map[interface{}]interface{}, paths []string, docs [][]byte, options aviator.MergeConf, lits *literals) error {
+	literal := map[string]bool{}
+	for _, path := range options.SkipEvalFiles {
+		literal[path] = true
+	}
+
+	m := &Merger{AppendByDefault: options.FallbackAppend}
+	for i, path := range paths {
+		data := docs[i]
 
 		if sc.CurlyBraces {
 			data = quoteConcourse(data)
@@ -89,7 +174,7 @@ func (sc *SpruceClient) mergeAllDocs(root map[interface{}]interface{}, paths []s
 
 		doc, err := parseYAML(data)
 		if err != nil {
-			if isArrayError(err) && goPatchEnabled {
+			if isArrayError(err) && options.EnableGoPatch {
 				ops, err := parseGoPatch(data)
 				if err != nil {
 					return ansi.Errorf("@m{%s}: @R{%s}\n", path, err.Error())
@@ -107,6 +192,9 @@ func (sc *SpruceClient) mergeAllDocs(root map[interface{}]interface{}, paths []s
 				return ansi.Errorf("@m{%s}: @R{%s}\n", path, err.Error())
 			}
 		} else {
+			if literal[path] {
+				lits.hide(doc)
+			}
 			m.Merge(root, doc)
 		}
 	}
@@ -1,12 +1,19 @@
 package spruce_test
 
 import (
+	"github.com/JulzDiverse/aviator/spruce"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	"testing"
 )
 
+func init() {
+	// guarded merges run in a child process, which is the test binary
+	spruce.ServeIsolatedMerge()
+}
+
 func TestSpruce(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Spruce Suite")
@@ -1,9 +1,14 @@
 package spruce_test
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
 	"github.com/JulzDiverse/aviator"
 	"github.com/JulzDiverse/aviator/filemanager"
 	. "github.com/JulzDiverse/aviator/spruce"
+	gspruce "github.com/geofffranks/spruce"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -53,5 +58,218 @@ var _ = Describe("Spruce", func() {
 			value, _ := result["the"]
 			Expect(value).To(BeNil())
 		})
+
+		It("reports the time spent in the read, merge and eval phases", func() {
+			phases := &aviator.MergePhases{}
+			opts := aviator.MergeConf{
+				Files: []string{
+					"../processor/integration/yamls/base.yml",
+					"../processor/integration/yamls/fake.yml",
+				},
+				Phases: phases,
+			}
+
+			_, err := spruce.MergeWithOpts(opts)
+			Expect(err).To(BeNil())
+			Expect(phases.Read).To(BeNumerically(">", 0))
+			Expect(phases.Eval).To(BeNumerically(">", 0))
+			Expect(phases.Write).To(BeZero())
+		})
+	})
+})
+
+var _ = Describe("Offline Vault", func() {
+
+	var (
+		spruce *SpruceClient
+		store  *filemanager.FileManager
+		opts   aviator.MergeConf
+	)
+
+	BeforeEach(func() {
+		store = filemanager.New(false, false)
+		spruce = NewWithFileFilemanager(store, false)
+		store.WriteFile("{{vault.yml}}", []byte(`password: (( vault "secret/app:password" ))`))
+		opts = aviator.MergeConf{Files: []string{"{{vault.yml}}"}}
+	})
+
+	AfterEach(func() {
+		gspruce.RegisterOp("vault", gspruce.VaultOperator{})
+	})
+
+	It("resolves vault operators from the stub file", func() {
+		stub, err := ioutil.TempFile("", "vault-stub")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.Remove(stub.Name())
+		stub.WriteString(`"secret/app:password": s3cr3t`)
+		stub.Close()
+
+		Expect(EnableOfflineVault(stub.Name())).To(Succeed())
+
+		result, err := spruce.MergeWithOptsRaw(opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result["password"]).To(Equal("s3cr3t"))
+	})
+
+	It("fails with a clear message if a secret is not stubbed", func() {
+		Expect(EnableOfflineVault("")).To(Succeed())
+
+		_, err := spruce.MergeWithOptsRaw(opts)
+		Expect(err).To(MatchError(ContainSubstring("offline mode")))
+		Expect(err).To(MatchError(ContainSubstring("secret/app:password")))
+	})
+})
+
+var _ = Describe("Guarded merges", func() {
+
+	var (
+		spruce *SpruceClient
+		store  *filemanager.FileManager
+		opts   aviator.MergeConf
+	)
+
+	BeforeEach(func() {
+		store = filemanager.New(false, false)
+		spruce = NewWithFileFilemanager(store, false)
+		store.WriteFile("{{base.yml}}", []byte("name: base\nreplicas: 1\n"))
+		store.WriteFile("{{overlay.yml}}", []byte("replicas: 3\nref: (( grab name ))\n"))
+		opts = aviator.MergeConf{
+			Files: []string{"{{base.yml}}", "{{overlay.yml}}"},
+			Guard: aviator.MergeGuard{Step: "spruce: result.yml", Timeout: "30s", MaxMemory: "1Gi"},
+		}
+	})
+
+	AfterEach(func() {
+		gspruce.RegisterOp("vault", gspruce.VaultOperator{})
+	})
+
+	It("merges in a child process", func() {
+		result, err := spruce.MergeWithOpts(opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(MatchYAML("name: base\nreplicas: 3\nref: base\n"))
+	})
+
+	It("reports errors of the merge", func() {
+		store.WriteFile("{{overlay.yml}}", []byte("ref: (( grab missing ))\n"))
+		_, err := spruce.MergeWithOpts(opts)
+		Expect(err).To(MatchError(ContainSubstring("missing")))
+	})
+
+	It("uses the vault stub in the child process", func() {
+		UseVaultStub(map[string]interface{}{"secret/app:password": "s3cr3t"})
+		store.WriteFile("{{overlay.yml}}", []byte(`password: (( vault "secret/app:password" ))`))
+		result, err := spruce.MergeWithOpts(opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(MatchYAML("name: base\nreplicas: 1\npassword: s3cr3t\n"))
+	})
+
+	It("kills merges exceeding the timeout", func() {
+		opts.Guard.Timeout = "1ms"
+		_, err := spruce.MergeWithOpts(opts)
+		Expect(err).To(MatchError(ContainSubstring("spruce: result.yml: merge did not finish within 1ms")))
+
+		opts.Guard = aviator.MergeGuard{}
+		_, err = spruce.MergeWithOpts(opts)
+		Expect(err).ToNot(HaveOccurred())
+	})
+})
+
+var _ = Describe("SOPS inputs", func() {
+
+	var (
+		spruce   *SpruceClient
+		store    *filemanager.FileManager
+		dir, env string
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "sops")
+		Expect(err).ToNot(HaveOccurred())
+		script := "#!/bin/sh\necho 'password: s3cr3t'\n"
+		Expect(ioutil.WriteFile(filepath.Join(dir, "sops"), []byte(script), 0755)).To(Succeed())
+		env = os.Getenv("PATH")
+		os.Setenv("PATH", dir+string(os.PathListSeparator)+env)
+
+		store = filemanager.New(false, false)
+		spruce = NewWithFileFilemanager(store, false)
+		store.WriteFile("{{base.yml}}", []byte("name: app\npassword: (( param \"required\" ))\n"))
+	})
+
+	AfterEach(func() {
+		os.Setenv("PATH", env)
+		os.RemoveAll(dir)
+	})
+
+	It("decrypts encrypted inputs", func() {
+		store.WriteFile("{{secrets.yml}}", []byte("password: ENC[AES256_GCM,data:x]\nsops:\n  mac: ENC[AES256_GCM,data:y]\n"))
+
+		result, err := spruce.MergeWithOptsRaw(aviator.MergeConf{Files: []string{"{{base.yml}}", "{{secrets.yml}}"}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result["password"]).To(Equal("s3cr3t"))
+		Expect(result).ToNot(HaveKey("sops"))
+	})
+
+	It("decrypts inputs marked with sops", func() {
+		store.WriteFile("{{secrets.yml}}", []byte("password: ENC[AES256_GCM,data:x]\n"))
+
+		result, err := spruce.MergeWithOptsRaw(aviator.MergeConf{
+			Files: []string{"{{base.yml}}", "{{secrets.yml}}"},
+			Sops:  []string{"{{secrets.yml}}"},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result["password"]).To(Equal("s3cr3t"))
+	})
+})
+
+var _ = Describe("skip_eval files", func() {
+
+	var (
+		spruce *SpruceClient
+		store  *filemanager.FileManager
+	)
+
+	BeforeEach(func() {
+		store = filemanager.New(false, false)
+		spruce = NewWithFileFilemanager(store, false)
+		store.WriteFile("{{library.yml}}", []byte(`
+meta:
+  name: (( grab deployment.name ))
+jobs:
+- (( append ))
+- name: (( concat "job-" deployment.name ))
+`))
+		store.WriteFile("{{values.yml}}", []byte(`
+deployment:
+  name: app
+jobs:
+- name: worker
+name: (( grab deployment.name ))
+library_name: (( grab meta.name ))
+`))
+	})
+
+	It("merges the operators of marked files literally and evaluates the others", func() {
+		result, err := spruce.MergeWithOpts(aviator.MergeConf{
+			Files:         []string{"{{values.yml}}", "{{library.yml}}"},
+			SkipEvalFiles: []string{"{{library.yml}}"},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(result)).To(Equal(`deployment:
+  name: app
+jobs:
+- name: worker
+- name: (( concat "job-" deployment.name ))
+library_name: (( grab deployment.name ))
+meta:
+  name: (( grab deployment.name ))
+name: app
+`))
+	})
+
+	It("evaluates all files without marks", func() {
+		result, err := spruce.MergeWithOptsRaw(aviator.MergeConf{Files: []string{"{{values.yml}}", "{{library.yml}}"}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result["library_name"]).To(Equal("app"))
 	})
 })
@@ -0,0 +1,150 @@
+package spruce
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/JulzDiverse/aviator/resolver"
+	. "github.com/geofffranks/spruce"
+	"github.com/starkandwayne/goutils/ansi"
+	"github.com/starkandwayne/goutils/tree"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	vaultStubbed      = "stub"
+	vaultPlaceholders = "placeholders"
+)
+
+// vaultMode and vaultStub record the vault operator registered, so isolated
+// merges register the same one.
+var (
+	vaultMode string
+	vaultStub map[string]interface{}
+)
+
+type StubVaultOperator struct {
+	Secrets map[string]interface{}
+}
+
+// placeholderVaultOperator keeps vault lookups as placeholders in the
+// result, to be resolved later.
+type placeholderVaultOperator struct {
+	StubVaultOperator
+}
+
+func EnableOfflineVault(stubFile string) error {
+	secrets, err := ReadVaultStub(stubFile)
+	if err != nil {
+		return err
+	}
+
+	UseVaultStub(secrets)
+	return nil
+}
+
+// UseVaultStub makes the vault operator look up secrets in secrets instead
+// of Vault.
+func UseVaultStub(secrets map[string]interface{}) {
+	RegisterOp("vault", StubVaultOperator{secrets})
+	vaultMode, vaultStub = vaultStubbed, secrets
+}
+
+// KeepVaultPlaceholders makes the vault operator return its placeholder,
+// e.g. (( vault "secret/app:password" )), instead of reading the secret.
+func KeepVaultPlaceholders() {
+	RegisterOp("vault", placeholderVaultOperator{})
+	vaultMode = vaultPlaceholders
+}
+
+// ReadVaultStub reads a YAML file mapping vault keys of the form
+// secret/path:key to their values.
+func ReadVaultStub(stubFile string) (map[string]interface{}, error) {
+	secrets := map[string]interface{}{}
+	if stubFile != "" {
+		data, err := ioutil.ReadFile(stubFile)
+		if err != nil {
+			return nil, ansi.Errorf("@R{Reading vault stub file} @m{%s} @R{failed}: %s", stubFile, err.Error())
+		}
+		if err = yaml.Unmarshal(data, &secrets); err != nil {
+			return nil, ansi.Errorf("@R{Parsing vault stub file} @m{%s} @R{failed}: %s", stubFile, err.Error())
+		}
+	}
+	return secrets, nil
+}
+
+func (StubVaultOperator) Setup() error {
+	return nil
+}
+
+func (StubVaultOperator) Phase() OperatorPhase {
+	return EvalPhase
+}
+
+func (StubVaultOperator) Dependencies(_ *Evaluator, _ []*Expr, _ []*tree.Cursor, auto []*tree.Cursor) []*tree.Cursor {
+	return auto
+}
+
+func (op StubVaultOperator) Run(ev *Evaluator, args []*Expr) (*Response, error) {
+	key, err := vaultKey(ev, args)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := resolver.Stub(op.Secrets)(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Response{
+		Type:  Replace,
+		Value: secret,
+	}, nil
+}
+
+func (placeholderVaultOperator) Run(ev *Evaluator, args []*Expr) (*Response, error) {
+	key, err := vaultKey(ev, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Response{
+		Type:  Replace,
+		Value: resolver.Placeholder(key),
+	}, nil
+}
+
+func vaultKey(ev *Evaluator, args []*Expr) (string, error) {
+	if len(args) < 1 {
+		return "", fmt.Errorf("vault operator requires at least one argument")
+	}
+
+	var l []string
+	for _, arg := range args {
+		v, err := arg.Resolve(ev.Tree)
+		if err != nil {
+			return "", err
+		}
+
+		switch v.Type {
+		case Literal:
+			l = append(l, fmt.Sprintf("%v", v.Literal))
+		case Reference:
+			s, err := v.Reference.Resolve(ev.Tree)
+			if err != nil {
+				return "", fmt.Errorf("Unable to resolve `%s`: %s", v.Reference, err)
+			}
+			switch s.(type) {
+			case map[interface{}]interface{}, []interface{}:
+				return "", ansi.Errorf("@R{tried to look up} @c{$.%s}@R{, which is not a string scalar}", v.Reference)
+			default:
+				l = append(l, fmt.Sprintf("%v", s))
+			}
+		default:
+			return "", fmt.Errorf("vault operator only accepts string literals and key reference arguments")
+		}
+	}
+
+	return strings.Join(l, ""), nil
+}
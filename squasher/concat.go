@@ -0,0 +1,38 @@
+package squasher
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var docSeparator = regexp.MustCompile(`(?m)^---\s*$`)
+
+func Concat(names []string, files [][]byte) ([]byte, error) {
+	var result bytes.Buffer
+	for i, file := range files {
+		for _, doc := range docSeparator.Split(string(file), -1) {
+			doc := bytes.TrimSpace([]byte(doc))
+			if len(doc) == 0 {
+				continue
+			}
+
+			var parsed interface{}
+			err := yaml.Unmarshal(doc, &parsed)
+			if err != nil {
+				return nil, errors.Wrap(err, ansi.Sprintf("@R{Concatenating} @m{%s} @R{FAILED: not a valid YAML document}", names[i]))
+			}
+			if parsed == nil {
+				continue
+			}
+
+			result.WriteString("---\n")
+			result.Write(doc)
+			result.WriteString("\n")
+		}
+	}
+	return result.Bytes(), nil
+}
@@ -0,0 +1,46 @@
+package squasher_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/JulzDiverse/aviator/squasher"
+)
+
+var _ = Describe("Concat", func() {
+
+	It("joins the documents of all files in the given order", func() {
+		result, err := Concat(
+			[]string{"service.yml", "deployment.yml"},
+			[][]byte{
+				[]byte("kind: Service\n"),
+				[]byte("---\nkind: Deployment\n---\nkind: ConfigMap\n"),
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(result)).To(Equal("---\nkind: Service\n---\nkind: Deployment\n---\nkind: ConfigMap\n"))
+	})
+
+	It("drops empty and comment-only documents", func() {
+		result, err := Concat(
+			[]string{"a.yml", "b.yml"},
+			[][]byte{
+				[]byte("---\n---\nkind: Service\n\n\n"),
+				[]byte("# nothing rendered\n"),
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(result)).To(Equal("---\nkind: Service\n"))
+	})
+
+	It("concatenates a single file", func() {
+		result, err := Concat([]string{"a.yml"}, [][]byte{[]byte("kind: Service")})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(result)).To(Equal("---\nkind: Service\n"))
+	})
+
+	It("fails naming the file with an invalid document", func() {
+		_, err := Concat([]string{"a.yml", "broken.yml"}, [][]byte{[]byte("a: 1"), []byte("a: [")})
+		Expect(err).To(MatchError(ContainSubstring("broken.yml")))
+	})
+})
@@ -0,0 +1,262 @@
+package strategic
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/starkandwayne/goutils/ansi"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const directive = "$patch"
+
+var docSeparator = regexp.MustCompile(`(?m)^---\s*$`)
+
+var mergeKeys = map[string][]string{
+	"containers":                {"name"},
+	"initContainers":            {"name"},
+	"ephemeralContainers":       {"name"},
+	"env":                       {"name"},
+	"volumes":                   {"name"},
+	"volumeMounts":              {"mountPath"},
+	"volumeDevices":             {"devicePath"},
+	"ports":                     {"containerPort", "port"},
+	"imagePullSecrets":          {"name"},
+	"hostAliases":               {"ip"},
+	"topologySpreadConstraints": {"topologyKey"},
+	"conditions":                {"type"},
+}
+
+// Merge applies the patches docs[1:] to the base docs[0]. Files may hold
+// several YAML documents: a patch document is applied to the base document
+// with the same apiVersion, kind and metadata.name, or to the only document
+// of a single document base.
+func Merge(docs [][]byte) ([]byte, error) {
+	var result []interface{}
+	for i, doc := range docs {
+		patches, err := split(doc)
+		if err != nil {
+			return nil, ansi.Errorf("@R{Parsing document %d for strategic merge failed}: %s", i, err.Error())
+		}
+		if i == 0 {
+			for _, patch := range patches {
+				result = append(result, strip(patch))
+			}
+			continue
+		}
+		for _, patch := range patches {
+			if len(result) == 0 {
+				result = append(result, strip(patch))
+				continue
+			}
+			target, err := findTarget(result, patch)
+			if err != nil {
+				return nil, ansi.Errorf("@R{Strategic merge of document %d failed}: %s", i, err.Error())
+			}
+			result[target] = mergeValue(result[target], patch, "")
+		}
+	}
+
+	if len(result) <= 1 {
+		var single interface{}
+		if len(result) == 1 {
+			single = result[0]
+		}
+		return yaml.Marshal(single)
+	}
+	out := []byte{}
+	for i, doc := range result {
+		content, err := yaml.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			out = append(out, "---\n"...)
+		}
+		out = append(out, content...)
+	}
+	return out, nil
+}
+
+// split parses the YAML documents of a file, leaving out empty ones.
+func split(file []byte) ([]interface{}, error) {
+	docs := []interface{}{}
+	for _, content := range docSeparator.Split(string(file), -1) {
+		var doc interface{}
+		if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+			return nil, err
+		}
+		if doc != nil {
+			docs = append(docs, doc)
+		}
+	}
+	return docs, nil
+}
+
+// findTarget returns the index of the base document patch applies to.
+func findTarget(base []interface{}, patch interface{}) (int, error) {
+	id, ok := identity(patch)
+	if !ok {
+		if len(base) == 1 {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("the base has %d documents, a patch requires apiVersion, kind and metadata.name to select one", len(base))
+	}
+
+	for i, doc := range base {
+		if docID, ok := identity(doc); ok && docID == id {
+			return i, nil
+		}
+	}
+	if len(base) == 1 {
+		if _, ok := identity(base[0]); !ok {
+			return 0, nil
+		}
+	}
+	return 0, fmt.Errorf("no base document matches %s", id)
+}
+
+// identity is the apiVersion, kind and metadata.name of a resource.
+func identity(doc interface{}) (string, bool) {
+	m, ok := doc.(map[interface{}]interface{})
+	if !ok {
+		return "", false
+	}
+	metadata, _ := m["metadata"].(map[interface{}]interface{})
+	apiVersion, _ := m["apiVersion"].(string)
+	kind, _ := m["kind"].(string)
+	name, _ := metadata["name"].(string)
+	if apiVersion == "" || kind == "" || name == "" {
+		return "", false
+	}
+	return apiVersion + " " + kind + " " + name, true
+}
+
+func mergeValue(orig, patch interface{}, field string) interface{} {
+	switch p := patch.(type) {
+	case map[interface{}]interface{}:
+		o, ok := orig.(map[interface{}]interface{})
+		if !ok || p[directive] == "replace" {
+			return strip(p)
+		}
+		return mergeMap(o, p)
+	case []interface{}:
+		o, ok := orig.([]interface{})
+		if !ok {
+			return strip(p)
+		}
+		return mergeList(o, p, field)
+	}
+	return patch
+}
+
+func mergeMap(orig, patch map[interface{}]interface{}) map[interface{}]interface{} {
+	result := map[interface{}]interface{}{}
+	for k, v := range orig {
+		result[k] = v
+	}
+
+	for k, v := range patch {
+		if k == directive {
+			continue
+		}
+		if v == nil {
+			delete(result, k)
+			continue
+		}
+		if existing, ok := result[k]; ok {
+			result[k] = mergeValue(existing, v, fmt.Sprint(k))
+		} else {
+			result[k] = strip(v)
+		}
+	}
+	return result
+}
+
+func mergeList(orig, patch []interface{}, field string) []interface{} {
+	key := mergeKey(field, patch)
+	if key == "" || replacesList(patch) {
+		return strip(patch).([]interface{})
+	}
+
+	result := append([]interface{}{}, orig...)
+	for _, item := range patch {
+		m := item.(map[interface{}]interface{})
+		idx := indexOf(result, key, m[key])
+		if m[directive] == "delete" {
+			if idx >= 0 {
+				result = append(result[:idx], result[idx+1:]...)
+			}
+			continue
+		}
+		if idx >= 0 {
+			result[idx] = mergeValue(result[idx], m, "")
+		} else {
+			result = append(result, strip(m))
+		}
+	}
+	return result
+}
+
+func mergeKey(field string, items []interface{}) string {
+	for _, key := range mergeKeys[field] {
+		if hasKey(items, key) {
+			return key
+		}
+	}
+	return ""
+}
+
+func hasKey(items []interface{}, key string) bool {
+	for _, item := range items {
+		m, ok := item.(map[interface{}]interface{})
+		if !ok {
+			return false
+		}
+		if _, ok := m[key]; !ok && m[directive] != "replace" {
+			return false
+		}
+	}
+	return true
+}
+
+func replacesList(items []interface{}) bool {
+	for _, item := range items {
+		if m, ok := item.(map[interface{}]interface{}); ok && len(m) == 1 && m[directive] == "replace" {
+			return true
+		}
+	}
+	return false
+}
+
+func indexOf(items []interface{}, key string, value interface{}) int {
+	for i, item := range items {
+		if m, ok := item.(map[interface{}]interface{}); ok && m[key] == value {
+			return i
+		}
+	}
+	return -1
+}
+
+func strip(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		result := map[interface{}]interface{}{}
+		for k, val := range v {
+			if k != directive {
+				result[k] = strip(val)
+			}
+		}
+		return result
+	case []interface{}:
+		result := []interface{}{}
+		for _, item := range v {
+			if m, ok := item.(map[interface{}]interface{}); ok && len(m) == 1 && m[directive] != nil {
+				continue
+			}
+			result = append(result, strip(item))
+		}
+		return result
+	}
+	return value
+}
@@ -0,0 +1,13 @@
+package strategic_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestStrategic(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Strategic Suite")
+}
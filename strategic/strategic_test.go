@@ -0,0 +1,193 @@
+package strategic_test
+
+import (
+	. "github.com/JulzDiverse/aviator/strategic"
+	yaml "gopkg.in/yaml.v2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Strategic Merge", func() {
+
+	var base = []byte(`
+spec:
+  replicas: 1
+  containers:
+  - name: app
+    image: app:1.0
+    env:
+    - name: LOG_LEVEL
+      value: info
+    - name: DEBUG
+      value: "false"
+  - name: sidecar
+    image: envoy:1.9
+  args:
+  - --one
+`)
+
+	merge := func(docs ...string) map[interface{}]interface{} {
+		input := [][]byte{base}
+		for _, d := range docs {
+			input = append(input, []byte(d))
+		}
+		out, err := Merge(input)
+		Expect(err).ToNot(HaveOccurred())
+
+		result := map[interface{}]interface{}{}
+		Expect(yaml.Unmarshal(out, &result)).To(Succeed())
+		return result
+	}
+
+	containers := func(doc map[interface{}]interface{}) []interface{} {
+		return doc["spec"].(map[interface{}]interface{})["containers"].([]interface{})
+	}
+
+	It("merges lists by their merge key", func() {
+		result := merge(`
+spec:
+  containers:
+  - name: app
+    image: app:2.0
+    env:
+    - name: DEBUG
+      value: "true"
+`)
+		c := containers(result)
+		Expect(c).To(HaveLen(2))
+		app := c[0].(map[interface{}]interface{})
+		Expect(app["image"]).To(Equal("app:2.0"))
+		Expect(app["env"]).To(Equal([]interface{}{
+			map[interface{}]interface{}{"name": "LOG_LEVEL", "value": "info"},
+			map[interface{}]interface{}{"name": "DEBUG", "value": "true"},
+		}))
+	})
+
+	It("appends list items with a new merge key", func() {
+		result := merge(`
+spec:
+  containers:
+  - name: logger
+    image: fluentd
+`)
+		c := containers(result)
+		Expect(c).To(HaveLen(3))
+		Expect(c[2].(map[interface{}]interface{})["name"]).To(Equal("logger"))
+	})
+
+	It("deletes list items with '$patch: delete'", func() {
+		result := merge(`
+spec:
+  containers:
+  - name: sidecar
+    $patch: delete
+`)
+		c := containers(result)
+		Expect(c).To(HaveLen(1))
+		Expect(c[0].(map[interface{}]interface{})["name"]).To(Equal("app"))
+	})
+
+	It("replaces lists with '$patch: replace'", func() {
+		result := merge(`
+spec:
+  containers:
+  - $patch: replace
+  - name: only
+    image: only:1
+`)
+		c := containers(result)
+		Expect(c).To(Equal([]interface{}{
+			map[interface{}]interface{}{"name": "only", "image": "only:1"},
+		}))
+	})
+
+	It("replaces lists without a merge key", func() {
+		result := merge(`
+spec:
+  args:
+  - --two
+`)
+		Expect(result["spec"].(map[interface{}]interface{})["args"]).To(Equal([]interface{}{"--two"}))
+	})
+
+	It("deletes keys set to null", func() {
+		result := merge(`
+spec:
+  replicas: null
+`)
+		Expect(result["spec"]).ToNot(HaveKey("replicas"))
+	})
+
+	It("returns an error for invalid documents", func() {
+		_, err := Merge([][]byte{base, []byte("key: [")})
+		Expect(err).To(HaveOccurred())
+	})
+
+	Context("multi-document bases", func() {
+		var manifests = []byte(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  replicas: 1
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+spec:
+  type: ClusterIP
+`)
+
+		It("patches the document with the same apiVersion, kind and name", func() {
+			out, err := Merge([][]byte{manifests, []byte(`
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+spec:
+  type: LoadBalancer
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  replicas: 3
+`)})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(out)).To(Equal(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  replicas: 3
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+spec:
+  type: LoadBalancer
+`))
+		})
+
+		It("fails on patches matching no document", func() {
+			_, err := Merge([][]byte{manifests, []byte(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+data: {}
+`)})
+			Expect(err).To(MatchError(ContainSubstring("no base document matches v1 ConfigMap app")))
+		})
+
+		It("fails on patches without apiVersion, kind and name", func() {
+			_, err := Merge([][]byte{manifests, []byte("spec:\n  replicas: 3\n")})
+			Expect(err).To(MatchError(ContainSubstring("the base has 2 documents")))
+		})
+	})
+})
@@ -0,0 +1,184 @@
+package sweeper
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JulzDiverse/aviator/guard"
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+// Policy limits the size of a cache and the age of its entries. Zero values
+// don't limit.
+type Policy struct {
+	MaxSize int64
+	MaxAge  time.Duration
+}
+
+// NewPolicy parses a policy from a size like 2Gi (see guard.ParseSize) and an
+// age like 30d. Empty strings don't limit.
+func NewPolicy(maxSize, maxAge string) (Policy, error) {
+	policy := Policy{}
+	var err error
+	if maxSize != "" {
+		size, err := guard.ParseSize(maxSize)
+		if err != nil {
+			return policy, err
+		}
+		policy.MaxSize = int64(size)
+	}
+	if maxAge != "" {
+		if policy.MaxAge, err = ParseAge(maxAge); err != nil {
+			return policy, err
+		}
+	}
+	return policy, nil
+}
+
+func (p Policy) Empty() bool {
+	return p.MaxSize <= 0 && p.MaxAge <= 0
+}
+
+// Result lists the removed entries and the size of the cache afterwards.
+type Result struct {
+	Removed []string
+	Freed   int64
+	Size    int64
+}
+
+type entry struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// Sweep removes the entries of the cache in dir violating the policy: first
+// the entries not used within MaxAge, then the least recently used entries
+// until the cache fits in MaxSize. An entry is a file or directory within a
+// top level directory of the cache (e.g. sha256/<digest> or a git checkout).
+func Sweep(dir string, policy Policy) (Result, error) {
+	return sweep(dir, policy, true)
+}
+
+// Plan returns what Sweep would remove from the cache in dir without
+// removing anything.
+func Plan(dir string, policy Policy) (Result, error) {
+	return sweep(dir, policy, false)
+}
+
+func sweep(dir string, policy Policy, remove bool) (Result, error) {
+	result := Result{Removed: []string{}}
+	entries, err := list(dir)
+	if err != nil {
+		return result, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].modTime.Before(entries[j].modTime) })
+
+	for _, e := range entries {
+		result.Size += e.size
+	}
+
+	now := time.Now()
+	for _, e := range entries {
+		expired := policy.MaxAge > 0 && now.Sub(e.modTime) > policy.MaxAge
+		tooLarge := policy.MaxSize > 0 && result.Size > policy.MaxSize
+		if !expired && !tooLarge {
+			continue
+		}
+		if remove {
+			if err := os.RemoveAll(e.path); err != nil {
+				return result, errors.Wrap(err, ansi.Sprintf("@R{Removing cache entry} @m{%s} @R{FAILED}", e.path))
+			}
+		}
+		result.Removed = append(result.Removed, e.path)
+		result.Freed += e.size
+		result.Size -= e.size
+	}
+	return result, nil
+}
+
+func list(dir string) ([]entry, error) {
+	top, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, ansi.Sprintf("@R{Reading cache} @m{%s} @R{FAILED}", dir))
+	}
+
+	entries := []entry{}
+	for _, info := range top {
+		path := filepath.Join(dir, info.Name())
+		if !info.IsDir() {
+			entries = append(entries, entry{path, info.Size(), info.ModTime()})
+			continue
+		}
+		children, err := ioutil.ReadDir(path)
+		if err != nil {
+			return nil, errors.Wrap(err, ansi.Sprintf("@R{Reading cache} @m{%s} @R{FAILED}", path))
+		}
+		for _, child := range children {
+			size, err := sizeOf(filepath.Join(path, child.Name()))
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry{filepath.Join(path, child.Name()), size, child.ModTime()})
+		}
+	}
+	return entries, nil
+}
+
+func sizeOf(path string) (int64, error) {
+	var size int64
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, ansi.Sprintf("@R{Reading cache entry} @m{%s} @R{FAILED}", path))
+	}
+	return size, nil
+}
+
+var sizeUnits = []struct {
+	suffix string
+	factor int64
+}{
+	{"Ti", 1 << 40}, {"Gi", 1 << 30}, {"Mi", 1 << 20}, {"Ki", 1 << 10},
+}
+
+// FormatSize formats size with the largest binary unit, e.g. 1.5Gi, which
+// guard.ParseSize reads back.
+func FormatSize(size int64) string {
+	for _, unit := range sizeUnits {
+		if size >= unit.factor {
+			return strconv.FormatFloat(float64(size)/float64(unit.factor), 'f', 1, 64) + unit.suffix
+		}
+	}
+	return strconv.FormatInt(size, 10) + "B"
+}
+
+// ParseAge parses durations like 30d, 12h or 90m.
+func ParseAge(age string) (time.Duration, error) {
+	s := strings.TrimSpace(age)
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.ParseFloat(strings.TrimSuffix(s, "d"), 64)
+		if err == nil && days >= 0 {
+			return time.Duration(days * float64(24*time.Hour)), nil
+		}
+	} else if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return d, nil
+	}
+	return 0, ansi.Errorf("@R{Invalid age} @m{%s}@R{: expected e.g. 30d or 12h}", age)
+}
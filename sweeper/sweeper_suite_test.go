@@ -0,0 +1,13 @@
+package sweeper_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestSweeper(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Sweeper Suite")
+}
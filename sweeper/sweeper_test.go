@@ -0,0 +1,96 @@
+package sweeper_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/JulzDiverse/aviator/sweeper"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sweeper", func() {
+	var dir string
+
+	write := func(path string, size int, age time.Duration) {
+		path = filepath.Join(dir, path)
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(path, make([]byte, size), 0644)).To(Succeed())
+		modTime := time.Now().Add(-age)
+		for ; path != dir; path = filepath.Dir(path) {
+			Expect(os.Chtimes(path, modTime, modTime)).To(Succeed())
+		}
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "sweeper")
+		Expect(err).ToNot(HaveOccurred())
+
+		write("sha256/old", 100, 40*24*time.Hour)
+		write("sha256/recent", 100, time.Hour)
+		write("git/1a2b/repo/deployment.yml", 300, 2*time.Hour)
+		write("sha256/new", 100, 0)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("removes entries older than the max age", func() {
+		result, err := Sweep(dir, Policy{MaxAge: 30 * 24 * time.Hour})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Removed).To(Equal([]string{filepath.Join(dir, "sha256/old")}))
+		Expect(result.Freed).To(Equal(int64(100)))
+		Expect(result.Size).To(Equal(int64(500)))
+	})
+
+	It("removes the least recently used entries until the cache fits", func() {
+		result, err := Sweep(dir, Policy{MaxSize: 250})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Removed).To(Equal([]string{
+			filepath.Join(dir, "sha256/old"),
+			filepath.Join(dir, "git/1a2b"),
+		}))
+		Expect(result.Size).To(Equal(int64(200)))
+		Expect(filepath.Join(dir, "sha256/recent")).To(BeAnExistingFile())
+		Expect(filepath.Join(dir, "git/1a2b")).ToNot(BeAnExistingFile())
+	})
+
+	It("plans the removals without removing anything", func() {
+		result, err := Plan(dir, Policy{MaxSize: 250})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Removed).To(HaveLen(2))
+		Expect(result.Freed).To(Equal(int64(400)))
+		Expect(filepath.Join(dir, "sha256/old")).To(BeAnExistingFile())
+		Expect(filepath.Join(dir, "git/1a2b")).To(BeADirectory())
+	})
+
+	It("ignores a missing cache", func() {
+		result, err := Sweep(filepath.Join(dir, "missing"), Policy{MaxSize: 1})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Removed).To(BeEmpty())
+	})
+
+	It("parses sizes and ages", func() {
+		policy, err := NewPolicy("2Gi", "30d")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(policy).To(Equal(Policy{MaxSize: 2 << 30, MaxAge: 30 * 24 * time.Hour}))
+
+		policy, err = NewPolicy("2GB", "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(policy.MaxSize).To(Equal(int64(2000000000)))
+
+		Expect(ParseAge("12h")).To(Equal(12 * time.Hour))
+		Expect(FormatSize(3 << 29)).To(Equal("1.5Gi"))
+		Expect(FormatSize(512)).To(Equal("512B"))
+
+		_, err = NewPolicy("lots", "")
+		Expect(err).To(MatchError(ContainSubstring("Invalid size")))
+		_, err = NewPolicy("", "a month")
+		Expect(err).To(MatchError(ContainSubstring("Invalid age")))
+	})
+})
@@ -0,0 +1,43 @@
+package tags
+
+type Filter struct {
+	include []string
+	exclude []string
+}
+
+var filter *Filter
+
+func New(include, exclude []string) *Filter {
+	return &Filter{include, exclude}
+}
+
+func Default() *Filter {
+	return filter
+}
+
+func Use(f *Filter) {
+	filter = f
+}
+
+// Matches reports whether a step with the given tags runs: it must not carry
+// an excluded tag and, if tags are included, carry at least one of them.
+func (f *Filter) Matches(tags []string) bool {
+	if f == nil {
+		return true
+	}
+	if intersects(f.exclude, tags) {
+		return false
+	}
+	return len(f.include) == 0 || intersects(f.include, tags)
+}
+
+func intersects(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
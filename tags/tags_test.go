@@ -0,0 +1,36 @@
+package tags_test
+
+import (
+	. "github.com/JulzDiverse/aviator/tags"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tags", func() {
+
+	It("matches everything when no filter is used", func() {
+		var unfiltered *Filter
+		Expect(unfiltered.Matches(nil)).To(BeTrue())
+		Expect(unfiltered.Matches([]string{"prod"})).To(BeTrue())
+	})
+
+	It("matches steps carrying one of the included tags", func() {
+		filter := New([]string{"k8s", "prod"}, nil)
+		Expect(filter.Matches([]string{"prod", "db"})).To(BeTrue())
+		Expect(filter.Matches([]string{"dev"})).To(BeFalse())
+		Expect(filter.Matches(nil)).To(BeFalse())
+	})
+
+	It("does not match steps carrying an excluded tag", func() {
+		filter := New(nil, []string{"slow"})
+		Expect(filter.Matches(nil)).To(BeTrue())
+		Expect(filter.Matches([]string{"k8s"})).To(BeTrue())
+		Expect(filter.Matches([]string{"k8s", "slow"})).To(BeFalse())
+	})
+
+	It("lets exclusion win over inclusion", func() {
+		filter := New([]string{"k8s"}, []string{"slow"})
+		Expect(filter.Matches([]string{"k8s", "slow"})).To(BeFalse())
+	})
+})
@@ -0,0 +1,47 @@
+package templater
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+func Render(name string, tmpl []byte, values []byte) ([]byte, error) {
+	var doc interface{}
+	err := yaml.Unmarshal(values, &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := template.New(name).Funcs(funcs).Parse(string(tmpl))
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	err = t.Execute(&out, normalize(doc))
+	if err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		result := map[string]interface{}{}
+		for k, val := range v {
+			result[fmt.Sprint(k)] = normalize(val)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, val := range v {
+			result[i] = normalize(val)
+		}
+		return result
+	}
+	return value
+}
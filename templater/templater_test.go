@@ -0,0 +1,51 @@
+package templater_test
+
+import (
+	. "github.com/JulzDiverse/aviator/templater"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Templater", func() {
+
+	values := []byte(`
+server:
+  name: example.com
+  port: 8080
+  upstreams:
+  - app-1
+  - app-2
+`)
+
+	It("renders a template against the values document", func() {
+		tmpl := []byte("server_name {{ .server.name }};\nlisten {{ .server.port }};\n")
+		out, err := Render("nginx.conf", tmpl, values)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(out)).To(Equal("server_name example.com;\nlisten 8080;\n"))
+	})
+
+	It("provides sprig style functions", func() {
+		tmpl := []byte(`{{ .server.upstreams | join "," | upper }} {{ .server.missing | default "none" }} {{ .server.name | quote }}`)
+		out, err := Render("t", tmpl, values)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(out)).To(Equal(`APP-1,APP-2 none "example.com"`))
+	})
+
+	It("renders nested values as YAML", func() {
+		tmpl := []byte("config:{{ .server.upstreams | toYaml | nindent 2 }}")
+		out, err := Render("t", tmpl, values)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(out)).To(Equal("config:\n  - app-1\n  - app-2"))
+	})
+
+	It("fails on missing required values", func() {
+		_, err := Render("t", []byte(`{{ required "server.tls is required" .server.tls }}`), values)
+		Expect(err).To(MatchError(ContainSubstring("server.tls is required")))
+	})
+
+	It("fails on invalid templates", func() {
+		_, err := Render("t", []byte("{{ .server.name "), values)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,178 @@
+package themer
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+// roles are the meanings of the colors of aviator's output, by the color
+// codes of the ansi package printing them, e.g. @m{path} for targets.
+var roles = map[string]string{
+	"target":  "mp",
+	"success": "g",
+	"warning": "y",
+	"error":   "r",
+	"info":    "c",
+	"heading": "b",
+}
+
+var palette = map[string]int{
+	"black":   0,
+	"red":     1,
+	"green":   2,
+	"yellow":  3,
+	"blue":    4,
+	"magenta": 5,
+	"cyan":    6,
+	"white":   7,
+}
+
+// the SGR parameters of the ansi package, e.g. 01;33 for @Y{}
+var codes = map[string]string{
+	"k": "00;30", "K": "01;30",
+	"r": "00;31", "R": "01;31",
+	"g": "00;32", "G": "01;32",
+	"y": "00;33", "Y": "01;33",
+	"b": "00;34", "B": "01;34",
+	"m": "00;35", "M": "01;35",
+	"c": "00;36", "C": "01;36",
+	"w": "00;37", "W": "01;37",
+}
+
+var presets = map[string]map[string]string{
+	"default": {},
+	// yellow, cyan and white are unreadable on light backgrounds
+	"light": {
+		"warning": "130",
+		"info":    "24",
+		"w":       "black",
+	},
+	// no colors, the bold variants stay bold
+	"monochrome": {
+		"target": "none", "success": "none", "warning": "none",
+		"error": "none", "info": "none", "heading": "none",
+		"k": "none", "w": "none",
+	},
+}
+
+var sequence = regexp.MustCompile("\x1b\\[([0-9;]*)m")
+
+// Theme replaces the colors of aviator's output. It maps the SGR parameters
+// printed by the ansi package to the ones to print instead.
+type Theme struct {
+	replace map[string]string
+}
+
+// Presets returns the names of the available presets.
+func Presets() []string {
+	result := []string{}
+	for name := range presets {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// New builds the theme of a preset ("default" if empty) with the colors of
+// single roles overridden. A color is one of black, red, green, yellow,
+// blue, magenta, cyan and white, a 256-color number, or none; it can be
+// prefixed with "bold ".
+func New(preset string, colors map[string]string) (*Theme, error) {
+	if preset == "" {
+		preset = "default"
+	}
+	base, ok := presets[preset]
+	if !ok {
+		return nil, ansi.Errorf("@R{Unknown theme preset} @m{%s}@R{; use one of} %s", preset, strings.Join(Presets(), ", "))
+	}
+
+	t := &Theme{replace: map[string]string{}}
+	for _, overrides := range []map[string]string{base, colors} {
+		for role, color := range overrides {
+			if err := t.set(role, color); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return t, nil
+}
+
+func (t *Theme) set(role, color string) error {
+	letters, ok := roles[role]
+	if !ok {
+		// presets also address the colors without a role directly
+		if _, isCode := codes[role]; !isCode {
+			return ansi.Errorf("@R{Unknown theme color} @m{%s}@R{; use one of} %s", role, strings.Join(roleNames(), ", "))
+		}
+		letters = role
+	}
+
+	bold := strings.HasPrefix(color, "bold ")
+	name := strings.TrimSpace(strings.TrimPrefix(color, "bold "))
+	param := ""
+	if n, ok := palette[name]; ok {
+		param = "3" + strconv.Itoa(n)
+	} else if n, err := strconv.Atoi(name); err == nil && n >= 0 && n < 256 {
+		param = "38;5;" + name
+	} else if name != "none" {
+		return ansi.Errorf("@R{Invalid theme color} @m{%s} @R{for} @m{%s}@R{; use a color name, a 256-color number or none}", color, role)
+	}
+
+	for _, l := range letters {
+		if l == 'p' {
+			// @p{} prints like @m{}
+			continue
+		}
+		lower, upper := codes[string(l)], codes[strings.ToUpper(string(l))]
+		t.replace[lower] = sgr(bold, param)
+		t.replace[upper] = sgr(true, param)
+	}
+	return nil
+}
+
+func sgr(bold bool, param string) string {
+	weight := "00"
+	if bold {
+		weight = "01"
+	}
+	if param == "" {
+		return weight
+	}
+	return weight + ";" + param
+}
+
+// IsDefault reports whether the theme leaves all colors as they are.
+func (t *Theme) IsDefault() bool {
+	return t == nil || len(t.replace) == 0
+}
+
+// Rewrite replaces the colors of the escape sequences in p.
+func (t *Theme) Rewrite(p []byte) []byte {
+	if t.IsDefault() {
+		return p
+	}
+	return sequence.ReplaceAllFunc(p, func(m []byte) []byte {
+		params := string(m[2 : len(m)-1])
+		replacement, ok := t.replace[params]
+		if !ok {
+			return m
+		}
+		if replacement == "00" {
+			return nil
+		}
+		return []byte("\x1b[" + replacement + "m")
+	})
+}
+
+func roleNames() []string {
+	result := []string{}
+	for role := range roles {
+		result = append(result, role)
+	}
+	sort.Strings(result)
+	return result
+}
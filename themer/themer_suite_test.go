@@ -0,0 +1,13 @@
+package themer_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestThemer(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Themer Suite")
+}
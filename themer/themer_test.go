@@ -0,0 +1,104 @@
+package themer_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	. "github.com/JulzDiverse/aviator/themer"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Themer", func() {
+
+	// as printed by ansi.Printf("@Y{WARNING}: @m{file.yml} @G{to: out.yml}")
+	output := "\x1b[01;33mWARNING\x1b[00m: \x1b[00;35mfile.yml\x1b[00m \x1b[01;32mto: out.yml\x1b[00m"
+
+	rewrite := func(preset string, colors map[string]string) string {
+		theme, err := New(preset, colors)
+		Expect(err).ToNot(HaveOccurred())
+		return string(theme.Rewrite([]byte(output)))
+	}
+
+	Context("New", func() {
+		It("leaves the colors of the default preset", func() {
+			theme, err := New("", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(theme.IsDefault()).To(BeTrue())
+			Expect(rewrite("default", nil)).To(Equal(output))
+		})
+
+		It("replaces the colors of roles", func() {
+			Expect(rewrite("", map[string]string{"target": "blue", "warning": "bold red"})).To(Equal(
+				"\x1b[01;31mWARNING\x1b[00m: \x1b[00;34mfile.yml\x1b[00m \x1b[01;32mto: out.yml\x1b[00m",
+			))
+		})
+
+		It("supports 256 colors and overrides the colors of presets", func() {
+			Expect(rewrite("light", map[string]string{"success": "28"})).To(Equal(
+				"\x1b[01;38;5;130mWARNING\x1b[00m: \x1b[00;35mfile.yml\x1b[00m \x1b[01;38;5;28mto: out.yml\x1b[00m",
+			))
+		})
+
+		It("keeps only bold without colors in monochrome", func() {
+			Expect(rewrite("monochrome", nil)).To(Equal(
+				"\x1b[01mWARNING\x1b[00m: file.yml\x1b[00m \x1b[01mto: out.yml\x1b[00m",
+			))
+		})
+
+		It("fails for unknown presets, roles and colors", func() {
+			_, err := New("solarized", nil)
+			Expect(err).To(MatchError(ContainSubstring("default, light, monochrome")))
+
+			_, err = New("", map[string]string{"targets": "blue"})
+			Expect(err).To(MatchError(ContainSubstring("error, heading, info, success, target, warning")))
+
+			_, err = New("", map[string]string{"target": "mauve"})
+			Expect(err).To(MatchError(ContainSubstring("mauve")))
+
+			_, err = New("", map[string]string{"target": "256"})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("Writer", func() {
+		It("rewrites escape sequences split between writes", func() {
+			theme, err := New("", map[string]string{"warning": "bold blue"})
+			Expect(err).ToNot(HaveOccurred())
+
+			var out bytes.Buffer
+			w := theme.Writer(&out)
+			for _, part := range []string{"a \x1b[01", ";33mWARN", "ING\x1b[00m \x1b"} {
+				_, err := w.Write([]byte(part))
+				Expect(err).ToNot(HaveOccurred())
+			}
+			Expect(w.Close()).To(Succeed())
+			Expect(out.String()).To(Equal("a \x1b[01;34mWARNING\x1b[00m \x1b"))
+		})
+	})
+
+	Context("Apply", func() {
+		It("rewrites stdout until stopped", func() {
+			theme, err := New("", map[string]string{"warning": "bold blue"})
+			Expect(err).ToNot(HaveOccurred())
+
+			file, err := ioutil.TempFile("", "themer")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.Remove(file.Name())
+			stdout := os.Stdout
+			os.Stdout = file
+
+			stop, err := Apply(theme)
+			Expect(err).ToNot(HaveOccurred())
+			fmt.Fprint(os.Stdout, "\x1b[01;33mWARNING\x1b[00m")
+			stop()
+			os.Stdout = stdout
+			file.Close()
+
+			Expect(ioutil.ReadFile(file.Name())).To(Equal([]byte("\x1b[01;34mWARNING\x1b[00m")))
+		})
+	})
+})
@@ -0,0 +1,95 @@
+package themer
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+)
+
+// maxSequence is the longest escape sequence held back when a write ends
+// in the middle of one.
+const maxSequence = 16
+
+type writer struct {
+	theme   *Theme
+	out     io.Writer
+	pending []byte
+}
+
+// Writer returns a writer rewriting the colors of everything written to out.
+func (t *Theme) Writer(out io.Writer) io.WriteCloser {
+	return &writer{theme: t, out: out}
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	data := append(w.pending, p...)
+	w.pending = nil
+
+	// an escape sequence split between writes is completed by the next one
+	if i := bytes.LastIndexByte(data, '\x1b'); i >= 0 && len(data)-i < maxSequence && bytes.IndexByte(data[i:], 'm') < 0 {
+		w.pending = append([]byte{}, data[i:]...)
+		data = data[:i]
+	}
+
+	if _, err := w.out.Write(w.theme.Rewrite(data)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close writes what is left of an incomplete escape sequence.
+func (w *writer) Close() error {
+	if len(w.pending) == 0 {
+		return nil
+	}
+	_, err := w.out.Write(w.pending)
+	w.pending = nil
+	return err
+}
+
+// Apply rewrites the colors of everything written to stdout and stderr,
+// including the output of executed commands, until the returned stop
+// restores them. A default theme changes nothing.
+func Apply(t *Theme) (func(), error) {
+	if t.IsDefault() {
+		return func() {}, nil
+	}
+	stdout, stderr := os.Stdout, os.Stderr
+
+	outReader, outWriter, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	errReader, errWriter, err := os.Pipe()
+	if err != nil {
+		outReader.Close()
+		outWriter.Close()
+		return nil, err
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	for _, p := range []struct {
+		out io.Writer
+		in  io.Reader
+	}{{stdout, outReader}, {stderr, errReader}} {
+		go func(out io.Writer, in io.Reader) {
+			defer wg.Done()
+			w := t.Writer(out)
+			io.Copy(w, in)
+			w.Close()
+		}(p.out, p.in)
+	}
+	os.Stdout, os.Stderr = outWriter, errWriter
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			os.Stdout, os.Stderr = stdout, stderr
+			outWriter.Close()
+			errWriter.Close()
+			wg.Wait()
+		})
+	}, nil
+}
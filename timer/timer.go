@@ -0,0 +1,145 @@
+package timer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+const maxHistory = 20
+
+type Step struct {
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"duration"`
+}
+
+type Run struct {
+	Steps []Step `json:"steps"`
+}
+
+type Timer struct {
+	steps     []Step
+	observers []func(Step, error)
+}
+
+var timer = New()
+
+func New() *Timer {
+	return &Timer{}
+}
+
+func Default() *Timer {
+	return timer
+}
+
+func (t *Timer) Track(name, budget string, run func() error) error {
+	var limit time.Duration
+	if budget != "" {
+		var err error
+		limit, err = time.ParseDuration(budget)
+		if err != nil {
+			return errors.Wrap(err, ansi.Sprintf("@R{Invalid budget for step} @m{%s}", name))
+		}
+	}
+
+	start := time.Now()
+	err := run()
+	elapsed := time.Since(start)
+	t.steps = append(t.steps, Step{Name: name, Duration: elapsed})
+
+	if limit > 0 && elapsed > limit {
+		ansi.Fprintf(os.Stderr, "@Y{WARNING}: step @m{%s} took %s (budget %s)\n", name, round(elapsed), limit)
+	}
+
+	for _, observe := range t.observers {
+		observe(t.steps[len(t.steps)-1], err)
+	}
+	return err
+}
+
+// Observe calls observe with every tracked step and its error once the step
+// is done.
+func (t *Timer) Observe(observe func(Step, error)) {
+	t.observers = append(t.observers, observe)
+}
+
+func (t *Timer) Steps() []Step {
+	return t.steps
+}
+
+func (t *Timer) Report(historyFile string) (string, error) {
+	history, err := readHistory(historyFile)
+	if err != nil {
+		return "", err
+	}
+
+	history = append(history, Run{Steps: t.steps})
+	if len(history) > maxHistory {
+		history = history[len(history)-maxHistory:]
+	}
+
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	err = ioutil.WriteFile(historyFile, data, 0644)
+	if err != nil {
+		return "", errors.Wrap(err, ansi.Sprintf("@R{Writing timing history} @m{%s} @R{FAILED}", historyFile))
+	}
+
+	return report(t.steps, history), nil
+}
+
+func readHistory(historyFile string) ([]Run, error) {
+	history := []Run{}
+	data, err := ioutil.ReadFile(historyFile)
+	if os.IsNotExist(err) {
+		return history, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, &history)
+	if err != nil {
+		return nil, errors.Wrap(err, ansi.Sprintf("@R{Parsing timing history} @m{%s} @R{FAILED}", historyFile))
+	}
+	return history, nil
+}
+
+func report(steps []Step, history []Run) string {
+	totals := map[string]time.Duration{}
+	counts := map[string]int{}
+	for _, run := range history {
+		for _, s := range run.Steps {
+			totals[s.Name] += s.Duration
+			counts[s.Name]++
+		}
+	}
+
+	sorted := make([]Step, len(steps))
+	copy(sorted, steps)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Duration > sorted[j].Duration
+	})
+
+	var total time.Duration
+	result := ansi.Sprintf("@G{STEP TIMINGS:}\n")
+	for _, s := range sorted {
+		total += s.Duration
+		avg := totals[s.Name] / time.Duration(counts[s.Name])
+		result += fmt.Sprintf("\t%-10s avg %-10s (%d runs)  %s\n", round(s.Duration), round(avg), counts[s.Name], s.Name)
+	}
+	result += ansi.Sprintf("\t@G{total: %s}\n", round(total))
+	return result
+}
+
+func round(d time.Duration) time.Duration {
+	return d.Round(time.Millisecond)
+}
@@ -0,0 +1,102 @@
+package timer_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/JulzDiverse/aviator/timer"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Timer", func() {
+
+	var t *Timer
+
+	BeforeEach(func() {
+		t = New()
+	})
+
+	Context("Track", func() {
+		It("records the duration of a step", func() {
+			err := t.Track("spruce: result.yml", "", func() error {
+				time.Sleep(10 * time.Millisecond)
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(t.Steps()).To(HaveLen(1))
+			Expect(t.Steps()[0].Name).To(Equal("spruce: result.yml"))
+			Expect(t.Steps()[0].Duration).To(BeNumerically(">=", 10*time.Millisecond))
+		})
+
+		It("notifies observers of finished steps", func() {
+			names, errs := []string{}, []error{}
+			t.Observe(func(step Step, err error) {
+				names = append(names, step.Name)
+				errs = append(errs, err)
+			})
+
+			t.Track("first", "", func() error { return nil })
+			t.Track("second", "", func() error { return errors.New("failed") })
+
+			Expect(names).To(Equal([]string{"first", "second"}))
+			Expect(errs[0]).ToNot(HaveOccurred())
+			Expect(errs[1]).To(MatchError("failed"))
+		})
+
+		It("records failing steps and returns their error", func() {
+			err := t.Track("failing", "", func() error {
+				return errors.New("failed")
+			})
+			Expect(err).To(MatchError("failed"))
+			Expect(t.Steps()).To(HaveLen(1))
+		})
+
+		It("fails on an invalid budget", func() {
+			err := t.Track("step", "thirty seconds", func() error { return nil })
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("Report", func() {
+		var dir string
+
+		BeforeEach(func() {
+			dir, _ = ioutil.TempDir("", "timer")
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("appends the run to the history and reports the average", func() {
+			history := filepath.Join(dir, "timings.json")
+			for i := 0; i < 2; i++ {
+				t = New()
+				t.Track("step", "", func() error { return nil })
+				report, err := t.Report(history)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(report).To(ContainSubstring("step"))
+			}
+
+			report, err := New().Report(history)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(report).To(ContainSubstring("total"))
+			Expect(history).To(BeAnExistingFile())
+		})
+
+		It("reports the number of recorded runs per step", func() {
+			history := filepath.Join(dir, "timings.json")
+			t.Track("step", "", func() error { return nil })
+			t.Report(history)
+
+			report, err := t.Report(history)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(report).To(ContainSubstring("(2 runs)"))
+		})
+	})
+})
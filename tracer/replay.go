@@ -0,0 +1,113 @@
+package tracer
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/JulzDiverse/aviator/history"
+	"github.com/JulzDiverse/aviator/spruce"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+// Replay runs op again with the inputs recorded in the cache below root and
+// returns its output. Commands run in dir, which gets the recorded input
+// files; everything else, e.g. the environment, is taken from the current
+// process.
+func Replay(root string, op Operation, dir string) ([]byte, error) {
+	inputs := map[string][]byte{}
+	for _, path := range sortedKeys(op.Inputs) {
+		content, err := history.Cached(root, op.Inputs[path])
+		if err != nil {
+			return nil, ansi.Errorf("@R{Input} @m{%s} @R{of operation} @m{%d} @R{is unavailable}: %s", path, op.ID, err.Error())
+		}
+		inputs[path] = content
+	}
+
+	switch op.Kind {
+	case KindMerge:
+		if op.Merge == nil {
+			return nil, ansi.Errorf("@R{Operation} @m{%d} @R{has no merge options}", op.ID)
+		}
+		return spruce.NewWithFileFilemanager(store(inputs), false).MergeWithOpts(op.Merge.conf())
+	case KindExec:
+		if len(op.Command) == 0 {
+			return nil, ansi.Errorf("@R{Operation} @m{%d} @R{has no command}", op.ID)
+		}
+		if err := restore(inputs, dir); err != nil {
+			return nil, err
+		}
+		var stdout bytes.Buffer
+		cmd := exec.Command(op.Command[0], op.Command[1:]...)
+		cmd.Dir = dir
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, &stdout, os.Stderr
+		err := cmd.Run()
+		return stdout.Bytes(), err
+	}
+	return nil, ansi.Errorf("@R{Operation} @m{%d} @R{has the unknown kind} @m{%s}", op.ID, op.Kind)
+}
+
+// restore writes the relative inputs of a command into dir. Absolute inputs
+// stay where they are, so they must not have changed since the trace.
+func restore(inputs map[string][]byte, dir string) error {
+	for path, content := range inputs {
+		if filepath.IsAbs(path) {
+			current, err := ioutil.ReadFile(path)
+			if err != nil || !bytes.Equal(current, content) {
+				return ansi.Errorf("@R{Input} @m{%s} @R{changed since the trace}", path)
+			}
+			continue
+		}
+		if strings.HasPrefix(filepath.Clean(path), "..") {
+			return ansi.Errorf("@R{Input} @m{%s} @R{is outside of the directory of the command and cannot be restored}", path)
+		}
+		target := filepath.Join(dir, path)
+		err := os.MkdirAll(filepath.Dir(target), 0755)
+		if err == nil {
+			err = ioutil.WriteFile(target, content, 0644)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Matches reports whether output is the output recorded for op.
+func Matches(op Operation, output []byte) bool {
+	return op.Output != "" && history.Digest(output) == op.Output
+}
+
+// store serves the recorded inputs of a merge, so it cannot read anything
+// else.
+type store map[string][]byte
+
+func (s store) ReadFile(path string) ([]byte, bool) {
+	content, ok := s[path]
+	return content, ok
+}
+
+func (s store) WriteFile(path string, content []byte) error {
+	return ansi.Errorf("@R{Replayed merges cannot write} @m{%s}", path)
+}
+
+func (s store) ReadDir(path string) ([]os.FileInfo, error) {
+	return nil, ansi.Errorf("@R{Replayed merges cannot read the directory} @m{%s}", path)
+}
+
+func (s store) Walk(path string) ([]string, error) {
+	return nil, ansi.Errorf("@R{Replayed merges cannot read the directory} @m{%s}", path)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,266 @@
+package tracer
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/JulzDiverse/aviator"
+	"github.com/JulzDiverse/aviator/filemanager"
+	"github.com/JulzDiverse/aviator/history"
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+const (
+	KindMerge = "merge"
+	KindExec  = "exec"
+)
+
+// Trace is the ordered list of the operations of a run.
+type Trace struct {
+	Args       []string    `json:"args"`
+	Operations []Operation `json:"operations"`
+}
+
+// Operation is a spruce merge or an executed command, with the digests of
+// its inputs and its output. The contents of the inputs are kept in the
+// cache of the history, so the operation can be replayed.
+type Operation struct {
+	ID       int               `json:"id"`
+	Step     string            `json:"step"`
+	Kind     string            `json:"kind"`
+	Merge    *Merge            `json:"merge,omitempty"`
+	Command  []string          `json:"command,omitempty"`
+	Dir      string            `json:"dir,omitempty"`
+	Inputs   map[string]string `json:"inputs"`
+	Output   string            `json:"output,omitempty"`
+	Error    string            `json:"error,omitempty"`
+	Duration time.Duration     `json:"duration"`
+}
+
+// Merge are the options of a spruce merge.
+type Merge struct {
+	Files          []string `json:"files"`
+	Prune          []string `json:"prune,omitempty"`
+	CherryPicks    []string `json:"cherry_picks,omitempty"`
+	SkipEval       bool     `json:"skip_eval,omitempty"`
+	FallbackAppend bool     `json:"fallback_append,omitempty"`
+	GoPatch        bool     `json:"go_patch,omitempty"`
+	KeepBOM        bool     `json:"keep_bom,omitempty"`
+	Sops           []string `json:"sops,omitempty"`
+	SkipEvalFiles  []string `json:"skip_eval_files,omitempty"`
+}
+
+func (m Merge) conf() aviator.MergeConf {
+	return aviator.MergeConf{
+		Files:          m.Files,
+		Prune:          m.Prune,
+		CherryPicks:    m.CherryPicks,
+		SkipEval:       m.SkipEval,
+		FallbackAppend: m.FallbackAppend,
+		EnableGoPatch:  m.GoPatch,
+		KeepBOM:        m.KeepBOM,
+		Sops:           m.Sops,
+		SkipEvalFiles:  m.SkipEvalFiles,
+	}
+}
+
+// Tracer records the operations of a run. A nil tracer, the default, only
+// runs them.
+type Tracer struct {
+	root string
+
+	lock       sync.Mutex
+	operations []*Operation
+}
+
+var tracer *Tracer
+
+// New returns a tracer caching the inputs of the operations below root.
+func New(root string) *Tracer {
+	return &Tracer{root: root}
+}
+
+func Default() *Tracer {
+	return tracer
+}
+
+func Use(t *Tracer) {
+	tracer = t
+}
+
+// Merge runs the spruce merge conf of step and records it; read returns the
+// content of a file as the merge reads it.
+func (t *Tracer) Merge(step string, conf aviator.MergeConf, read func(string) ([]byte, bool), run func() ([]byte, error)) ([]byte, error) {
+	if t == nil {
+		return run()
+	}
+
+	inputs := map[string][]byte{}
+	for _, file := range conf.Files {
+		if content, ok := read(file); ok {
+			inputs[file] = content
+		}
+	}
+	op := t.start(Operation{
+		Step: step,
+		Kind: KindMerge,
+		Merge: &Merge{
+			Files:          conf.Files,
+			Prune:          conf.Prune,
+			CherryPicks:    conf.CherryPicks,
+			SkipEval:       conf.SkipEval,
+			FallbackAppend: conf.FallbackAppend,
+			GoPatch:        conf.EnableGoPatch,
+			KeepBOM:        conf.KeepBOM,
+			Sops:           conf.Sops,
+			SkipEvalFiles:  conf.SkipEvalFiles,
+		},
+	}, inputs)
+
+	start := time.Now()
+	result, err := run()
+	t.finish(op, time.Since(start), result, err)
+	return result, err
+}
+
+// Exec runs cmd and records it; run returns what the command printed to
+// stdout. Arguments naming files rendered by aviator in this run are
+// recorded as inputs. Other files, e.g. a kubeconfig or credentials passed
+// to the command, are neither read nor copied to the cache.
+func (t *Tracer) Exec(cmd *exec.Cmd, run func() ([]byte, error)) ([]byte, error) {
+	if t == nil {
+		return run()
+	}
+
+	rendered := map[string]bool{}
+	for _, file := range filemanager.Store(false, false).Written() {
+		if abs, err := filepath.Abs(file); err == nil {
+			rendered[abs] = true
+		}
+	}
+	inputs := map[string][]byte{}
+	for _, arg := range cmd.Args[1:] {
+		path := arg
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(cmd.Dir, path)
+		}
+		if abs, err := filepath.Abs(path); err != nil || !rendered[abs] {
+			continue
+		}
+		if content, err := ioutil.ReadFile(path); err == nil {
+			inputs[arg] = content
+		}
+	}
+	op := t.start(Operation{
+		Step:    "exec: " + filepath.Base(cmd.Args[0]),
+		Kind:    KindExec,
+		Command: append([]string{}, cmd.Args...),
+		Dir:     cmd.Dir,
+	}, inputs)
+
+	start := time.Now()
+	output, err := run()
+	t.finish(op, time.Since(start), output, err)
+	return output, err
+}
+
+func (t *Tracer) start(op Operation, inputs map[string][]byte) *Operation {
+	op.Inputs = map[string]string{}
+	for path, content := range inputs {
+		sum, err := history.Cache(t.root, content)
+		if err != nil {
+			// the operation can still be traced, but not replayed
+			sum = history.Digest(content)
+		}
+		op.Inputs[path] = sum
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	op.ID = len(t.operations) + 1
+	t.operations = append(t.operations, &op)
+	return &op
+}
+
+func (t *Tracer) finish(op *Operation, elapsed time.Duration, output []byte, err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	op.Duration = elapsed
+	if err != nil {
+		op.Error = err.Error()
+	} else {
+		op.Output = history.Digest(output)
+	}
+}
+
+// Write writes the trace of the operations recorded so far to file.
+func (t *Tracer) Write(file string, args []string) error {
+	if t == nil {
+		return nil
+	}
+
+	t.lock.Lock()
+	trace := Trace{Args: args, Operations: []Operation{}}
+	for _, op := range t.operations {
+		trace.Operations = append(trace.Operations, *op)
+	}
+	t.lock.Unlock()
+
+	content, err := json.MarshalIndent(trace, "", "  ")
+	if err == nil {
+		err = ioutil.WriteFile(file, content, 0644)
+	}
+	if err != nil {
+		return errors.Wrap(err, ansi.Sprintf("@R{Writing trace} @m{%s} @R{FAILED}", file))
+	}
+	return nil
+}
+
+// Load reads a trace written by Write.
+func Load(file string) (Trace, error) {
+	var trace Trace
+	content, err := ioutil.ReadFile(file)
+	if err == nil {
+		err = json.Unmarshal(content, &trace)
+	}
+	if err != nil {
+		return trace, errors.Wrap(err, ansi.Sprintf("@R{Reading trace} @m{%s} @R{FAILED}", file))
+	}
+	return trace, nil
+}
+
+// Find returns the operation with the id step, or the only operation of the
+// step named step.
+func (trace Trace) Find(step string) (Operation, error) {
+	if id, err := strconv.Atoi(step); err == nil {
+		for _, op := range trace.Operations {
+			if op.ID == id {
+				return op, nil
+			}
+		}
+		return Operation{}, ansi.Errorf("@R{The trace has no operation} @m{%d}", id)
+	}
+
+	found := []Operation{}
+	ids := []string{}
+	for _, op := range trace.Operations {
+		if op.Step == step {
+			found = append(found, op)
+			ids = append(ids, strconv.Itoa(op.ID))
+		}
+	}
+	switch len(found) {
+	case 0:
+		return Operation{}, ansi.Errorf("@R{The trace has no operation for step} @m{%s}", step)
+	case 1:
+		return found[0], nil
+	}
+	return Operation{}, ansi.Errorf("@R{Step} @m{%s} @R{ran several operations; replay one of} %s", step, strings.Join(ids, ", "))
+}
@@ -0,0 +1,205 @@
+package tracer_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/JulzDiverse/aviator"
+	"github.com/JulzDiverse/aviator/filemanager"
+	"github.com/JulzDiverse/aviator/spruce"
+	. "github.com/JulzDiverse/aviator/tracer"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tracer", func() {
+
+	var root, dir string
+	var files map[string][]byte
+	var tracer *Tracer
+
+	read := func(path string) ([]byte, bool) {
+		content, ok := files[path]
+		return content, ok
+	}
+
+	merge := func(conf aviator.MergeConf) func() ([]byte, error) {
+		return func() ([]byte, error) {
+			return spruce.NewWithFileFilemanager(fakeStore(files), false).MergeWithOpts(conf)
+		}
+	}
+
+	load := func() Trace {
+		file := filepath.Join(root, "trace.json")
+		Expect(tracer.Write(file, []string{"--trace", file})).To(Succeed())
+		trace, err := Load(file)
+		Expect(err).ToNot(HaveOccurred())
+		return trace
+	}
+
+	BeforeEach(func() {
+		var err error
+		root, err = ioutil.TempDir("", "aviator-trace")
+		Expect(err).ToNot(HaveOccurred())
+		dir, err = ioutil.TempDir("", "aviator-replay")
+		Expect(err).ToNot(HaveOccurred())
+
+		files = map[string][]byte{
+			"base.yml":  []byte("name: (( grab meta.name ))\nmeta:\n  name: base\n"),
+			"patch.yml": []byte("meta:\n  name: patched\n"),
+		}
+		tracer = New(root)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(root)
+		os.RemoveAll(dir)
+	})
+
+	It("only runs operations without a tracer", func() {
+		var none *Tracer
+		result, err := none.Merge("spruce: out.yml", aviator.MergeConf{}, read, func() ([]byte, error) { return []byte("a: 1\n"), nil })
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(result)).To(Equal("a: 1\n"))
+		Expect(none.Write(filepath.Join(root, "trace.json"), nil)).To(Succeed())
+		Expect(filepath.Join(root, "trace.json")).ToNot(BeAnExistingFile())
+	})
+
+	Context("merges", func() {
+		It("records the options, inputs and output in order", func() {
+			conf := aviator.MergeConf{Files: []string{"base.yml", "patch.yml"}, Prune: []string{"meta"}}
+			_, err := tracer.Merge("spruce: out.yml", conf, read, merge(conf))
+			Expect(err).ToNot(HaveOccurred())
+			_, err = tracer.Merge("spruce: missing.yml", aviator.MergeConf{Files: []string{"missing.yml"}}, read, merge(aviator.MergeConf{Files: []string{"missing.yml"}}))
+			Expect(err).To(HaveOccurred())
+
+			trace := load()
+			Expect(trace.Args).To(Equal([]string{"--trace", filepath.Join(root, "trace.json")}))
+			Expect(trace.Operations).To(HaveLen(2))
+
+			op := trace.Operations[0]
+			Expect(op.ID).To(Equal(1))
+			Expect(op.Kind).To(Equal(KindMerge))
+			Expect(op.Merge.Files).To(Equal([]string{"base.yml", "patch.yml"}))
+			Expect(op.Merge.Prune).To(Equal([]string{"meta"}))
+			Expect(op.Inputs).To(HaveKey("base.yml"))
+			Expect(op.Output).ToNot(BeEmpty())
+			Expect(op.Error).To(BeEmpty())
+
+			Expect(trace.Operations[1].ID).To(Equal(2))
+			Expect(trace.Operations[1].Error).To(ContainSubstring("missing.yml"))
+			Expect(trace.Operations[1].Output).To(BeEmpty())
+		})
+
+		It("replays a merge with the recorded inputs", func() {
+			conf := aviator.MergeConf{Files: []string{"base.yml", "patch.yml"}, Prune: []string{"meta"}}
+			_, err := tracer.Merge("spruce: out.yml", conf, read, merge(conf))
+			Expect(err).ToNot(HaveOccurred())
+			op := load().Operations[0]
+
+			// the replay does not see the files as they are now
+			files["patch.yml"] = []byte("meta:\n  name: changed\n")
+
+			output, err := Replay(root, op, dir)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(output)).To(Equal("name: patched\n"))
+			Expect(Matches(op, output)).To(BeTrue())
+			Expect(Matches(op, []byte("name: changed\n"))).To(BeFalse())
+		})
+
+		It("fails to replay if the inputs are not cached anymore", func() {
+			conf := aviator.MergeConf{Files: []string{"base.yml"}}
+			_, err := tracer.Merge("spruce: out.yml", conf, read, merge(conf))
+			Expect(err).ToNot(HaveOccurred())
+			op := load().Operations[0]
+
+			Expect(os.RemoveAll(filepath.Join(root, ".aviator", "cache"))).To(Succeed())
+			_, err = Replay(root, op, dir)
+			Expect(err).To(MatchError(ContainSubstring("base.yml")))
+		})
+	})
+
+	Context("commands", func() {
+		It("records rendered file arguments as inputs and replays the command with them", func() {
+			work := filepath.Join(root, "work")
+			Expect(os.MkdirAll(work, 0755)).To(Succeed())
+			Expect(filemanager.Store(false, false).WriteFile(filepath.Join(work, "values.yml"), []byte("a: 1\n"))).To(Succeed())
+
+			cmd := exec.Command("cat", "values.yml")
+			cmd.Dir = work
+			output, err := tracer.Exec(cmd, func() ([]byte, error) { return cmd.Output() })
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(output)).To(Equal("a: 1\n"))
+
+			op := load().Operations[0]
+			Expect(op.Step).To(Equal("exec: cat"))
+			Expect(op.Command).To(Equal([]string{"cat", "values.yml"}))
+			Expect(op.Inputs).To(HaveKey("values.yml"))
+
+			Expect(ioutil.WriteFile(filepath.Join(work, "values.yml"), []byte("a: 2\n"), 0644)).To(Succeed())
+			output, err = Replay(root, op, dir)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(output)).To(Equal("a: 1\n"))
+			Expect(Matches(op, output)).To(BeTrue())
+		})
+
+		It("does not record files which were not rendered by aviator", func() {
+			Expect(ioutil.WriteFile(filepath.Join(root, "kubeconfig"), []byte("token: secret\n"), 0644)).To(Succeed())
+
+			cmd := exec.Command("cat", "kubeconfig")
+			cmd.Dir = root
+			_, err := tracer.Exec(cmd, func() ([]byte, error) { return cmd.Output() })
+			Expect(err).ToNot(HaveOccurred())
+			Expect(load().Operations[0].Inputs).To(BeEmpty())
+		})
+
+		It("records failures", func() {
+			cmd := exec.Command("false")
+			_, err := tracer.Exec(cmd, func() ([]byte, error) { return nil, errors.New("exit status 1") })
+			Expect(err).To(HaveOccurred())
+			Expect(load().Operations[0].Error).To(Equal("exit status 1"))
+		})
+	})
+
+	Context("Find", func() {
+
+		var trace Trace
+
+		BeforeEach(func() {
+			trace = Trace{Operations: []Operation{
+				{ID: 1, Step: "spruce: a.yml"},
+				{ID: 2, Step: "exec: kubectl"},
+				{ID: 3, Step: "exec: kubectl"},
+			}}
+		})
+
+		It("finds operations by id or step", func() {
+			Expect(trace.Find("2")).To(Equal(trace.Operations[1]))
+			Expect(trace.Find("spruce: a.yml")).To(Equal(trace.Operations[0]))
+		})
+
+		It("fails for unknown and ambiguous steps", func() {
+			_, err := trace.Find("4")
+			Expect(err).To(MatchError(ContainSubstring("no operation")))
+			_, err = trace.Find("exec: kubectl")
+			Expect(err).To(MatchError(ContainSubstring("2, 3")))
+		})
+	})
+})
+
+type fakeStore map[string][]byte
+
+func (s fakeStore) ReadFile(path string) ([]byte, bool) {
+	content, ok := s[path]
+	return content, ok
+}
+
+func (s fakeStore) WriteFile(string, []byte) error { return nil }
+
+func (s fakeStore) ReadDir(string) ([]os.FileInfo, error) { return nil, nil }
+
+func (s fakeStore) Walk(string) ([]string, error) { return nil, nil }
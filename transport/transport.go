@@ -0,0 +1,159 @@
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+// The services aviator connects to. Each of them can have its own TLS
+// settings.
+const (
+	Remote   = "remote"
+	Vault    = "vault"
+	Callback = "callback"
+	Annotate = "annotate"
+)
+
+// Settings configure the TLS connections to a service. CABundle is a PEM file
+// of certificates trusted in addition to the system roots; ClientCert and
+// ClientKey are the PEM files of a client certificate.
+type Settings struct {
+	CABundle   string
+	ClientCert string
+	ClientKey  string
+}
+
+// serviceEnv names the environment variables overriding the settings of a
+// service. Vault uses the variables of the vault CLI.
+var serviceEnv = map[string]Settings{
+	Remote:   {"AVIATOR_REMOTE_CA_BUNDLE", "AVIATOR_REMOTE_CLIENT_CERT", "AVIATOR_REMOTE_CLIENT_KEY"},
+	Vault:    {"VAULT_CACERT", "VAULT_CLIENT_CERT", "VAULT_CLIENT_KEY"},
+	Callback: {"AVIATOR_CALLBACK_CA_BUNDLE", "AVIATOR_CALLBACK_CLIENT_CERT", "AVIATOR_CALLBACK_CLIENT_KEY"},
+	Annotate: {"AVIATOR_ANNOTATE_CA_BUNDLE", "AVIATOR_ANNOTATE_CLIENT_CERT", "AVIATOR_ANNOTATE_CLIENT_KEY"},
+}
+
+var (
+	lock     sync.Mutex
+	defaults Settings
+)
+
+// Configure sets the TLS settings of all services and verifies that the
+// settings of every service can be loaded.
+func Configure(settings Settings) error {
+	if _, err := load(settings, ""); err != nil {
+		return err
+	}
+
+	lock.Lock()
+	defaults = settings
+	lock.Unlock()
+
+	services := []string{}
+	for service := range serviceEnv {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	for _, service := range services {
+		if _, err := Config(service); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SettingsOf returns the TLS settings of service: the ones passed to
+// Configure, overridden by the environment variables of the service.
+func SettingsOf(service string) Settings {
+	lock.Lock()
+	settings := defaults
+	lock.Unlock()
+
+	env := serviceEnv[service]
+	if v := os.Getenv(env.CABundle); env.CABundle != "" && v != "" {
+		settings.CABundle = v
+	}
+	if v := os.Getenv(env.ClientCert); env.ClientCert != "" && v != "" {
+		settings.ClientCert = v
+	}
+	if v := os.Getenv(env.ClientKey); env.ClientKey != "" && v != "" {
+		settings.ClientKey = v
+	}
+	return settings
+}
+
+// Config returns the TLS configuration of service, nil if it has no
+// settings and uses the system defaults.
+func Config(service string) (*tls.Config, error) {
+	return load(SettingsOf(service), " of "+service)
+}
+
+func load(settings Settings, of string) (*tls.Config, error) {
+	if settings == (Settings{}) {
+		return nil, nil
+	}
+
+	config := &tls.Config{}
+	if settings.CABundle != "" {
+		pem, err := ioutil.ReadFile(settings.CABundle)
+		if err != nil {
+			return nil, errors.Wrap(err, ansi.Sprintf("@R{Reading CA bundle} @m{%s}@R{%s FAILED}", settings.CABundle, of))
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, ansi.Errorf("@R{CA bundle} @m{%s}@R{%s contains no PEM certificates}", settings.CABundle, of)
+		}
+		config.RootCAs = pool
+	}
+
+	if (settings.ClientCert == "") != (settings.ClientKey == "") {
+		return nil, ansi.Errorf("@R{The client certificate%s requires both a certificate and a key}", of)
+	}
+	if settings.ClientCert != "" {
+		cert, err := tls.LoadX509KeyPair(settings.ClientCert, settings.ClientKey)
+		if err != nil {
+			return nil, errors.Wrap(err, ansi.Sprintf("@R{Loading client certificate} @m{%s}@R{%s FAILED}", settings.ClientCert, of))
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// Client returns an HTTP client for service. Like all clients of aviator it
+// honors HTTPS_PROXY, HTTP_PROXY and NO_PROXY.
+func Client(service string, timeout time.Duration) (*http.Client, error) {
+	config, err := Config(service)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(config, timeout), nil
+}
+
+// NewClient returns an HTTP client using config for TLS connections, the
+// default transport if config is nil.
+func NewClient(config *tls.Config, timeout time.Duration) *http.Client {
+	client := &http.Client{Timeout: timeout}
+	if config == nil {
+		return client
+	}
+	transport, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		transport = &http.Transport{Proxy: http.ProxyFromEnvironment}
+	}
+	transport = transport.Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	transport.TLSClientConfig = config
+	client.Transport = transport
+	return client
+}
@@ -0,0 +1,126 @@
+package transport_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/JulzDiverse/aviator/transport"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Transport", func() {
+
+	var (
+		dir      string
+		server   *httptest.Server
+		clients  []string
+		ca       string
+		cert     string
+		key      string
+		settings Settings
+	)
+
+	writePEM := func(name, kind string, der []byte) string {
+		path := filepath.Join(dir, name)
+		Expect(ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der}), 0600)).To(Succeed())
+		return path
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "aviator-transport")
+		Expect(err).ToNot(HaveOccurred())
+
+		clients = []string{}
+		server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, c := range r.TLS.PeerCertificates {
+				clients = append(clients, c.Subject.CommonName)
+			}
+		}))
+		server.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+		server.StartTLS()
+		ca = writePEM("ca.pem", "CERTIFICATE", server.Certificate().Raw)
+
+		private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "aviator"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, &private.PublicKey, private)
+		Expect(err).ToNot(HaveOccurred())
+		cert = writePEM("client.pem", "CERTIFICATE", der)
+		der, err = x509.MarshalECPrivateKey(private)
+		Expect(err).ToNot(HaveOccurred())
+		key = writePEM("client-key.pem", "EC PRIVATE KEY", der)
+
+		settings = Settings{CABundle: ca, ClientCert: cert, ClientKey: key}
+	})
+
+	AfterEach(func() {
+		Configure(Settings{})
+		os.Unsetenv("AVIATOR_CALLBACK_CA_BUNDLE")
+		server.Close()
+		os.RemoveAll(dir)
+	})
+
+	get := func(service string) error {
+		client, err := Client(service, time.Second)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+
+	It("uses the system defaults without settings", func() {
+		Expect(Config(Remote)).To(BeNil())
+		Expect(get(Remote)).To(MatchError(ContainSubstring("certificate")))
+	})
+
+	It("trusts the CA bundle and sends the client certificate", func() {
+		Expect(Configure(settings)).To(Succeed())
+		Expect(get(Remote)).To(Succeed())
+		Expect(get(Vault)).To(Succeed())
+		Expect(clients).To(Equal([]string{"aviator", "aviator"}))
+	})
+
+	It("overrides the settings of a service with its environment variables", func() {
+		Expect(Configure(Settings{CABundle: ca})).To(Succeed())
+		os.Setenv("AVIATOR_CALLBACK_CA_BUNDLE", cert)
+		Expect(SettingsOf(Callback).CABundle).To(Equal(cert))
+		Expect(SettingsOf(Remote).CABundle).To(Equal(ca))
+		Expect(get(Callback)).To(MatchError(ContainSubstring("certificate")))
+		Expect(get(Remote)).To(Succeed())
+	})
+
+	It("fails for invalid settings", func() {
+		Expect(Configure(Settings{CABundle: key})).To(MatchError(ContainSubstring("contains no PEM certificates")))
+		Expect(Configure(Settings{CABundle: filepath.Join(dir, "missing.pem")})).To(MatchError(ContainSubstring("Reading CA bundle")))
+		Expect(Configure(Settings{ClientCert: cert})).To(MatchError(ContainSubstring("requires both")))
+		Expect(Configure(Settings{ClientCert: cert, ClientKey: ca})).To(MatchError(ContainSubstring("Loading client certificate")))
+
+		os.Setenv("AVIATOR_CALLBACK_CA_BUNDLE", key)
+		Expect(Configure(Settings{})).To(MatchError(ContainSubstring("of callback contains no PEM certificates")))
+	})
+})
@@ -2,8 +2,15 @@ package validator
 
 import (
 	"errors"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/JulzDiverse/aviator"
+	"github.com/JulzDiverse/aviator/filemanager"
+	"github.com/JulzDiverse/aviator/guard"
 	"github.com/starkandwayne/goutils/ansi"
 )
 
@@ -20,6 +27,36 @@ type ForEachInCombinationError struct{ error }
 type ForEachRegexpCombinationError struct{ error }
 type ForEachWalkCombinationError struct{ error }
 
+//Error Types: Encoding-Section
+type EncodingNewlineError struct{ error }
+
+//Error Types: Failure-Policy
+type FailurePolicyError struct{ error }
+
+//Error Types: Budget
+type BudgetError struct{ error }
+
+//Error Types: Engine
+type EngineError struct{ error }
+
+//Error Types: Secret-Scanning
+type ScanSecretsError struct{ error }
+
+//Error Types: Merge-Guard
+type MergeGuardError struct{ error }
+
+//Error Types: Normalize-Target
+type NormalizeTargetError struct{ error }
+
+//Error Types: Encrypt
+type EncryptError struct{ error }
+
+//Error Types: Permissions
+type PermissionsError struct{ error }
+
+//Error Types: Split-By-Kind
+type SplitByKindError struct{ error }
+
 type Validator struct{}
 
 func New() *Validator {
@@ -41,10 +78,103 @@ func (v *Validator) ValidateSpruce(cfg []aviator.Spruce) error {
 				return err
 			}
 		}
+
+		err := validateEncoding(spruce.Encoding)
+		if err != nil {
+			return err
+		}
+
+		err = validateFailurePolicy(spruce.FailurePolicy)
+		if err != nil {
+			return err
+		}
+
+		err = validateBudget(spruce.Budget)
+		if err != nil {
+			return err
+		}
+
+		err = validateEngine(spruce)
+		if err != nil {
+			return err
+		}
+
+		err = validateScanSecrets(spruce.ScanSecrets)
+		if err != nil {
+			return err
+		}
+
+		err = validateMergeGuard(spruce)
+		if err != nil {
+			return err
+		}
+
+		err = validateNormalizeTarget(spruce.NormalizeTarget)
+		if err != nil {
+			return err
+		}
+
+		err = validateEncrypt(spruce)
+		if err != nil {
+			return err
+		}
+
+		err = validatePermissions(spruce)
+		if err != nil {
+			return err
+		}
+
+		err = validateSplitByKind(spruce)
+		if err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
+// ValidateExecutors validates the failure policies of the executor
+// sections, also of the profiles, which run through the same policies as
+// the spruce steps.
+func (v *Validator) ValidateExecutors(cfg aviator.AviatorYaml) error {
+	err := validateExecutorPolicies("", cfg.Fly, cfg.Kube, cfg.Helm, cfg.Exec)
+	if err != nil {
+		return err
+	}
+	for name, profile := range cfg.Profiles {
+		err = validateExecutorPolicies("profiles."+name+".", profile.Fly, profile.Kube, profile.Helm, profile.Exec)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateExecutorPolicies(prefix string, fly aviator.Fly, kube aviator.Kube, helm aviator.Helm, exec []aviator.Executable) error {
+	policies := map[string]aviator.FailurePolicy{
+		prefix + "fly":           fly.FailurePolicy,
+		prefix + "kubectl.apply": kube.Apply.FailurePolicy,
+		prefix + "helm":          helm.FailurePolicy,
+	}
+	for i, e := range exec {
+		policies[prefix+"exec["+strconv.Itoa(i)+"]"] = e.FailurePolicy
+	}
+	for _, section := range sortedSections(policies) {
+		if err := validateFailurePolicy(policies[section]); err != nil {
+			return FailurePolicyError{errors.New(ansi.Sprintf("@m{%s}: %s", section, err.Error()))}
+		}
+	}
+	return nil
+}
+
+func sortedSections(policies map[string]aviator.FailurePolicy) []string {
+	sections := []string{}
+	for section := range policies {
+		sections = append(sections, section)
+	}
+	sort.Strings(sections)
+	return sections
+}
+
 func validateMergeSection(cfg []aviator.Merge) error {
 	for _, merge := range cfg {
 		if !isMergeEmpty(merge) {
@@ -138,6 +268,9 @@ func validateMergeRegexpCombination(merge aviator.Merge) error {
 		)
 		return MergeRegexpCombinationError{err}
 	}
+	if err := validateRegexp("merge", merge.Regexp, merge.Invert); err != nil {
+		return MergeRegexpCombinationError{err}
+	}
 	return nil
 }
 
@@ -178,6 +311,23 @@ func validateForEachRegexpCombination(forEach aviator.ForEach) error {
 		)
 		return ForEachRegexpCombinationError{err}
 	}
+	if err := validateRegexp("for_each", forEach.Regexp, forEach.Invert); err != nil {
+		return ForEachRegexpCombinationError{err}
+	}
+	return nil
+}
+
+func validateRegexp(section, regex string, invert bool) error {
+	if invert && regex == "" {
+		return errors.New(
+			ansi.Sprintf("@R{INVALID SYNTAX}: '%s.regexp_invert' requires '%s.regexp'", section, section),
+		)
+	}
+	if _, err := regexp.Compile(regex); err != nil {
+		return errors.New(
+			ansi.Sprintf("@R{INVALID SYNTAX}: '%s.regexp' @m{%s} is not a valid regular expression: %s", section, regex, err.Error()),
+		)
+	}
 	return nil
 }
 
@@ -191,6 +341,183 @@ func validateForEachWalkCombinations(forEach aviator.ForEach) error {
 	return nil
 }
 
+func validateEncoding(enc aviator.Encoding) error {
+	switch enc.Newline {
+	case "", "lf", "crlf":
+		return nil
+	}
+	err := errors.New(
+		ansi.Sprintf("@R{INVALID SYNTAX}: 'encoding.newline' must be either 'lf' or 'crlf', got '%s'", enc.Newline),
+	)
+	return EncodingNewlineError{err}
+}
+
+func validateFailurePolicy(policy aviator.FailurePolicy) error {
+	switch policy.OnFailure {
+	case "", "abort", "continue", "retry":
+		return nil
+	case "run-hook":
+		if len(policy.FailureHook) > 0 {
+			return nil
+		}
+		err := errors.New(
+			ansi.Sprintf("@R{INVALID SYNTAX}: 'on_failure: run-hook' requires a 'failure_hook'"),
+		)
+		return FailurePolicyError{err}
+	}
+	err := errors.New(
+		ansi.Sprintf("@R{INVALID SYNTAX}: 'on_failure' must be one of 'abort', 'continue', 'retry', 'run-hook', got '%s'", policy.OnFailure),
+	)
+	return FailurePolicyError{err}
+}
+
+func validateEngine(spruce aviator.Spruce) error {
+	if len(spruce.OpsFiles.Before) != 0 && spruce.Engine != "" && spruce.Engine != "spruce" {
+		err := errors.New(
+			ansi.Sprintf("@R{INVALID SYNTAX}: 'ops_files.before' is applied during the spruce merge and can not be combined with 'engine: %s'", spruce.Engine),
+		)
+		return EngineError{err}
+	}
+
+	usesYtt := len(spruce.Templates) != 0 || len(spruce.Values) != 0 || spruce.DataValuesEnv != ""
+	if usesYtt && spruce.Engine != "ytt" {
+		err := errors.New(
+			ansi.Sprintf("@R{INVALID SYNTAX}: 'templates', 'values' and 'data_values_env' require 'engine: ytt'"),
+		)
+		return EngineError{err}
+	}
+
+	switch spruce.Engine {
+	case "", "spruce", "strategic-merge":
+		return nil
+	case "ytt":
+		if spruce.Base != "" || len(spruce.Templates) != 0 {
+			return nil
+		}
+		err := errors.New(
+			ansi.Sprintf("@R{INVALID SYNTAX}: 'engine: ytt' requires 'base' or 'templates'"),
+		)
+		return EngineError{err}
+	case "jsonnet":
+		if isMergeArrayEmpty(spruce.Merge) && isForEachEmpty(spruce.ForEach) && spruce.DefaultsDoc == "" {
+			return nil
+		}
+		err := errors.New(
+			ansi.Sprintf("@R{INVALID SYNTAX}: 'engine: jsonnet' evaluates the 'base' entrypoint and can not be combined with 'defaults_doc', 'merge' or 'for_each'"),
+		)
+		return EngineError{err}
+	}
+	err := errors.New(
+		ansi.Sprintf("@R{INVALID SYNTAX}: 'engine' must be one of 'spruce', 'strategic-merge', 'jsonnet', 'ytt', got '%s'", spruce.Engine),
+	)
+	return EngineError{err}
+}
+
+func validateScanSecrets(mode string) error {
+	switch mode {
+	case "", "warn", "fail":
+		return nil
+	}
+	err := errors.New(
+		ansi.Sprintf("@R{INVALID SYNTAX}: 'scan_secrets' must be one of 'warn', 'fail', got '%s'", mode),
+	)
+	return ScanSecretsError{err}
+}
+
+func validateMergeGuard(spruce aviator.Spruce) error {
+	if spruce.MergeTimeout != "" {
+		if _, err := time.ParseDuration(spruce.MergeTimeout); err != nil {
+			err := errors.New(
+				ansi.Sprintf("@R{INVALID SYNTAX}: 'merge_timeout' must be a duration (e.g. '2m'), got '%s'", spruce.MergeTimeout),
+			)
+			return MergeGuardError{err}
+		}
+	}
+	if spruce.MaxMemory != "" {
+		if _, err := guard.ParseSize(spruce.MaxMemory); err != nil {
+			err := errors.New(
+				ansi.Sprintf("@R{INVALID SYNTAX}: 'max_memory' must be a size (e.g. '512Mi'), got '%s'", spruce.MaxMemory),
+			)
+			return MergeGuardError{err}
+		}
+	}
+	return nil
+}
+
+func validateNormalizeTarget(mode string) error {
+	switch mode {
+	case "", "none", "lower", "slug":
+		return nil
+	}
+	err := errors.New(
+		ansi.Sprintf("@R{INVALID SYNTAX}: 'normalize_target' must be one of 'slug', 'lower', 'none', got '%s'", mode),
+	)
+	return NormalizeTargetError{err}
+}
+
+func validateEncrypt(spruce aviator.Spruce) error {
+	for _, r := range spruce.Encrypt.AgeRecipients {
+		if !strings.HasPrefix(r, "age1") && !strings.HasPrefix(r, "ssh-") {
+			err := errors.New(
+				ansi.Sprintf("@R{INVALID SYNTAX}: 'age_recipients' must be age ('age1...') or SSH ('ssh-...') public keys, got '%s'", r),
+			)
+			return EncryptError{err}
+		}
+	}
+	if len(spruce.Encrypt.AgeRecipients) > 0 && strings.HasPrefix(spruce.To, "{{") {
+		err := errors.New(
+			ansi.Sprintf("@R{INVALID SYNTAX}: 'encrypt' can not be combined with the datastore target '%s'", spruce.To),
+		)
+		return EncryptError{err}
+	}
+	return nil
+}
+
+func validatePermissions(spruce aviator.Spruce) error {
+	if spruce.Mode == "" && !spruce.PreservePerms {
+		return nil
+	}
+	if _, err := filemanager.ParseMode(spruce.Mode); err != nil {
+		err := errors.New(
+			ansi.Sprintf("@R{INVALID SYNTAX}: 'mode' must be octal permissions (e.g. '0600'), got '%s'", spruce.Mode),
+		)
+		return PermissionsError{err}
+	}
+	if strings.HasPrefix(spruce.To, "{{") || strings.HasPrefix(spruce.ToDir, "{{") {
+		err := errors.New(
+			ansi.Sprintf("@R{INVALID SYNTAX}: 'mode' and 'preserve_permissions' can not be combined with datastore targets"),
+		)
+		return PermissionsError{err}
+	}
+	return nil
+}
+
+func validateSplitByKind(spruce aviator.Spruce) error {
+	if !spruce.SplitByKind {
+		return nil
+	}
+	if spruce.ToDir == "" || spruce.To != "" || !isForEachEmpty(spruce.ForEach) {
+		err := errors.New(
+			ansi.Sprintf("@R{INVALID SYNTAX}: 'split_by_kind' requires 'to_dir' instead of 'to' and can not be combined with 'for_each'"),
+		)
+		return SplitByKindError{err}
+	}
+	return nil
+}
+
+func validateBudget(budget aviator.Budget) error {
+	if budget.WarnIfLongerThan == "" {
+		return nil
+	}
+	if _, err := time.ParseDuration(budget.WarnIfLongerThan); err != nil {
+		err := errors.New(
+			ansi.Sprintf("@R{INVALID SYNTAX}: 'warn_if_longer_than' must be a duration (e.g. '30s'), got '%s'", budget.WarnIfLongerThan),
+		)
+		return BudgetError{err}
+	}
+	return nil
+}
+
 func isForEachEmpty(forEach aviator.ForEach) bool {
 	if (forEach.Files == nil || len(forEach.Files) == 0) &&
 		forEach.InDir == "" &&
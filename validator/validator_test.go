@@ -133,6 +133,22 @@ var _ = PDescribe("Validator", func() {
 					Expect(err).To(MatchError(ContainSubstring("INVALID SYNTAX: 'merge.regexp' is only allowed in combination with 'merge.with_in' or 'merge.with_all_in'")))
 				})
 			})
+
+			It("returns an error if 'regexp_invert' is defined without 'regexp'", func() {
+				cfg.Merge[0].WithIn = "path/"
+				cfg.Merge[0].Invert = true
+
+				err := validator.ValidateSpruce([]aviator.Spruce{cfg})
+				Expect(err).To(MatchError(ContainSubstring("INVALID SYNTAX: 'merge.regexp_invert' requires 'merge.regexp'")))
+			})
+
+			It("returns an error if 'regexp' does not compile", func() {
+				cfg.Merge[0].WithIn = "path/"
+				cfg.Merge[0].Regexp = "base[.yml"
+
+				err := validator.ValidateSpruce([]aviator.Spruce{cfg})
+				Expect(err).To(MatchError(ContainSubstring("INVALID SYNTAX: 'merge.regexp' base[.yml is not a valid regular expression")))
+			})
 		})
 	})
 
@@ -289,3 +305,286 @@ var _ = PDescribe("Validator", func() {
 		})
 	})
 })
+
+var _ = Describe("Encoding Validator", func() {
+
+	var cfg aviator.Spruce
+
+	BeforeEach(func() {
+		cfg = aviator.Spruce{
+			Base: "base.yml",
+			To:   "target.yml",
+		}
+	})
+
+	It("accepts 'lf' and 'crlf' as newline styles", func() {
+		for _, newline := range []string{"", "lf", "crlf"} {
+			cfg.Encoding.Newline = newline
+			err := New().ValidateSpruce([]aviator.Spruce{cfg})
+			Expect(err).ToNot(HaveOccurred())
+		}
+	})
+
+	It("returns an error for unknown newline styles", func() {
+		cfg.Encoding.Newline = "cr"
+		err := New().ValidateSpruce([]aviator.Spruce{cfg})
+		Expect(err).To(BeAssignableToTypeOf(EncodingNewlineError{}))
+		Expect(err).To(MatchError(ContainSubstring("'encoding.newline'")))
+	})
+})
+
+var _ = Describe("Failure Policy Validator", func() {
+
+	var cfg aviator.Spruce
+
+	BeforeEach(func() {
+		cfg = aviator.Spruce{
+			Base: "base.yml",
+			To:   "target.yml",
+		}
+	})
+
+	It("accepts the known policies", func() {
+		for _, policy := range []string{"", "abort", "continue", "retry"} {
+			cfg.OnFailure = policy
+			Expect(New().ValidateSpruce([]aviator.Spruce{cfg})).To(Succeed())
+		}
+	})
+
+	It("returns an error for unknown policies", func() {
+		cfg.OnFailure = "ignore"
+		err := New().ValidateSpruce([]aviator.Spruce{cfg})
+		Expect(err).To(BeAssignableToTypeOf(FailurePolicyError{}))
+	})
+
+	It("requires a failure_hook for 'run-hook'", func() {
+		cfg.OnFailure = "run-hook"
+		err := New().ValidateSpruce([]aviator.Spruce{cfg})
+		Expect(err).To(MatchError(ContainSubstring("failure_hook")))
+	})
+
+	It("validates the policies of the executor sections", func() {
+		cfg := aviator.AviatorYaml{Exec: []aviator.Executable{{Executable: "true"}}}
+		cfg.Kube.Apply.OnFailure = "retry"
+		Expect(New().ValidateExecutors(cfg)).To(Succeed())
+
+		for _, section := range []string{"fly", "kubectl.apply", "helm", "exec[0]"} {
+			invalid := cfg
+			invalid.Exec = []aviator.Executable{{Executable: "true"}}
+			switch section {
+			case "fly":
+				invalid.Fly.OnFailure = "retyr"
+			case "kubectl.apply":
+				invalid.Kube.Apply.OnFailure = "retyr"
+			case "helm":
+				invalid.Helm.OnFailure = "retyr"
+			case "exec[0]":
+				invalid.Exec[0].OnFailure = "retyr"
+			}
+			err := New().ValidateExecutors(invalid)
+			Expect(err).To(BeAssignableToTypeOf(FailurePolicyError{}))
+			Expect(err).To(MatchError(ContainSubstring(section)))
+		}
+	})
+
+	It("validates the policies of the executors of profiles", func() {
+		profile := aviator.Profile{Helm: aviator.Helm{FailurePolicy: aviator.FailurePolicy{OnFailure: "run-hook"}}}
+		err := New().ValidateExecutors(aviator.AviatorYaml{Profiles: map[string]aviator.Profile{"prod": profile}})
+		Expect(err).To(MatchError(ContainSubstring("profiles.prod.helm")))
+	})
+})
+
+var _ = Describe("Budget Validator", func() {
+
+	var cfg aviator.Spruce
+
+	BeforeEach(func() {
+		cfg = aviator.Spruce{
+			Base: "base.yml",
+			To:   "target.yml",
+		}
+	})
+
+	It("accepts durations", func() {
+		cfg.WarnIfLongerThan = "1m30s"
+		Expect(New().ValidateSpruce([]aviator.Spruce{cfg})).To(Succeed())
+	})
+
+	It("returns an error for invalid durations", func() {
+		cfg.WarnIfLongerThan = "30"
+		err := New().ValidateSpruce([]aviator.Spruce{cfg})
+		Expect(err).To(BeAssignableToTypeOf(BudgetError{}))
+	})
+})
+
+var _ = Describe("Engine Validator", func() {
+
+	var cfg aviator.Spruce
+
+	BeforeEach(func() {
+		cfg = aviator.Spruce{
+			Base: "base.yml",
+			To:   "target.yml",
+		}
+	})
+
+	It("accepts the known engines", func() {
+		for _, engine := range []string{"", "spruce", "strategic-merge"} {
+			cfg.Engine = engine
+			Expect(New().ValidateSpruce([]aviator.Spruce{cfg})).To(Succeed())
+		}
+	})
+
+	It("accepts the jsonnet engine for a base entrypoint", func() {
+		cfg.Engine = "jsonnet"
+		Expect(New().ValidateSpruce([]aviator.Spruce{cfg})).To(Succeed())
+	})
+
+	It("rejects merge sections for the jsonnet engine", func() {
+		cfg.Engine = "jsonnet"
+		cfg.Merge = []aviator.Merge{{WithIn: "dir/"}}
+		err := New().ValidateSpruce([]aviator.Spruce{cfg})
+		Expect(err).To(BeAssignableToTypeOf(EngineError{}))
+	})
+
+	It("rejects a defaults_doc for the jsonnet engine", func() {
+		cfg.Engine = "jsonnet"
+		cfg.DefaultsDoc = "defaults.yml"
+		err := New().ValidateSpruce([]aviator.Spruce{cfg})
+		Expect(err).To(BeAssignableToTypeOf(EngineError{}))
+		Expect(err).To(MatchError(ContainSubstring("defaults_doc")))
+	})
+
+	It("rejects ops files applied before the merge for other engines", func() {
+		cfg.Engine = "strategic-merge"
+		cfg.OpsFiles = aviator.OpsFiles{After: []string{"ops.yml"}}
+		Expect(New().ValidateSpruce([]aviator.Spruce{cfg})).To(Succeed())
+
+		cfg.OpsFiles.Before = []string{"ops.yml"}
+		err := New().ValidateSpruce([]aviator.Spruce{cfg})
+		Expect(err).To(BeAssignableToTypeOf(EngineError{}))
+	})
+
+	It("accepts the ytt engine with templates instead of a base", func() {
+		cfg.Base = ""
+		cfg.Engine = "ytt"
+		Expect(New().ValidateSpruce([]aviator.Spruce{cfg})).To(MatchError(ContainSubstring("requires 'base' or 'templates'")))
+
+		cfg.Templates = []string{"config/"}
+		cfg.Values = []string{"values.yml"}
+		Expect(New().ValidateSpruce([]aviator.Spruce{cfg})).To(Succeed())
+	})
+
+	It("rejects ytt options for other engines", func() {
+		cfg.Values = []string{"values.yml"}
+		err := New().ValidateSpruce([]aviator.Spruce{cfg})
+		Expect(err).To(BeAssignableToTypeOf(EngineError{}))
+	})
+
+	It("returns an error for unknown engines", func() {
+		cfg.Engine = "kustomize"
+		err := New().ValidateSpruce([]aviator.Spruce{cfg})
+		Expect(err).To(BeAssignableToTypeOf(EngineError{}))
+	})
+})
+
+var _ = Describe("Scan Secrets Validator", func() {
+
+	It("returns an error for unknown modes", func() {
+		cfg := aviator.Spruce{Base: "base.yml", To: "target.yml", ScanSecrets: "block"}
+		err := New().ValidateSpruce([]aviator.Spruce{cfg})
+		Expect(err).To(BeAssignableToTypeOf(ScanSecretsError{}))
+
+		cfg.ScanSecrets = "fail"
+		Expect(New().ValidateSpruce([]aviator.Spruce{cfg})).To(Succeed())
+	})
+})
+
+var _ = Describe("Merge Guard Validator", func() {
+
+	It("returns an error for an invalid merge_timeout", func() {
+		cfg := aviator.Spruce{Base: "base.yml", To: "target.yml", MergeTimeout: "soon"}
+		err := New().ValidateSpruce([]aviator.Spruce{cfg})
+		Expect(err).To(BeAssignableToTypeOf(MergeGuardError{}))
+
+		cfg.MergeTimeout = "2m"
+		Expect(New().ValidateSpruce([]aviator.Spruce{cfg})).To(Succeed())
+	})
+
+	It("returns an error for an invalid max_memory", func() {
+		cfg := aviator.Spruce{Base: "base.yml", To: "target.yml", MaxMemory: "a lot"}
+		err := New().ValidateSpruce([]aviator.Spruce{cfg})
+		Expect(err).To(BeAssignableToTypeOf(MergeGuardError{}))
+
+		cfg.MaxMemory = "512Mi"
+		Expect(New().ValidateSpruce([]aviator.Spruce{cfg})).To(Succeed())
+	})
+})
+
+var _ = Describe("Normalize Target Validator", func() {
+
+	It("returns an error for an unknown normalize_target", func() {
+		cfg := aviator.Spruce{Base: "base.yml", ToDir: "out/", NormalizeTarget: "kebab"}
+		err := New().ValidateSpruce([]aviator.Spruce{cfg})
+		Expect(err).To(BeAssignableToTypeOf(NormalizeTargetError{}))
+
+		for _, mode := range []string{"slug", "lower", "none"} {
+			cfg.NormalizeTarget = mode
+			Expect(New().ValidateSpruce([]aviator.Spruce{cfg})).To(Succeed())
+		}
+	})
+})
+
+var _ = Describe("Permissions Validator", func() {
+
+	It("returns an error for modes which are not octal permissions", func() {
+		cfg := aviator.Spruce{Base: "base.yml", To: "secrets.yml", Mode: "rw-------"}
+		err := New().ValidateSpruce([]aviator.Spruce{cfg})
+		Expect(err).To(BeAssignableToTypeOf(PermissionsError{}))
+
+		cfg.Mode = "0600"
+		Expect(New().ValidateSpruce([]aviator.Spruce{cfg})).To(Succeed())
+	})
+
+	It("returns an error for datastore targets", func() {
+		cfg := aviator.Spruce{Base: "base.yml", To: "{{secrets.yml}}", PreservePerms: true}
+		err := New().ValidateSpruce([]aviator.Spruce{cfg})
+		Expect(err).To(BeAssignableToTypeOf(PermissionsError{}))
+	})
+})
+
+var _ = Describe("Split By Kind Validator", func() {
+
+	It("requires to_dir", func() {
+		cfg := aviator.Spruce{Base: "chart.yml", To: "manifests.yml", SplitByKind: true}
+		err := New().ValidateSpruce([]aviator.Spruce{cfg})
+		Expect(err).To(BeAssignableToTypeOf(SplitByKindError{}))
+
+		cfg.To, cfg.ToDir = "", "manifests/"
+		Expect(New().ValidateSpruce([]aviator.Spruce{cfg})).To(Succeed())
+	})
+
+	It("returns an error in combination with for_each", func() {
+		cfg := aviator.Spruce{Base: "chart.yml", ToDir: "manifests/", SplitByKind: true, ForEach: aviator.ForEach{Files: []string{"values.yml"}}}
+		err := New().ValidateSpruce([]aviator.Spruce{cfg})
+		Expect(err).To(BeAssignableToTypeOf(SplitByKindError{}))
+	})
+})
+
+var _ = Describe("Encrypt Validator", func() {
+
+	It("returns an error for recipients which are not public keys", func() {
+		cfg := aviator.Spruce{Base: "base.yml", To: "secrets.yml.age", Encrypt: aviator.Encrypt{AgeRecipients: []string{"AGE-SECRET-KEY-1XYZ"}}}
+		err := New().ValidateSpruce([]aviator.Spruce{cfg})
+		Expect(err).To(BeAssignableToTypeOf(EncryptError{}))
+
+		cfg.Encrypt.AgeRecipients = []string{"age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p", "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIHs"}
+		Expect(New().ValidateSpruce([]aviator.Spruce{cfg})).To(Succeed())
+	})
+
+	It("returns an error for encrypted datastore targets", func() {
+		cfg := aviator.Spruce{Base: "base.yml", To: "{{secrets.yml}}", Encrypt: aviator.Encrypt{AgeRecipients: []string{"age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"}}}
+		err := New().ValidateSpruce([]aviator.Spruce{cfg})
+		Expect(err).To(BeAssignableToTypeOf(EncryptError{}))
+	})
+})
@@ -0,0 +1,139 @@
+package vendorer
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/starkandwayne/goutils/ansi"
+)
+
+func isArchive(location string) bool {
+	p := urlPath(location)
+	return strings.HasSuffix(p, ".tar.gz") || strings.HasSuffix(p, ".tgz")
+}
+
+func urlPath(location string) string {
+	u, err := url.Parse(location)
+	if err != nil {
+		return location
+	}
+	return u.Path
+}
+
+// unpack extracts the gzipped tarball file into dir. A single top-level
+// directory, as in the release archives of GitHub, is stripped.
+func unpack(file []byte, dir string) error {
+	entries, err := readTar(file)
+	if err != nil {
+		return err
+	}
+	prefix := commonDir(entries)
+
+	for _, e := range entries {
+		name := strings.TrimPrefix(e.name, prefix)
+		if name == "" {
+			continue
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if e.dir {
+			err = os.MkdirAll(target, 0755)
+		} else {
+			err = os.MkdirAll(filepath.Dir(target), 0755)
+			if err == nil {
+				err = writeFile(target, e.content, e.mode)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type entry struct {
+	name    string
+	dir     bool
+	mode    os.FileMode
+	content []byte
+}
+
+func readTar(file []byte) ([]entry, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(file))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	entries := []entry{}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name := path.Clean(strings.TrimPrefix(header.Name, "./"))
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return nil, ansi.Errorf("@R{archive entry} @m{%s} @R{is outside of the archive}", header.Name)
+		}
+		if name == "." {
+			continue
+		}
+
+		switch {
+		case header.Typeflag == tar.TypeDir:
+			entries = append(entries, entry{name: name + "/", dir: true})
+		case header.FileInfo().Mode().IsRegular():
+			content, err := ioutil.ReadAll(tr)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry{name: name, mode: os.FileMode(header.Mode).Perm(), content: content})
+		}
+		// links and devices are not part of template bundles
+	}
+}
+
+// commonDir returns the single top-level directory all entries are in, if
+// there is one, including the trailing slash.
+func commonDir(entries []entry) string {
+	prefix := ""
+	for _, e := range entries {
+		i := strings.Index(e.name, "/")
+		if i < 0 {
+			return ""
+		}
+		if prefix == "" {
+			prefix = e.name[:i+1]
+		} else if e.name[:i+1] != prefix {
+			return ""
+		}
+	}
+	return prefix
+}
+
+func writeFile(name string, content []byte, mode os.FileMode) error {
+	if mode == 0 {
+		mode = 0644
+	}
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,80 @@
+package vendorer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/JulzDiverse/aviator"
+	"github.com/JulzDiverse/aviator/recorder"
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ReadLock reads the lock file of the vendor directory dir. A missing lock
+// file has no entries.
+func ReadLock(dir string) (map[string]Lock, error) {
+	locks := map[string]Lock{}
+	content, err := ioutil.ReadFile(filepath.Join(dir, LockFile))
+	if os.IsNotExist(err) {
+		return locks, nil
+	}
+	if err == nil {
+		err = yaml.Unmarshal(content, &locks)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, ansi.Sprintf("@R{Reading} @m{%s} @R{FAILED}", filepath.Join(dir, LockFile)))
+	}
+	return locks, nil
+}
+
+// Verify compares the vendor directory dir with the declared dependencies.
+// It returns a problem for every dependency which is not vendored, was
+// vendored from a different declaration or whose files were changed since.
+func Verify(dir string, deps map[string]aviator.Dependency) ([]string, error) {
+	if dir == "" {
+		dir = DefaultDir
+	}
+	locks, err := ReadLock(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	problems := []string{}
+	for _, name := range names(deps) {
+		dep := deps[name]
+		lock, ok := locks[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s is not vendored", name))
+			continue
+		}
+		if lock.Git != dep.Git || lock.URL != dep.URL || lock.Version != dep.Version || lock.Path != dep.Path {
+			problems = append(problems, fmt.Sprintf("%s is vendored from %s, but declared as %s", name, version(declared(lock)), version(dep)))
+			continue
+		}
+		if dep.Sha256 != "" && lock.Sha256 != dep.Sha256 {
+			problems = append(problems, fmt.Sprintf("%s is vendored with sha256 %s, but pinned to %s", name, lock.Sha256, dep.Sha256))
+			continue
+		}
+		digest, err := recorder.Digest(filepath.Join(dir, name), true, nil)
+		if err != nil || digest != lock.Digest {
+			problems = append(problems, fmt.Sprintf("%s was changed since it was vendored", name))
+		}
+	}
+
+	stale := []string{}
+	for name := range locks {
+		if _, ok := deps[name]; !ok {
+			stale = append(stale, fmt.Sprintf("%s is vendored, but no longer declared", name))
+		}
+	}
+	sort.Strings(stale)
+	return append(problems, stale...), nil
+}
+
+func declared(lock Lock) aviator.Dependency {
+	return aviator.Dependency{Git: lock.Git, URL: lock.URL, Version: lock.Version, Path: lock.Path}
+}
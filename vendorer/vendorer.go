@@ -0,0 +1,252 @@
+package vendorer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/JulzDiverse/aviator"
+	"github.com/JulzDiverse/aviator/authorizer"
+	"github.com/JulzDiverse/aviator/filemanager"
+	"github.com/JulzDiverse/aviator/recorder"
+	"github.com/pkg/errors"
+	"github.com/starkandwayne/goutils/ansi"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	DefaultDir = "vendor"
+	LockFile   = "dependencies.lock"
+)
+
+// Lock records what a dependency was vendored from: the declaration, the
+// commit a git version resolved to, the sha256 of a download and the digest
+// of the vendored files.
+type Lock struct {
+	Git     string `yaml:"git,omitempty"`
+	URL     string `yaml:"url,omitempty"`
+	Version string `yaml:"version,omitempty"`
+	Path    string `yaml:"path,omitempty"`
+	Commit  string `yaml:"commit,omitempty"`
+	Sha256  string `yaml:"sha256,omitempty"`
+	Digest  string `yaml:"digest"`
+}
+
+// Vendorer fetches the template bundles declared as dependencies into
+// <dir>/<name>, so steps reference reviewed, committed copies instead of
+// fetching them on every run.
+type Vendorer struct {
+	dir   string
+	fetch func(key string) ([]byte, error)
+	auth  *authorizer.Authorizer
+}
+
+func New(dir string) *Vendorer {
+	if dir == "" {
+		dir = DefaultDir
+	}
+	return &Vendorer{dir: dir, fetch: filemanager.New(false, false).Fetch}
+}
+
+// UseAuth sets the credentials of the git repositories and downloads.
+func (v *Vendorer) UseAuth(auth *authorizer.Authorizer) {
+	files := filemanager.New(false, false)
+	files.UseAuth(auth)
+	v.fetch = files.Fetch
+	v.auth = auth
+}
+
+// Vendor fetches all dependencies and writes the lock file. Bundles of
+// dependencies which are no longer declared are removed.
+func (v *Vendorer) Vendor(deps map[string]aviator.Dependency) (map[string]Lock, error) {
+	previous, err := ReadLock(v.dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(v.dir, 0755); err != nil {
+		return nil, errors.Wrap(err, ansi.Sprintf("@R{Creating vendor directory} @m{%s} @R{FAILED}", v.dir))
+	}
+
+	locks := map[string]Lock{}
+	for _, name := range names(deps) {
+		lock, err := v.vendor(name, deps[name])
+		if err != nil {
+			return nil, err
+		}
+		locks[name] = lock
+	}
+
+	for name := range previous {
+		if _, ok := deps[name]; !ok {
+			if err := os.RemoveAll(filepath.Join(v.dir, name)); err != nil {
+				return nil, errors.Wrap(err, ansi.Sprintf("@R{Removing vendored dependency} @m{%s} @R{FAILED}", name))
+			}
+		}
+	}
+
+	content, err := yaml.Marshal(locks)
+	if err == nil {
+		err = ioutil.WriteFile(filepath.Join(v.dir, LockFile), content, 0644)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, ansi.Sprintf("@R{Writing} @m{%s} @R{FAILED}", filepath.Join(v.dir, LockFile)))
+	}
+	return locks, nil
+}
+
+func (v *Vendorer) vendor(name string, dep aviator.Dependency) (Lock, error) {
+	if err := validate(name, dep); err != nil {
+		return Lock{}, err
+	}
+
+	// fetched next to the target, so the bundle can be moved in place
+	tmp, err := ioutil.TempDir(v.dir, "."+name)
+	if err != nil {
+		return Lock{}, errors.Wrap(err, ansi.Sprintf("@R{Vendoring} @m{%s} @R{FAILED}", name))
+	}
+	defer os.RemoveAll(tmp)
+
+	lock := Lock{Git: dep.Git, URL: dep.URL, Version: dep.Version, Path: dep.Path}
+	root := filepath.Join(tmp, "src")
+	if dep.Git != "" {
+		lock.Commit, err = v.checkout(dep.Git, dep.Version, root)
+	} else {
+		lock.Sha256, err = v.download(dep, root)
+	}
+	if err != nil {
+		return Lock{}, err
+	}
+
+	src := filepath.Join(root, filepath.FromSlash(dep.Path))
+	if _, err := os.Stat(src); err != nil {
+		return Lock{}, ansi.Errorf("@R{Dependency} @m{%s}@R{:} @m{%s} @R{does not exist at} @m{%s}", name, dep.Path, version(dep))
+	}
+	os.RemoveAll(filepath.Join(src, ".git"))
+
+	dest := filepath.Join(v.dir, name)
+	err = os.RemoveAll(dest)
+	if err == nil {
+		err = os.Rename(src, dest)
+	}
+	if err != nil {
+		return Lock{}, errors.Wrap(err, ansi.Sprintf("@R{Vendoring} @m{%s} @R{FAILED}", name))
+	}
+
+	lock.Digest, err = recorder.Digest(dest, true, nil)
+	return lock, err
+}
+
+func validate(name string, dep aviator.Dependency) error {
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return ansi.Errorf("@R{Invalid dependency name} @m{%s}@R{; must be a plain directory name}", name)
+	}
+	if (dep.Git == "") == (dep.URL == "") {
+		return ansi.Errorf("@R{Dependency} @m{%s} @R{requires either} @m{git} @R{or} @m{url}", name)
+	}
+	if dep.Git != "" && dep.Version == "" {
+		return ansi.Errorf("@R{Dependency} @m{%s} @R{requires a} @m{version} @R{(tag, branch or commit) to pin the git repository}", name)
+	}
+	p := path.Clean(dep.Path)
+	if path.IsAbs(p) || p == ".." || strings.HasPrefix(p, "../") {
+		return ansi.Errorf("@R{Dependency} @m{%s}@R{:} @m{path} @R{must be within the bundle, got} @m{%s}", name, dep.Path)
+	}
+	return nil
+}
+
+// checkout fetches version of repo into dir and returns the commit it
+// resolved to.
+func (v *Vendorer) checkout(repo, version, dir string) (string, error) {
+	authArgs, err := v.auth.GitArgs(repo)
+	if err != nil {
+		return "", err
+	}
+	env := v.auth.GitEnv(repo)
+	for _, args := range [][]string{
+		{"init", "-q", dir},
+		append(authArgs, "-C", dir, "fetch", "-q", "--depth", "1", repo, version),
+		{"-C", dir, "checkout", "-q", "FETCH_HEAD"},
+	} {
+		if _, err := git(env, args...); err != nil {
+			return "", ansi.Errorf("@R{Fetching} @m{%s} @R{at} @m{%s} @R{FAILED}:\n%s", repo, version, err.Error())
+		}
+	}
+	commit, err := git(nil, "-C", dir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", ansi.Errorf("@R{Resolving} @m{%s} @R{at} @m{%s} @R{FAILED}:\n%s", repo, version, err.Error())
+	}
+	return commit, nil
+}
+
+func git(env []string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", errors.New(strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// download fetches url into dir: archives are unpacked, other files are
+// written with the name of the url. It returns the sha256 of the download.
+func (v *Vendorer) download(dep aviator.Dependency, dir string) (string, error) {
+	key := dep.URL
+	if dep.Sha256 != "" {
+		key += "#sha256=" + strings.ToLower(dep.Sha256)
+	}
+	file, err := v.fetch(key)
+	if err != nil {
+		return "", err
+	}
+
+	if isArchive(dep.URL) {
+		err = unpack(file, dir)
+		if err != nil {
+			return "", errors.Wrap(err, ansi.Sprintf("@R{Unpacking} @m{%s} @R{FAILED}", dep.URL))
+		}
+	} else {
+		err = os.MkdirAll(dir, 0755)
+		if err == nil {
+			err = ioutil.WriteFile(filepath.Join(dir, path.Base(urlPath(dep.URL))), file, 0644)
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	sum := sha256.Sum256(file)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func version(dep aviator.Dependency) string {
+	location := dep.Git
+	if location == "" {
+		location = dep.URL
+	}
+	if dep.Version != "" {
+		location += "@" + dep.Version
+	}
+	if dep.Path != "" {
+		location += " (" + dep.Path + ")"
+	}
+	return location
+}
+
+func names(deps map[string]aviator.Dependency) []string {
+	result := []string{}
+	for name := range deps {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,13 @@
+package vendorer_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestVendorer(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Vendorer Suite")
+}
@@ -0,0 +1,196 @@
+package vendorer_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/JulzDiverse/aviator"
+	. "github.com/JulzDiverse/aviator/vendorer"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Vendorer", func() {
+
+	var dir, repo string
+
+	git := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-C", repo, "-c", "user.name=aviator", "-c", "user.email=aviator@example.com"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		Expect(err).ToNot(HaveOccurred(), string(out))
+		return string(bytes.TrimSpace(out))
+	}
+
+	read := func(path string) string {
+		content, err := ioutil.ReadFile(filepath.Join(dir, path))
+		Expect(err).ToNot(HaveOccurred())
+		return string(content)
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "vendorer")
+		Expect(err).ToNot(HaveOccurred())
+		repo = filepath.Join(dir, "repo")
+
+		Expect(os.MkdirAll(filepath.Join(repo, "spruce"), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(repo, "spruce", "base.yml"), []byte("version: v1\n"), 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(repo, "README.md"), []byte("templates\n"), 0644)).To(Succeed())
+		git("init", "-q")
+		git("add", ".")
+		git("commit", "-q", "-m", "v1")
+		git("tag", "v1")
+		Expect(ioutil.WriteFile(filepath.Join(repo, "spruce", "base.yml"), []byte("version: v2\n"), 0644)).To(Succeed())
+		git("commit", "-q", "-am", "v2")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	Context("git dependencies", func() {
+		It("vendors a path of the repository at the pinned version", func() {
+			vendor := filepath.Join(dir, "vendor")
+			deps := map[string]aviator.Dependency{
+				"platform": {Git: "file://" + repo, Version: "v1", Path: "spruce"},
+				"full":     {Git: "file://" + repo, Version: "master"},
+			}
+			git("branch", "-M", "master")
+
+			locks, err := New(vendor).Vendor(deps)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(read("vendor/platform/base.yml")).To(Equal("version: v1\n"))
+			Expect(read("vendor/full/spruce/base.yml")).To(Equal("version: v2\n"))
+			Expect(filepath.Join(vendor, "full", ".git")).ToNot(BeADirectory())
+			Expect(locks["platform"].Commit).To(Equal(git("rev-parse", "v1")))
+			Expect(locks["platform"].Digest).ToNot(BeEmpty())
+
+			Expect(ReadLock(vendor)).To(Equal(locks))
+			Expect(Verify(vendor, deps)).To(BeEmpty())
+		})
+
+		It("fails for versions which do not exist", func() {
+			deps := map[string]aviator.Dependency{"platform": {Git: "file://" + repo, Version: "v9"}}
+			_, err := New(filepath.Join(dir, "vendor")).Vendor(deps)
+			Expect(err).To(MatchError(ContainSubstring("v9")))
+		})
+
+		It("requires a version", func() {
+			deps := map[string]aviator.Dependency{"platform": {Git: "file://" + repo}}
+			_, err := New(filepath.Join(dir, "vendor")).Vendor(deps)
+			Expect(err).To(MatchError(ContainSubstring("requires a version")))
+		})
+	})
+
+	Context("url dependencies", func() {
+
+		var (
+			server    *httptest.Server
+			transport http.RoundTripper
+			archive   []byte
+		)
+
+		BeforeEach(func() {
+			var buf bytes.Buffer
+			gz := gzip.NewWriter(&buf)
+			tw := tar.NewWriter(gz)
+			for name, content := range map[string]string{"bundle-v1/spruce/base.yml": "version: v1\n", "bundle-v1/README.md": "templates\n"} {
+				Expect(tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg})).To(Succeed())
+				tw.Write([]byte(content))
+			}
+			tw.Close()
+			gz.Close()
+			archive = buf.Bytes()
+
+			server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/bundle-v1.tar.gz":
+					w.Write(archive)
+				case "/defaults.yml":
+					w.Write([]byte("defaults: true\n"))
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			transport = http.DefaultTransport
+			http.DefaultTransport = server.Client().Transport
+		})
+
+		AfterEach(func() {
+			http.DefaultTransport = transport
+			server.Close()
+		})
+
+		It("unpacks archives without their top-level directory and writes files", func() {
+			vendor := filepath.Join(dir, "vendor")
+			sum := sha256.Sum256(archive)
+			deps := map[string]aviator.Dependency{
+				"bundle":   {URL: server.URL + "/bundle-v1.tar.gz", Version: "v1", Path: "spruce", Sha256: hex.EncodeToString(sum[:])},
+				"defaults": {URL: server.URL + "/defaults.yml"},
+			}
+
+			locks, err := New(vendor).Vendor(deps)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(read("vendor/bundle/base.yml")).To(Equal("version: v1\n"))
+			Expect(read("vendor/defaults/defaults.yml")).To(Equal("defaults: true\n"))
+			Expect(locks["bundle"].Sha256).To(Equal(hex.EncodeToString(sum[:])))
+			Expect(Verify(vendor, deps)).To(BeEmpty())
+		})
+
+		It("fails if the download does not match the sha256", func() {
+			deps := map[string]aviator.Dependency{"bundle": {URL: server.URL + "/bundle-v1.tar.gz", Sha256: "0000"}}
+			_, err := New(filepath.Join(dir, "vendor")).Vendor(deps)
+			Expect(err).To(MatchError(ContainSubstring("Checksum mismatch")))
+		})
+	})
+
+	Context("Verify", func() {
+
+		var vendor string
+		var deps map[string]aviator.Dependency
+
+		BeforeEach(func() {
+			vendor = filepath.Join(dir, "vendor")
+			deps = map[string]aviator.Dependency{"platform": {Git: "file://" + repo, Version: "v1", Path: "spruce"}}
+			_, err := New(vendor).Vendor(deps)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("reports dependencies which are not vendored", func() {
+			deps["other"] = aviator.Dependency{Git: "file://" + repo, Version: "v1"}
+			Expect(Verify(vendor, deps)).To(Equal([]string{"other is not vendored"}))
+		})
+
+		It("reports changed declarations", func() {
+			deps["platform"] = aviator.Dependency{Git: "file://" + repo, Version: "v2", Path: "spruce"}
+			Expect(Verify(vendor, deps)).To(Equal([]string{
+				"platform is vendored from file://" + repo + "@v1 (spruce), but declared as file://" + repo + "@v2 (spruce)",
+			}))
+		})
+
+		It("reports vendored files which were changed", func() {
+			Expect(ioutil.WriteFile(filepath.Join(vendor, "platform", "base.yml"), []byte("version: edited\n"), 0644)).To(Succeed())
+			Expect(Verify(vendor, deps)).To(Equal([]string{"platform was changed since it was vendored"}))
+		})
+
+		It("removes dependencies which are no longer declared", func() {
+			Expect(Verify(vendor, map[string]aviator.Dependency{})).To(Equal([]string{"platform is vendored, but no longer declared"}))
+
+			_, err := New(vendor).Vendor(map[string]aviator.Dependency{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(filepath.Join(vendor, "platform")).ToNot(BeADirectory())
+			Expect(Verify(vendor, map[string]aviator.Dependency{})).To(BeEmpty())
+		})
+	})
+})
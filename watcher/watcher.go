@@ -0,0 +1,84 @@
+package watcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Watcher polls files and directories for changes. Polling keeps aviator
+// free of platform specific notification APIs and works on network and
+// container mounts, at the cost of the poll interval as latency.
+type Watcher struct {
+	paths    []string
+	interval time.Duration
+}
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+func New(paths []string, interval time.Duration) *Watcher {
+	return &Watcher{paths: paths, interval: interval}
+}
+
+// Watch calls onChange with the files which were created, modified or
+// removed since the last poll until ctx is done. Changes made while onChange
+// runs, e.g. by writing rendered files, are not reported.
+func (w *Watcher) Watch(ctx context.Context, onChange func([]string)) error {
+	state := w.scan()
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		current := w.scan()
+		changed := diff(state, current)
+		if len(changed) == 0 {
+			state = current
+			continue
+		}
+		onChange(changed)
+		state = w.scan()
+	}
+}
+
+func (w *Watcher) scan() map[string]fileState {
+	result := map[string]fileState{}
+	for _, path := range w.paths {
+		filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if !info.IsDir() {
+				result[file] = fileState{info.ModTime(), info.Size()}
+			}
+			return nil
+		})
+	}
+	return result
+}
+
+func diff(before, after map[string]fileState) []string {
+	changed := []string{}
+	for file, state := range after {
+		if prev, ok := before[file]; !ok || prev != state {
+			changed = append(changed, file)
+		}
+	}
+	for file := range before {
+		if _, ok := after[file]; !ok {
+			changed = append(changed, file)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
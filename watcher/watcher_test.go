@@ -0,0 +1,66 @@
+package watcher_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/JulzDiverse/aviator/watcher"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Watcher", func() {
+
+	var (
+		dir     string
+		changes chan []string
+		cancel  context.CancelFunc
+		done    chan error
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "aviator-watch")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(dir, "overlays"), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "base.yml"), []byte("a: 1"), 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "overlays", "prod.yml"), []byte("a: 2"), 0644)).To(Succeed())
+
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(context.Background())
+		changes, done = make(chan []string, 10), make(chan error, 1)
+		w := New([]string{filepath.Join(dir, "base.yml"), filepath.Join(dir, "overlays")}, 10*time.Millisecond)
+		go func() {
+			done <- w.Watch(ctx, func(files []string) { changes <- files })
+		}()
+		time.Sleep(30 * time.Millisecond)
+	})
+
+	AfterEach(func() {
+		cancel()
+		Eventually(done).Should(Receive(BeNil()))
+		os.RemoveAll(dir)
+	})
+
+	It("reports modified files", func() {
+		Expect(ioutil.WriteFile(filepath.Join(dir, "base.yml"), []byte("a: 10"), 0644)).To(Succeed())
+		Eventually(changes).Should(Receive(Equal([]string{filepath.Join(dir, "base.yml")})))
+	})
+
+	It("reports files created in and removed from watched directories", func() {
+		Expect(ioutil.WriteFile(filepath.Join(dir, "overlays", "dev.yml"), []byte("a: 3"), 0644)).To(Succeed())
+		Eventually(changes).Should(Receive(Equal([]string{filepath.Join(dir, "overlays", "dev.yml")})))
+
+		Expect(os.Remove(filepath.Join(dir, "overlays", "prod.yml"))).To(Succeed())
+		Eventually(changes).Should(Receive(Equal([]string{filepath.Join(dir, "overlays", "prod.yml")})))
+	})
+
+	It("ignores files which are not watched", func() {
+		Expect(ioutil.WriteFile(filepath.Join(dir, "other.yml"), []byte("b: 1"), 0644)).To(Succeed())
+		Consistently(changes, 100*time.Millisecond).ShouldNot(Receive())
+	})
+})